@@ -0,0 +1,161 @@
+package api
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadArchive streams a ZIP of a directory, or of a selection of entries
+// inside it given as ?paths=a,b,c. Entries are written as the tree is walked
+// so memory use does not grow with its size.
+func (f *FileSystemAPI) DownloadArchive(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path parameter is required"})
+		return
+	}
+	if !f.isPathAllowed(path) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access to this path is not allowed"})
+		return
+	}
+	root := filepath.Clean(expandPath(path))
+	info, err := os.Stat(root)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Unable to read directory: %v", err)})
+		return
+	}
+	if !info.IsDir() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is not a directory"})
+		return
+	}
+
+	// Resolve the selection up front so bad entries fail before streaming
+	selection := []string{root}
+	if paths := c.Query("paths"); paths != "" {
+		selection = selection[:0]
+		for _, name := range strings.Split(paths, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			entry := filepath.Join(root, name)
+			if !isSubPath(entry, root) || !f.isPathAllowed(entry) {
+				c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Access to %s is not allowed", name)})
+				return
+			}
+			if _, err := os.Lstat(entry); err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Unable to read %s: %v", name, err)})
+				return
+			}
+			selection = append(selection, entry)
+		}
+		if len(selection) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No paths selected"})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(root)+".zip"))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	showHidden := f.config().ShowHidden
+	for _, entry := range selection {
+		if err := f.archiveTree(zw, root, entry, showHidden); err != nil {
+			// The response has started, so all that can be done is to stop
+			// and leave the client with a truncated archive
+			fmt.Printf("Archive of %s failed: %v\n", root, err)
+			c.Abort()
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		fmt.Printf("Archive of %s failed: %v\n", root, err)
+	}
+}
+
+// archiveTree writes start and everything below it to zw, naming entries
+// relative to root. Entries outside the allowed paths are skipped, as are
+// symlinks whose target is not allowed.
+func (f *FileSystemAPI) archiveTree(zw *zip.Writer, root, start string, showHidden bool) error {
+	return filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable entries are left out rather than failing the archive
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path != root && !showHidden && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !f.isPathAllowed(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+
+		source := path
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := filepath.EvalSymlinks(path)
+			if err != nil || !f.isPathAllowed(target) {
+				return nil
+			}
+			// Linked directories are not followed, which also avoids cycles
+			if info, err := os.Stat(target); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			source = target
+		}
+
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			header.Name = name + "/"
+			_, err := zw.CreateHeader(header)
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+
+		file, err := os.Open(source)
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = f.buffers.Copy(w, file)
+		return err
+	})
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// readArchive returns the entries of the ZIP in body, mapping names to
+// contents. Directory entries map to an empty string.
+func readArchive(t *testing.T, body []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("response is not a ZIP: %v", err)
+	}
+	entries := make(map[string]string)
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+		entries[file.Name] = string(data)
+	}
+	return entries
+}
+
+func TestDownloadArchive(t *testing.T) {
+	// Keep the user's config file from being picked up
+	t.Setenv("HOME", t.TempDir())
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	docs := filepath.Join(allowed, "docs")
+	files := map[string]string{
+		"a.txt":         "alpha",
+		"sub/b.txt":     "bravo",
+		"sub/deep/c.md": "charlie",
+		".hidden":       "secret",
+	}
+	for name, content := range files {
+		path := filepath.Join(docs, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "passwd"), []byte("root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(docs, "a.txt"), filepath.Join(docs, "link-in")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "passwd"), filepath.Join(docs, "link-out")); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	fs := NewFileSystemAPI(&config.Config{AllowedPaths: []string{allowed}})
+	router.GET("/archive", fs.DownloadArchive)
+
+	tests := []struct {
+		name   string
+		path   string
+		paths  string
+		status int
+		want   map[string]string
+	}{
+		{"directory", docs, "", http.StatusOK, map[string]string{
+			"a.txt":         "alpha",
+			"link-in":       "alpha",
+			"sub/":          "",
+			"sub/b.txt":     "bravo",
+			"sub/deep/":     "",
+			"sub/deep/c.md": "charlie",
+		}},
+		{"selection", docs, "a.txt, sub/deep", http.StatusOK, map[string]string{
+			"a.txt":         "alpha",
+			"sub/deep/":     "",
+			"sub/deep/c.md": "charlie",
+		}},
+		{"symlink outside selected", docs, "link-out", http.StatusForbidden, nil},
+		{"empty selection", docs, ",,", http.StatusBadRequest, nil},
+		{"selection escaping the directory", docs, "../../" + filepath.Base(outside), http.StatusForbidden, nil},
+		{"missing selection", docs, "nope.txt", http.StatusNotFound, nil},
+		{"no path", "", "", http.StatusBadRequest, nil},
+		{"file", filepath.Join(docs, "a.txt"), "", http.StatusBadRequest, nil},
+		{"missing directory", filepath.Join(allowed, "nope"), "", http.StatusNotFound, nil},
+		{"outside allowed paths", outside, "", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.path != "" {
+				query.Set("path", tt.path)
+			}
+			if tt.paths != "" {
+				query.Set("paths", tt.paths)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/archive?"+query.Encode(), nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/zip" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if got, want := rec.Header().Get("Content-Disposition"), `attachment; filename="docs.zip"`; got != want {
+				t.Errorf("Content-Disposition = %q, want %q", got, want)
+			}
+
+			entries := readArchive(t, rec.Body.Bytes())
+			var names, wantNames []string
+			for name := range entries {
+				names = append(names, name)
+			}
+			for name := range tt.want {
+				wantNames = append(wantNames, name)
+			}
+			slices.Sort(names)
+			slices.Sort(wantNames)
+			if !slices.Equal(names, wantNames) {
+				t.Fatalf("entries = %v, want %v", names, wantNames)
+			}
+			for name, content := range tt.want {
+				if entries[name] != content {
+					t.Errorf("%s = %q, want %q", name, entries[name], content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// TokenVerifier checks bearer tokens. The platform's security manager is
+// one, so the tokens it issues are accepted here too.
+type TokenVerifier interface {
+	ValidateToken(ctx context.Context, token string) (*core.TokenInfo, error)
+}
+
+// SetTokenVerifier sets what the guarded endpoints check bearer tokens with.
+// Until one is set they answer 503.
+func (a *API) SetTokenVerifier(verifier TokenVerifier) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.verifier = verifier
+}
+
+func (a *API) tokenVerifier() TokenVerifier {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.verifier
+}
+
+// requireToken returns a middleware that only lets through requests carrying
+// a bearer token the verifier accepts
+func (a *API) requireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		verifier := a.tokenVerifier()
+		if verifier == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "authentication is not configured",
+			})
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if token == "" || token == header {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		info, err := verifier.ValidateToken(c.Request.Context(), token)
+		if err != nil || info == nil || !info.Valid {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Set("userID", info.UserID)
+		c.Next()
+	}
+}
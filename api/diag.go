@@ -0,0 +1,96 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// diagMaxMessageSize limits messages sent to the echo endpoint
+const diagMaxMessageSize = 64 * 1024
+
+// redactedHeaders are reported by name only
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+}
+
+// DiagAPI handles connectivity diagnostics
+type DiagAPI struct {
+	config     *config.Config
+	wsUpgrader websocket.Upgrader
+}
+
+// NewDiagAPI creates a new diagnostics API handler
+func NewDiagAPI(cfg *config.Config) *DiagAPI {
+	return &DiagAPI{
+		config: cfg,
+		wsUpgrader: websocket.Upgrader{
+			CheckOrigin: core.WebSocketOriginChecker(cfg.WebSocketOrigins, true),
+		},
+	}
+}
+
+// EchoWebSocket echoes every message back to the client. Pings are answered
+// with pongs by the WebSocket library.
+func (d *DiagAPI) EchoWebSocket(c *gin.Context) {
+	conn, err := d.wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upgrade connection: " + err.Error(),
+		})
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(diagMaxMessageSize)
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := conn.WriteMessage(messageType, message); err != nil {
+			return
+		}
+	}
+}
+
+// GetNetworkInfo reports how the server sees the client: its address, the
+// request headers and the TLS connection, if any
+func (d *DiagAPI) GetNetworkInfo(c *gin.Context) {
+	headers := make(map[string]string, len(c.Request.Header))
+	for name, values := range c.Request.Header {
+		if redactedHeaders[name] {
+			headers[name] = "[redacted]"
+			continue
+		}
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+
+	info := gin.H{
+		"clientIP":   c.ClientIP(),
+		"remoteAddr": c.Request.RemoteAddr,
+		"host":       c.Request.Host,
+		"method":     c.Request.Method,
+		"proto":      c.Request.Proto,
+		"headers":    headers,
+		"tls":        nil,
+	}
+	if state := c.Request.TLS; state != nil {
+		info["tls"] = gin.H{
+			"version":            tls.VersionName(state.Version),
+			"cipherSuite":        tls.CipherSuiteName(state.CipherSuite),
+			"serverName":         state.ServerName,
+			"negotiatedProtocol": state.NegotiatedProtocol,
+		}
+	}
+
+	c.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// newDiagServer serves the diagnostics endpoints
+func newDiagServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	diag := NewDiagAPI(&config.Config{})
+	router := gin.New()
+	router.GET("/diag/ws", diag.EchoWebSocket)
+	router.GET("/diag/net", diag.GetNetworkInfo)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiagEcho(t *testing.T) {
+	server := newDiagServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/diag/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	messages := []struct {
+		kind int
+		data string
+	}{
+		{websocket.TextMessage, "hello"},
+		{websocket.BinaryMessage, "\x00\x01\x02"},
+		{websocket.TextMessage, strings.Repeat("x", 4096)},
+	}
+	for _, msg := range messages {
+		if err := conn.WriteMessage(msg.kind, []byte(msg.data)); err != nil {
+			t.Fatal(err)
+		}
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kind != msg.kind || string(data) != msg.data {
+			t.Errorf("echo = %d %q, want %d %q", kind, data, msg.kind, msg.data)
+		}
+	}
+
+	// Pings are answered with pongs, seen while reading the next echo
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(data string) error { pong <- data; return nil })
+	if err := conn.WriteControl(websocket.PingMessage, []byte("probe"), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte("after ping"))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-pong:
+		if data != "probe" {
+			t.Errorf("pong = %q", data)
+		}
+	default:
+		t.Error("no pong for ping")
+	}
+}
+
+func TestDiagEchoRejectsCrossOrigin(t *testing.T) {
+	server := newDiagServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/diag/ws"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	if err == nil {
+		conn.Close()
+		t.Fatal("cross-origin upgrade succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("response = %v, want 403", resp)
+	}
+}
+
+func TestDiagNetworkInfo(t *testing.T) {
+	server := newDiagServer(t)
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/diag/net", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Probe", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		ClientIP string            `json:"clientIP"`
+		Headers  map[string]string `json:"headers"`
+		TLS      interface{}       `json:"tls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.ClientIP != "127.0.0.1" {
+		t.Errorf("clientIP = %q, want 127.0.0.1", info.ClientIP)
+	}
+	if info.Headers["X-Probe"] != "1" {
+		t.Errorf("headers = %v", info.Headers)
+	}
+	if info.Headers["Authorization"] != "[redacted]" {
+		t.Errorf("Authorization = %q, want it redacted", info.Headers["Authorization"])
+	}
+	if info.TLS != nil {
+		t.Errorf("tls = %v over plain HTTP", info.TLS)
+	}
+}
@@ -125,7 +125,9 @@ func InitDocs() {
 				Method:      "GET",
 				Description: "List contents of a directory",
 				Parameters: map[string]string{
-					"path": "Directory path to list",
+					"path":      "Directory path to list",
+					"recursive": "Set to true to list the whole tree breadth-first as flat entries with relative paths",
+					"maxDepth":  "Levels to descend in recursive mode (default 3)",
 				},
 				Response: map[string]interface{}{
 					"path":        "/path/to/dir",
@@ -148,6 +150,16 @@ func InitDocs() {
 				},
 				Example: "curl -X GET \"http://localhost:8080/api/v1/filesystem/content?path=/home/user/file.txt\"",
 			},
+			{
+				Path:        "/api/v1/filesystem/archive",
+				Method:      "GET",
+				Description: "Download a directory, or selected entries in it, as a ZIP archive",
+				Parameters: map[string]string{
+					"path":  "Path to directory",
+					"paths": "Optional comma-separated entries inside the directory to include",
+				},
+				Example: "curl -OJ \"http://localhost:8080/api/v1/filesystem/archive?path=/home/user/Documents\"",
+			},
 		},
 	})
 
@@ -303,6 +315,35 @@ func InitDocs() {
 		},
 	})
 
+	// Connectivity diagnostics
+	apiDocs = append(apiDocs, APICategory{
+		Name:        "Diagnostics",
+		Description: "Debug connectivity between clients and the server",
+		Endpoints: []APIEndpoint{
+			{
+				Path:        "/api/v1/diag/ws",
+				Method:      "GET",
+				Description: "Echo every message sent over a WebSocket and answer pings",
+				Example:     "Accessible via WebSocket: ws://localhost:8080/api/v1/diag/ws",
+			},
+			{
+				Path:        "/api/v1/diag/net",
+				Method:      "GET",
+				Description: "Show the server's view of the client: address, headers and TLS",
+				Response: map[string]interface{}{
+					"clientIP":   "192.168.1.20",
+					"remoteAddr": "192.168.1.20:51234",
+					"host":       "localhost:8080",
+					"method":     "GET",
+					"proto":      "HTTP/1.1",
+					"headers":    map[string]string{"User-Agent": "curl/8.5.0"},
+					"tls":        nil,
+				},
+				Example: "curl -X GET http://localhost:8080/api/v1/diag/net",
+			},
+		},
+	})
+
 	// Sort categories alphabetically
 	sort.Slice(apiDocs, func(i, j int) bool {
 		return apiDocs[i].Name < apiDocs[j].Name
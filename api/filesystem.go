@@ -1,20 +1,23 @@
 package api
 
 import (
+	"context"
 	// "errors"
 	"fmt"
-	// "io/fs"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nathfavour/noplacelike.go/config"
-
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 	// "strings" // Import strings package
-	"io"
 )
 
 // FileInfo represents information about a file
@@ -34,24 +37,53 @@ type DirContents struct {
 	Files       []FileInfo `json:"files"`
 }
 
+// Limits for recursive directory listings
+const (
+	// DefaultListDepth is used when a recursive listing gives no maxDepth
+	DefaultListDepth = 3
+	// DefaultMaxListEntries caps a recursive listing when the config does not
+	DefaultMaxListEntries = 10000
+)
+
+// TreeEntry is one entry of a recursive directory listing
+type TreeEntry struct {
+	FileInfo
+	// Path is relative to the listed directory, with forward slashes
+	Path  string `json:"path"`
+	Depth int    `json:"depth"`
+}
+
+// DirTree is the result of a recursive directory listing. Truncated is set
+// when the entry cap was reached before the walk finished.
+type DirTree struct {
+	Path      string      `json:"path"`
+	MaxDepth  int         `json:"maxDepth"`
+	Entries   []TreeEntry `json:"entries"`
+	Truncated bool        `json:"truncated"`
+}
+
 // FileSystemAPI handles filesystem operations
 type FileSystemAPI struct {
-	config *config.Config
+	store   *config.Store
+	buffers *core.BufferPool
 }
 
 // NewFileSystemAPI creates a new filesystem API handler
 func NewFileSystemAPI(cfg *config.Config) *FileSystemAPI {
 	return &FileSystemAPI{
-		config: cfg,
+		store:   config.NewStore(cfg),
+		buffers: core.NewBufferPool(cfg.CopyBufferSize),
 	}
 }
 
+// config returns the current configuration, picking up changes to the
+// config file without re-reading it on every request
+func (f *FileSystemAPI) config() *config.Config {
+	return f.store.Current()
+}
+
 // ListDirectory lists contents of a directory
 func (f *FileSystemAPI) ListDirectory(c *gin.Context) {
-	// Reload configuration on each request
-	if cfg, err := config.Load(); err == nil {
-		f.config = cfg
-	}
 	path := c.Query("path")
 	if path == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -71,6 +103,11 @@ func (f *FileSystemAPI) ListDirectory(c *gin.Context) {
 	// Expand path if needed
 	expandedPath := expandPath(path)
 
+	if c.Query("recursive") == "true" {
+		f.listTree(c, path, expandedPath)
+		return
+	}
+
 	// Read directory contents
 	entries, err := os.ReadDir(expandedPath)
 	if err != nil {
@@ -81,6 +118,7 @@ func (f *FileSystemAPI) ListDirectory(c *gin.Context) {
 	}
 
 	// Process directory contents
+	showHidden := f.config().ShowHidden
 	contents := DirContents{
 		Path:        path,
 		Directories: []string{},
@@ -89,7 +127,7 @@ func (f *FileSystemAPI) ListDirectory(c *gin.Context) {
 
 	for _, entry := range entries {
 		// Skip hidden files by default, unless explicitly requested
-		if !f.config.ShowHidden && entry.Name()[0] == '.' {
+		if !showHidden && entry.Name()[0] == '.' {
 			continue
 		}
 
@@ -124,12 +162,95 @@ func (f *FileSystemAPI) ListDirectory(c *gin.Context) {
 	c.JSON(http.StatusOK, contents)
 }
 
+// listTree walks a directory breadth-first for ListDirectory's recursive
+// mode, down to maxDepth levels and up to the configured entry cap
+func (f *FileSystemAPI) listTree(c *gin.Context, path, root string) {
+	maxDepth := DefaultListDepth
+	if v := c.Query("maxDepth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "maxDepth must be a positive integer",
+			})
+			return
+		}
+		maxDepth = n
+	}
+
+	cfg := f.config()
+	maxEntries := cfg.MaxListEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxListEntries
+	}
+
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("Unable to read directory: %s", path),
+		})
+		return
+	}
+
+	type pending struct {
+		dir   string
+		rel   string
+		depth int
+	}
+
+	tree := DirTree{Path: path, MaxDepth: maxDepth, Entries: []TreeEntry{}}
+	queue := []pending{{dir: root, depth: 1}}
+	for len(queue) > 0 && !tree.Truncated {
+		next := queue[0]
+		queue = queue[1:]
+
+		entries, err := os.ReadDir(next.dir)
+		if err != nil {
+			continue // Skip directories that can't be read
+		}
+		for _, entry := range entries {
+			if !cfg.ShowHidden && entry.Name()[0] == '.' {
+				continue
+			}
+			full := filepath.Join(next.dir, entry.Name())
+			if !f.isPathAllowed(full) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if len(tree.Entries) >= maxEntries {
+				tree.Truncated = true
+				break
+			}
+
+			rel := entry.Name()
+			if next.rel != "" {
+				rel = next.rel + "/" + rel
+			}
+			tree.Entries = append(tree.Entries, TreeEntry{
+				FileInfo: FileInfo{
+					Name:    entry.Name(),
+					Size:    info.Size(),
+					IsDir:   entry.IsDir(),
+					ModTime: info.ModTime(),
+					Mode:    info.Mode().String(),
+				},
+				Path:  rel,
+				Depth: next.depth,
+			})
+
+			// Symlinked directories are listed but not followed
+			if entry.IsDir() && next.depth < maxDepth {
+				queue = append(queue, pending{dir: full, rel: rel, depth: next.depth + 1})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
 // GetFileContent retrieves the content of a file
 func (f *FileSystemAPI) GetFileContent(c *gin.Context) {
-	// Reload configuration on each request
-	if cfg, err := config.Load(); err == nil {
-		f.config = cfg
-	}
 	path := c.Query("path")
 	if path == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -166,9 +287,10 @@ func (f *FileSystemAPI) GetFileContent(c *gin.Context) {
 	}
 
 	// Only enforce size limit if MaxFileContentSize > 0 (0 means unlimited)
-	if f.config.MaxFileContentSize > 0 && info.Size() > int64(f.config.MaxFileContentSize) {
+	maxSize := f.config().MaxFileContentSize
+	if maxSize > 0 && info.Size() > int64(maxSize) {
 		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
-			"error": fmt.Sprintf("File too large (max %d bytes)", f.config.MaxFileContentSize),
+			"error": fmt.Sprintf("File too large (max %d bytes)", maxSize),
 		})
 		return
 	}
@@ -202,20 +324,25 @@ func (f *FileSystemAPI) GetFileContent(c *gin.Context) {
 	})
 }
 
-// isPathAllowed checks if a path is allowed for access
+// isPathAllowed checks if a path is allowed for access. Both the path and
+// the allowed paths are compared with symlinks resolved, so a symlinked
+// allowed directory still admits the files in it.
 func (f *FileSystemAPI) isPathAllowed(path string) bool {
+	target := resolvePath(expandPath(path))
+
 	// If no allowed paths are specified, use a safe default
-	if len(f.config.AllowedPaths) == 0 {
+	allowedPaths := f.config().AllowedPaths
+	if len(allowedPaths) == 0 {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return false
 		}
-		return isSubPath(expandPath(path), filepath.Join(homeDir, "Downloads"))
+		return isSubPath(target, resolvePath(filepath.Join(homeDir, "Downloads")))
 	}
 
 	// Otherwise check if path is within any allowed path
-	for _, allowedPath := range f.config.AllowedPaths {
-		if isSubPath(expandPath(path), expandPath(allowedPath)) {
+	for _, allowedPath := range allowedPaths {
+		if isSubPath(target, resolvePath(expandPath(allowedPath))) {
 			return true
 		}
 	}
@@ -226,9 +353,8 @@ func (f *FileSystemAPI) isPathAllowed(path string) bool {
 // detectContentType tries to determine if a file is text or binary
 func detectContentType(content []byte, path string) string {
 	// First check file extension
-	switch filepath.Ext(path) {
-	case ".txt", ".md", ".json", ".xml", ".html", ".htm", ".css", ".js", ".go", ".py", ".c", ".cpp", ".h", ".java":
-		return "text/plain"
+	if filetype.Is(path, filetype.Text) {
+		return filetype.MIMEType(path)
 	}
 
 	// Then try http.DetectContentType
@@ -237,10 +363,6 @@ func detectContentType(content []byte, path string) string {
 
 // ServeFile serves raw file content for download or streaming
 func (f *FileSystemAPI) ServeFile(c *gin.Context) {
-	// Reload config on each request
-	if cfg, err := config.Load(); err == nil {
-		f.config = cfg
-	}
 	path := c.Query("path")
 	if path == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Path parameter is required"})
@@ -251,6 +373,9 @@ func (f *FileSystemAPI) ServeFile(c *gin.Context) {
 		return
 	}
 	expandedPath := expandPath(path)
+	if info, ok := filetype.Lookup(expandedPath); ok {
+		c.Header("Content-Type", info.MIMEType)
+	}
 	// Serve file with proper headers (supports Range). Use attachment when download=true
 	if c.Query("download") == "true" {
 		c.FileAttachment(expandedPath, filepath.Base(expandedPath))
@@ -342,7 +467,7 @@ func (f *FileSystemAPI) CopyFile(c *gin.Context) {
 		return
 	}
 	defer out.Close()
-	if _, err := io.Copy(out, in); err != nil {
+	if _, err := f.buffers.Copy(out, in); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -367,27 +492,50 @@ func (f *FileSystemAPI) MoveFile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "moved"})
 }
 
-// SearchFiles searches for files by name in allowed paths
+// SearchFiles searches for files by name in allowed paths. A search that
+// runs past the walk timeout returns what it found so far, marked truncated.
 func (f *FileSystemAPI) SearchFiles(c *gin.Context) {
 	q := c.Query("q")
 	if q == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing query"})
 		return
 	}
+
+	cfg := f.config()
+	workers, timeout := walkLimits(cfg.WalkWorkers, cfg.WalkTimeoutSeconds)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	roots := make([]string, 0, len(cfg.AllowedPaths))
+	for _, base := range cfg.AllowedPaths {
+		roots = append(roots, expandPath(base))
+	}
+
+	var mu sync.Mutex
 	var results []FileInfo
-	for _, base := range f.config.AllowedPaths {
-		_ = filepath.Walk(expandPath(base), func(path string, info os.FileInfo, err error) error {
-			if err == nil && info != nil && !info.IsDir() && filepath.Base(path) == q {
-				results = append(results, FileInfo{
-					Name:    info.Name(),
-					Size:    info.Size(),
-					IsDir:   false,
-					ModTime: info.ModTime(),
-					Mode:    info.Mode().String(),
-				})
+	err := walkDirs(ctx, roots, workers, func(dir string, entries []fs.DirEntry) {
+		for _, entry := range entries {
+			if entry.IsDir() || entry.Name() != q {
+				continue
 			}
-			return nil
-		})
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			mu.Lock()
+			results = append(results, FileInfo{
+				Name:    info.Name(),
+				Size:    info.Size(),
+				IsDir:   false,
+				ModTime: info.ModTime(),
+				Mode:    info.Mode().String(),
+			})
+			mu.Unlock()
+		}
+	})
+	if err != nil && c.Request.Context().Err() != nil {
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"results": results})
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "truncated": err != nil})
 }
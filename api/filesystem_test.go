@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+func TestServeFileHead(t *testing.T) {
+	// Keep the user's config file from being picked up
+	t.Setenv("HOME", t.TempDir())
+	allowed := t.TempDir()
+	file := filepath.Join(allowed, "notes.txt")
+	if err := os.WriteFile(file, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	fs := NewFileSystemAPI(&config.Config{AllowedPaths: []string{allowed}})
+	router.GET("/serve", fs.ServeFile)
+	router.HEAD("/serve", fs.ServeFile)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		status        int
+		contentLength string
+		body          string
+	}{
+		{"head", http.MethodHead, file, http.StatusOK, "10", ""},
+		{"get", http.MethodGet, file, http.StatusOK, "10", "0123456789"},
+		{"head outside allowed paths", http.MethodHead, filepath.Join(t.TempDir(), "x"), http.StatusForbidden, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, "/serve?path="+url.QueryEscape(tt.path), nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Length"); got != tt.contentLength {
+				t.Errorf("Content-Length = %q, want %q", got, tt.contentLength)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestListDirectoryRecursive(t *testing.T) {
+	// Keep the user's config file from being picked up
+	t.Setenv("HOME", t.TempDir())
+	allowed := t.TempDir()
+	for _, name := range []string{"a.txt", ".hidden", "dir1/b.txt", "dir1/dir2/c.txt", "dir1/dir2/dir3/d.txt"} {
+		path := filepath.Join(allowed, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all := []string{"a.txt", "dir1", "dir1/b.txt", "dir1/dir2", "dir1/dir2/c.txt", "dir1/dir2/dir3", "dir1/dir2/dir3/d.txt"}
+
+	tests := []struct {
+		name       string
+		query      string
+		showHidden bool
+		maxEntries int
+		status     int
+		want       []string
+		truncated  bool
+	}{
+		{"default depth", "", false, 0, http.StatusOK, all[:6], false},
+		{"one level", "&maxDepth=1", false, 0, http.StatusOK, all[:2], false},
+		{"two levels", "&maxDepth=2", false, 0, http.StatusOK, all[:4], false},
+		{"whole tree", "&maxDepth=10", false, 0, http.StatusOK, all, false},
+		{"hidden files shown", "&maxDepth=1", true, 0, http.StatusOK, []string{".hidden", "a.txt", "dir1"}, false},
+		{"truncated", "&maxDepth=10", false, 3, http.StatusOK, all[:3], true},
+		{"cap matches the tree", "&maxDepth=10", false, len(all), http.StatusOK, all, false},
+		{"zero depth", "&maxDepth=0", false, 0, http.StatusBadRequest, nil, false},
+		{"bad depth", "&maxDepth=deep", false, 0, http.StatusBadRequest, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			fs := NewFileSystemAPI(&config.Config{
+				AllowedPaths:   []string{allowed},
+				ShowHidden:     tt.showHidden,
+				MaxListEntries: tt.maxEntries,
+			})
+			router.GET("/list", fs.ListDirectory)
+
+			rec := httptest.NewRecorder()
+			target := "/list?recursive=true&path=" + url.QueryEscape(allowed) + tt.query
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var tree DirTree
+			if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			var paths []string
+			for _, entry := range tree.Entries {
+				paths = append(paths, entry.Path)
+				if want := strings.Count(entry.Path, "/") + 1; entry.Depth != want {
+					t.Errorf("%s has depth %d, want %d", entry.Path, entry.Depth, want)
+				}
+			}
+			// Breadth-first, so shallower entries always come first
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("entries = %v, want %v", paths, tt.want)
+			}
+			if tree.Truncated != tt.truncated {
+				t.Errorf("truncated = %v, want %v", tree.Truncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestListDirectoryRecursiveOutsideAllowedPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(allowed, "a.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	fs := NewFileSystemAPI(&config.Config{AllowedPaths: []string{allowed}})
+	router.GET("/list", fs.ListDirectory)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   []string
+	}{
+		{"symlink leaving the allowed paths", allowed, http.StatusOK, []string{"a.txt"}},
+		{"root outside the allowed paths", outside, http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			target := "/list?recursive=true&maxDepth=5&path=" + url.QueryEscape(tt.path)
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var tree DirTree
+			if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			var paths []string
+			for _, entry := range tree.Entries {
+				paths = append(paths, entry.Path)
+			}
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("entries = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
@@ -1,22 +1,29 @@
 package api
 
 import (
+	"context"
 	// "errors"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 )
 
 // MediaAPI handles media streaming operations
 type MediaAPI struct {
 	config     *config.Config
 	wsUpgrader websocket.Upgrader
+	scans      mediaScanCache
 }
 
 // NewMediaAPI creates a new media API handler
@@ -24,9 +31,7 @@ func NewMediaAPI(cfg *config.Config) *MediaAPI {
 	return &MediaAPI{
 		config: cfg,
 		wsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow connections from any origin
-			},
+			CheckOrigin: core.WebSocketOriginChecker(cfg.WebSocketOrigins, true),
 		},
 	}
 }
@@ -195,45 +200,66 @@ type MediaDirInfo struct {
 	SampleFiles []string `json:"sampleFiles"`
 }
 
-// ScanMediaDirectories scans allowed paths for media-rich directories
+// ScanMediaDirectories scans allowed paths for media-rich directories. Scans
+// are cached until the TTL passes or a scanned directory changes; refresh=true
+// forces a new scan. A scan that runs past the walk timeout returns what it
+// found so far, marked truncated, and is not cached.
 func (m *MediaAPI) ScanMediaDirectories(c *gin.Context) {
+	ttl := time.Duration(m.config.MediaScanCacheSeconds) * time.Second
+	if m.config.MediaScanCacheSeconds == 0 {
+		ttl = DefaultMediaScanTTL
+	}
+	audioExts := m.config.AudioExtensions()
+	key := mediaScanKey(m.config.AllowedPaths, audioExts)
+	if ttl > 0 && c.Query("refresh") != "true" {
+		if results, ok := m.scans.get(key); ok {
+			c.JSON(http.StatusOK, gin.H{"mediaDirs": results, "truncated": false, "cached": true})
+			return
+		}
+	}
+
+	workers, timeout := walkLimits(m.config.WalkWorkers, m.config.WalkTimeoutSeconds)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	var mu sync.Mutex
 	var results []MediaDirInfo
-	audioExts := map[string]bool{".mp3": true, ".wav": true, ".flac": true, ".aac": true, ".ogg": true, ".m4a": true}
-	visited := make(map[string]bool)
-	for _, base := range m.config.AllowedPaths {
-		_ = filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
-			if err != nil || !info.IsDir() {
-				return nil
+	err := walkDirs(ctx, m.config.AllowedPaths, workers, func(path string, files []fs.DirEntry) {
+		total, audio := 0, 0
+		var samples []string
+		for _, f := range files {
+			if f.IsDir() {
+				continue
 			}
-			if visited[path] {
-				return nil
-			}
-			visited[path] = true
-			files, _ := os.ReadDir(path)
-			total, audio := 0, 0
-			var samples []string
-			for _, f := range files {
-				if f.IsDir() {
-					continue
-				}
-				total++
-				ext := filepath.Ext(f.Name())
-				if audioExts[ext] {
-					audio++
-					if len(samples) < 3 {
-						samples = append(samples, f.Name())
-					}
+			total++
+			if audioExts.Has(f.Name()) {
+				audio++
+				if len(samples) < 3 {
+					samples = append(samples, f.Name())
 				}
 			}
-			if total > 0 && float64(audio)/float64(total) > 0.5 && audio >= 3 {
-				results = append(results, MediaDirInfo{
-					Path: path, AudioCount: audio, TotalCount: total, Ratio: float64(audio) / float64(total), SampleFiles: samples,
-				})
-			}
-			return nil
-		})
+		}
+		if total > 0 && float64(audio)/float64(total) > 0.5 && audio >= 3 {
+			mu.Lock()
+			results = append(results, MediaDirInfo{
+				Path: path, AudioCount: audio, TotalCount: total, Ratio: float64(audio) / float64(total), SampleFiles: samples,
+			})
+			mu.Unlock()
+		}
+	})
+	if err != nil && c.Request.Context().Err() != nil {
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"mediaDirs": results})
+
+	// Workers finish in any order
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	if err == nil && ttl > 0 {
+		dirs := append([]string(nil), m.config.AllowedPaths...)
+		for _, result := range results {
+			dirs = append(dirs, result.Path)
+		}
+		m.scans.store(key, results, ttl, dirs)
+	}
+	c.JSON(http.StatusOK, gin.H{"mediaDirs": results, "truncated": err != nil, "cached": false})
 }
 
 // ListMediaFiles lists audio files in a directory
@@ -245,9 +271,9 @@ func (m *MediaAPI) ListMediaFiles(c *gin.Context) {
 	}
 	files, _ := os.ReadDir(dir)
 	var audioFiles []string
-	audioExts := map[string]bool{".mp3": true, ".wav": true, ".flac": true, ".aac": true, ".ogg": true, ".m4a": true}
+	audioExts := m.config.AudioExtensions()
 	for _, f := range files {
-		if !f.IsDir() && audioExts[filepath.Ext(f.Name())] {
+		if !f.IsDir() && audioExts.Has(f.Name()) {
 			audioFiles = append(audioFiles, f.Name())
 		}
 	}
@@ -279,38 +305,16 @@ func (m *MediaAPI) StreamAudioFile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
-	ext := filepath.Ext(file)
-	audioExts := map[string]bool{".mp3": true, ".wav": true, ".flac": true, ".aac": true, ".ogg": true, ".m4a": true}
-	if !audioExts[ext] {
+	if !m.config.AudioExtensions().Has(file) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Not an audio file"})
 		return
 	}
 	// Set headers for streaming
-	c.Header("Content-Type", getAudioMimeType(ext))
+	c.Header("Content-Type", filetype.MIMEType(file))
 	c.Header("Content-Disposition", "inline; filename="+filepath.Base(file))
 	c.File(file)
 }
 
-// getAudioMimeType returns the MIME type for a given audio file extension
-func getAudioMimeType(ext string) string {
-	switch ext {
-	case ".mp3":
-		return "audio/mpeg"
-	case ".wav":
-		return "audio/wav"
-	case ".flac":
-		return "audio/flac"
-	case ".aac":
-		return "audio/aac"
-	case ".ogg":
-		return "audio/ogg"
-	case ".m4a":
-		return "audio/mp4"
-	default:
-		return "application/octet-stream"
-	}
-}
-
 // GetMediaMetadata returns basic metadata for an audio file
 func (m *MediaAPI) GetMediaMetadata(c *gin.Context) {
 	file := c.Query("file")
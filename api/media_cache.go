@@ -0,0 +1,110 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
+)
+
+// DefaultMediaScanTTL is used when the config gives no media scan cache TTL
+const DefaultMediaScanTTL = 5 * time.Minute
+
+// mediaScanCache holds the last media directory scan. The scanned roots and
+// the media directories found are watched, and any change in them drops the
+// cached scan; changes deeper in the tree are picked up when it expires.
+type mediaScanCache struct {
+	mu      sync.Mutex
+	key     string
+	results []MediaDirInfo
+	expires time.Time
+	watcher *fsnotify.Watcher
+}
+
+// mediaScanKey identifies a scan of a set of directories for a set of audio
+// extensions, regardless of order
+func mediaScanKey(roots []string, extensions filetype.ExtensionSet) string {
+	sorted := append([]string(nil), roots...)
+	sort.Strings(sorted)
+	exts := make([]string, 0, len(extensions))
+	for ext := range extensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return strings.Join(sorted, "\x00") + "\x01" + strings.Join(exts, "\x00")
+}
+
+// get returns the cached scan for key, if there is a current one
+func (m *mediaScanCache) get(key string) ([]MediaDirInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.results == nil || m.key != key || time.Now().After(m.expires) {
+		return nil, false
+	}
+	return m.results, true
+}
+
+// store caches results for key until ttl passes or one of dirs changes
+func (m *mediaScanCache) store(key string, results []MediaDirInfo, ttl time.Duration, dirs []string) {
+	if results == nil {
+		results = []MediaDirInfo{}
+	}
+
+	// Watching is best effort: without it the scan is still cached for ttl
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		for _, dir := range dirs {
+			_ = watcher.Add(dir)
+		}
+	}
+
+	m.mu.Lock()
+	old := m.watcher
+	m.key = key
+	m.results = results
+	m.expires = time.Now().Add(ttl)
+	m.watcher = nil
+	if err == nil {
+		m.watcher = watcher
+	}
+	m.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	if err == nil {
+		go m.watch(watcher)
+	}
+}
+
+// watch drops the cached scan on the first change watcher reports, unless
+// the scan was replaced in the meantime
+func (m *mediaScanCache) watch(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			m.mu.Lock()
+			if m.watcher == watcher {
+				m.results = nil
+				m.watcher = nil
+			}
+			m.mu.Unlock()
+			watcher.Close()
+			return
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
+)
+
+type mediaScanResponse struct {
+	MediaDirs []MediaDirInfo `json:"mediaDirs"`
+	Truncated bool           `json:"truncated"`
+	Cached    bool           `json:"cached"`
+}
+
+// scanMedia calls the scan handler on router with query
+func scanMedia(t *testing.T, router *gin.Engine, query string) mediaScanResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scan"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var body mediaScanResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	return body
+}
+
+// newMediaRouter returns a router serving scans of a fresh allowed directory
+// holding one media directory, and the path of that media directory
+func newMediaRouter(t *testing.T, cacheSeconds int) (*gin.Engine, string) {
+	t.Helper()
+	root := t.TempDir()
+	music := filepath.Join(root, "music")
+	if err := os.Mkdir(music, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"1.mp3", "2.mp3", "3.mp3"} {
+		if err := os.WriteFile(filepath.Join(music, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	media := NewMediaAPI(&config.Config{AllowedPaths: []string{root}, MediaScanCacheSeconds: cacheSeconds})
+	router.GET("/scan", media.ScanMediaDirectories)
+	return router, music
+}
+
+func TestMediaScanKey(t *testing.T) {
+	mp3 := filetype.NewExtensionSet([]string{".mp3"}, filetype.Audio)
+	mp3flac := filetype.NewExtensionSet([]string{".mp3", ".flac"}, filetype.Audio)
+	flacmp3 := filetype.NewExtensionSet([]string{".flac", ".mp3"}, filetype.Audio)
+
+	tests := []struct {
+		name         string
+		rootsA       []string
+		extsA        filetype.ExtensionSet
+		rootsB       []string
+		extsB        filetype.ExtensionSet
+		wantSameKeys bool
+	}{
+		{"same", []string{"/a", "/b"}, mp3, []string{"/a", "/b"}, mp3, true},
+		{"roots reordered", []string{"/a", "/b"}, mp3flac, []string{"/b", "/a"}, flacmp3, true},
+		{"other roots", []string{"/a"}, mp3, []string{"/b"}, mp3, false},
+		{"extra root", []string{"/a"}, mp3, []string{"/a", "/b"}, mp3, false},
+		{"other extensions", []string{"/a"}, mp3, []string{"/a"}, mp3flac, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := mediaScanKey(tt.rootsA, tt.extsA), mediaScanKey(tt.rootsB, tt.extsB)
+			if (a == b) != tt.wantSameKeys {
+				t.Errorf("keys equal = %v, want %v", a == b, tt.wantSameKeys)
+			}
+		})
+	}
+}
+
+func TestScanMediaDirectoriesCache(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheSeconds int
+		query        string
+		wantCached   bool
+	}{
+		{"default TTL", 0, "", true},
+		{"configured TTL", 60, "", true},
+		{"refresh", 60, "?refresh=true", false},
+		{"cache disabled", -1, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newMediaRouter(t, tt.cacheSeconds)
+			if first := scanMedia(t, router, ""); first.Cached || len(first.MediaDirs) != 1 {
+				t.Fatalf("first scan = %+v, want one uncached media directory", first)
+			}
+			second := scanMedia(t, router, tt.query)
+			if second.Cached != tt.wantCached || len(second.MediaDirs) != 1 {
+				t.Errorf("second scan = %+v, want cached = %v", second, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestScanMediaDirectoriesCacheInvalidated(t *testing.T) {
+	router, music := newMediaRouter(t, 60)
+	scanMedia(t, router, "")
+	if !scanMedia(t, router, "").Cached {
+		t.Fatal("second scan not served from the cache")
+	}
+
+	// Another track changes the counts the cached scan reported
+	if err := os.WriteFile(filepath.Join(music, "4.mp3"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := scanMedia(t, router, "")
+		if !body.Cached {
+			if len(body.MediaDirs) != 1 || body.MediaDirs[0].AudioCount != 4 {
+				t.Errorf("rescan = %+v, want 4 tracks", body.MediaDirs)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cached scan served after a scanned directory changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMediaScanCacheExpires(t *testing.T) {
+	var cache mediaScanCache
+	results := []MediaDirInfo{{Path: "/music"}}
+	cache.store("key", results, 20*time.Millisecond, nil)
+
+	if _, ok := cache.get("other"); ok {
+		t.Error("scan served for another key")
+	}
+	if got, ok := cache.get("key"); !ok || len(got) != 1 {
+		t.Errorf("get = %v, %v before the TTL passed", got, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := cache.get("key"); ok {
+		t.Error("scan served after the TTL passed")
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+func TestConfiguredAudioExtensions(t *testing.T) {
+	root := t.TempDir()
+	music := filepath.Join(root, "music")
+	if err := os.Mkdir(music, 0755); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"a.opus", "b.opus", "c.OPUS", "d.mp3", "e.flac"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(music, name), []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		extensions []string
+		// audio lists the files in music treated as audio
+		audio []string
+	}{
+		{"default", nil, []string{"a.opus", "b.opus", "c.OPUS", "d.mp3", "e.flac"}},
+		{"new format only", []string{".opus"}, []string{"a.opus", "b.opus", "c.OPUS"}},
+		{"without a dot", []string{"opus", "mp3"}, []string{"a.opus", "b.opus", "c.OPUS", "d.mp3"}},
+		{"other formats", []string{".wav", ".flac"}, []string{"e.flac"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			media := NewMediaAPI(&config.Config{AllowedPaths: []string{root}, AllowedAudioExtensions: tt.extensions})
+			router.GET("/scan", media.ScanMediaDirectories)
+			router.GET("/files", media.ListMediaFiles)
+			router.GET("/stream", media.StreamAudioFile)
+			get := func(path string) *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				return rec
+			}
+
+			// Scans count the configured formats, needing 3 or more making
+			// up most of the directory
+			var scan struct {
+				MediaDirs []MediaDirInfo `json:"mediaDirs"`
+			}
+			if err := json.Unmarshal(get("/scan").Body.Bytes(), &scan); err != nil {
+				t.Fatalf("decode scan: %v", err)
+			}
+			wantDir := len(tt.audio) >= 3
+			if gotDir := len(scan.MediaDirs) == 1; gotDir != wantDir {
+				t.Fatalf("scan found %v, want the music directory = %v", scan.MediaDirs, wantDir)
+			}
+			if wantDir && scan.MediaDirs[0].AudioCount != len(tt.audio) {
+				t.Errorf("scan counted %d audio files, want %d", scan.MediaDirs[0].AudioCount, len(tt.audio))
+			}
+
+			var list struct {
+				Files []string `json:"files"`
+			}
+			if err := json.Unmarshal(get("/files?dir="+url.QueryEscape(music)).Body.Bytes(), &list); err != nil {
+				t.Fatalf("decode listing: %v", err)
+			}
+			slices.Sort(list.Files)
+			if !slices.Equal(list.Files, tt.audio) {
+				t.Errorf("listed %v, want %v", list.Files, tt.audio)
+			}
+
+			for _, name := range names {
+				rec := get("/stream?file=" + url.QueryEscape(filepath.Join(music, name)))
+				want := http.StatusBadRequest
+				if slices.Contains(tt.audio, name) {
+					want = http.StatusOK
+				}
+				if rec.Code != want {
+					t.Errorf("streaming %s: status %d, want %d", name, rec.Code, want)
+				}
+			}
+		})
+	}
+}
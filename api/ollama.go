@@ -2,20 +2,104 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	ollama "github.com/JexSrs/go-ollama"
 	"github.com/gin-gonic/gin"
 )
 
+// DefaultOllamaTimeout is how long the proxy waits for Ollama to send
+// something before giving up
+const DefaultOllamaTimeout = 2 * time.Minute
+
 type OllamaAPI struct {
 	BaseURL string
+	// Timeout aborts an upstream request when Ollama sends nothing for this
+	// long. Streamed responses restart it on every chunk. Defaults to
+	// DefaultOllamaTimeout.
+	Timeout time.Duration
+	// Authorize guards the endpoints that change which models are installed
+	// (pull and delete). Nil leaves them open.
+	Authorize gin.HandlerFunc
 }
 
 func NewOllamaAPI(baseURL string) *OllamaAPI {
-	return &OllamaAPI{BaseURL: baseURL}
+	return &OllamaAPI{BaseURL: baseURL, Timeout: DefaultOllamaTimeout}
+}
+
+// upstreamWatchdog cancels an upstream request once it has been idle for
+// longer than the timeout
+type upstreamWatchdog struct {
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+func newUpstreamWatchdog(timeout time.Duration, cancel context.CancelFunc) *upstreamWatchdog {
+	w := &upstreamWatchdog{timeout: timeout}
+	w.timer = time.AfterFunc(timeout, func() {
+		w.timedOut.Store(true)
+		cancel()
+	})
+	return w
+}
+
+// reset restarts the idle timeout, e.g. after a chunk was received
+func (w *upstreamWatchdog) reset() {
+	w.timer.Reset(w.timeout)
+}
+
+func (w *upstreamWatchdog) stop() {
+	w.timer.Stop()
+}
+
+// watchdogTransport binds upstream requests to the proxy request's context
+// and feeds the watchdog as response data arrives
+type watchdogTransport struct {
+	ctx      context.Context
+	watchdog *upstreamWatchdog
+}
+
+func (t *watchdogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req.WithContext(t.ctx))
+	if err != nil {
+		return nil, err
+	}
+	t.watchdog.reset()
+	resp.Body = &watchdogBody{ReadCloser: resp.Body, watchdog: t.watchdog}
+	return resp, nil
+}
+
+type watchdogBody struct {
+	io.ReadCloser
+	watchdog *upstreamWatchdog
+}
+
+func (b *watchdogBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.watchdog.reset()
+	}
+	return n, err
+}
+
+// upstreamError reports a failed Ollama call, as 504 if it timed out
+func (o *OllamaAPI) upstreamError(c *gin.Context, err error, watchdog *upstreamWatchdog) {
+	if watchdog.timedOut.Load() {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error": fmt.Sprintf("Ollama did not respond within %s", watchdog.timeout),
+		})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 }
 
 // Proxy all requests to Ollama
@@ -33,6 +117,42 @@ func (o *OllamaAPI) Proxy(c *gin.Context) {
 	}
 	LLM := ollama.New(*parsedURL)
 
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = DefaultOllamaTimeout
+	}
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	watchdog := newUpstreamWatchdog(timeout, cancel)
+	defer watchdog.stop()
+	LLM.Http = &http.Client{Transport: &watchdogTransport{ctx: ctx, watchdog: watchdog}}
+
+	if path == "/pull" || strings.HasPrefix(path, "/models/") {
+		if o.Authorize != nil {
+			if o.Authorize(c); c.IsAborted() {
+				return
+			}
+		}
+	}
+
+	switch {
+	case path == "/pull" && c.Request.Method == http.MethodPost:
+		o.pullModel(c, LLM, watchdog)
+		return
+	case strings.HasPrefix(path, "/models/") && c.Request.Method == http.MethodDelete:
+		name := strings.TrimPrefix(path, "/models/")
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "model name is required"})
+			return
+		}
+		if err := LLM.Models.Delete(name); err != nil {
+			o.upstreamError(c, err, watchdog)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "deleted", "model": name})
+		return
+	}
+
 	switch path {
 	case "/chat":
 		var req map[string]interface{}
@@ -69,7 +189,7 @@ func (o *OllamaAPI) Proxy(c *gin.Context) {
 			LLM.Chat.WithMessage(msg),
 		)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			o.upstreamError(c, err, watchdog)
 			return
 		}
 		c.JSON(http.StatusOK, res)
@@ -87,7 +207,7 @@ func (o *OllamaAPI) Proxy(c *gin.Context) {
 			LLM.Generate.WithPrompt(prompt),
 		)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			o.upstreamError(c, err, watchdog)
 			return
 		}
 		c.JSON(http.StatusOK, res)
@@ -95,7 +215,7 @@ func (o *OllamaAPI) Proxy(c *gin.Context) {
 	case "/tags":
 		res, err := LLM.Models.List()
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			o.upstreamError(c, err, watchdog)
 			return
 		}
 		c.JSON(http.StatusOK, res)
@@ -104,3 +224,62 @@ func (o *OllamaAPI) Proxy(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "unsupported endpoint"})
 	}
 }
+
+// pullModel downloads a model into Ollama, streaming its progress to the
+// client as newline-delimited JSON
+func (o *OllamaAPI) pullModel(c *gin.Context, LLM *ollama.Ollama, watchdog *upstreamWatchdog) {
+	var req struct {
+		Model    string `json:"model"`
+		Name     string `json:"name"`
+		Insecure bool   `json:"insecure"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+	model := req.Model
+	if model == "" {
+		model = req.Name
+	}
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+
+	started := false
+	enc := json.NewEncoder(c.Writer)
+	_, err := LLM.Models.Pull(
+		LLM.Models.Pull.WithModel(model),
+		LLM.Models.Pull.WithInsecure(req.Insecure),
+		LLM.Models.Pull.WithStream(true, 512000, func(r *ollama.PushPullModelResponse, err error) {
+			if err != nil || r == nil {
+				return
+			}
+			if !started {
+				started = true
+				c.Header("Content-Type", "application/x-ndjson")
+				c.Header("Cache-Control", "no-cache")
+				c.Status(http.StatusOK)
+			}
+			enc.Encode(r)
+			c.Writer.Flush()
+		}),
+	)
+	if err == nil {
+		if !started {
+			c.JSON(http.StatusOK, gin.H{"status": "success", "model": model})
+		}
+		return
+	}
+	if !started {
+		o.upstreamError(c, err, watchdog)
+		return
+	}
+	// Progress has already been sent, so report the failure in the stream
+	status := err.Error()
+	if watchdog.timedOut.Load() {
+		status = fmt.Sprintf("Ollama did not respond within %s", watchdog.timeout)
+	}
+	enc.Encode(gin.H{"status": "error", "error": status})
+	c.Writer.Flush()
+}
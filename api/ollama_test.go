@@ -0,0 +1,302 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+// fakeOllama records the requests it gets and answers pulls with two
+// progress lines, deletes with 200 and model lists with one model
+type fakeOllama struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFakeOllama(t *testing.T) *fakeOllama {
+	t.Helper()
+	f := &fakeOllama{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+		f.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/pull":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			io.WriteString(w, `{"status":"pulling manifest"}`+"\n")
+			w.(http.Flusher).Flush()
+			io.WriteString(w, `{"status":"success"}`+"\n")
+		case "/api/delete":
+			w.WriteHeader(http.StatusOK)
+		case "/api/tags":
+			io.WriteString(w, `{"models":[{"name":"llama3:latest"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeOllama) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// newOllamaRouter proxies to upstream, guarding model changes with an API
+// using verifier
+func newOllamaRouter(upstream string, verifier TokenVerifier) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := NewAPI(&config.Config{})
+	if verifier != nil {
+		a.SetTokenVerifier(verifier)
+	}
+	ollama := NewOllamaAPI(upstream)
+	ollama.Authorize = a.requireToken()
+	router := gin.New()
+	router.Any("/ollama/*proxyPath", ollama.Proxy)
+	return router
+}
+
+// newTestSecurity is a security manager like the platform's
+func newTestSecurity(t *testing.T) core.SecurityManager {
+	t.Helper()
+	security, err := platform.NewSecurityManager(platform.SecurityConfig{
+		EnableAuth:  true,
+		JWTSecret:   "0123456789abcdef0123456789abcdef",
+		JWTIssuer:   "noplacelike",
+		JWTAudience: []string{"noplacelike"},
+		TokenExpiry: time.Hour,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+	return security
+}
+
+func TestOllamaModelChangesNeedToken(t *testing.T) {
+	security := newTestSecurity(t)
+	token, err := security.GenerateToken(&core.User{ID: "alice"})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	other, err := platform.NewSecurityManager(platform.SecurityConfig{
+		EnableAuth: true,
+		JWTSecret:  "fedcba9876543210fedcba9876543210",
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+	foreign, _ := other.GenerateToken(&core.User{ID: "mallory"})
+
+	requests := []struct {
+		method, path, body, upstream string
+	}{
+		{http.MethodPost, "/ollama/pull", `{"model":"llama3"}`, "POST /api/pull"},
+		{http.MethodDelete, "/ollama/models/llama3", "", "DELETE /api/delete"},
+	}
+	tests := []struct {
+		name     string
+		verifier TokenVerifier
+		auth     string
+		want     int
+	}{
+		{"no verifier", nil, "Bearer " + token, http.StatusServiceUnavailable},
+		{"no token", security, "", http.StatusUnauthorized},
+		{"not a bearer token", security, "Basic " + token, http.StatusUnauthorized},
+		{"token from another issuer", security, "Bearer " + foreign, http.StatusUnauthorized},
+		{"garbage token", security, "Bearer nope", http.StatusUnauthorized},
+		{"platform token", security, "Bearer " + token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		for _, r := range requests {
+			t.Run(tt.name+" "+r.method, func(t *testing.T) {
+				upstream := newFakeOllama(t)
+				router := newOllamaRouter(upstream.URL, tt.verifier)
+
+				req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+				req.Header.Set("Content-Type", "application/json")
+				if tt.auth != "" {
+					req.Header.Set("Authorization", tt.auth)
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				if rec.Code != tt.want {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+				}
+				got := upstream.received()
+				if tt.want != http.StatusOK {
+					if len(got) != 0 {
+						t.Fatalf("rejected request reached Ollama: %v", got)
+					}
+					return
+				}
+				if len(got) != 1 || !strings.HasPrefix(got[0], r.upstream) {
+					t.Fatalf("Ollama got %v, want %s", got, r.upstream)
+				}
+			})
+		}
+	}
+}
+
+func TestOllamaPullStreamsProgress(t *testing.T) {
+	security := newTestSecurity(t)
+	token, _ := security.GenerateToken(&core.User{ID: "alice"})
+	upstream := newFakeOllama(t)
+	router := newOllamaRouter(upstream.URL, security)
+
+	req := httptest.NewRequest(http.MethodPost, "/ollama/pull", strings.NewReader(`{"name":"llama3"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var statuses []string
+	dec := json.NewDecoder(rec.Body)
+	for dec.More() {
+		var line struct {
+			Status string `json:"status"`
+		}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		statuses = append(statuses, line.Status)
+	}
+	if strings.Join(statuses, ",") != "pulling manifest,success" {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if got := upstream.received(); len(got) != 1 || !strings.Contains(got[0], `"model":"llama3"`) {
+		t.Errorf("Ollama got %v", got)
+	}
+}
+
+func TestOllamaListNeedsNoToken(t *testing.T) {
+	upstream := newFakeOllama(t)
+	router := newOllamaRouter(upstream.URL, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ollama/tags", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "llama3:latest") {
+		t.Errorf("body = %s", rec.Body)
+	}
+}
+
+// newSlowOllama serves handler as Ollama
+func newSlowOllama(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(handler)
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// stall blocks until the proxy gives up on r
+func stall(r *http.Request) {
+	// The server only notices the client going away once the body is read
+	io.Copy(io.Discard, r.Body)
+	select {
+	case <-r.Context().Done():
+	case <-time.After(5 * time.Second):
+	}
+}
+
+// pullLines writes the pull progress lines, waiting gap before each, then
+// stalls if stuck
+func pullLines(lines int, gap time.Duration, stuck bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 0; i < lines; i++ {
+			time.Sleep(gap)
+			io.WriteString(w, `{"status":"downloading"}`+"\n")
+			w.(http.Flusher).Flush()
+		}
+		if stuck {
+			stall(r)
+			return
+		}
+		io.WriteString(w, `{"status":"success"}`+"\n")
+	}
+}
+
+func TestOllamaTimeout(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		upstream   http.HandlerFunc
+		wantStatus int
+		// wantLast is the status of the last streamed progress line
+		wantLast string
+	}{
+		{"stuck request", http.MethodGet, "/ollama/tags", "", func(w http.ResponseWriter, r *http.Request) { stall(r) }, http.StatusGatewayTimeout, ""},
+		{"stuck before progress", http.MethodPost, "/ollama/pull", `{"model":"llama3"}`, pullLines(0, 0, true), http.StatusGatewayTimeout, ""},
+		// Each chunk arrives within the timeout, though the whole pull takes longer
+		{"slow stream", http.MethodPost, "/ollama/pull", `{"model":"llama3"}`, pullLines(5, timeout/2, false), http.StatusOK, "success"},
+		{"stream stalls", http.MethodPost, "/ollama/pull", `{"model":"llama3"}`, pullLines(1, 0, true), http.StatusOK, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := newSlowOllama(t, tt.upstream)
+			gin.SetMode(gin.TestMode)
+			ollama := NewOllamaAPI(upstream.URL)
+			ollama.Timeout = timeout
+			router := gin.New()
+			router.Any("/ollama/*proxyPath", ollama.Proxy)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			router.ServeHTTP(rec, req)
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("proxy took %v with a %v timeout", elapsed, timeout)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantLast == "" {
+				return
+			}
+			lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+			var last struct {
+				Status string `json:"status"`
+				Error  string `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+				t.Fatalf("decode %q: %v", lines[len(lines)-1], err)
+			}
+			if last.Status != tt.wantLast {
+				t.Errorf("last progress = %+v, want status %q", last, tt.wantLast)
+			}
+			if tt.wantLast == "error" && !strings.Contains(last.Error, "did not respond") {
+				t.Errorf("error %q doesn't report the timeout", last.Error)
+			}
+		})
+	}
+}
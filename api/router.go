@@ -6,9 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/core"
 )
 
 // API represents the main API handler
@@ -19,6 +22,10 @@ type API struct {
 	shell      *ShellAPI
 	system     *SystemAPI
 	media      *MediaAPI
+	diag       *DiagAPI
+
+	mu       sync.RWMutex
+	verifier TokenVerifier
 }
 
 // NewAPI creates a new API instance
@@ -30,6 +37,7 @@ func NewAPI(cfg *config.Config) *API {
 		shell:      NewShellAPI(cfg),
 		system:     NewSystemAPI(cfg),
 		media:      NewMediaAPI(cfg),
+		diag:       NewDiagAPI(cfg),
 	}
 }
 
@@ -38,6 +46,13 @@ func (a *API) CreateRoutes(router *gin.Engine) {
 	// Initialize API documentation
 	InitDocs()
 
+	// Long-lived streams share one per-client cap
+	maxStreams := a.config.MaxStreamsPerClient
+	if maxStreams == 0 {
+		maxStreams = core.DefaultMaxStreamsPerClient
+	}
+	limitStreams := core.NewStreamLimiter(maxStreams).Middleware()
+
 	// Base API route group
 	api := router.Group("/api")
 	{
@@ -57,7 +72,7 @@ func (a *API) CreateRoutes(router *gin.Engine) {
 				clipboard.POST("", a.clipboard.SetClipboard)
 				clipboard.GET("/history", a.clipboard.GetClipboardHistory)
 				clipboard.DELETE("/history", a.clipboard.ClearClipboardHistory)
-				clipboard.GET("/stream", a.clipboard.StreamClipboardSSE)
+				clipboard.GET("/stream", limitStreams, a.clipboard.StreamClipboardSSE)
 			}
 
 			// File operations
@@ -66,6 +81,7 @@ func (a *API) CreateRoutes(router *gin.Engine) {
 				files.GET("", a.listFiles)
 				files.POST("", a.uploadFile)
 				files.GET("/:filename", a.downloadFile)
+				files.HEAD("/:filename", a.downloadFile)
 				files.DELETE("/:filename", a.deleteFile)
 			}
 
@@ -75,6 +91,8 @@ func (a *API) CreateRoutes(router *gin.Engine) {
 				filesystem.GET("/list", a.filesystem.ListDirectory)
 				filesystem.GET("/content", a.filesystem.GetFileContent)
 				filesystem.GET("/serve", a.filesystem.ServeFile)
+				filesystem.HEAD("/serve", a.filesystem.ServeFile)
+				filesystem.GET("/archive", a.filesystem.DownloadArchive)
 				// Additional filesystem endpoints could be added here
 			}
 
@@ -99,22 +117,34 @@ func (a *API) CreateRoutes(router *gin.Engine) {
 				audio := media.Group("/audio")
 				{
 					audio.GET("/devices", a.media.GetAudioDevices)
-					audio.GET("/stream", a.media.StreamAudio)
+					audio.GET("/stream", limitStreams, a.media.StreamAudio)
+					audio.HEAD("/stream", limitStreams, a.media.StreamAudio)
 				}
 
-				media.GET("/screen", a.media.StreamScreen)
+				media.GET("/screen", limitStreams, a.media.StreamScreen)
 				// API documentation routes
 				v1.GET("/docs", ServeAPIDocsUI)
 				v1.GET("/docs/json", ServeAPIDocsJSON)
 			}
 
+			// Connectivity diagnostics
+			diag := v1.Group("/diag")
+			{
+				diag.GET("/ws", a.diag.EchoWebSocket)
+				diag.GET("/net", a.diag.GetNetworkInfo)
+			}
+
 			// Live audio streaming endpoint
-			v1.GET("/live/audio", a.media.LiveAudioWebSocket)
+			v1.GET("/live/audio", limitStreams, a.media.LiveAudioWebSocket)
 			// Live audio HTML page
 			router.GET("/live/audio", LiveAudioPage)
 
 			// Ollama proxy endpoints
 			ollama := NewOllamaAPI("http://localhost:11434")
+			if a.config.OllamaTimeoutSeconds > 0 {
+				ollama.Timeout = time.Duration(a.config.OllamaTimeoutSeconds) * time.Second
+			}
+			ollama.Authorize = a.requireToken()
 			v1.Any("/ollama/*proxyPath", ollama.Proxy)
 		}
 
@@ -125,6 +155,7 @@ func (a *API) CreateRoutes(router *gin.Engine) {
 		api.GET("/files", a.listFiles)
 		api.POST("/files", a.uploadFile)
 		api.GET("/files/:filename", a.downloadFile)
+		api.HEAD("/files/:filename", a.downloadFile)
 	}
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/core"
 )
 
 // ShellRequest represents a shell command execution request
@@ -44,9 +45,7 @@ func NewShellAPI(cfg *config.Config) *ShellAPI {
 	return &ShellAPI{
 		config: cfg,
 		wsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow connections from any origin
-			},
+			CheckOrigin: core.WebSocketOriginChecker(cfg.WebSocketOrigins, true),
 		},
 	}
 }
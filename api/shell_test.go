@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// newShellServer serves the shell stream of a ShellAPI allowing origins
+func newShellServer(t *testing.T, origins []string) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	shell := NewShellAPI(&config.Config{EnableShell: true, WebSocketOrigins: origins})
+	router := gin.New()
+	router.GET("/shell/stream", shell.StreamCommand)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestShellStreamOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string // "self" is the server's own origin
+		want    bool
+	}{
+		{"same origin", nil, "self", true},
+		{"no origin header", nil, "", true},
+		{"cross origin", nil, "http://evil.example", false},
+		{"listed origin", []string{"https://app.example"}, "https://app.example", true},
+		{"unlisted origin", []string{"https://app.example"}, "http://evil.example", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newShellServer(t, tt.allowed)
+			header := http.Header{}
+			switch tt.origin {
+			case "":
+			case "self":
+				header.Set("Origin", server.URL)
+			default:
+				header.Set("Origin", tt.origin)
+			}
+
+			url := "ws" + strings.TrimPrefix(server.URL, "http") + "/shell/stream?command=echo+hi"
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if !tt.want {
+				if err == nil {
+					conn.Close()
+					t.Fatal("upgrade from a disallowed origin succeeded")
+				}
+				if resp == nil || resp.StatusCode != http.StatusForbidden {
+					t.Fatalf("response = %v, want 403", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer conn.Close()
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("ReadJSON: %v", err)
+			}
+		})
+	}
+}
@@ -1,21 +1,34 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"runtime"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nathfavour/noplacelike.go/config"
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/shirou/gopsutil/v3/process"
 )
 
+// SystemInfoUnavailable marks fields that cannot be collected on this build
+// or target
+const SystemInfoUnavailable = "unavailable"
+
+// errSystemInfoUnavailable is returned by the collectors when native system
+// information is not available
+var errSystemInfoUnavailable = errors.New("system information unavailable")
+
+// systemInfoKeys are the fields GetSystemInfo always reports, whatever the
+// build. Those it can't collect are SystemInfoUnavailable.
+var systemInfoKeys = []string{
+	"hostname", "platform",
+	"os", "platformFamily", "platformVersion", "kernelVersion", "kernelArch", "uptime",
+	"cpuUsage",
+	"memoryTotal", "memoryAvailable", "memoryUsed", "memoryUsage",
+	"diskTotal", "diskFree", "diskUsed", "diskUsage",
+}
+
 // SystemAPI handles system information and operations
 type SystemAPI struct {
 	config *config.Config
@@ -37,7 +50,10 @@ type NotificationRequest struct {
 
 // GetSystemInfo returns basic system information
 func (s *SystemAPI) GetSystemInfo(c *gin.Context) {
-	info := map[string]interface{}{}
+	info := make(map[string]interface{}, len(systemInfoKeys))
+	for _, key := range systemInfoKeys {
+		info[key] = SystemInfoUnavailable
+	}
 
 	// Get hostname
 	hostname, err := os.Hostname()
@@ -51,51 +67,24 @@ func (s *SystemAPI) GetSystemInfo(c *gin.Context) {
 	info["goVersion"] = runtime.Version()
 	info["numCPU"] = runtime.NumCPU()
 
-	// Host info
-	if hostInfo, err := host.Info(); err == nil {
-		info["os"] = hostInfo.OS
-		info["platform"] = hostInfo.Platform
-		info["platformFamily"] = hostInfo.PlatformFamily
-		info["platformVersion"] = hostInfo.PlatformVersion
-		info["kernelVersion"] = hostInfo.KernelVersion
-		info["kernelArch"] = hostInfo.KernelArch
-		
-		// Format uptime
-		uptime := time.Duration(hostInfo.Uptime) * time.Second
-		days := int(uptime.Hours() / 24)
-		hours := int(uptime.Hours()) % 24
-		minutes := int(uptime.Minutes()) % 60
-		info["uptime"] = fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
-	}
-
-	// CPU info
-	if cpuPercents, err := cpu.Percent(time.Second, false); err == nil && len(cpuPercents) > 0 {
-		info["cpuUsage"] = fmt.Sprintf("%.1f%%", cpuPercents[0])
-	}
-
-	// Memory info
-	if memInfo, err := mem.VirtualMemory(); err == nil {
-		info["memoryTotal"] = memInfo.Total
-		info["memoryAvailable"] = memInfo.Available
-		info["memoryUsed"] = memInfo.Used
-		info["memoryUsage"] = fmt.Sprintf("%.1f%%", memInfo.UsedPercent)
-	}
-
-	// Disk info
-	if diskInfo, err := disk.Usage("/"); err == nil {
-		info["diskTotal"] = diskInfo.Total
-		info["diskFree"] = diskInfo.Free
-		info["diskUsed"] = diskInfo.Used
-		info["diskUsage"] = fmt.Sprintf("%.1f%%", diskInfo.UsedPercent)
-	}
+	// Host, CPU, memory and disk details come from gopsutil unless it was
+	// built out, in which case they stay unavailable
+	collectHostInfo(info)
 
 	c.JSON(http.StatusOK, info)
 }
 
 // GetProcesses returns a list of running processes
 func (s *SystemAPI) GetProcesses(c *gin.Context) {
-	// Get list of processes
-	processes, err := process.Processes()
+	processInfos, err := listProcesses()
+	if errors.Is(err, errSystemInfoUnavailable) {
+		c.JSON(http.StatusOK, gin.H{
+			"count":     0,
+			"processes": []map[string]interface{}{},
+			"status":    SystemInfoUnavailable,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Unable to get processes: " + err.Error(),
@@ -103,42 +92,6 @@ func (s *SystemAPI) GetProcesses(c *gin.Context) {
 		return
 	}
 
-	// Collect process info
-	processInfos := make([]map[string]interface{}, 0, len(processes))
-	for _, p := range processes {
-		info := make(map[string]interface{})
-
-		// Get process ID
-		info["pid"] = p.Pid
-
-		// Try to get other info, continue if any fails
-		if name, err := p.Name(); err == nil {
-			info["name"] = name
-		}
-
-		if status, err := p.Status(); err == nil {
-			info["status"] = status
-		}
-
-		if cmdline, err := p.Cmdline(); err == nil {
-			info["cmdline"] = cmdline
-		}
-
-		if createTime, err := p.CreateTime(); err == nil {
-			info["createTime"] = time.Unix(createTime/1000, 0).Format(time.RFC3339)
-		}
-
-		if cpuPercent, err := p.CPUPercent(); err == nil {
-			info["cpuPercent"] = fmt.Sprintf("%.1f%%", cpuPercent)
-		}
-
-		if memPercent, err := p.MemoryPercent(); err == nil {
-			info["memPercent"] = fmt.Sprintf("%.1f%%", memPercent)
-		}
-
-		processInfos = append(processInfos, info)
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"count":     len(processInfos),
 		"processes": processInfos,
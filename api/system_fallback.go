@@ -0,0 +1,13 @@
+//go:build nosysinfo || js || wasip1
+
+package api
+
+// collectHostInfo leaves the gopsutil-backed fields unavailable on targets
+// built without native system information. The platform stays the one Go
+// was built for.
+func collectHostInfo(info map[string]interface{}) {}
+
+// listProcesses is not supported without native system information
+func listProcesses() ([]map[string]interface{}, error) {
+	return nil, errSystemInfoUnavailable
+}
@@ -0,0 +1,56 @@
+//go:build nosysinfo || js || wasip1
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+func TestFallbackSystemInfo(t *testing.T) {
+	info := getSystemInfo(t)
+	for _, key := range systemInfoKeys {
+		want := SystemInfoUnavailable
+		switch key {
+		case "hostname":
+			continue
+		case "platform":
+			want = runtime.GOOS
+		}
+		if info[key] != want {
+			t.Errorf("%s = %v, want %q", key, info[key], want)
+		}
+	}
+}
+
+func TestFallbackProcesses(t *testing.T) {
+	if _, err := listProcesses(); !errors.Is(err, errSystemInfoUnavailable) {
+		t.Fatalf("listProcesses error = %v, want %v", err, errSystemInfoUnavailable)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/system/processes", NewSystemAPI(&config.Config{}).GetProcesses)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/processes", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var body struct {
+		Count  int    `json:"count"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Count != 0 || body.Status != SystemInfoUnavailable {
+		t.Errorf("processes = %+v, want none and status %q", body, SystemInfoUnavailable)
+	}
+}
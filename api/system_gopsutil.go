@@ -0,0 +1,100 @@
+//go:build !nosysinfo && !js && !wasip1
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// collectHostInfo fills in the host, CPU, memory and disk details it can
+// collect, leaving the rest unavailable
+func collectHostInfo(info map[string]interface{}) {
+	// Host info
+	if hostInfo, err := host.Info(); err == nil {
+		info["os"] = hostInfo.OS
+		info["platform"] = hostInfo.Platform
+		info["platformFamily"] = hostInfo.PlatformFamily
+		info["platformVersion"] = hostInfo.PlatformVersion
+		info["kernelVersion"] = hostInfo.KernelVersion
+		info["kernelArch"] = hostInfo.KernelArch
+
+		// Format uptime
+		uptime := time.Duration(hostInfo.Uptime) * time.Second
+		days := int(uptime.Hours() / 24)
+		hours := int(uptime.Hours()) % 24
+		minutes := int(uptime.Minutes()) % 60
+		info["uptime"] = fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+
+	// CPU info
+	if cpuPercents, err := cpu.Percent(time.Second, false); err == nil && len(cpuPercents) > 0 {
+		info["cpuUsage"] = fmt.Sprintf("%.1f%%", cpuPercents[0])
+	}
+
+	// Memory info
+	if memInfo, err := mem.VirtualMemory(); err == nil {
+		info["memoryTotal"] = memInfo.Total
+		info["memoryAvailable"] = memInfo.Available
+		info["memoryUsed"] = memInfo.Used
+		info["memoryUsage"] = fmt.Sprintf("%.1f%%", memInfo.UsedPercent)
+	}
+
+	// Disk info
+	if diskInfo, err := disk.Usage("/"); err == nil {
+		info["diskTotal"] = diskInfo.Total
+		info["diskFree"] = diskInfo.Free
+		info["diskUsed"] = diskInfo.Used
+		info["diskUsage"] = fmt.Sprintf("%.1f%%", diskInfo.UsedPercent)
+	}
+}
+
+// listProcesses returns details of the running processes
+func listProcesses() ([]map[string]interface{}, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	processInfos := make([]map[string]interface{}, 0, len(processes))
+	for _, p := range processes {
+		info := make(map[string]interface{})
+
+		// Get process ID
+		info["pid"] = p.Pid
+
+		// Try to get other info, continue if any fails
+		if name, err := p.Name(); err == nil {
+			info["name"] = name
+		}
+
+		if status, err := p.Status(); err == nil {
+			info["status"] = status
+		}
+
+		if cmdline, err := p.Cmdline(); err == nil {
+			info["cmdline"] = cmdline
+		}
+
+		if createTime, err := p.CreateTime(); err == nil {
+			info["createTime"] = time.Unix(createTime/1000, 0).Format(time.RFC3339)
+		}
+
+		if cpuPercent, err := p.CPUPercent(); err == nil {
+			info["cpuPercent"] = fmt.Sprintf("%.1f%%", cpuPercent)
+		}
+
+		if memPercent, err := p.MemoryPercent(); err == nil {
+			info["memPercent"] = fmt.Sprintf("%.1f%%", memPercent)
+		}
+
+		processInfos = append(processInfos, info)
+	}
+	return processInfos, nil
+}
@@ -0,0 +1,31 @@
+//go:build !nosysinfo && !js && !wasip1
+
+package api
+
+import (
+	"os"
+	"testing"
+)
+
+func TestHostInfoCollected(t *testing.T) {
+	info := getSystemInfo(t)
+	// Memory is available on every platform gopsutil supports
+	for _, key := range []string{"memoryTotal", "memoryUsage"} {
+		if info[key] == SystemInfoUnavailable {
+			t.Errorf("%s unavailable with native system information", key)
+		}
+	}
+}
+
+func TestListProcessesIncludesSelf(t *testing.T) {
+	processes, err := listProcesses()
+	if err != nil {
+		t.Fatalf("listProcesses: %v", err)
+	}
+	for _, process := range processes {
+		if pid, _ := process["pid"].(int32); int(pid) == os.Getpid() {
+			return
+		}
+	}
+	t.Errorf("%d processes listed, none is this one (pid %d)", len(processes), os.Getpid())
+}
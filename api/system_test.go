@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// getSystemInfo returns the decoded response of GET /system/info
+func getSystemInfo(t *testing.T) map[string]interface{} {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/system/info", NewSystemAPI(&config.Config{}).GetSystemInfo)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/system/info", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var info map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return info
+}
+
+func TestSystemInfoKeys(t *testing.T) {
+	info := getSystemInfo(t)
+	keys := append([]string{"architecture", "goVersion", "numCPU"}, systemInfoKeys...)
+	for _, key := range keys {
+		if value, ok := info[key]; !ok || value == nil || value == "" {
+			t.Errorf("%s = %v, want a value or %q", key, value, SystemInfoUnavailable)
+		}
+	}
+}
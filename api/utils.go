@@ -17,6 +17,29 @@ func expandPath(path string) string {
 	return path
 }
 
+// resolvePath returns path made absolute with symlinks resolved. Trailing
+// parts that don't exist yet are kept as given, so paths about to be created
+// resolve too.
+func resolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	var missing []string
+	for dir := abs; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+		dir = parent
+	}
+}
+
 // isSubPath checks if path is a subpath of basePath
 func isSubPath(path, basePath string) bool {
 	rel, err := filepath.Rel(basePath, path)
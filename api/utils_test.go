@@ -0,0 +1,78 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+func TestResolvePath(t *testing.T) {
+	real, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(real, "a.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"real path", filepath.Join(real, "a.txt"), filepath.Join(real, "a.txt")},
+		{"link", link, real},
+		{"file through link", filepath.Join(link, "a.txt"), filepath.Join(real, "a.txt")},
+		{"missing file through link", filepath.Join(link, "new", "b.txt"), filepath.Join(real, "new", "b.txt")},
+		{"unclean", link + "/./sub/../a.txt", filepath.Join(real, "a.txt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolvePath(tt.path); got != tt.want {
+				t.Errorf("resolvePath(%s) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPathAllowedSymlinks(t *testing.T) {
+	// Keep the user's config file from being picked up
+	t.Setenv("HOME", t.TempDir())
+	real := t.TempDir()
+	outside := t.TempDir()
+	allowed := filepath.Join(t.TempDir(), "shared")
+	if err := os.Symlink(real, allowed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(real, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	fs := NewFileSystemAPI(&config.Config{AllowedPaths: []string{allowed}})
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"allowed link itself", allowed, true},
+		{"file through the link", filepath.Join(allowed, "a.txt"), true},
+		{"file by its real path", filepath.Join(real, "a.txt"), true},
+		{"new directory through the link", filepath.Join(allowed, "new", "b.txt"), true},
+		{"link out of the allowed path", filepath.Join(allowed, "escape", "c.txt"), false},
+		{"outside", filepath.Join(outside, "c.txt"), false},
+		{"parent", filepath.Dir(allowed), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fs.isPathAllowed(tt.path); got != tt.want {
+				t.Errorf("isPathAllowed(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
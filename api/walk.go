@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Limits for file searches and media scans
+const (
+	// DefaultWalkWorkers is used when the config gives no walk worker count
+	DefaultWalkWorkers = 8
+	// DefaultWalkTimeout is used when the config gives no walk timeout
+	DefaultWalkTimeout = 30 * time.Second
+)
+
+// walkLimits returns the worker count and timeout for a directory walk,
+// filling in defaults for unset values
+func walkLimits(workers, timeoutSeconds int) (int, time.Duration) {
+	if workers <= 0 {
+		workers = DefaultWalkWorkers
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultWalkTimeout
+	}
+	return workers, timeout
+}
+
+// dirWalker reads the directories under a set of roots with a fixed number
+// of workers. Directories waiting to be read are queued, so the number of
+// goroutines doesn't grow with the tree.
+type dirWalker struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// queue holds directories not yet read; pending also counts the ones
+	// being read, so the walk is done when it reaches zero
+	queue   []string
+	pending int
+	seen    map[string]bool
+	visit   func(dir string, entries []fs.DirEntry)
+}
+
+// walkDirs calls visit with the entries of every directory under roots,
+// including the roots, using up to workers goroutines. visit may be called
+// concurrently. Each directory is visited once even when roots overlap, and
+// symlinks are not followed. If ctx is done before the walk finishes,
+// walkDirs stops early and returns ctx.Err().
+func walkDirs(ctx context.Context, roots []string, workers int, visit func(dir string, entries []fs.DirEntry)) error {
+	if workers <= 0 {
+		workers = DefaultWalkWorkers
+	}
+
+	w := &dirWalker{seen: make(map[string]bool), visit: visit}
+	w.cond = sync.NewCond(&w.mu)
+	for _, root := range roots {
+		w.push(filepath.Clean(root))
+	}
+
+	// Wake idle workers so they see the cancellation
+	stop := context.AfterFunc(ctx, func() {
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// push queues dir unless it was already queued. The caller holds w.mu or
+// has not started any workers yet.
+func (w *dirWalker) push(dir string) {
+	if w.seen[dir] {
+		return
+	}
+	w.seen[dir] = true
+	w.queue = append(w.queue, dir)
+	w.pending++
+}
+
+func (w *dirWalker) work(ctx context.Context) {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 && ctx.Err() == nil {
+			w.cond.Wait()
+		}
+		if w.pending == 0 || ctx.Err() != nil {
+			w.mu.Unlock()
+			return
+		}
+		dir := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		entries, err := os.ReadDir(dir)
+		if err == nil && ctx.Err() == nil {
+			w.visit(dir, entries)
+		}
+
+		w.mu.Lock()
+		queued := len(w.queue)
+		if err == nil {
+			for _, entry := range entries {
+				if entry.IsDir() {
+					w.push(filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+		w.pending--
+		if len(w.queue) > queued || w.pending == 0 {
+			w.cond.Broadcast()
+		}
+		w.mu.Unlock()
+	}
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// makeTree creates width subdirectories per level, depth levels deep, under
+// root, with a file in each directory. It returns every directory created,
+// root included.
+func makeTree(tb testing.TB, root string, width, depth int) []string {
+	tb.Helper()
+	dirs := []string{root}
+	level := []string{root}
+	for d := 0; d < depth; d++ {
+		var next []string
+		for _, parent := range level {
+			for i := 0; i < width; i++ {
+				dir := filepath.Join(parent, fmt.Sprintf("d%d", i))
+				if err := os.Mkdir(dir, 0755); err != nil {
+					tb.Fatal(err)
+				}
+				next = append(next, dir)
+			}
+		}
+		dirs = append(dirs, next...)
+		level = next
+	}
+	for _, dir := range dirs {
+		if err := os.WriteFile(filepath.Join(dir, "file.txt"), nil, 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return dirs
+}
+
+func TestWalkDirs(t *testing.T) {
+	root := t.TempDir()
+	dirs := makeTree(t, root, 3, 3)
+	// A link back up the tree is not followed, so the walk still ends
+	if err := os.Symlink(root, filepath.Join(root, "d0", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		roots   []string
+		workers int
+		want    []string
+	}{
+		{"one worker", []string{root}, 1, dirs},
+		{"many workers", []string{root}, 16, dirs},
+		{"default workers", []string{root}, 0, dirs},
+		{"overlapping roots", []string{root, filepath.Join(root, "d1"), root + "/"}, 4, dirs},
+		{"subtree", []string{filepath.Join(root, "d2", "d2")}, 4, []string{
+			filepath.Join(root, "d2", "d2"),
+			filepath.Join(root, "d2", "d2", "d0"),
+			filepath.Join(root, "d2", "d2", "d1"),
+			filepath.Join(root, "d2", "d2", "d2"),
+		}},
+		{"missing root", []string{filepath.Join(root, "nope")}, 4, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var visited []string
+			err := walkDirs(context.Background(), tt.roots, tt.workers, func(dir string, entries []fs.DirEntry) {
+				mu.Lock()
+				visited = append(visited, dir)
+				mu.Unlock()
+			})
+			if err != nil {
+				t.Fatalf("walkDirs: %v", err)
+			}
+			slices.Sort(visited)
+			want := slices.Clone(tt.want)
+			slices.Sort(want)
+			if !slices.Equal(visited, want) {
+				t.Errorf("visited %d directories %v, want %d", len(visited), visited, len(want))
+			}
+		})
+	}
+}
+
+func TestWalkDirsTimeout(t *testing.T) {
+	root := t.TempDir()
+	dirs := makeTree(t, root, 4, 3)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"already expired", 0},
+		{"expires during the walk", 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			var mu sync.Mutex
+			visited := 0
+			start := time.Now()
+			// Slow enough that the whole tree would take seconds
+			err := walkDirs(ctx, []string{root}, 2, func(string, []fs.DirEntry) {
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				visited++
+				mu.Unlock()
+			})
+			elapsed := time.Since(start)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("walkDirs = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if elapsed > tt.timeout+time.Second {
+				t.Errorf("walk took %v with a %v timeout", elapsed, tt.timeout)
+			}
+			if visited >= len(dirs) {
+				t.Errorf("visited all %d directories despite the timeout", visited)
+			}
+		})
+	}
+}
+
+func TestWalkLimits(t *testing.T) {
+	tests := []struct {
+		workers, timeoutSeconds int
+		wantWorkers             int
+		wantTimeout             time.Duration
+	}{
+		{0, 0, DefaultWalkWorkers, DefaultWalkTimeout},
+		{-1, -5, DefaultWalkWorkers, DefaultWalkTimeout},
+		{2, 10, 2, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		workers, timeout := walkLimits(tt.workers, tt.timeoutSeconds)
+		if workers != tt.wantWorkers || timeout != tt.wantTimeout {
+			t.Errorf("walkLimits(%d, %d) = %d, %v, want %d, %v", tt.workers, tt.timeoutSeconds, workers, timeout, tt.wantWorkers, tt.wantTimeout)
+		}
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	// Keep the user's config file from being picked up
+	t.Setenv("HOME", t.TempDir())
+	first, second := t.TempDir(), t.TempDir()
+	makeTree(t, first, 2, 2)
+	makeTree(t, second, 2, 1)
+	if err := os.WriteFile(filepath.Join(first, "d1", "notes.md"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	fs := NewFileSystemAPI(&config.Config{AllowedPaths: []string{first, second}, WalkWorkers: 3})
+	router.GET("/search", fs.SearchFiles)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		// 7 directories in the first tree, 3 in the second
+		{"file.txt", 10},
+		{"notes.md", 1},
+		{"missing.txt", 0},
+		// Directories aren't results
+		{"d1", 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q="+url.QueryEscape(tt.query), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", tt.query, rec.Code)
+		}
+		var body struct {
+			Results   []FileInfo `json:"results"`
+			Truncated bool       `json:"truncated"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode %s: %v", tt.query, rec.Body, err)
+		}
+		if len(body.Results) != tt.want || body.Truncated {
+			t.Errorf("%s: %d results, truncated %v, want %d", tt.query, len(body.Results), body.Truncated, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("search without a query: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func BenchmarkWalkDirs(b *testing.B) {
+	root := b.TempDir()
+	makeTree(b, root, 6, 4)
+
+	b.Run("filepath.WalkDir", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error { return nil })
+		}
+	})
+	for _, workers := range []int{1, 4, DefaultWalkWorkers, 32} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				walkDirs(context.Background(), []string{root}, workers, func(string, []fs.DirEntry) {})
+			}
+		})
+	}
+}
+
+func TestScanMediaDirectories(t *testing.T) {
+	root := t.TempDir()
+	files := map[string][]string{
+		"music/b":   {"1.mp3", "2.flac", "3.ogg", "cover.jpg"},
+		"music/a":   {"1.mp3", "2.mp3", "3.mp3"},
+		"docs":      {"a.txt", "b.txt", "1.mp3"},
+		"few-songs": {"1.mp3", "2.mp3"},
+	}
+	for dir, names := range files {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(root, dir, name), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	media := NewMediaAPI(&config.Config{AllowedPaths: []string{root}, WalkWorkers: 2})
+	router.GET("/scan", media.ScanMediaDirectories)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scan", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	var body struct {
+		MediaDirs []MediaDirInfo `json:"mediaDirs"`
+		Truncated bool           `json:"truncated"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	var paths []string
+	for _, dir := range body.MediaDirs {
+		paths = append(paths, dir.Path)
+	}
+	// Sorted, whatever order the workers found them in
+	want := []string{filepath.Join(root, "music/a"), filepath.Join(root, "music/b")}
+	if !slices.Equal(paths, want) || body.Truncated {
+		t.Errorf("media dirs = %v, truncated %v, want %v", paths, body.Truncated, want)
+	}
+}
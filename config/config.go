@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 )
 
 // Config holds the application configuration
@@ -11,6 +13,12 @@ type Config struct {
 	// Server settings
 	Host           string `json:"host"`
 	Port           int    `json:"port"`
+	// LegacyPort serves the legacy API and web UI on this port alongside
+	// the platform (0 disables it)
+	LegacyPort int `json:"legacyPort"`
+	// PeerPort is where other instances open peer connections (0 uses
+	// Port + 2, after the discovery port)
+	PeerPort int `json:"peerPort"`
 
 	// Directory settings
 	UploadFolder   string   `json:"uploadFolder"`
@@ -18,6 +26,26 @@ type Config struct {
 	AudioFolders   []string `json:"audioFolders"`
 	AllowedPaths   []string `json:"allowedPaths"`
 	ShowHidden     bool     `json:"showHidden"`
+	// MaxListEntries caps recursive directory listings (0 uses the default)
+	MaxListEntries int `json:"maxListEntries"`
+	// WalkWorkers is how many directories file searches and media scans
+	// read at once (0 uses the default)
+	WalkWorkers int `json:"walkWorkers"`
+	// WalkTimeoutSeconds stops file searches and media scans that run
+	// longer, returning what was found so far (0 uses the default)
+	WalkTimeoutSeconds int `json:"walkTimeoutSeconds"`
+	// MediaScanCacheSeconds is how long media directory scans are cached
+	// (0 uses the default, negative disables the cache)
+	MediaScanCacheSeconds int `json:"mediaScanCacheSeconds"`
+	// AllowedAudioExtensions lists the file extensions, such as ".mp3",
+	// treated as audio by media scans, listings and streaming (empty uses
+	// every audio extension filetype knows); see AudioExtensions
+	AllowedAudioExtensions []string `json:"allowedAudioExtensions"`
+	// UploadFilenameStrategy handles uploads whose name is already taken:
+	// "overwrite", "rename" (default) or "reject"
+	UploadFilenameStrategy string `json:"uploadFilenameStrategy"`
+	// RejectEmptyUploads refuses zero-byte uploads
+	RejectEmptyUploads bool `json:"rejectEmptyUploads"`
 
 	// Feature flags
 	EnableShell           bool `json:"enableShell"`
@@ -31,11 +59,49 @@ type Config struct {
 	JWTSecret            string   `json:"jwtSecret"`
 	JWTIssuer            string   `json:"jwtIssuer"`
 	JWTAudience          []string `json:"jwtAudience"`
+	// WebSocketOrigins lists the browser origins allowed to open WebSockets
+	// (empty allows only the same origin)
+	WebSocketOrigins []string `json:"webSocketOrigins"`
+
+	// DeviceCookieMaxAgeSeconds is how long the device ID cookie lasts
+	// (0 uses the default of one year)
+	DeviceCookieMaxAgeSeconds int `json:"deviceCookieMaxAgeSeconds"`
+
+	// OllamaTimeoutSeconds aborts Ollama proxy requests that see no data
+	// from the upstream for this long (0 uses the default)
+	OllamaTimeoutSeconds int `json:"ollamaTimeoutSeconds"`
+
+	// ClipboardTTLSeconds expires clipboard entries set without their own
+	// TTL after this long (0 keeps them)
+	ClipboardTTLSeconds int `json:"clipboardTtlSeconds"`
+	// ClipboardRedactionPatterns are regular expressions for clipboard text
+	// that must not be synced, such as card numbers or API keys
+	ClipboardRedactionPatterns []string `json:"clipboardRedactionPatterns"`
+	// ClipboardHistoryBytes caps the total size of a clipboard channel's
+	// history, evicting the oldest entries first (0 uses the default,
+	// negative removes the cap)
+	ClipboardHistoryBytes int `json:"clipboardHistoryBytes"`
+
+	// StaleTempFileSeconds is how old temp files left by interrupted uploads
+	// and writes must be to be removed at startup (0 uses the default)
+	StaleTempFileSeconds int `json:"staleTempFileSeconds"`
+
+	// MaxStreamsPerClient caps the audio, screen and event streams each
+	// client IP may hold open at once (0 uses the default, negative removes
+	// the cap)
+	MaxStreamsPerClient int `json:"maxStreamsPerClient"`
+
+	// CopyBufferSize is the size in bytes of the pooled buffers file data is
+	// copied through (0 uses the default)
+	CopyBufferSize int `json:"copyBufferSize"`
 
 	// API version
 	APIVersion string `json:"apiVersion"`
 }
 
+// DefaultDeviceCookieMaxAge is the lifetime of the device ID cookie in seconds
+const DefaultDeviceCookieMaxAge = 365 * 24 * 3600
+
 // DefaultConfig returns a config with default values
 func DefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
@@ -50,19 +116,29 @@ func DefaultConfig() *Config {
 		AudioFolders:        []string{},
 		AllowedPaths:        []string{homeDir},
 		ShowHidden:          false,
+		MaxListEntries:      10000,
+		UploadFilenameStrategy: "rename",
 		EnableShell:         true,
 		EnableAudioStreaming: false,
 		EnableScreenStreaming: false,
 		AllowedCommands:     []string{},
 		MaxFileContentSize:   1024 * 1024, // 1MB
 		ClipboardHistorySize: 50,
-		JWTSecret:            "change-me",
+		JWTSecret:            "", // generated on first run, see main.go
 		JWTIssuer:            "noplacelike",
 		JWTAudience:          []string{"noplacelike"},
+		OllamaTimeoutSeconds: 120,
+		DeviceCookieMaxAgeSeconds: DefaultDeviceCookieMaxAge,
 		APIVersion:           "v1",
 	}
 }
 
+// AudioExtensions returns the extensions media scans, listings and streaming
+// treat as audio
+func (c *Config) AudioExtensions() filetype.ExtensionSet {
+	return filetype.NewExtensionSet(c.AllowedAudioExtensions, filetype.Audio)
+}
+
 // configPath returns the path to the config file
 func configPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -72,6 +148,11 @@ func configPath() (string, error) {
 	return filepath.Join(homeDir, ".noplacelike.json"), nil
 }
 
+// Path returns the path to the config file
+func Path() (string, error) {
+	return configPath()
+}
+
 // Load loads configuration from the config file
 func Load() (*Config, error) {
 	path, err := configPath()
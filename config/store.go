@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is how often a Store checks the config file for changes
+const DefaultReloadInterval = 2 * time.Second
+
+// Store holds the current configuration in memory and reloads it when the
+// config file changes on disk. It is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	cfg       *Config
+	path      string
+	modTime   time.Time
+	size      int64
+	interval  time.Duration
+	lastCheck time.Time
+}
+
+// NewStore creates a store seeded with cfg that follows the default config
+// file. If cfg is nil the file is loaded immediately.
+func NewStore(cfg *Config) *Store {
+	s := &Store{interval: DefaultReloadInterval}
+	if path, err := configPath(); err == nil {
+		s.path = path
+		if info, err := os.Stat(path); err == nil {
+			s.modTime = info.ModTime()
+			s.size = info.Size()
+		}
+	}
+	if cfg == nil {
+		cfg, _ = Load()
+	}
+	s.cfg = cfg
+	s.lastCheck = time.Now()
+	return s
+}
+
+// Current returns the in-memory configuration. At most once per reload
+// interval it stats the config file and re-reads it if it has changed; a
+// file that fails to parse leaves the previous configuration in place.
+func (s *Store) Current() *Config {
+	s.mu.RLock()
+	cfg := s.cfg
+	due := s.path != "" && time.Since(s.lastCheck) >= s.interval
+	s.mu.RUnlock()
+	if !due {
+		return cfg
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.lastCheck) < s.interval {
+		return s.cfg
+	}
+	s.lastCheck = time.Now()
+
+	info, err := os.Stat(s.path)
+	if err != nil || (info.ModTime().Equal(s.modTime) && info.Size() == s.size) {
+		return s.cfg
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.cfg
+	}
+	var next Config
+	if err := json.Unmarshal(data, &next); err != nil {
+		return s.cfg
+	}
+	s.cfg = &next
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return s.cfg
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// useTempHome points the config file at a fresh home directory and saves cfg
+// there, returning the file's path
+func useTempHome(tb testing.TB, cfg *Config) string {
+	tb.Helper()
+	tb.Setenv("HOME", tb.TempDir())
+	if err := Save(cfg); err != nil {
+		tb.Fatalf("Save: %v", err)
+	}
+	path, err := Path()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestStorePicksUpChanges(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Port = 8000
+	path := useTempHome(t, cfg)
+	s := NewStore(nil)
+	s.interval = 0
+	if got := s.Current().Port; got != 8000 {
+		t.Fatalf("Port = %d, want 8000", got)
+	}
+
+	cfg.Port = 9000
+	cfg.AllowedPaths = []string{"/srv/shared"}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Current(); got.Port != 9000 || len(got.AllowedPaths) != 1 {
+		t.Fatalf("after change Port = %d, AllowedPaths = %v, want the new config", got.Port, got.AllowedPaths)
+	}
+
+	// A file that fails to parse keeps the last good config
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Current().Port; got != 9000 {
+		t.Errorf("after bad write Port = %d, want 9000", got)
+	}
+}
+
+func TestStoreChecksAtMostOncePerInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Port = 8000
+	useTempHome(t, cfg)
+	s := NewStore(nil)
+	s.interval = time.Hour
+
+	cfg.Port = 9000
+	cfg.AllowedPaths = []string{"/srv/shared"}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Current().Port; got != 8000 {
+		t.Errorf("Port = %d before the interval elapsed, want 8000", got)
+	}
+
+	s.lastCheck = time.Now().Add(-time.Hour)
+	if got := s.Current().Port; got != 9000 {
+		t.Errorf("Port = %d after the interval, want 9000", got)
+	}
+}
+
+// BenchmarkLoad reads and parses the config file, as the file system
+// handlers used to on every request
+func BenchmarkLoad(b *testing.B) {
+	useTempHome(b, DefaultConfig())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Load(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStoreCurrent reads the config from memory, touching the file at
+// most once per reload interval
+func BenchmarkStoreCurrent(b *testing.B) {
+	useTempHome(b, DefaultConfig())
+	s := NewStore(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Current()
+	}
+}
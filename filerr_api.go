@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
 )
 
 // Pairing endpoints
@@ -95,7 +96,7 @@ func GetMonitorStatus(w http.ResponseWriter, r *http.Request) {
 
 // WebSocket for real-time updates
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin: core.WebSocketOriginChecker(nil, true),
 }
 
 func TransferWebSocket(w http.ResponseWriter, r *http.Request) {
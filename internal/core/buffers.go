@@ -0,0 +1,46 @@
+package core
+
+import (
+	"io"
+	"sync"
+)
+
+// DefaultCopyBufferSize is the buffer size of a BufferPool created with a
+// size of zero
+const DefaultCopyBufferSize = 32 * 1024
+
+// BufferPool reuses the buffers used to copy file data, so busy uploads
+// don't allocate a fresh buffer per copy
+type BufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+// NewBufferPool creates a pool of size-byte buffers
+func NewBufferPool(size int) *BufferPool {
+	if size <= 0 {
+		size = DefaultCopyBufferSize
+	}
+	p := &BufferPool{size: size}
+	p.pool.New = func() interface{} {
+		buf := make([]byte, p.size)
+		return &buf
+	}
+	return p
+}
+
+// Size returns the size of the pool's buffers
+func (p *BufferPool) Size() int {
+	return p.size
+}
+
+// Copy copies src to dst like io.CopyBuffer with a pooled buffer. The copy
+// is still handed to dst's ReadFrom or src's WriteTo where they exist, so
+// file to file and file to socket copies keep using copy_file_range and
+// sendfile; the buffer serves every other copy.
+func (p *BufferPool) Copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := p.pool.Get().(*[]byte)
+	defer p.pool.Put(buf)
+
+	return io.CopyBuffer(dst, src, *buf)
+}
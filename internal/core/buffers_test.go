@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBufferPoolCopy(t *testing.T) {
+	data := strings.Repeat("noplacelike", 10000)
+	tests := []struct {
+		name string
+		size int
+		src  func(t *testing.T) io.Reader
+		dst  func(t *testing.T) (io.Writer, func() string)
+	}{
+		{
+			name: "reader to buffer",
+			size: 16,
+			src:  func(*testing.T) io.Reader { return strings.NewReader(data) },
+			dst:  bufferDst,
+		},
+		{
+			name: "reader without WriteTo",
+			src:  func(*testing.T) io.Reader { return io.LimitReader(strings.NewReader(data), int64(len(data))) },
+			dst:  bufferDst,
+		},
+		{
+			name: "file to file",
+			src:  func(t *testing.T) io.Reader { return tempFile(t, data) },
+			dst: func(t *testing.T) (io.Writer, func() string) {
+				out := tempFile(t, "")
+				return out, func() string {
+					got, err := os.ReadFile(out.Name())
+					if err != nil {
+						t.Fatal(err)
+					}
+					return string(got)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewBufferPool(tt.size)
+			dst, written := tt.dst(t)
+			n, err := pool.Copy(dst, tt.src(t))
+			if err != nil {
+				t.Fatalf("Copy: %v", err)
+			}
+			if n != int64(len(data)) {
+				t.Errorf("copied %d bytes, want %d", n, len(data))
+			}
+			if got := written(); got != data {
+				t.Errorf("copied %d bytes of wrong data", len(got))
+			}
+		})
+	}
+}
+
+func bufferDst(*testing.T) (io.Writer, func() string) {
+	var buf bytes.Buffer
+	return &buf, buf.String
+}
+
+// tempFile returns a file holding data, open for reading and writing
+func tempFile(t *testing.T, data string) *os.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// BenchmarkBufferPoolCopy reports allocations per copy. Copies that neither
+// side can take over should allocate nothing once the pool is warm.
+func BenchmarkBufferPoolCopy(b *testing.B) {
+	data := bytes.Repeat([]byte("x"), 1<<20)
+	benchmarks := []struct {
+		name string
+		copy func(dst io.Writer, src io.Reader) (int64, error)
+	}{
+		{"pool", NewBufferPool(0).Copy},
+		{"io.Copy", io.Copy},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			src := &onlyReader{}
+			for i := 0; i < b.N; i++ {
+				src.r.Reset(data)
+				if _, err := bm.copy(onlyWriter{io.Discard}, src); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// onlyReader and onlyWriter hide everything but Read and Write, like a
+// multipart part or a hash
+type onlyReader struct {
+	r bytes.Reader
+}
+
+func (o *onlyReader) Read(p []byte) (int, error) { return o.r.Read(p) }
+
+type onlyWriter struct {
+	w io.Writer
+}
+
+func (o onlyWriter) Write(p []byte) (int, error) { return o.w.Write(p) }
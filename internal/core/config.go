@@ -16,12 +16,17 @@ type Config struct {
 	Monitoring  MonitoringConfig `json:"monitoring" yaml:"monitoring"`
 }
 
-// NetworkConfig holds network-related configuration
+// NetworkConfig holds network-related configuration. It is shared by the
+// platform and network packages, which alias it.
 type NetworkConfig struct {
 	Host              string        `json:"host" yaml:"host"`
 	Port              int           `json:"port" yaml:"port"`
 	EnableDiscovery   bool          `json:"enableDiscovery" yaml:"enableDiscovery"`
+	DiscoveryPort     int           `json:"discoveryPort" yaml:"discoveryPort"`
+	DiscoveryInterval time.Duration `json:"discoveryInterval" yaml:"discoveryInterval"`
 	MaxPeers          int           `json:"maxPeers" yaml:"maxPeers"`
+	Timeout           time.Duration `json:"timeout" yaml:"timeout"`
+	KeepAliveInterval time.Duration `json:"keepAliveInterval" yaml:"keepAliveInterval"`
 	EnableTLS         bool          `json:"enableTLS" yaml:"enableTLS"`
 	TLSCertFile       string        `json:"tlsCertFile" yaml:"tlsCertFile"`
 	TLSKeyFile        string        `json:"tlsKeyFile" yaml:"tlsKeyFile"`
@@ -30,26 +35,98 @@ type NetworkConfig struct {
 	IdleTimeout       time.Duration `json:"idleTimeout" yaml:"idleTimeout"`
 	MaxHeaderBytes    int           `json:"maxHeaderBytes" yaml:"maxHeaderBytes"`
 	EnableCompression bool          `json:"enableCompression" yaml:"enableCompression"`
+
+	// DiscoveryTimeout bounds how long a discovery broadcast waits for
+	// responses
+	DiscoveryTimeout time.Duration `json:"discoveryTimeout" yaml:"discoveryTimeout"`
+	// DiscoveryTargetPeers ends a discovery broadcast early once this many
+	// peers have responded. Zero waits for the full timeout.
+	DiscoveryTargetPeers int `json:"discoveryTargetPeers" yaml:"discoveryTargetPeers"`
+	// DiscoveryBindAddress is the address the discovery socket listens on,
+	// defaulting to Host. Note that a socket bound to a unicast address does
+	// not receive broadcast discovery requests on most systems.
+	DiscoveryBindAddress string `json:"discoveryBindAddress" yaml:"discoveryBindAddress"`
+
+	// Capabilities advertised in discovery messages
+	Capabilities []string `json:"capabilities" yaml:"capabilities"`
+	// RequiredCapabilities are capabilities a peer must advertise to be added
+	RequiredCapabilities []string `json:"requiredCapabilities" yaml:"requiredCapabilities"`
+
+	// DiscoveryMethod selects how peers are found: "broadcast" (default),
+	// "mdns" or "both"
+	DiscoveryMethod string `json:"discoveryMethod" yaml:"discoveryMethod"`
+
+	// MaxMessageSize is the largest message, in bytes, a peer may send over
+	// its WebSocket connection
+	MaxMessageSize int64 `json:"maxMessageSize" yaml:"maxMessageSize"`
 }
 
-// SecurityConfig holds security-related configuration
+// SecurityConfig holds security-related configuration. It is shared by the
+// platform and network packages, which alias it.
 type SecurityConfig struct {
 	EnableAuth       bool          `json:"enableAuth" yaml:"enableAuth"`
+	AuthMethod       string        `json:"authMethod" yaml:"authMethod"`
+	TokenExpiry      time.Duration `json:"tokenExpiry" yaml:"tokenExpiry"`
 	EnableEncryption bool          `json:"enableEncryption" yaml:"enableEncryption"`
-	JWTSecret        string        `json:"jwtSecret" yaml:"jwtSecret"`
-	JWTExpiry        time.Duration `json:"jwtExpiry" yaml:"jwtExpiry"`
+	EncryptionAlgo   string        `json:"encryptionAlgo" yaml:"encryptionAlgo"`
+	// EncryptionKey is a secret shared by all peers, used for peers without a
+	// key agreed by handshake
+	EncryptionKey string `json:"encryptionKey" yaml:"encryptionKey"`
+	// EncryptionSalt salts the derivation of the key from EncryptionKey. All
+	// peers must use the same salt; a built-in one is used when it is empty.
+	EncryptionSalt   string        `json:"encryptionSalt" yaml:"encryptionSalt"`
+	MaxLoginAttempts int           `json:"maxLoginAttempts" yaml:"maxLoginAttempts"`
+	LockoutDuration  time.Duration `json:"lockoutDuration" yaml:"lockoutDuration"`
+	AllowedPeers     []string      `json:"allowedPeers" yaml:"allowedPeers"`
+	BlockedPeers     []string      `json:"blockedPeers" yaml:"blockedPeers"`
 	EnableRBAC       bool          `json:"enableRBAC" yaml:"enableRBAC"`
 	EnableAuditLog   bool          `json:"enableAuditLog" yaml:"enableAuditLog"`
+	AuditLogFile     string        `json:"auditLogFile" yaml:"auditLogFile"`
 	TrustedProxies   []string      `json:"trustedProxies" yaml:"trustedProxies"`
 	CORSOrigins      []string      `json:"corsOrigins" yaml:"corsOrigins"`
+	// WebSocketOrigins lists the browser origins allowed to open WebSockets.
+	// Empty allows only the same origin.
+	WebSocketOrigins []string `json:"webSocketOrigins" yaml:"webSocketOrigins"`
+	// Roles maps a role name to the permissions it grants
+	Roles map[string][]string `json:"roles" yaml:"roles"`
+	// JWT settings. JWTAlgorithm is HS256 (default, shared JWTSecret),
+	// RS256 or ES256 (key pair from JWTPrivateKeyFile/JWTPublicKeyFile).
+	// Verify-only nodes configure just the public key.
+	JWTAlgorithm      string        `json:"jwtAlgorithm" yaml:"jwtAlgorithm"`
+	JWTPrivateKeyFile string        `json:"jwtPrivateKeyFile" yaml:"jwtPrivateKeyFile"`
+	JWTPublicKeyFile  string        `json:"jwtPublicKeyFile" yaml:"jwtPublicKeyFile"`
+	JWTSecret         string        `json:"jwtSecret" yaml:"jwtSecret"`
+	JWTExpiry         time.Duration `json:"jwtExpiry" yaml:"jwtExpiry"`
+	JWTIssuer         string        `json:"jwtIssuer" yaml:"jwtIssuer"`
+	JWTAudience       []string      `json:"jwtAudience" yaml:"jwtAudience"`
+	// Lifetime of refresh tokens (defaults to 7 days)
+	RefreshTokenExpiry time.Duration `json:"refreshTokenExpiry" yaml:"refreshTokenExpiry"`
+	// When JWTSecret is empty, generate a strong secret and persist it to
+	// JWTSecretFile so tokens survive restarts
+	AutoGenerateSecret bool   `json:"autoGenerateSecret" yaml:"autoGenerateSecret"`
+	JWTSecretFile      string `json:"jwtSecretFile" yaml:"jwtSecretFile"`
+	// AdminTokenFile is where an admin token pair is written at startup.
+	// Issuing tokens over the API needs platform:admin, so this is how an
+	// operator obtains the first one.
+	AdminTokenFile string `json:"adminTokenFile" yaml:"adminTokenFile"`
 }
 
-// PluginsConfig holds plugin-related configuration
+// PluginsConfig holds plugin-related configuration. It is shared by the
+// platform package, which aliases it.
 type PluginsConfig struct {
 	EnablePlugins bool     `json:"enablePlugins" yaml:"enablePlugins"`
 	PluginDir     string   `json:"pluginDir" yaml:"pluginDir"`
+	PluginDirs    []string `json:"pluginDirs" yaml:"pluginDirs"`
 	AutoLoad      []string `json:"autoLoad" yaml:"autoLoad"`
+	Disabled      []string `json:"disabled" yaml:"disabled"`
+	Sandbox       bool     `json:"sandbox" yaml:"sandbox"`
 	MaxPlugins    int      `json:"maxPlugins" yaml:"maxPlugins"`
+	// Required plugins abort startup if they fail to load; other plugins
+	// are skipped and leave the platform degraded
+	Required []string `json:"required" yaml:"required"`
+	// LifecycleTimeout bounds each plugin Initialize, Start and Stop call;
+	// a plugin that takes longer is marked failed (0 uses the default)
+	LifecycleTimeout time.Duration `json:"lifecycleTimeout" yaml:"lifecycleTimeout"`
 }
 
 // StorageConfig holds storage-related configuration
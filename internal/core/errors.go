@@ -11,4 +11,13 @@ var (
 	ErrResourceNotFound = errors.New("resource not found")
 	ErrUnauthorized     = errors.New("unauthorized access")
 	ErrInvalidRequest   = errors.New("invalid request")
+	ErrDecryptionFailed = errors.New("decryption failed")
+	ErrInvalidState     = errors.New("invalid state transition")
+	ErrPluginInUse      = errors.New("plugin is in use")
+	ErrPluginTimeout    = errors.New("plugin lifecycle call timed out")
+
+	ErrPluginExists         = errors.New("plugin already loaded")
+	ErrMissingDependency    = errors.New("plugin dependency not loaded")
+	ErrServiceNotFound      = errors.New("service not found")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+)
+
+// NewID returns a random (version 4) UUID such as
+// "0b0df9d6-6a56-46d8-a999-74d4c32c3841". IDs come from crypto/rand, so
+// they are unguessable and, unlike timestamps, can't collide when generated
+// concurrently. NewID is safe for concurrent use.
+func NewID() string {
+	var b [16]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		// The system's random source failing leaves nothing safe to use
+		panic("core: failed to read random ID: " + err.Error())
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+
+	var id [36]byte
+	hex.Encode(id[0:8], b[0:4])
+	id[8] = '-'
+	hex.Encode(id[9:13], b[4:6])
+	id[13] = '-'
+	hex.Encode(id[14:18], b[6:8])
+	id[18] = '-'
+	hex.Encode(id[19:23], b[8:10])
+	id[23] = '-'
+	hex.Encode(id[24:], b[10:])
+	return string(id[:])
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewIDConcurrentIDsAreUnique(t *testing.T) {
+	const goroutines, perGoroutine = 16, 500
+	ids := make(chan string, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				ids <- NewID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool, goroutines*perGoroutine)
+	for id := range ids {
+		if !uuidV4Pattern.MatchString(id) {
+			t.Fatalf("NewID = %q, not a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewID returned %s twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("%d IDs, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
@@ -3,6 +3,8 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -19,6 +21,45 @@ type Service interface {
 	Configuration() ConfigSchema
 }
 
+// PluginState is where a loaded plugin is in its lifecycle
+type PluginState string
+
+const (
+	PluginStateInitialized PluginState = "initialized"
+	PluginStateStarting    PluginState = "starting"
+	PluginStateStarted     PluginState = "started"
+	PluginStateStopping    PluginState = "stopping"
+	PluginStateStopped     PluginState = "stopped"
+	PluginStateFailed      PluginState = "failed"
+)
+
+// CanTransition reports whether a plugin in state s may move to next. A
+// plugin is starting or stopping while its Start or Stop call runs, which
+// keeps a second call from racing it. A failed plugin has to be initialized
+// again before it can be started.
+func (s PluginState) CanTransition(next PluginState) bool {
+	if next == PluginStateFailed {
+		return true
+	}
+	switch s {
+	case "":
+		return next == PluginStateInitialized
+	case PluginStateInitialized:
+		return next == PluginStateStarting
+	case PluginStateStarting:
+		return next == PluginStateStarted
+	case PluginStateStarted:
+		return next == PluginStateStopping
+	case PluginStateStopping:
+		return next == PluginStateStopped
+	case PluginStateStopped:
+		return next == PluginStateStarting || next == PluginStateInitialized
+	case PluginStateFailed:
+		return next == PluginStateInitialized
+	}
+	return false
+}
+
 // Plugin represents a platform plugin
 type Plugin interface {
 	Service
@@ -51,6 +92,13 @@ type PlatformAPI interface {
 	GetSecurityManager() SecurityManager
 	GetMetrics() MetricsCollector
 	GetHealthChecker() HealthChecker
+
+	// PublishEvent publishes an event with a generated ID and timestamp. The
+	// Source is set to the ID of the plugin the PlatformAPI was given to.
+	PublishEvent(eventType string, data map[string]interface{}) error
+	// PublishEventContext is PublishEvent for events published while
+	// handling a request: the request ID carried by ctx is added to data.
+	PublishEventContext(ctx context.Context, eventType string, data map[string]interface{}) error
 }
 
 // Logger interface for structured logging - use logger.Logger instead
@@ -63,12 +111,22 @@ type EventBus interface {
 
 	Publish(event Event) error
 	PublishToTopic(ctx context.Context, topic string, event Event) error
-	Subscribe(eventType string, handler EventHandler) error
-	SubscribeWithContext(ctx context.Context, eventType string, handler func(context.Context, Event) error) error
-	Unsubscribe(eventType string, handler EventHandler) error
+	Subscribe(eventType string, handler EventHandler) (Subscription, error)
+	// SubscribeWithContext subscribes handler until ctx is done
+	SubscribeWithContext(ctx context.Context, eventType string, handler func(context.Context, Event) error) (Subscription, error)
+	// Unsubscribe removes the one handler sub refers to
+	Unsubscribe(sub Subscription) error
 	Configuration() ConfigSchema
 }
 
+// Subscription identifies a subscribed event handler. Handlers can't be
+// compared, so it is what Unsubscribe uses to remove one handler without
+// affecting others subscribed to the same event type.
+type Subscription struct {
+	EventType string `json:"eventType"`
+	ID        uint64 `json:"id"`
+}
+
 // Field is a key-value pair for structured logging
 // This is a stub for compatibility with platform.go
 // Replace with your actual implementation as needed
@@ -98,10 +156,57 @@ type Resource interface {
 	GetSize() int64
 }
 
-// ResourceFilter for filtering resources
+// StreamableResource is a Resource that can stream its own content. The bytes
+// produced by Stream add up to GetSize().
+type StreamableResource interface {
+	Resource
+	Stream(ctx context.Context) (ResourceStream, error)
+}
+
+// SeekableResource is a Resource whose content can be read from any offset,
+// so it can answer HTTP range requests
+type SeekableResource interface {
+	Resource
+	Open(ctx context.Context) (io.ReadSeekCloser, error)
+}
+
+// ResourceContentTypeKey is the metadata key holding a resource's MIME type
+const ResourceContentTypeKey = "contentType"
+
+// ResourceFilter for filtering resources. Empty fields match everything.
 type ResourceFilter struct {
-	Name string `json:"name,omitempty"`
-	Type string `json:"type,omitempty"`
+	// Name and Owner match the "name" and "owner" metadata values
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Owner string `json:"owner,omitempty"`
+	// Metadata matches resources having every listed key, with a value
+	// whose string form equals the given one
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Matches reports whether resource passes the filter
+func (f ResourceFilter) Matches(resource Resource) bool {
+	if f.Type != "" && resource.Type() != f.Type {
+		return false
+	}
+
+	metadata := resource.GetMetadata()
+	matches := func(key, want string) bool {
+		value, ok := metadata[key]
+		return ok && fmt.Sprint(value) == want
+	}
+	if f.Name != "" && !matches("name", f.Name) {
+		return false
+	}
+	if f.Owner != "" && !matches("owner", f.Owner) {
+		return false
+	}
+	for key, want := range f.Metadata {
+		if !matches(key, want) {
+			return false
+		}
+	}
+	return true
 }
 
 // ResourceStream for streaming resource data (single, canonical)
@@ -130,11 +235,25 @@ type SecurityManager interface {
 	Authenticate(token string) (*User, error)
 	Authorize(user *User, resource string, action string) bool
 	GenerateToken(user *User) (string, error)
+	GenerateRefreshToken(user *User) (string, error)
+	GenerateTokenPair(user *User) (*TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error)
+	RevokeToken(jti string) error
 	ValidatePermissions(userID string, permissions []string) bool
 	ValidateToken(ctx context.Context, token string) (*TokenInfo, error)
+	Encrypt(data []byte, peerID string) ([]byte, error)
+	Decrypt(data []byte, peerID string) ([]byte, error)
 	Configuration() ConfigSchema
 }
 
+// TokenPair is a short-lived access token with the refresh token that
+// replaces it
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresAt    int64  `json:"expiresAt"`
+}
+
 // TokenInfo contains token validation information
 type TokenInfo struct {
 	Valid       bool     `json:"valid"`
@@ -150,15 +269,26 @@ type Permission struct {
 	Action   string `json:"action"`
 }
 
+// String returns the permission in the "resource:action" form used in
+// TokenInfo.Permissions and token claims
+func (p Permission) String() string {
+	return p.Resource + ":" + p.Action
+}
+
 // MetricsCollector collects and exports metrics
 type MetricsCollector interface {
 	Service
 
 	Counter(name string) Counter
+	// CounterVec returns the counters called name that are told apart by
+	// the values of labels
+	CounterVec(name string, labels ...string) CounterVec
 	Gauge(name string) Gauge
 	Histogram(name string) Histogram
 	Timer(name string) Timer
 	Export(format string) ([]byte, error)
+	// Reset zeroes the named metrics, or every metric if none are named
+	Reset(names ...string) error
 	Configuration() ConfigSchema
 }
 
@@ -205,8 +335,14 @@ type ServiceManager interface {
 	RegisterService(service Service) error
 }
 
-// ConfigManager stub
-type ConfigManager interface{}
+// ConfigManager reads and changes the platform configuration at runtime.
+// Keys are dotted paths of JSON field names, such as "network.port".
+type ConfigManager interface {
+	Get(key string) interface{}
+	Set(key string, value interface{}) error
+	Reload() error
+	Save() error
+}
 
 // Supporting types
 
@@ -214,8 +350,8 @@ type ConfigManager interface{}
 type Route struct {
 	Method      string
 	Path        string
-	Handler     http.HandlerFunc
-	Middleware  []func(http.Handler) http.Handler
+	Handler     http.HandlerFunc                  `json:"-"`
+	Middleware  []func(http.Handler) http.Handler `json:"-"`
 	Auth        AuthRequirement
 	Description string
 }
@@ -234,20 +370,44 @@ type Event struct {
 	Source    string                 `json:"source"`
 	Timestamp int64                  `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
+	// Seq is assigned by the event bus when the event is published and
+	// increases by one with every event
+	Seq uint64 `json:"seq,omitempty"`
 }
 
 // EventHandler handles events
 type EventHandler func(event Event) error
 
+// Events published by the network manager as peers come and go. Their data
+// holds the peer's id, name, address and port.
+const (
+	EventPeerJoined = "peer.connected"
+	EventPeerLeft   = "peer.disconnected"
+)
+
+// Message is a message exchanged between peers. From is the ID of the
+// sending peer.
+type Message struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	From      string                 `json:"from"`
+	To        string                 `json:"to,omitempty"`
+	Timestamp int64                  `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
 // Peer represents a network peer
 type Peer struct {
-	ID          string                 `json:"id"`
-	Address     string                 `json:"address"`
-	Name        string                 `json:"name"`
-	Status      string                 `json:"status"`
-	Metadata    map[string]interface{} `json:"metadata"`
-	ConnectedAt int64                  `json:"connectedAt"`
-	LastSeen    int64                  `json:"lastSeen"`
+	ID           string                 `json:"id"`
+	Address      string                 `json:"address"`
+	Port         int                    `json:"port,omitempty"`
+	Name         string                 `json:"name"`
+	Status       string                 `json:"status"`
+	Version      string                 `json:"version,omitempty"`
+	Capabilities []string               `json:"capabilities,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	ConnectedAt  int64                  `json:"connectedAt"`
+	LastSeen     int64                  `json:"lastSeen"`
 }
 
 // User represents a platform user
@@ -269,6 +429,14 @@ type Counter interface {
 	Get() float64
 }
 
+// CounterVec is a family of counters sharing a name, one per combination of
+// label values
+type CounterVec interface {
+	// With returns the counter for the given label values. Labels the
+	// vector doesn't have are ignored; missing ones are empty.
+	With(labels map[string]string) Counter
+}
+
 type Gauge interface {
 	Set(value float64)
 	Inc()
@@ -0,0 +1,92 @@
+package core
+
+import "testing"
+
+func TestPluginStateCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to PluginState
+		want     bool
+	}{
+		{"", PluginStateInitialized, true},
+		{"", PluginStateStarting, false},
+		{PluginStateInitialized, PluginStateStarting, true},
+		{PluginStateInitialized, PluginStateStarted, false},
+		{PluginStateInitialized, PluginStateStopping, false},
+		{PluginStateStarting, PluginStateStarted, true},
+		{PluginStateStarting, PluginStateStarting, false},
+		{PluginStateStarting, PluginStateStopping, false},
+		{PluginStateStarted, PluginStateStopping, true},
+		{PluginStateStarted, PluginStateStarting, false},
+		{PluginStateStarted, PluginStateInitialized, false},
+		{PluginStateStopping, PluginStateStopped, true},
+		{PluginStateStopping, PluginStateStopping, false},
+		{PluginStateStopping, PluginStateStarting, false},
+		{PluginStateStopped, PluginStateStarting, true},
+		{PluginStateStopped, PluginStateInitialized, true},
+		{PluginStateStopped, PluginStateStopping, false},
+		{PluginStateFailed, PluginStateInitialized, true},
+		{PluginStateFailed, PluginStateStarting, false},
+		{PluginStateStarting, PluginStateFailed, true},
+		{PluginStateStarted, PluginStateFailed, true},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to); got != tt.want {
+			t.Errorf("%q.CanTransition(%q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionString(t *testing.T) {
+	tests := []struct {
+		permission Permission
+		want       string
+	}{
+		{Permission{Resource: "plugins", Action: "start"}, "plugins:start"},
+		{Permission{Resource: "platform", Action: "admin"}, "platform:admin"},
+		{Permission{}, ":"},
+	}
+	for _, tt := range tests {
+		if got := tt.permission.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestResourceFilterMatches(t *testing.T) {
+	resource := NewMemoryResource("mem-hello", "memory", nil, map[string]interface{}{
+		"name":  "hello",
+		"owner": "alice",
+		"tag":   "greeting",
+		"count": 3,
+	})
+	tests := []struct {
+		name   string
+		filter ResourceFilter
+		want   bool
+	}{
+		{"empty", ResourceFilter{}, true},
+		{"type", ResourceFilter{Type: "memory"}, true},
+		{"other type", ResourceFilter{Type: "file"}, false},
+		{"name", ResourceFilter{Name: "hello"}, true},
+		{"other name", ResourceFilter{Name: "bye"}, false},
+		{"owner", ResourceFilter{Owner: "alice"}, true},
+		{"other owner", ResourceFilter{Owner: "bob"}, false},
+		{"metadata value", ResourceFilter{Metadata: map[string]string{"tag": "greeting"}}, true},
+		{"non-string metadata value", ResourceFilter{Metadata: map[string]string{"count": "3"}}, true},
+		{"other metadata value", ResourceFilter{Metadata: map[string]string{"tag": "farewell"}}, false},
+		{"missing metadata key", ResourceFilter{Metadata: map[string]string{"colour": ""}}, false},
+		{"every key must match", ResourceFilter{Metadata: map[string]string{"tag": "greeting", "count": "4"}}, false},
+		{"all fields", ResourceFilter{Name: "hello", Type: "memory", Owner: "alice", Metadata: map[string]string{"tag": "greeting"}}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Matches(resource); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Name and Owner don't match resources without those keys
+	bare := NewMemoryResource("bare", "memory", nil, nil)
+	if (ResourceFilter{Name: "bare"}).Matches(bare) || (ResourceFilter{Owner: "alice"}).Matches(bare) {
+		t.Error("resource without metadata matched a name or owner filter")
+	}
+}
@@ -55,6 +55,10 @@ func (m *metricsCollector) Counter(name string) Counter {
 	return &counter{}
 }
 
+func (m *metricsCollector) CounterVec(name string, labels ...string) CounterVec {
+	return &counterVec{}
+}
+
 func (m *metricsCollector) Gauge(name string) Gauge {
 	return &gauge{}
 }
@@ -83,6 +87,10 @@ func (m *metricsCollector) Export(format string) ([]byte, error) {
 	return []byte("metrics data"), nil // TODO: implement actual metrics export
 }
 
+func (m *metricsCollector) Reset(names ...string) error {
+	return nil
+}
+
 func (m *metricsCollector) Health() HealthStatus {
 	return HealthStatus{
 		Status:    HealthStatusHealthy,
@@ -91,6 +99,12 @@ func (m *metricsCollector) Health() HealthStatus {
 }
 
 // Simple metric implementations
+type counterVec struct{}
+
+func (v *counterVec) With(labels map[string]string) Counter {
+	return &counter{}
+}
+
 type counter struct {
 	value float64
 	mu    sync.RWMutex
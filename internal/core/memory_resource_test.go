@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestMemoryResource(t *testing.T) {
+	res := NewMemoryResource("digits", "memory", []byte("0123456789"), map[string]interface{}{"name": "digits"})
+	if res.Name() != "resource:digits" || res.GetSize() != 10 {
+		t.Fatalf("Name, GetSize = %q, %d", res.Name(), res.GetSize())
+	}
+
+	stream, err := res.Stream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk, err := stream.Read(); err != nil || string(chunk) != "0123456789" {
+		t.Errorf("first Read = %q, %v", chunk, err)
+	}
+	if _, err := stream.Read(); err != io.EOF {
+		t.Errorf("second Read error = %v, want EOF", err)
+	}
+
+	tests := []struct {
+		offset int64
+		n      int
+		want   string
+	}{
+		{0, 3, "012"},
+		{7, 3, "789"},
+		{4, 2, "45"},
+	}
+	for _, tt := range tests {
+		reader, err := res.Open(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := reader.Seek(tt.offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, tt.n)
+		if _, err := io.ReadFull(reader, buf); err != nil || string(buf) != tt.want {
+			t.Errorf("read %d at %d = %q, %v, want %q", tt.n, tt.offset, buf, err, tt.want)
+		}
+		if err := reader.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	}
+}
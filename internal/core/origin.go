@@ -0,0 +1,44 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WebSocketOriginChecker returns a CheckOrigin function for WebSocket
+// upgraders. Requests without an Origin header come from non-browser clients
+// and are always accepted. Browser requests are accepted when their origin
+// is listed in allowed ("*" allows any) or matches the request host.
+//
+// With no allowed origins configured every origin is accepted, unless
+// sameOriginByDefault is set - as it should be when authentication is on, so
+// that another site cannot open a socket with the user's credentials.
+func WebSocketOriginChecker(allowed []string, sameOriginByDefault bool) func(r *http.Request) bool {
+	origins := make(map[string]bool, len(allowed))
+	for _, origin := range allowed {
+		origins[NormalizeOrigin(origin)] = true
+	}
+	allowAny := origins["*"] || (len(origins) == 0 && !sameOriginByDefault)
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowAny {
+			return true
+		}
+		if origins[NormalizeOrigin(origin)] {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		return strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// NormalizeOrigin lower-cases origin and drops surrounding space and a
+// trailing slash, so equivalent origins compare equal
+func NormalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
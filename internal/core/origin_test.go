@@ -0,0 +1,37 @@
+package core
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebSocketOriginChecker(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowed    []string
+		sameOrigin bool
+		origin     string
+		want       bool
+	}{
+		{"no origin header", nil, true, "", true},
+		{"same origin", nil, true, "http://example.com", true},
+		{"cross origin by default", nil, true, "http://evil.example", false},
+		{"cross origin allowed when not same-origin by default", nil, false, "http://evil.example", true},
+		{"listed origin", []string{"https://app.example/"}, true, "HTTPS://app.example", true},
+		{"unlisted origin", []string{"https://app.example"}, false, "http://evil.example", false},
+		{"same origin with a list", []string{"https://app.example"}, false, "http://example.com", true},
+		{"wildcard", []string{"*"}, true, "http://evil.example", true},
+		{"malformed origin", nil, true, "://", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := WebSocketOriginChecker(tt.allowed, tt.sameOrigin)(req); got != tt.want {
+				t.Errorf("check(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
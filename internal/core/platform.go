@@ -169,6 +169,25 @@ func (p *Platform) GetHealthChecker() HealthChecker {
 	return p.healthChecker
 }
 
+// PublishEvent publishes an event attributed to the platform
+func (p *Platform) PublishEvent(eventType string, data map[string]interface{}) error {
+	return p.PublishEventContext(context.Background(), eventType, data)
+}
+
+// PublishEventContext publishes an event attributed to the platform, tagged
+// with the request ID carried by ctx
+func (p *Platform) PublishEventContext(ctx context.Context, eventType string, data map[string]interface{}) error {
+	data = WithRequestIDData(ctx, data)
+	now := time.Now()
+	return p.eventBus.Publish(Event{
+		ID:        NewID(),
+		Type:      eventType,
+		Source:    "platform",
+		Timestamp: now.Unix(),
+		Data:      data,
+	})
+}
+
 // initializeComponents initializes all platform components
 func (p *Platform) initializeComponents(ctx context.Context) error {
 	var err error
@@ -192,7 +211,7 @@ func (p *Platform) initializeComponents(ctx context.Context) error {
 	p.resourceMgr = NewResourceManager(p.logger, p.eventBus)
 
 	// Initialize network manager
-	p.networkMgr, err = NewNetworkManager(p.config.Network, p.logger, p.eventBus)
+	p.networkMgr, err = newNetworkManager(p.config.Network, p.logger, p.eventBus)
 	if err != nil {
 		return err
 	}
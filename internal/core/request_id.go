@@ -0,0 +1,69 @@
+package core
+
+import "context"
+
+// RequestIDHeader carries a request's ID, both inbound from clients and
+// proxies that already assigned one and outbound on every response
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDDataKey is the event data key holding the ID of the request an
+// event was published while handling
+const RequestIDDataKey = "requestId"
+
+// maxRequestIDLength bounds inbound request IDs so clients can't bloat logs
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "" if there
+// is none
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID generates a random request ID
+func NewRequestID() string {
+	return NewID()
+}
+
+// ValidRequestID reports whether an inbound request ID can be used as is: it
+// must be non-empty, not too long, and printable ASCII without spaces
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestIDData adds the request ID carried by ctx to event data. data
+// is returned unchanged when ctx carries no ID or data already has one, and
+// is copied rather than modified otherwise.
+func WithRequestIDData(ctx context.Context, data map[string]interface{}) map[string]interface{} {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		return data
+	}
+	if _, ok := data[RequestIDDataKey]; ok {
+		return data
+	}
+	stamped := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		stamped[key] = value
+	}
+	stamped[RequestIDDataKey] = id
+	return stamped
+}
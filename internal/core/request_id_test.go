@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"req-1", true},
+		{"0f8fad5b-d9cb-469f-a165-70867728950e", true},
+		{strings.Repeat("a", maxRequestIDLength), true},
+		{"", false},
+		{strings.Repeat("a", maxRequestIDLength+1), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"tab\t", false},
+		{"café", false},
+	}
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+
+	if id := NewRequestID(); !ValidRequestID(id) {
+		t.Errorf("generated request ID %q is not valid", id)
+	}
+	if NewRequestID() == NewRequestID() {
+		t.Error("generated the same request ID twice")
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("ID from a bare context = %q", id)
+	}
+	var nilCtx context.Context
+	if id := RequestIDFromContext(nilCtx); id != "" {
+		t.Errorf("ID from a nil context = %q", id)
+	}
+	if id := RequestIDFromContext(WithRequestID(context.Background(), "req-1")); id != "req-1" {
+		t.Errorf("ID = %q, want req-1", id)
+	}
+}
+
+func TestWithRequestIDData(t *testing.T) {
+	withID := WithRequestID(context.Background(), "req-1")
+	tests := []struct {
+		name string
+		ctx  context.Context
+		data map[string]interface{}
+		want interface{}
+	}{
+		{"no request ID", context.Background(), map[string]interface{}{"a": 1}, nil},
+		{"added", withID, map[string]interface{}{"a": 1}, "req-1"},
+		{"nil data", withID, nil, "req-1"},
+		{"already set", withID, map[string]interface{}{RequestIDDataKey: "upstream"}, "upstream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before int
+			if tt.data != nil {
+				before = len(tt.data)
+			}
+			got := WithRequestIDData(tt.ctx, tt.data)
+			if got[RequestIDDataKey] != tt.want {
+				t.Errorf("request ID = %v, want %v", got[RequestIDDataKey], tt.want)
+			}
+			if tt.data != nil && len(tt.data) != before {
+				t.Errorf("caller's data modified to %v", tt.data)
+			}
+			if tt.data != nil && got["a"] != tt.data["a"] {
+				t.Errorf("data %v lost the caller's values %v", got, tt.data)
+			}
+		})
+	}
+}
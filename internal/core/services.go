@@ -12,15 +12,22 @@ import (
 // EventBus implementation
 type eventBus struct {
 	logger      logger.Logger
-	subscribers map[string][]EventHandler
+	subscribers map[string][]subscriber
+	nextID      uint64
 	mu          sync.RWMutex
 	running     bool
 }
 
+// subscriber is a handler together with the ID of its subscription
+type subscriber struct {
+	id      uint64
+	handler EventHandler
+}
+
 func NewEventBus(log logger.Logger) EventBus {
 	return &eventBus{
 		logger:      log,
-		subscribers: make(map[string][]EventHandler),
+		subscribers: make(map[string][]subscriber),
 	}
 }
 
@@ -57,12 +64,12 @@ func (e *eventBus) Publish(event Event) error {
 	handlers := e.subscribers[event.Type]
 	e.mu.RUnlock()
 
-	for _, handler := range handlers {
+	for _, sub := range handlers {
 		go func(h EventHandler) {
 			if err := h(event); err != nil {
 				e.logger.Error("Error handling event", "type", event.Type, "error", err)
 			}
-		}(handler)
+		}(sub.handler)
 	}
 
 	return nil
@@ -73,32 +80,43 @@ func (e *eventBus) PublishToTopic(ctx context.Context, topic string, event Event
 	return e.Publish(event)
 }
 
-func (e *eventBus) Subscribe(eventType string, handler EventHandler) error {
+func (e *eventBus) Subscribe(eventType string, handler EventHandler) (Subscription, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	e.subscribers[eventType] = append(e.subscribers[eventType], handler)
-	return nil
+	e.nextID++
+	e.subscribers[eventType] = append(e.subscribers[eventType], subscriber{id: e.nextID, handler: handler})
+	return Subscription{EventType: eventType, ID: e.nextID}, nil
 }
 
-// Fix the EventHandler wrapping issue
-func (e *eventBus) SubscribeWithContext(ctx context.Context, topic string, handler func(context.Context, Event) error) error {
-	// TODO: implement context-aware subscription with proper handler type
-	// For now, wrap the handler to match EventHandler signature
-	wrappedHandler := EventHandler(func(event Event) error {
+func (e *eventBus) SubscribeWithContext(ctx context.Context, topic string, handler func(context.Context, Event) error) (Subscription, error) {
+	sub, err := e.Subscribe(topic, func(event Event) error {
 		return handler(ctx, event)
 	})
-	return e.Subscribe(topic, wrappedHandler)
+	if err != nil {
+		return sub, err
+	}
+	if done := ctx.Done(); done != nil {
+		go func() {
+			<-done
+			e.Unsubscribe(sub)
+		}()
+	}
+	return sub, nil
 }
 
-func (e *eventBus) Unsubscribe(eventType string, handler EventHandler) error {
+func (e *eventBus) Unsubscribe(sub Subscription) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Note: This is a simplified implementation
-	// In production, you'd want to properly match and remove handlers
-	delete(e.subscribers, eventType)
-	return nil
+	subs := e.subscribers[sub.EventType]
+	for i, s := range subs {
+		if s.id == sub.ID {
+			e.subscribers[sub.EventType] = append(subs[:i:i], subs[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %d to %s", ErrSubscriptionNotFound, sub.ID, sub.EventType)
 }
 
 func (e *eventBus) Configuration() ConfigSchema {
@@ -130,7 +148,10 @@ type networkManager struct {
 	running  bool
 }
 
-func NewNetworkManager(config NetworkConfig, log logger.Logger, eventBus EventBus) (NetworkManager, error) {
+// newNetworkManager creates the placeholder network manager used by the
+// standalone core Platform. Everything else should use the canonical
+// platform.NewNetworkManager.
+func newNetworkManager(config NetworkConfig, log logger.Logger, eventBus EventBus) (NetworkManager, error) {
 	return &networkManager{
 		config:   config,
 		logger:   log,
@@ -283,10 +304,17 @@ func (r *resourceManager) UnregisterResource(id string) error {
 	return nil
 }
 
-// Fix ListResources method signature
 func (r *resourceManager) ListResources(ctx context.Context, filter ResourceFilter) ([]Resource, error) {
-	// TODO: implement actual resource listing with filter
-	return []Resource{}, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resources := make([]Resource, 0, len(r.resources))
+	for _, resource := range r.resources {
+		if filter.Matches(resource) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
 }
 
 // Simple resource implementation that should satisfy any Resource interface
@@ -318,7 +346,7 @@ func (s *simpleResource) Type() string    { return "simple" }
 // Fix GetResource to return a valid Resource instead of nil
 func (r *resourceManager) GetResource(ctx context.Context, name string) (Resource, error) {
 	// TODO: implement actual resource lookup
-	return &simpleResource{name: "not-found"}, fmt.Errorf("resource %s not found", name)
+	return &simpleResource{name: "not-found"}, fmt.Errorf("%w: %s", ErrResourceNotFound, name)
 }
 
 // Fix StreamResource method signature
@@ -415,6 +443,26 @@ func (s *securityManager) GenerateToken(user *User) (string, error) {
 	return "", fmt.Errorf("not implemented")
 }
 
+func (s *securityManager) GenerateRefreshToken(user *User) (string, error) {
+	// TODO: Implement refresh token generation
+	return "", fmt.Errorf("not implemented")
+}
+
+func (s *securityManager) GenerateTokenPair(user *User) (*TokenPair, error) {
+	// TODO: Implement token pair generation
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s *securityManager) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	// TODO: Implement token refresh
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s *securityManager) RevokeToken(jti string) error {
+	// TODO: Implement token revocation
+	return fmt.Errorf("not implemented")
+}
+
 func (s *securityManager) ValidatePermissions(userID string, permissions []string) bool {
 	// TODO: Implement permission validation
 	return true
@@ -425,6 +473,16 @@ func (s *securityManager) ValidateToken(ctx context.Context, token string) (*Tok
 	return &TokenInfo{Valid: false}, fmt.Errorf("token validation not implemented")
 }
 
+func (s *securityManager) Encrypt(data []byte, peerID string) ([]byte, error) {
+	// TODO: Implement payload encryption
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (s *securityManager) Decrypt(data []byte, peerID string) ([]byte, error) {
+	// TODO: Implement payload decryption
+	return nil, fmt.Errorf("not implemented")
+}
+
 func (s *securityManager) Configuration() ConfigSchema {
 	return ConfigSchema{
 		Properties: map[string]PropertySchema{
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/logger"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{})                    {}
+func (nopLogger) Info(string, ...interface{})                     {}
+func (nopLogger) Warn(string, ...interface{})                     {}
+func (nopLogger) Error(string, ...interface{})                    {}
+func (nopLogger) Fatal(string, ...interface{})                    {}
+func (nopLogger) WithFields(map[string]interface{}) logger.Logger { return nopLogger{} }
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	bus := NewEventBus(nopLogger{})
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	handler := func(name string) EventHandler {
+		return func(Event) error {
+			mu.Lock()
+			counts[name]++
+			mu.Unlock()
+			return nil
+		}
+	}
+	first, _ := bus.Subscribe("clipboard.sync", handler("first"))
+	second, _ := bus.Subscribe("clipboard.sync", handler("second"))
+	if first == second {
+		t.Fatalf("both subscriptions are %+v", first)
+	}
+	if err := bus.Unsubscribe(first); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		sub  Subscription
+	}{
+		{"already removed", first},
+		{"other event type", Subscription{EventType: "peer.connected", ID: second.ID}},
+		{"unknown ID", Subscription{EventType: "clipboard.sync", ID: second.ID + 1}},
+	}
+	for _, tt := range tests {
+		if err := bus.Unsubscribe(tt.sub); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("%s: Unsubscribe = %v, want ErrSubscriptionNotFound", tt.name, err)
+		}
+	}
+
+	bus.Publish(Event{Type: "clipboard.sync"})
+	// Handlers run on their own goroutines, so wait for the kept one and
+	// give the removed one the same time to show up
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := counts["second"]
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("remaining handler never got the event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if counts["first"] != 0 {
+		t.Errorf("unsubscribed handler got %d events", counts["first"])
+	}
+}
+
+func TestResourceManagerListResources(t *testing.T) {
+	manager := NewResourceManager(nopLogger{}, NewEventBus(nopLogger{}))
+	for _, resource := range []Resource{
+		NewMemoryResource("a", "memory", nil, map[string]interface{}{"owner": "alice"}),
+		NewMemoryResource("b", "memory", nil, map[string]interface{}{"owner": "bob"}),
+		NewMemoryResource("c", "blob", nil, map[string]interface{}{"owner": "alice"}),
+	} {
+		if err := manager.RegisterResource(resource); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter ResourceFilter
+		want   []string
+	}{
+		{"everything", ResourceFilter{}, []string{"a", "b", "c"}},
+		{"by type", ResourceFilter{Type: "memory"}, []string{"a", "b"}},
+		{"by owner", ResourceFilter{Owner: "alice"}, []string{"a", "c"}},
+		{"by type and owner", ResourceFilter{Type: "blob", Owner: "alice"}, []string{"c"}},
+		{"no match", ResourceFilter{Owner: "carol"}, nil},
+	}
+	for _, tt := range tests {
+		resources, err := manager.ListResources(context.Background(), tt.filter)
+		if err != nil {
+			t.Fatalf("%s: ListResources: %v", tt.name, err)
+		}
+		var ids []string
+		for _, resource := range resources {
+			ids = append(ids, resource.ID())
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: listed %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxStreamsPerClient caps each client's concurrent streaming
+// connections when the config doesn't set a cap
+const DefaultMaxStreamsPerClient = 8
+
+// StreamLimiter caps how many long-lived streams (SSE, WebSockets, media)
+// each client may hold open at once
+type StreamLimiter struct {
+	mu     sync.Mutex
+	max    int
+	active map[string]int
+
+	// Key names the client of a request in Middleware. The default is
+	// the peer IP, which forwarding headers can't change; routers that
+	// only trust their configured proxies can key on ClientIP instead.
+	Key func(c *gin.Context) string
+}
+
+// NewStreamLimiter creates a limiter allowing max concurrent streams per
+// client. A max of zero or less allows any number.
+func NewStreamLimiter(max int) *StreamLimiter {
+	return &StreamLimiter{max: max, active: make(map[string]int)}
+}
+
+// Acquire claims a stream slot for client. If the client is at the cap it
+// returns false; otherwise the returned release must be called when the
+// stream ends. Calling release more than once has no further effect.
+func (l *StreamLimiter) Acquire(client string) (release func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.max > 0 && l.active[client] >= l.max {
+		return nil, false
+	}
+	l.active[client]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.active[client]--; l.active[client] <= 0 {
+				delete(l.active, client)
+			}
+		})
+	}, true
+}
+
+// Active returns how many streams client has open
+func (l *StreamLimiter) Active(client string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[client]
+}
+
+// Middleware holds a stream slot for the client while the rest of the
+// chain runs, answering 429 when the client already has the maximum open
+func (l *StreamLimiter) Middleware() gin.HandlerFunc {
+	key := l.Key
+	if key == nil {
+		key = (*gin.Context).RemoteIP
+	}
+	return func(c *gin.Context) {
+		release, ok := l.Acquire(key(c))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "too many concurrent streams",
+			})
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamLimiterCapAndRelease(t *testing.T) {
+	l := NewStreamLimiter(2)
+
+	first, ok := l.Acquire("a")
+	if !ok {
+		t.Fatal("first stream refused")
+	}
+	second, ok := l.Acquire("a")
+	if !ok {
+		t.Fatal("second stream refused")
+	}
+	if _, ok := l.Acquire("a"); ok {
+		t.Fatal("stream over the cap allowed")
+	}
+	if _, ok := l.Acquire("b"); !ok {
+		t.Fatal("another client's stream refused")
+	}
+
+	// Releasing twice frees one slot only
+	first()
+	first()
+	if got := l.Active("a"); got != 1 {
+		t.Fatalf("active after release = %d, want 1", got)
+	}
+	third, ok := l.Acquire("a")
+	if !ok {
+		t.Fatal("stream refused after a release")
+	}
+	if _, ok := l.Acquire("a"); ok {
+		t.Fatal("double release freed a second slot")
+	}
+
+	second()
+	third()
+	if got := l.Active("a"); got != 0 {
+		t.Fatalf("active after releasing all = %d, want 0", got)
+	}
+
+	unlimited := NewStreamLimiter(0)
+	for i := 0; i < 100; i++ {
+		if _, ok := unlimited.Acquire("a"); !ok {
+			t.Fatalf("stream %d refused without a cap", i)
+		}
+	}
+}
+
+func TestStreamLimiterMiddlewareKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		key  func(*gin.Context) string
+		want int
+	}{
+		// Forwarding headers don't buy another slot
+		{"peer IP by default", nil, http.StatusTooManyRequests},
+		// gin.New trusts every proxy, so this key follows the header
+		{"client IP", (*gin.Context).ClientIP, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewStreamLimiter(1)
+			l.Key = tt.key
+			router := gin.New()
+			router.GET("/stream", l.Middleware(), func(c *gin.Context) {
+				if got := l.Active("203.0.113.7"); got != 1 {
+					t.Errorf("forwarded client holds %d slots during the request, want 1", got)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			// httptest requests come from 192.0.2.1, which holds its slot
+			release, _ := l.Acquire("192.0.2.1")
+			defer release()
+
+			req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if got := l.Active("203.0.113.7"); got != 0 {
+				t.Fatalf("slot not released after the request, %d active", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// WorkerPool runs background goroutines bound to one context, so their
+// owner can cancel them all and wait for them to exit when it shuts down
+type WorkerPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// parent, if set, also runs and waits for this pool's workers
+	parent *WorkerPool
+
+	mu      sync.Mutex
+	stopped bool
+	running map[string]int
+	errs    []error
+}
+
+// NewWorkerPool creates a pool whose workers run until parent is done or
+// the pool is stopped
+func NewWorkerPool(parent context.Context) *WorkerPool {
+	ctx, cancel := context.WithCancel(parent)
+	return &WorkerPool{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]int),
+	}
+}
+
+// Child creates a pool for one component whose workers also run in w:
+// stopping the child ends just its own workers, while stopping w cancels
+// and waits for the workers of its children too
+func (w *WorkerPool) Child() *WorkerPool {
+	child := NewWorkerPool(w.ctx)
+	child.parent = w
+	return child
+}
+
+// Go runs fn in a new goroutine with the pool's context. name identifies
+// the worker in Running and in the errors reported by Stop. Once the pool
+// has stopped, fn is not run and ErrNotRunning is returned.
+func (w *WorkerPool) Go(name string, fn func(ctx context.Context) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return fmt.Errorf("%w: worker pool stopped before %s started", ErrNotRunning, name)
+	}
+
+	run := func() error {
+		defer w.wg.Done()
+		err := fn(w.ctx)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.running[name]--; w.running[name] == 0 {
+			delete(w.running, name)
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			w.errs = append(w.errs, fmt.Errorf("%s: %w", name, err))
+		}
+		return err
+	}
+
+	w.wg.Add(1)
+	if w.parent == nil {
+		go run()
+	} else if err := w.parent.Go(name, func(context.Context) error { return run() }); err != nil {
+		w.wg.Done()
+		return err
+	}
+	// run waits for w.mu, so it can't count the worker out before this
+	w.running[name]++
+	return nil
+}
+
+// Context returns the context workers run with. It is cancelled when the
+// pool stops.
+func (w *WorkerPool) Context() context.Context {
+	return w.ctx
+}
+
+// Running returns how many workers of each name are running
+func (w *WorkerPool) Running() map[string]int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	running := make(map[string]int, len(w.running))
+	for name, n := range w.running {
+		running[name] = n
+	}
+	return running
+}
+
+// Stop cancels the workers and waits for them to return, giving up when
+// ctx is done. It returns the errors workers failed with, other than
+// cancellation, or an error naming the workers still running.
+func (w *WorkerPool) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		running := w.Running()
+		names := make([]string, 0, len(running))
+		for name := range running {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("workers still running after stop: %v: %w", names, ctx.Err())
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return errors.Join(w.errs...)
+}
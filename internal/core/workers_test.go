@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// untilDone is a worker that runs until its context ends
+func untilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWorkerPoolStopWaitsForWorkers(t *testing.T) {
+	pool := NewWorkerPool(context.Background())
+	var exited atomic.Int32
+	for _, name := range []string{"keepalive", "discovery", "discovery"} {
+		err := pool.Go(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			// Slow to exit, so Stop has to wait
+			time.Sleep(20 * time.Millisecond)
+			exited.Add(1)
+			return ctx.Err()
+		})
+		if err != nil {
+			t.Fatalf("Go(%s): %v", name, err)
+		}
+	}
+	if got := pool.Running(); got["keepalive"] != 1 || got["discovery"] != 2 {
+		t.Errorf("Running = %v, want one keepalive and two discovery workers", got)
+	}
+
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := exited.Load(); n != 3 {
+		t.Errorf("%d of 3 workers exited before Stop returned", n)
+	}
+	if got := pool.Running(); len(got) != 0 {
+		t.Errorf("Running = %v after Stop", got)
+	}
+	if pool.Context().Err() == nil {
+		t.Error("pool context not cancelled by Stop")
+	}
+}
+
+func TestWorkerPoolStop(t *testing.T) {
+	errBroken := errors.New("broken")
+	tests := []struct {
+		name    string
+		workers map[string]func(ctx context.Context) error
+		// wantErr lists what the Stop error mentions; none means no error
+		wantErr []string
+		wantIs  error
+	}{
+		{"no workers", nil, nil, nil},
+		{"cancelled workers", map[string]func(ctx context.Context) error{"a": untilDone, "b": untilDone}, nil, nil},
+		{"finished workers", map[string]func(ctx context.Context) error{"a": func(context.Context) error { return nil }}, nil, nil},
+		{"failed workers", map[string]func(ctx context.Context) error{
+			"a": untilDone,
+			"b": func(context.Context) error { return errBroken },
+			"c": func(ctx context.Context) error { <-ctx.Done(); return errors.New("flush failed") },
+		}, []string{"b: broken", "c: flush failed"}, errBroken},
+		{"stuck workers", map[string]func(ctx context.Context) error{
+			"a":     untilDone,
+			"stuck": func(context.Context) error { time.Sleep(time.Second); return nil },
+		}, []string{"[stuck]"}, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewWorkerPool(context.Background())
+			for name, fn := range tt.workers {
+				if err := pool.Go(name, fn); err != nil {
+					t.Fatalf("Go(%s): %v", name, err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			err := pool.Stop(ctx)
+			if (err != nil) != (len(tt.wantErr) > 0) {
+				t.Fatalf("Stop = %v, want error %v", err, len(tt.wantErr) > 0)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Stop error %q does not mention %q", err, want)
+				}
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("Stop error %v does not wrap %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolGoAfterStop(t *testing.T) {
+	pool := NewWorkerPool(context.Background())
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	ran := false
+	err := pool.Go("late", func(context.Context) error { ran = true; return nil })
+	if !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Go after Stop = %v, want %v", err, ErrNotRunning)
+	}
+	if ran {
+		t.Error("worker ran after Stop")
+	}
+}
+
+func TestWorkerPoolParentCancelled(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	pool := NewWorkerPool(parent)
+	exited := make(chan struct{})
+	if err := pool.Go("worker", func(ctx context.Context) error {
+		defer close(exited)
+		return untilDone(ctx)
+	}); err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+
+	cancel()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker still running after the parent context was cancelled")
+	}
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Errorf("Stop: %v", err)
+	}
+}
+
+func TestWorkerPoolChild(t *testing.T) {
+	parent := NewWorkerPool(context.Background())
+	child := parent.Child()
+	var exited atomic.Int32
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		exited.Add(1)
+		return ctx.Err()
+	}
+	for _, pool := range []*WorkerPool{parent, child} {
+		if err := pool.Go("worker", slow); err != nil {
+			t.Fatalf("Go: %v", err)
+		}
+	}
+	// The parent counts its children's workers
+	if got := parent.Running(); got["worker"] != 2 {
+		t.Errorf("parent Running = %v, want both workers", got)
+	}
+	if got := child.Running(); got["worker"] != 1 {
+		t.Errorf("child Running = %v, want its own worker", got)
+	}
+
+	// Stopping the child ends only its workers
+	if err := child.Stop(context.Background()); err != nil {
+		t.Fatalf("child Stop: %v", err)
+	}
+	if n := exited.Load(); n != 1 {
+		t.Errorf("%d workers exited after the child stopped, want 1", n)
+	}
+	if got := parent.Running(); got["worker"] != 1 {
+		t.Errorf("parent Running = %v after the child stopped", got)
+	}
+
+	// Stopping the parent waits for the workers of children it still has
+	other := parent.Child()
+	if err := other.Go("worker", slow); err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+	if err := parent.Stop(context.Background()); err != nil {
+		t.Fatalf("parent Stop: %v", err)
+	}
+	if n := exited.Load(); n != 3 {
+		t.Errorf("%d of 3 workers exited before the parent's Stop returned", n)
+	}
+	if err := parent.Child().Go("late", untilDone); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Go in a child of a stopped pool = %v, want %v", err, ErrNotRunning)
+	}
+}
@@ -0,0 +1,255 @@
+// Package filetype maps file extensions to broad categories and MIME types,
+// so every handler that serves or classifies files agrees on what a file is
+package filetype
+
+import (
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Category is a broad kind of file
+type Category string
+
+// File categories
+const (
+	Text     Category = "text"
+	Image    Category = "image"
+	Audio    Category = "audio"
+	Video    Category = "video"
+	Document Category = "document"
+	Archive  Category = "archive"
+	// Other is the category of files with an unknown extension
+	Other Category = "other"
+)
+
+// DefaultMIMEType is the MIME type of files with an unknown extension
+const DefaultMIMEType = "application/octet-stream"
+
+// Info describes the files with one extension
+type Info struct {
+	Category Category `json:"category"`
+	MIMEType string   `json:"mimeType"`
+}
+
+// types maps lowercase extensions to their info
+var types = map[string]Info{
+	// Text and source code
+	".txt":  {Text, "text/plain; charset=utf-8"},
+	".md":   {Text, "text/markdown; charset=utf-8"},
+	".csv":  {Text, "text/csv; charset=utf-8"},
+	".log":  {Text, "text/plain; charset=utf-8"},
+	".json": {Text, "application/json"},
+	".xml":  {Text, "application/xml"},
+	".yaml": {Text, "application/yaml"},
+	".yml":  {Text, "application/yaml"},
+	".toml": {Text, "application/toml"},
+	".html": {Text, "text/html; charset=utf-8"},
+	".htm":  {Text, "text/html; charset=utf-8"},
+	".css":  {Text, "text/css; charset=utf-8"},
+	".js":   {Text, "text/javascript; charset=utf-8"},
+	".ts":   {Text, "text/plain; charset=utf-8"},
+	".go":   {Text, "text/plain; charset=utf-8"},
+	".py":   {Text, "text/plain; charset=utf-8"},
+	".c":    {Text, "text/plain; charset=utf-8"},
+	".cpp":  {Text, "text/plain; charset=utf-8"},
+	".h":    {Text, "text/plain; charset=utf-8"},
+	".java": {Text, "text/plain; charset=utf-8"},
+	".rs":   {Text, "text/plain; charset=utf-8"},
+	".sh":   {Text, "text/plain; charset=utf-8"},
+
+	// Images
+	".png":  {Image, "image/png"},
+	".jpg":  {Image, "image/jpeg"},
+	".jpeg": {Image, "image/jpeg"},
+	".gif":  {Image, "image/gif"},
+	".webp": {Image, "image/webp"},
+	".svg":  {Image, "image/svg+xml"},
+	".bmp":  {Image, "image/bmp"},
+	".ico":  {Image, "image/x-icon"},
+	".heic": {Image, "image/heic"},
+
+	// Audio
+	".mp3":  {Audio, "audio/mpeg"},
+	".wav":  {Audio, "audio/wav"},
+	".flac": {Audio, "audio/flac"},
+	".aac":  {Audio, "audio/aac"},
+	".ogg":  {Audio, "audio/ogg"},
+	".oga":  {Audio, "audio/ogg"},
+	".opus": {Audio, "audio/opus"},
+	".m4a":  {Audio, "audio/mp4"},
+	".wma":  {Audio, "audio/x-ms-wma"},
+
+	// Video
+	".mp4":  {Video, "video/mp4"},
+	".m4v":  {Video, "video/mp4"},
+	".webm": {Video, "video/webm"},
+	".mkv":  {Video, "video/x-matroska"},
+	".mov":  {Video, "video/quicktime"},
+	".avi":  {Video, "video/x-msvideo"},
+
+	// Documents
+	".pdf":  {Document, "application/pdf"},
+	".doc":  {Document, "application/msword"},
+	".docx": {Document, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	".xls":  {Document, "application/vnd.ms-excel"},
+	".xlsx": {Document, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	".ppt":  {Document, "application/vnd.ms-powerpoint"},
+	".pptx": {Document, "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	".odt":  {Document, "application/vnd.oasis.opendocument.text"},
+	".epub": {Document, "application/epub+zip"},
+
+	// Archives
+	".zip": {Archive, "application/zip"},
+	".tar": {Archive, "application/x-tar"},
+	".gz":  {Archive, "application/gzip"},
+	".tgz": {Archive, "application/gzip"},
+	".bz2": {Archive, "application/x-bzip2"},
+	".xz":  {Archive, "application/x-xz"},
+	".7z":  {Archive, "application/x-7z-compressed"},
+	".rar": {Archive, "application/vnd.rar"},
+}
+
+// Lookup returns what is known about files named name, matching its
+// extension case-insensitively. name may also be a bare extension such as
+// ".mp3".
+func Lookup(name string) (Info, bool) {
+	info, ok := types[strings.ToLower(filepath.Ext(name))]
+	return info, ok
+}
+
+// CategoryOf returns the category of files named name, or Other
+func CategoryOf(name string) Category {
+	if info, ok := Lookup(name); ok {
+		return info.Category
+	}
+	return Other
+}
+
+// MIMEType returns the MIME type of files named name. Extensions not known
+// here are looked up in the system's MIME table, and DefaultMIMEType is
+// returned for those it doesn't know either.
+func MIMEType(name string) string {
+	if info, ok := Lookup(name); ok {
+		return info.MIMEType
+	}
+	if mimeType := mime.TypeByExtension(filepath.Ext(name)); mimeType != "" {
+		return mimeType
+	}
+	return DefaultMIMEType
+}
+
+// Is reports whether files named name are of category c
+func Is(name string, c Category) bool {
+	return CategoryOf(name) == c
+}
+
+// Extensions returns the known extensions of category c, sorted
+func Extensions(c Category) []string {
+	var exts []string
+	for ext, info := range types {
+		if info.Category == c {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// ExtensionSet is a set of extensions configured to mean one category, such
+// as the audio extensions in the config, used where the known extensions of
+// a category aren't what the user wants
+type ExtensionSet map[string]bool
+
+// NewExtensionSet returns the set of exts, which may be given in any case
+// and with or without their leading dot. If exts has none, the set holds
+// the known extensions of fallback.
+func NewExtensionSet(exts []string, fallback Category) ExtensionSet {
+	set := make(ExtensionSet, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	if len(set) == 0 {
+		for _, ext := range Extensions(fallback) {
+			set[ext] = true
+		}
+	}
+	return set
+}
+
+// Has reports whether the extension of files named name is in the set,
+// matching it case-insensitively
+func (s ExtensionSet) Has(name string) bool {
+	return s[strings.ToLower(filepath.Ext(name))]
+}
+
+// inlineTypes are the MIME types content supplied by users may be served as.
+// Others, HTML and SVG among them, could run script on the serving origin.
+var inlineTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"text/plain": true,
+}
+
+// SafeMIMEType returns contentType if content declared as it is safe to serve
+// from this origin, and DefaultMIMEType otherwise
+func SafeMIMEType(contentType string) string {
+	return safeMIMEType(contentType, false)
+}
+
+// SafeMediaMIMEType is SafeMIMEType also allowing the audio and video types
+// known here, which players need to stream and seek
+func SafeMediaMIMEType(contentType string) string {
+	return safeMIMEType(contentType, true)
+}
+
+func safeMIMEType(contentType string, media bool) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return DefaultMIMEType
+	}
+	if inlineTypes[mediaType] {
+		return contentType
+	}
+	if media {
+		for _, info := range types {
+			if (info.Category == Audio || info.Category == Video) && info.MIMEType == mediaType {
+				return contentType
+			}
+		}
+	}
+	return DefaultMIMEType
+}
+
+// SetUntrustedHeaders sets the headers for serving content whose type was
+// declared by a user as contentType: the type if SafeMIMEType allows it,
+// nosniff so browsers don't guess another, and for anything but images a
+// Content-Disposition that makes browsers download rather than render it
+func SetUntrustedHeaders(h http.Header, contentType string) {
+	setUntrustedHeaders(h, SafeMIMEType(contentType))
+}
+
+// SetUntrustedMediaHeaders is SetUntrustedHeaders allowing the types
+// SafeMediaMIMEType does
+func SetUntrustedMediaHeaders(h http.Header, contentType string) {
+	setUntrustedHeaders(h, SafeMediaMIMEType(contentType))
+}
+
+func setUntrustedHeaders(h http.Header, contentType string) {
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	if !strings.HasPrefix(contentType, "image/") {
+		h.Set("Content-Disposition", "attachment")
+	}
+}
@@ -0,0 +1,178 @@
+package filetype
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSafeMIMEType(t *testing.T) {
+	tests := []struct {
+		declared string
+		want     string
+	}{
+		{"image/png", "image/png"},
+		{"image/jpeg", "image/jpeg"},
+		{"image/gif", "image/gif"},
+		{"image/webp", "image/webp"},
+		{"IMAGE/PNG", "IMAGE/PNG"},
+		{"text/plain; charset=utf-8", "text/plain; charset=utf-8"},
+		{"text/html", DefaultMIMEType},
+		{"image/svg+xml", DefaultMIMEType},
+		{"application/javascript", DefaultMIMEType},
+		{"application/xhtml+xml", DefaultMIMEType},
+		{"", DefaultMIMEType},
+		{"not a type", DefaultMIMEType},
+	}
+	for _, tt := range tests {
+		if got := SafeMIMEType(tt.declared); got != tt.want {
+			t.Errorf("SafeMIMEType(%q) = %q, want %q", tt.declared, got, tt.want)
+		}
+	}
+}
+
+func TestSafeMediaMIMEType(t *testing.T) {
+	tests := []struct {
+		declared string
+		want     string
+	}{
+		{"image/png", "image/png"},
+		{"text/plain", "text/plain"},
+		{"audio/mpeg", "audio/mpeg"},
+		{"audio/ogg", "audio/ogg"},
+		{"video/mp4", "video/mp4"},
+		{"video/webm", "video/webm"},
+		{"text/html", DefaultMIMEType},
+		{"image/svg+xml", DefaultMIMEType},
+		{"application/pdf", DefaultMIMEType},
+	}
+	for _, tt := range tests {
+		if got := SafeMediaMIMEType(tt.declared); got != tt.want {
+			t.Errorf("SafeMediaMIMEType(%q) = %q, want %q", tt.declared, got, tt.want)
+		}
+	}
+	// Media is only allowed where asked for
+	if got := SafeMIMEType("video/mp4"); got != DefaultMIMEType {
+		t.Errorf("SafeMIMEType(video/mp4) = %q", got)
+	}
+}
+
+func TestSetUntrustedHeaders(t *testing.T) {
+	tests := []struct {
+		declared    string
+		contentType string
+		disposition string
+	}{
+		{"image/webp", "image/webp", ""},
+		{"text/plain", "text/plain", "attachment"},
+		{"text/html", DefaultMIMEType, "attachment"},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		SetUntrustedHeaders(h, tt.declared)
+		if got := h.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.declared, got, tt.contentType)
+		}
+		if got := h.Get("Content-Disposition"); got != tt.disposition {
+			t.Errorf("%s: Content-Disposition = %q, want %q", tt.declared, got, tt.disposition)
+		}
+		if got := h.Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%s: X-Content-Type-Options = %q", tt.declared, got)
+		}
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want Category
+	}{
+		{"notes.txt", Text},
+		{"main.go", Text},
+		{"photo.JPG", Image},
+		{"icon.svg", Image},
+		{"song.mp3", Audio},
+		{"voice.OPUS", Audio},
+		{"clip.mkv", Video},
+		{"report.pdf", Document},
+		{"backup.tar", Archive},
+		{"data.bin", Other},
+		{"README", Other},
+	}
+	for _, tt := range tests {
+		if got := CategoryOf(tt.name); got != tt.want {
+			t.Errorf("CategoryOf(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMIMEType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"song.mp3", "audio/mpeg"},
+		{"song.M4A", "audio/mp4"},
+		// Not known here, but to the system's MIME table
+		{"module.wasm", "application/wasm"},
+		{"data.unknown-ext", DefaultMIMEType},
+		{"README", DefaultMIMEType},
+	}
+	for _, tt := range tests {
+		if got := MIMEType(tt.name); got != tt.want {
+			t.Errorf("MIMEType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtensionSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		exts     []string
+		fallback Category
+		has      []string
+		hasNot   []string
+	}{
+		{
+			name:     "configured",
+			exts:     []string{".mp3", "OGG", " .Wav "},
+			fallback: Audio,
+			has:      []string{"a.mp3", "b.ogg", "c.WAV"},
+			hasNot:   []string{"d.flac", "e.txt", "mp3"},
+		},
+		{
+			name:     "empty falls back to the category",
+			fallback: Audio,
+			has:      []string{"a.mp3", "b.flac", "c.opus"},
+			hasNot:   []string{"d.mp4", "e.txt"},
+		},
+		{
+			name:     "blank entries fall back too",
+			exts:     []string{"", "  "},
+			fallback: Video,
+			has:      []string{"a.mp4", "b.webm"},
+			hasNot:   []string{"c.mp3"},
+		},
+		{
+			name:     "extensions outside the category",
+			exts:     []string{".xyz"},
+			fallback: Audio,
+			has:      []string{"a.xyz"},
+			hasNot:   []string{"b.mp3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := NewExtensionSet(tt.exts, tt.fallback)
+			for _, name := range tt.has {
+				if !set.Has(name) {
+					t.Errorf("Has(%q) = false, want true", name)
+				}
+			}
+			for _, name := range tt.hasNot {
+				if set.Has(name) {
+					t.Errorf("Has(%q) = true, want false", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/logger"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{})                    {}
+func (nopLogger) Info(string, ...interface{})                     {}
+func (nopLogger) Warn(string, ...interface{})                     {}
+func (nopLogger) Error(string, ...interface{})                    {}
+func (nopLogger) Fatal(string, ...interface{})                    {}
+func (nopLogger) WithFields(map[string]interface{}) logger.Logger { return nopLogger{} }
+
+// keySecurity is a security manager that agrees keys with X25519 and
+// "encrypts" by XORing with them, so payloads only round-trip between peers
+// that agreed the same key
+type keySecurity struct {
+	core.SecurityManager
+
+	private *ecdh.PrivateKey
+	mu      sync.Mutex
+	keys    map[string][]byte
+	// signer, when set, issues and accepts tokens "signer:userID", so
+	// stubs with the same signer trust each other's peer IDs
+	signer string
+}
+
+func newKeySecurity(t *testing.T) *keySecurity {
+	t.Helper()
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keySecurity{private: private, keys: make(map[string][]byte)}
+}
+
+func (s *keySecurity) GenerateToken(user *core.User) (string, error) {
+	if s.signer == "" {
+		return "", core.ErrUnauthorized
+	}
+	return s.signer + ":" + user.ID, nil
+}
+
+func (s *keySecurity) ValidateToken(ctx context.Context, token string) (*core.TokenInfo, error) {
+	userID, ok := strings.CutPrefix(token, s.signer+":")
+	if s.signer == "" || !ok {
+		return &core.TokenInfo{Valid: false}, nil
+	}
+	return &core.TokenInfo{Valid: true, UserID: userID, PeerID: userID}, nil
+}
+
+func (s *keySecurity) EncryptionPublicKey() []byte { return s.private.PublicKey().Bytes() }
+
+func (s *keySecurity) EstablishPeerKey(peerID string, peerPublic []byte) error {
+	remote, err := ecdh.X25519().NewPublicKey(peerPublic)
+	if err != nil {
+		return err
+	}
+	secret, err := s.private.ECDH(remote)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[peerID] = secret
+	return nil
+}
+
+func (s *keySecurity) ForgetPeerKey(peerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, peerID)
+}
+
+func (s *keySecurity) key(peerID string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[peerID]
+}
+
+func (s *keySecurity) xor(data []byte, peerID string) ([]byte, error) {
+	key := s.key(peerID)
+	if key == nil {
+		return nil, core.ErrUnauthorized
+	}
+	out := make([]byte, len(data))
+	for i := range data {
+		out[i] = data[i] ^ key[i%len(key)]
+	}
+	return out, nil
+}
+
+func (s *keySecurity) Encrypt(data []byte, peerID string) ([]byte, error) { return s.xor(data, peerID) }
+func (s *keySecurity) Decrypt(data []byte, peerID string) ([]byte, error) { return s.xor(data, peerID) }
+
+// newTestManager creates a manager using security
+func newTestManager(t *testing.T, security core.SecurityManager) *NetworkManager {
+	t.Helper()
+	nm, err := NewNetworkManager(NetworkConfig{Host: "127.0.0.1"}, security, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+	return nm
+}
+
+// servePeer serves remote's WebSocket endpoint and registers it as a peer
+// of local
+func servePeer(t *testing.T, local, remote *NetworkManager) {
+	t.Helper()
+	servePeerWith(t, local, remote, http.HandlerFunc(remote.handleWebSocket))
+}
+
+// servePeerWith is servePeer with handler in front of remote's endpoint
+func servePeerWith(t *testing.T, local, remote *NetworkManager, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	host, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	remotePeer := *remote.localPeer
+	remotePeer.Address, remotePeer.Port = host, port
+	if err := local.RegisterPeer(remotePeer); err != nil {
+		t.Fatalf("RegisterPeer: %v", err)
+	}
+	// The remote only accepts messages from peers it knows
+	if err := remote.RegisterPeer(*local.localPeer); err != nil {
+		t.Fatalf("RegisterPeer: %v", err)
+	}
+}
+
+func TestSecureChannelAgreesPeerKey(t *testing.T) {
+	dialerSecurity, listenerSecurity := newKeySecurity(t), newKeySecurity(t)
+	dialer := newTestManager(t, dialerSecurity)
+	listener := newTestManager(t, listenerSecurity)
+	servePeer(t, dialer, listener)
+	dialerID, listenerID := dialer.localPeer.ID, listener.localPeer.ID
+
+	received := make(chan core.Message, 1)
+	listener.RegisterMessageHandler("ping", func(ctx context.Context, message core.Message) error {
+		received <- message
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dialer.SendMessage(ctx, listenerID, core.Message{Type: "ping", Data: map[string]interface{}{"text": "hello"}}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	dialerKey, listenerKey := dialerSecurity.key(listenerID), listenerSecurity.key(dialerID)
+	if dialerKey == nil || !bytes.Equal(dialerKey, listenerKey) {
+		t.Fatalf("agreed keys differ: dialer %x, listener %x", dialerKey, listenerKey)
+	}
+	select {
+	case message := <-received:
+		if message.From != dialerID || message.Data["text"] != "hello" {
+			t.Errorf("received %+v, want hello from %s", message, dialerID)
+		}
+	case <-ctx.Done():
+		t.Fatal("message encrypted with the agreed key was not received")
+	}
+
+	dialer.removePeer(listenerID)
+	if key := dialerSecurity.key(listenerID); key != nil {
+		t.Error("key still held after the peer was removed")
+	}
+}
+
+func TestSecureChannelRejectsMalformedPeerKey(t *testing.T) {
+	listener := newTestManager(t, newKeySecurity(t))
+	server := httptest.NewServer(http.HandlerFunc(listener.handleWebSocket))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		header http.Header
+	}{
+		{"not base64", http.Header{peerIDHeader: {"peer-1"}, peerKeyHeader: {"%%%"}}},
+		{"wrong length", http.Header{peerIDHeader: {"peer-1"}, peerKeyHeader: {"AAAA"}}},
+		{"no peer ID", http.Header{peerKeyHeader: {"AAAA"}}},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header = tt.header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
+
+// dialPeerKey opens a connection to server claiming peerID and offering a
+// fresh public key, with token as proof of the ID if set, and returns the
+// response status
+func dialPeerKey(t *testing.T, server *httptest.Server, peerID, token string) int {
+	t.Helper()
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set(peerIDHeader, peerID)
+	header.Set(peerKeyHeader, base64.StdEncoding.EncodeToString(private.PublicKey().Bytes()))
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if err == nil {
+		conn.Close()
+	}
+	if resp == nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestInboundHandshakeCannotReplacePeerKey(t *testing.T) {
+	security := newKeySecurity(t)
+	security.signer = "cluster"
+	listener := newTestManager(t, security)
+	server := httptest.NewServer(http.HandlerFunc(listener.handleWebSocket))
+	defer server.Close()
+
+	// The first connection for a peer ID agrees its key
+	if status := dialPeerKey(t, server, "peer-1", ""); status != http.StatusSwitchingProtocols {
+		t.Fatalf("first handshake status = %d", status)
+	}
+	first := security.key("peer-1")
+	if first == nil {
+		t.Fatal("no key agreed on first contact")
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantKept   bool
+	}{
+		{"unauthenticated", "", http.StatusUnauthorized, true},
+		{"token for another peer", "cluster:peer-2", http.StatusUnauthorized, true},
+		{"token from another signer", "other:peer-1", http.StatusUnauthorized, true},
+		{"token for the peer", "cluster:peer-1", http.StatusSwitchingProtocols, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := security.key("peer-1")
+			if status := dialPeerKey(t, server, "peer-1", tt.token); status != tt.wantStatus {
+				t.Errorf("second handshake status = %d, want %d", status, tt.wantStatus)
+			}
+			if kept := bytes.Equal(security.key("peer-1"), before); kept != tt.wantKept {
+				t.Errorf("key kept = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+
+	// Once the peer is forgotten, a new key is accepted on first contact
+	listener.forgetPeerKey("peer-1")
+	if status := dialPeerKey(t, server, "peer-1", ""); status != http.StatusSwitchingProtocols {
+		t.Errorf("handshake after the key was forgotten = %d", status)
+	}
+}
+
+func TestSecureChannelWithoutKeyExchange(t *testing.T) {
+	// Without a key exchange the shared key, here none at all, is used
+	dialer := newTestManager(t, nil)
+	listener := newTestManager(t, nil)
+	servePeer(t, dialer, listener)
+
+	channel, err := dialer.CreateSecureChannel(context.Background(), listener.localPeer.ID)
+	if err != nil {
+		t.Fatalf("CreateSecureChannel: %v", err)
+	}
+	defer channel.Close()
+	if err := channel.Send([]byte(`{"type":"ping"}`)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if _, err := dialer.CreateSecureChannel(context.Background(), "missing"); err == nil {
+		t.Errorf("CreateSecureChannel to an unknown peer = %v, want an error", err)
+	}
+}
+
+func TestConcurrentSendsShareOneChannel(t *testing.T) {
+	dialer := newTestManager(t, nil)
+	listener := newTestManager(t, nil)
+	var upgrades atomic.Int32
+	servePeerWith(t, dialer, listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrades.Add(1)
+		listener.handleWebSocket(w, r)
+	}))
+	defer dialer.Stop(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	const senders = 16
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := dialer.SendMessage(ctx, listener.localPeer.ID, core.Message{Type: "ping"}); err != nil {
+				t.Errorf("SendMessage: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := upgrades.Load(); got != 1 {
+		t.Errorf("%d connections dialed to the peer, want 1", got)
+	}
+	dialer.mu.RLock()
+	defer dialer.mu.RUnlock()
+	if len(dialer.channels) != 1 || len(dialer.dialing) != 0 {
+		t.Errorf("%d channels and %d dials in flight, want 1 and 0", len(dialer.channels), len(dialer.dialing))
+	}
+}
@@ -0,0 +1,377 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// newDiscoveryManager creates a manager whose discovery server listens on a
+// free loopback port
+func newDiscoveryManager(t *testing.T, config NetworkConfig) *NetworkManager {
+	t.Helper()
+	config.EnableDiscovery = true
+	config.DiscoveryBindAddress = "127.0.0.1"
+	nm, err := NewNetworkManager(config, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	nm.mu.Lock()
+	err = nm.startDiscoveryServer(ctx)
+	nm.mu.Unlock()
+	if err != nil {
+		t.Fatalf("startDiscoveryServer: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		nm.stopDiscoveryServer()
+	})
+	return nm
+}
+
+// freeUDPPort returns a UDP port nothing is listening on
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestDiscoveryCancelReturnsPromptly(t *testing.T) {
+	// Nothing answers on the port, so only cancellation ends the wait
+	nm, err := NewNetworkManager(NetworkConfig{
+		EnableDiscovery:  true,
+		DiscoveryPort:    freeUDPPort(t),
+		DiscoveryTimeout: time.Minute,
+	}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = nm.broadcastDiscovery(ctx)
+	elapsed := time.Since(start)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		t.Skipf("broadcast unavailable here: %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("broadcastDiscovery error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed > time.Second {
+		t.Errorf("broadcastDiscovery took %v after cancellation, want it to return promptly", elapsed)
+	}
+}
+
+func TestDiscoveryCollectionEnds(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     int
+		timeout    time.Duration
+		minElapsed time.Duration
+		maxElapsed time.Duration
+	}{
+		// One peer is known up front; when that is enough nothing is
+		// waited for
+		{"target reached", 1, time.Minute, 0, time.Second},
+		{"timeout", 0, 200 * time.Millisecond, 200 * time.Millisecond, 5 * time.Second},
+		{"target not reached", 2, 200 * time.Millisecond, 200 * time.Millisecond, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nm := newDiscoveryManager(t, NetworkConfig{
+				DiscoveryPort:        freeUDPPort(t),
+				DiscoveryTimeout:     tt.timeout,
+				DiscoveryTargetPeers: tt.target,
+			})
+			other, err := NewNetworkManager(NetworkConfig{Port: 7003}, nil, nil, nopLogger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !nm.recordDiscoveredPeer(other.discoveryPayload("discovery_response"), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 7003}) {
+				t.Fatal("peer not recorded")
+			}
+
+			start := time.Now()
+			peers, err := nm.broadcastDiscovery(context.Background())
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Skipf("broadcast unavailable here: %v", err)
+			}
+			if len(peers) != 1 || peers[0].ID != other.localPeer.ID {
+				t.Errorf("peers = %+v, want the known peer", peers)
+			}
+			if elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("broadcastDiscovery took %v, want between %v and %v", elapsed, tt.minElapsed, tt.maxElapsed)
+			}
+		})
+	}
+}
+
+func TestDiscoveryTimeoutDefault(t *testing.T) {
+	tests := []struct {
+		configured time.Duration
+		want       time.Duration
+	}{
+		{0, DefaultDiscoveryTimeout},
+		{-time.Second, DefaultDiscoveryTimeout},
+		{300 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		nm := &NetworkManager{config: NetworkConfig{DiscoveryTimeout: tt.configured}}
+		if got := nm.discoveryTimeout(); got != tt.want {
+			t.Errorf("discoveryTimeout with %v configured = %v, want %v", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoveryLoopback(t *testing.T) {
+	// The responder listens on all interfaces, so it advertises no usable
+	// address and is reached at the one its answer came from
+	seeker := newDiscoveryManager(t, NetworkConfig{Host: "127.0.0.1", Port: 7001})
+	responder := newDiscoveryManager(t, NetworkConfig{Host: "0.0.0.0", Port: 7002})
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	request, _ := json.Marshal(seeker.discoveryPayload("discovery"))
+	if _, err := conn.WriteToUDP(request, responder.discoveryServer.conn.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buffer := make([]byte, 4096)
+	n, from, err := conn.ReadFromUDP(buffer)
+	if err != nil {
+		t.Fatalf("no discovery response: %v", err)
+	}
+	var response discoveryMessage
+	if err := json.Unmarshal(buffer[:n], &response); err != nil || response.Type != "discovery_response" {
+		t.Fatalf("response = %s, %v", buffer[:n], err)
+	}
+	if !seeker.recordDiscoveredPeer(response, from) {
+		t.Fatal("seeker rejected the responder")
+	}
+
+	found := seeker.discoveredPeers()
+	if len(found) != 1 || found[0].ID != responder.localPeer.ID {
+		t.Fatalf("seeker discovered %+v, want the responder", found)
+	}
+	if found[0].Address != "127.0.0.1" || found[0].Port != 7002 {
+		t.Errorf("responder found at %s:%d, want 127.0.0.1:7002", found[0].Address, found[0].Port)
+	}
+	if answered := responder.discoveredPeers(); len(answered) != 1 || answered[0].ID != seeker.localPeer.ID {
+		t.Errorf("responder discovered %+v, want the seeker", answered)
+	}
+}
+
+func TestDiscoveryCompatibility(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []string
+		version  string
+		// capabilities advertised by the remote peer, DefaultCapabilities if nil
+		capabilities []string
+		want         bool
+	}{
+		{"same version", nil, ProtocolVersion, nil, true},
+		{"same major version", nil, "1.9.3", nil, true},
+		{"newer major version", nil, "2.0.0", nil, false},
+		{"older major version", nil, "0.9.0", nil, false},
+		{"no version", nil, "", nil, false},
+		{"required capability advertised", []string{"clipboard"}, ProtocolVersion, nil, true},
+		{"required capability missing", []string{"clipboard"}, ProtocolVersion, []string{"messaging"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nm, err := NewNetworkManager(NetworkConfig{EnableDiscovery: true, RequiredCapabilities: tt.required}, nil, nil, nopLogger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote, err := NewNetworkManager(NetworkConfig{Capabilities: tt.capabilities}, nil, nil, nopLogger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			message := remote.discoveryPayload("discovery_response")
+			message.ProtocolVersion = tt.version
+
+			if got := nm.recordDiscoveredPeer(message, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}); got != tt.want {
+				t.Fatalf("recordDiscoveredPeer = %v, want %v", got, tt.want)
+			}
+			if found := len(nm.discoveredPeers()) == 1; found != tt.want {
+				t.Errorf("peer listed = %v, want %v", found, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoveryBindAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		bind     string
+		wantIP   net.IP
+		wantFail bool
+	}{
+		{"bind address", "0.0.0.0", "127.0.0.1", net.IPv4(127, 0, 0, 1), false},
+		{"host by default", "127.0.0.1", "", net.IPv4(127, 0, 0, 1), false},
+		{"all interfaces", "", "", net.IPv4zero, false},
+		{"unusable address", "", "192.0.2.1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nm, err := NewNetworkManager(NetworkConfig{
+				Host:                 tt.host,
+				EnableDiscovery:      true,
+				DiscoveryBindAddress: tt.bind,
+			}, nil, nil, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewNetworkManager: %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			nm.mu.Lock()
+			err = nm.startDiscoveryServer(ctx)
+			nm.mu.Unlock()
+			if tt.wantFail {
+				if err == nil {
+					nm.stopDiscoveryServer()
+					t.Fatal("discovery server bound to an address this host doesn't have")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("startDiscoveryServer: %v", err)
+			}
+			defer nm.stopDiscoveryServer()
+
+			addr := nm.discoveryServer.conn.LocalAddr().(*net.UDPAddr)
+			if !addr.IP.Equal(tt.wantIP) && !(tt.wantIP.IsUnspecified() && addr.IP.IsUnspecified()) {
+				t.Errorf("discovery socket bound to %v, want %v", addr.IP, tt.wantIP)
+			}
+		})
+	}
+}
+
+func TestDiscoveryServerShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		// shutdown ends the discovery server started with cancel
+		shutdown func(t *testing.T, nm *NetworkManager, cancel context.CancelFunc)
+	}{
+		{"manager stopped", func(t *testing.T, nm *NetworkManager, cancel context.CancelFunc) {
+			if err := nm.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+		}},
+		{"context cancelled", func(t *testing.T, nm *NetworkManager, cancel context.CancelFunc) {
+			cancel()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := freeUDPPort(t)
+			nm, err := NewNetworkManager(NetworkConfig{
+				Host:                 "127.0.0.1",
+				EnableDiscovery:      true,
+				DiscoveryBindAddress: "127.0.0.1",
+				DiscoveryPort:        port,
+				KeepAliveInterval:    time.Minute,
+			}, nil, nil, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewNetworkManager: %v", err)
+			}
+			if err := nm.Start(context.Background()); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			t.Cleanup(func() { nm.Stop(context.Background()) })
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			nm.mu.Lock()
+			err = nm.startDiscoveryServer(ctx)
+			done := nm.discoveryServer.done
+			nm.mu.Unlock()
+			if err != nil {
+				t.Fatalf("startDiscoveryServer: %v", err)
+			}
+
+			tt.shutdown(t, nm, cancel)
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("discovery read loop still running")
+			}
+
+			// The socket is released, so the port can be bound again
+			nm.discoveryServer.mu.RLock()
+			conn := nm.discoveryServer.conn
+			nm.discoveryServer.mu.RUnlock()
+			if conn != nil {
+				t.Error("discovery server still holds its socket")
+			}
+			rebound, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+			if err != nil {
+				t.Fatalf("discovery port still in use: %v", err)
+			}
+			rebound.Close()
+
+			// And the server can start again
+			nm.mu.Lock()
+			err = nm.startDiscoveryServer(context.Background())
+			nm.mu.Unlock()
+			if err != nil {
+				t.Fatalf("restart: %v", err)
+			}
+			nm.stopDiscoveryServer()
+		})
+	}
+}
+
+func TestStopReleasesDiscoveryWithoutStart(t *testing.T) {
+	port := freeUDPPort(t)
+	nm, err := NewNetworkManager(NetworkConfig{
+		EnableDiscovery:      true,
+		DiscoveryBindAddress: "127.0.0.1",
+		DiscoveryPort:        port,
+		DiscoveryTimeout:     10 * time.Millisecond,
+	}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+
+	// Discovery binds the socket without the manager being started
+	if _, err := nm.DiscoverPeers(context.Background()); err != nil {
+		t.Fatalf("DiscoverPeers: %v", err)
+	}
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+	if conn, err := net.ListenUDP("udp", addr); err == nil {
+		conn.Close()
+		t.Fatal("discovery socket not bound by DiscoverPeers")
+	}
+
+	if err := nm.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		t.Fatalf("discovery port still in use after Stop: %v", err)
+	}
+	conn.Close()
+	// Stopping again is harmless
+	if err := nm.Stop(context.Background()); err != nil {
+		t.Errorf("second Stop: %v", err)
+	}
+}
@@ -2,11 +2,16 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,28 +34,67 @@ type NetworkManager struct {
 	// Network services
 	server          *http.Server
 	discoveryServer *DiscoveryServer
+	mdns            mdnsResponder
+	mdnsStop        context.CancelFunc
+
+	// workers runs the background goroutines. It is created on first use,
+	// within ownerPool if the owner set one, and Stop waits for it.
+	workers   *core.WorkerPool
+	ownerPool *core.WorkerPool
+
+	// peerKeys holds the public key each peer's payload key was agreed
+	// from, so an unauthenticated handshake can't replace it
+	keysMu   sync.Mutex
+	peerKeys map[string][]byte
 
 	// Communication channels
-	channels        map[string]core.SecureChannel
+	channels        map[string]SecureChannel
+	dialing         map[string]*channelDial
 	messageHandlers map[string]MessageHandler
 
 	// State
 	started bool
 }
 
-// NetworkConfig contains network configuration
-type NetworkConfig struct {
-	Host              string        `json:"host"`
-	Port              int           `json:"port"`
-	EnableDiscovery   bool          `json:"enableDiscovery"`
-	DiscoveryPort     int           `json:"discoveryPort"`
-	DiscoveryInterval time.Duration `json:"discoveryInterval"`
-	MaxPeers          int           `json:"maxPeers"`
-	Timeout           time.Duration `json:"timeout"`
-	KeepAliveInterval time.Duration `json:"keepAliveInterval"`
-	EnableTLS         bool          `json:"enableTLS"`
-	TLSCertFile       string        `json:"tlsCertFile"`
-	TLSKeyFile        string        `json:"tlsKeyFile"`
+// NetworkConfig contains network configuration. DiscoveryTimeout defaults
+// to DefaultDiscoveryTimeout and Capabilities to DefaultCapabilities.
+type NetworkConfig = core.NetworkConfig
+
+// ErrPeerLimitReached is returned when a new peer would exceed MaxPeers
+var ErrPeerLimitReached = errors.New("maximum peers reached")
+
+// ErrPeerKeyMismatch is returned when an unauthenticated handshake offers a
+// different key for a peer that already agreed one
+var ErrPeerKeyMismatch = fmt.Errorf("%w: peer already agreed a different key", core.ErrUnauthorized)
+
+// peerIDHeader identifies the connecting peer on inbound WebSocket connections
+const peerIDHeader = "X-Peer-ID"
+
+// peerKeyHeader carries each side's X25519 public key, base64 encoded, in
+// the WebSocket handshake so both agree a payload key for the channel
+const peerKeyHeader = "X-Peer-Key"
+
+// DefaultDiscoveryTimeout is how long a discovery broadcast waits for responses
+// when NetworkConfig.DiscoveryTimeout is not set
+const DefaultDiscoveryTimeout = 2 * time.Second
+
+// DefaultMaxMessageSize limits peer messages when
+// NetworkConfig.MaxMessageSize is not set
+const DefaultMaxMessageSize = 1 << 20
+
+// ProtocolVersion is the peer protocol version advertised during discovery.
+// Peers with a different major version are not added.
+const ProtocolVersion = "1.0.0"
+
+// DefaultCapabilities are advertised when NetworkConfig.Capabilities is empty
+var DefaultCapabilities = []string{"file-sharing", "clipboard", "messaging"}
+
+// discoveryMessage is the payload of discovery requests and responses
+type discoveryMessage struct {
+	Type            string    `json:"type"`
+	Peer            core.Peer `json:"peer"`
+	ProtocolVersion string    `json:"protocolVersion"`
+	Capabilities    []string  `json:"capabilities"`
 }
 
 // MessageHandler processes incoming messages
@@ -58,10 +102,41 @@ type MessageHandler func(ctx context.Context, message core.Message) error
 
 // DiscoveryServer handles peer discovery
 type DiscoveryServer struct {
+	bindAddr string
 	port     int
 	interval time.Duration
 	peers    map[string]*core.Peer
 	mu       sync.RWMutex
+
+	// conn is the listening socket while the server runs; done is closed
+	// once its read loop has exited
+	conn *net.UDPConn
+	done chan struct{}
+}
+
+// channelDial tracks an in-flight channel creation so concurrent callers for
+// the same peer share one connection
+type channelDial struct {
+	done    chan struct{}
+	channel SecureChannel
+	err     error
+}
+
+// SecureChannel is an encrypted connection to one peer
+type SecureChannel interface {
+	Send(data []byte) error
+	Receive() ([]byte, error)
+	Close() error
+}
+
+// PeerKeyExchanger is implemented by security managers that agree a payload
+// key with each peer from its public key. Channels exchange keys when the
+// network manager's security manager implements it; otherwise payloads use
+// whatever key the security manager has for the peer.
+type PeerKeyExchanger interface {
+	EncryptionPublicKey() []byte
+	EstablishPeerKey(peerID string, peerPublic []byte) error
+	ForgetPeerKey(peerID string)
 }
 
 // SecureChannelImpl implements encrypted communication
@@ -80,8 +155,11 @@ func NewNetworkManager(config NetworkConfig, security core.SecurityManager, even
 		eventBus:        eventBus,
 		logger:          logger,
 		peers:           make(map[string]*core.Peer),
-		channels:        make(map[string]core.SecureChannel),
+		peerKeys:        make(map[string][]byte),
+		channels:        make(map[string]SecureChannel),
+		dialing:         make(map[string]*channelDial),
 		messageHandlers: make(map[string]MessageHandler),
+		mdns:            multicastResponder{},
 	}
 
 	// Create local peer identity
@@ -92,6 +170,7 @@ func NewNetworkManager(config NetworkConfig, security core.SecurityManager, even
 	// Initialize discovery server if enabled
 	if config.EnableDiscovery {
 		nm.discoveryServer = &DiscoveryServer{
+			bindAddr: discoveryBindAddress(config),
 			port:     config.DiscoveryPort,
 			interval: config.DiscoveryInterval,
 			peers:    make(map[string]*core.Peer),
@@ -103,29 +182,57 @@ func NewNetworkManager(config NetworkConfig, security core.SecurityManager, even
 
 // DiscoverPeers finds other instances on the network
 func (nm *NetworkManager) DiscoverPeers(ctx context.Context) ([]core.Peer, error) {
-	nm.mu.Lock()
-	defer nm.mu.Unlock()
-
 	if !nm.config.EnableDiscovery {
 		return []core.Peer{}, nil
 	}
 
 	nm.logger.Info("Starting peer discovery")
 
-	// Start discovery server
-	if err := nm.startDiscoveryServer(ctx); err != nil {
-		return nil, fmt.Errorf("failed to start discovery server: %w", err)
+	useBroadcast, useMDNS := nm.usesBroadcast(), nm.usesMDNS()
+	nm.mu.Lock()
+	if useBroadcast {
+		// Start discovery server
+		if err := nm.startDiscoveryServer(ctx); err != nil {
+			nm.mu.Unlock()
+			return nil, fmt.Errorf("failed to start discovery server: %w", err)
+		}
+	}
+	if useMDNS {
+		if err := nm.startMDNSAdvertisement(ctx); err != nil {
+			nm.logger.Warn("mDNS advertisement failed", core.Field{Key: "error", Value: err})
+		}
 	}
+	nm.mu.Unlock()
 
-	// Broadcast discovery request
-	peers, err := nm.broadcastDiscovery(ctx)
-	if err != nil {
-		nm.logger.Warn("Discovery broadcast failed", core.Field{Key: "error", Value: err})
+	// Waiting for answers takes up to the discovery timeout, so it is done
+	// without nm.mu and peers stay usable meanwhile
+	var peers []core.Peer
+	if useBroadcast {
+		// Broadcast discovery request
+		var err error
+		if peers, err = nm.broadcastDiscovery(ctx); err != nil {
+			nm.logger.Warn("Discovery broadcast failed", core.Field{Key: "error", Value: err})
+		}
+	}
+	if useMDNS {
+		if _, err := nm.browseMDNS(ctx); err != nil {
+			nm.logger.Warn("mDNS browse failed", core.Field{Key: "error", Value: err})
+		}
+		peers = nm.discoveredPeers()
 	}
 
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
 	// Add discovered peers
 	for _, peer := range peers {
-		nm.addPeer(&peer)
+		peer := peer
+		if err := nm.addPeer(&peer); err != nil {
+			nm.logger.Warn("Discovered peer rejected",
+				core.Field{Key: "peerID", Value: peer.ID},
+				core.Field{Key: "error", Value: err},
+			)
+		}
 	}
 
 	result := make([]core.Peer, 0, len(nm.peers))
@@ -141,12 +248,7 @@ func (nm *NetworkManager) RegisterPeer(peer core.Peer) error {
 	nm.mu.Lock()
 	defer nm.mu.Unlock()
 
-	if len(nm.peers) >= nm.config.MaxPeers {
-		return fmt.Errorf("maximum peers (%d) reached", nm.config.MaxPeers)
-	}
-
-	nm.addPeer(&peer)
-	return nil
+	return nm.addPeer(&peer)
 }
 
 // GetPeers returns all known peers
@@ -195,7 +297,9 @@ func (nm *NetworkManager) SendMessage(ctx context.Context, peerID string, messag
 	)
 
 	// Update peer last seen
-	peer.LastSeen = time.Now()
+	nm.mu.Lock()
+	peer.LastSeen = time.Now().Unix()
+	nm.mu.Unlock()
 
 	return nil
 }
@@ -229,8 +333,14 @@ func (nm *NetworkManager) BroadcastMessage(ctx context.Context, message core.Mes
 	return nil
 }
 
-// CreateSecureChannel establishes an encrypted connection
-func (nm *NetworkManager) CreateSecureChannel(ctx context.Context, peerID string) (core.SecureChannel, error) {
+// CreateSecureChannel establishes an encrypted connection. If a channel to the
+// peer already exists or is being established, that channel is returned.
+func (nm *NetworkManager) CreateSecureChannel(ctx context.Context, peerID string) (SecureChannel, error) {
+	return nm.getOrCreateChannel(ctx, peerID)
+}
+
+// dialChannel opens a new connection to the peer without registering it
+func (nm *NetworkManager) dialChannel(ctx context.Context, peerID string) (SecureChannel, error) {
 	nm.mu.RLock()
 	peer, exists := nm.peers[peerID]
 	nm.mu.RUnlock()
@@ -241,10 +351,19 @@ func (nm *NetworkManager) CreateSecureChannel(ctx context.Context, peerID string
 
 	// Create WebSocket connection
 	addr := fmt.Sprintf("ws://%s:%d/ws", peer.Address, peer.Port)
-	conn, _, err := websocket.DefaultDialer.DialContext(ctx, addr, nil)
+	header := http.Header{}
+	header.Set(peerIDHeader, nm.localPeer.ID)
+	nm.offerPeerKey(header)
+	nm.vouchForPeerID(header)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, addr, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to peer %s: %w", peerID, err)
 	}
+	// The key comes from the peer we dialled, so it may replace an old one
+	if err := nm.acceptPeerKey(peerID, resp.Header, true); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to agree key with peer %s: %w", peerID, err)
+	}
 
 	channel := &SecureChannelImpl{
 		conn:     conn,
@@ -252,15 +371,95 @@ func (nm *NetworkManager) CreateSecureChannel(ctx context.Context, peerID string
 		security: nm.security,
 	}
 
-	nm.mu.Lock()
-	nm.channels[peerID] = channel
-	nm.mu.Unlock()
-
 	nm.logger.Info("Secure channel established", core.Field{Key: "peer", Value: peerID})
 
 	return channel, nil
 }
 
+// keyExchanger returns the security manager's key exchange, or nil when it
+// has none or encryption is disabled
+func (nm *NetworkManager) keyExchanger() PeerKeyExchanger {
+	kx, ok := nm.security.(PeerKeyExchanger)
+	if !ok || len(kx.EncryptionPublicKey()) == 0 {
+		return nil
+	}
+	return kx
+}
+
+// offerPeerKey adds the local public key to a handshake's headers
+func (nm *NetworkManager) offerPeerKey(header http.Header) {
+	if kx := nm.keyExchanger(); kx != nil {
+		header.Set(peerKeyHeader, base64.StdEncoding.EncodeToString(kx.EncryptionPublicKey()))
+	}
+}
+
+// acceptPeerKey agrees the payload key for peerID from the public key in
+// its handshake headers. Peers that send none keep using the shared key.
+// The first key agreed with a peer is kept until the peer is removed: a
+// different one is only accepted when authenticated is set, and otherwise
+// fails with ErrPeerKeyMismatch.
+func (nm *NetworkManager) acceptPeerKey(peerID string, header http.Header, authenticated bool) error {
+	encoded := header.Get(peerKeyHeader)
+	kx := nm.keyExchanger()
+	if encoded == "" || kx == nil {
+		return nil
+	}
+	if peerID == "" {
+		return fmt.Errorf("%w: peer key sent without a peer ID", core.ErrInvalidRequest)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: malformed peer key", core.ErrInvalidRequest)
+	}
+
+	nm.keysMu.Lock()
+	defer nm.keysMu.Unlock()
+	if pinned, ok := nm.peerKeys[peerID]; ok && !bytes.Equal(pinned, key) && !authenticated {
+		return ErrPeerKeyMismatch
+	}
+	if err := kx.EstablishPeerKey(peerID, key); err != nil {
+		return err
+	}
+	nm.peerKeys[peerID] = key
+	return nil
+}
+
+// forgetPeerKey drops the payload key agreed with peerID
+func (nm *NetworkManager) forgetPeerKey(peerID string) {
+	nm.keysMu.Lock()
+	delete(nm.peerKeys, peerID)
+	nm.keysMu.Unlock()
+
+	if kx := nm.keyExchanger(); kx != nil {
+		kx.ForgetPeerKey(peerID)
+	}
+}
+
+// vouchForPeerID adds a token for the local peer ID to a handshake's
+// headers, which peers sharing the signing key accept as proof of identity
+func (nm *NetworkManager) vouchForPeerID(header http.Header) {
+	if nm.security == nil {
+		return
+	}
+	token, err := nm.security.GenerateToken(&core.User{ID: nm.localPeer.ID})
+	if err != nil {
+		nm.logger.Debug("No peer token for handshake", core.Field{Key: "error", Value: err})
+		return
+	}
+	header.Set("Authorization", "Bearer "+token)
+}
+
+// authenticatedPeer reports whether r carries a valid token issued to
+// peerID, as vouchForPeerID adds
+func (nm *NetworkManager) authenticatedPeer(r *http.Request, peerID string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || nm.security == nil || peerID == "" {
+		return false
+	}
+	info, err := nm.security.ValidateToken(r.Context(), token)
+	return err == nil && info != nil && info.Valid && info.PeerID == peerID
+}
+
 // RegisterMessageHandler registers a handler for a message type
 func (nm *NetworkManager) RegisterMessageHandler(messageType string, handler MessageHandler) {
 	nm.mu.Lock()
@@ -285,7 +484,15 @@ func (nm *NetworkManager) Start(ctx context.Context) error {
 	}
 
 	// Start keep-alive routine
-	go nm.keepAliveRoutine(ctx)
+	if nm.config.KeepAliveInterval > 0 {
+		err := nm.workerPoolLocked(ctx).Go("network-keepalive", func(ctx context.Context) error {
+			nm.keepAliveRoutine(ctx)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start keep-alive: %w", err)
+		}
+	}
 
 	nm.started = true
 	nm.logger.Info("Network manager started",
@@ -296,16 +503,13 @@ func (nm *NetworkManager) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop gracefully shuts down network operations
+// Stop gracefully shuts down network operations. It also releases what
+// DiscoverPeers opens without Start, such as the discovery socket, so it is
+// safe to call on a manager that was never started.
 func (nm *NetworkManager) Stop(ctx context.Context) error {
 	nm.mu.Lock()
-	defer nm.mu.Unlock()
-
-	if !nm.started {
-		return fmt.Errorf("network manager not started")
-	}
 
-	// Close all channels
+	// Close all channels and drop the keys agreed for them
 	for peerID, channel := range nm.channels {
 		if err := channel.Close(); err != nil {
 			nm.logger.Warn("Failed to close channel",
@@ -313,6 +517,27 @@ func (nm *NetworkManager) Stop(ctx context.Context) error {
 				core.Field{Key: "error", Value: err},
 			)
 		}
+		delete(nm.channels, peerID)
+		nm.forgetPeerKey(peerID)
+	}
+	// and those agreed over inbound connections
+	nm.keysMu.Lock()
+	pinned := make([]string, 0, len(nm.peerKeys))
+	for peerID := range nm.peerKeys {
+		pinned = append(pinned, peerID)
+	}
+	nm.keysMu.Unlock()
+	for _, peerID := range pinned {
+		nm.forgetPeerKey(peerID)
+	}
+
+	// Stop answering discovery requests
+	nm.stopDiscoveryServer()
+
+	// Stop advertising over mDNS
+	if nm.mdnsStop != nil {
+		nm.mdnsStop()
+		nm.mdnsStop = nil
 	}
 
 	// Stop HTTP server
@@ -320,14 +545,49 @@ func (nm *NetworkManager) Stop(ctx context.Context) error {
 		if err := nm.server.Shutdown(ctx); err != nil {
 			nm.logger.Warn("Failed to shutdown HTTP server", core.Field{Key: "error", Value: err})
 		}
+		nm.server = nil
 	}
 
 	nm.started = false
-	nm.logger.Info("Network manager stopped")
+	workers := nm.workers
+	nm.workers = nil
+	nm.mu.Unlock()
 
+	// Workers such as keep-alive take nm.mu, so they are waited for
+	// without it
+	if workers != nil {
+		if err := workers.Stop(ctx); err != nil {
+			return fmt.Errorf("failed to stop network workers: %w", err)
+		}
+	}
+
+	nm.logger.Info("Network manager stopped")
 	return nil
 }
 
+// UseWorkerPool runs the manager's background goroutines within pool, so
+// its owner's shutdown waits for them as well as Stop
+func (nm *NetworkManager) UseWorkerPool(pool *core.WorkerPool) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	nm.ownerPool = pool
+}
+
+// workerPoolLocked returns the pool background goroutines run in, creating
+// it if needed: within the owner's pool if there is one, otherwise bound to
+// ctx. The caller holds nm.mu.
+func (nm *NetworkManager) workerPoolLocked(ctx context.Context) *core.WorkerPool {
+	if nm.workers == nil {
+		if nm.ownerPool != nil {
+			nm.workers = nm.ownerPool.Child()
+		} else {
+			nm.workers = core.NewWorkerPool(ctx)
+		}
+	}
+	return nm.workers
+}
+
 // Private methods
 func (nm *NetworkManager) initializeLocalPeer() error {
 	hostname, err := getHostname()
@@ -340,10 +600,10 @@ func (nm *NetworkManager) initializeLocalPeer() error {
 		Name:         hostname,
 		Address:      nm.config.Host,
 		Port:         nm.config.Port,
-		Version:      "1.0.0",
-		Capabilities: []string{"file-sharing", "clipboard", "messaging"},
-		LastSeen:     time.Now(),
-		Metadata: map[string]string{
+		Version:      ProtocolVersion,
+		Capabilities: nm.capabilities(),
+		LastSeen:     time.Now().Unix(),
+		Metadata: map[string]interface{}{
 			"platform": "noplacelike-go",
 			"hostname": hostname,
 		},
@@ -352,27 +612,30 @@ func (nm *NetworkManager) initializeLocalPeer() error {
 	return nil
 }
 
-func (nm *NetworkManager) addPeer(peer *core.Peer) {
+// addPeer adds or refreshes a peer. New peers are rejected with
+// ErrPeerLimitReached once MaxPeers is reached. The caller must hold nm.mu.
+func (nm *NetworkManager) addPeer(peer *core.Peer) error {
 	existing, exists := nm.peers[peer.ID]
+	if !exists && !nm.hasCapacity() {
+		return fmt.Errorf("%w: cannot add peer %s (limit %d)", ErrPeerLimitReached, peer.ID, nm.config.MaxPeers)
+	}
+
 	if exists {
 		// Update existing peer
-		existing.LastSeen = time.Now()
+		existing.LastSeen = time.Now().Unix()
 		existing.Address = peer.Address
 		existing.Port = peer.Port
 	} else {
 		// Add new peer
+		now := time.Now().Unix()
+		peer.LastSeen = now
+		if peer.ConnectedAt == 0 {
+			peer.ConnectedAt = now
+		}
 		nm.peers[peer.ID] = peer
 
 		// Publish peer joined event
-		event := core.Event{
-			ID:        generateID(),
-			Type:      core.EventPeerJoined,
-			Source:    "network",
-			Data:      *peer,
-			Timestamp: time.Now(),
-		}
-
-		if err := nm.eventBus.Publish(context.Background(), "network", event); err != nil {
+		if err := nm.publishPeerEvent(core.EventPeerJoined, peer); err != nil {
 			nm.logger.Warn("Failed to publish peer joined event", core.Field{Key: "error", Value: err})
 		}
 
@@ -381,18 +644,60 @@ func (nm *NetworkManager) addPeer(peer *core.Peer) {
 			core.Field{Key: "address", Value: peer.Address},
 		)
 	}
+
+	return nil
+}
+
+// hasCapacity reports whether another peer may be added. The caller must hold
+// nm.mu.
+func (nm *NetworkManager) hasCapacity() bool {
+	return nm.config.MaxPeers <= 0 || len(nm.peers) < nm.config.MaxPeers
 }
 
-func (nm *NetworkManager) getOrCreateChannel(ctx context.Context, peerID string) (core.SecureChannel, error) {
+// acceptsInbound reports whether an inbound connection from peerID may be
+// accepted: known peers always may, new ones only while below MaxPeers
+func (nm *NetworkManager) acceptsInbound(peerID string) bool {
 	nm.mu.RLock()
-	channel, exists := nm.channels[peerID]
-	nm.mu.RUnlock()
+	defer nm.mu.RUnlock()
 
-	if exists {
+	if _, known := nm.peers[peerID]; known && peerID != "" {
+		return true
+	}
+	return nm.hasCapacity()
+}
+
+// getOrCreateChannel returns the channel for a peer, dialing it if needed.
+// Concurrent callers for the same peer wait on a single dial.
+func (nm *NetworkManager) getOrCreateChannel(ctx context.Context, peerID string) (SecureChannel, error) {
+	nm.mu.Lock()
+	if channel, exists := nm.channels[peerID]; exists {
+		nm.mu.Unlock()
 		return channel, nil
 	}
+	if dial, inFlight := nm.dialing[peerID]; inFlight {
+		nm.mu.Unlock()
+		select {
+		case <-dial.done:
+			return dial.channel, dial.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	dial := &channelDial{done: make(chan struct{})}
+	nm.dialing[peerID] = dial
+	nm.mu.Unlock()
 
-	return nm.CreateSecureChannel(ctx, peerID)
+	dial.channel, dial.err = nm.dialChannel(ctx, peerID)
+
+	nm.mu.Lock()
+	delete(nm.dialing, peerID)
+	if dial.err == nil {
+		nm.channels[peerID] = dial.channel
+	}
+	nm.mu.Unlock()
+	close(dial.done)
+
+	return dial.channel, dial.err
 }
 
 func (nm *NetworkManager) startHTTPServer(ctx context.Context) error {
@@ -413,90 +718,201 @@ func (nm *NetworkManager) startHTTPServer(ctx context.Context) error {
 		Handler: mux,
 	}
 
-	go func() {
+	server := nm.server
+	return nm.workerPoolLocked(ctx).Go("network-http", func(context.Context) error {
 		var err error
 		if nm.config.EnableTLS {
-			err = nm.server.ListenAndServeTLS(nm.config.TLSCertFile, nm.config.TLSKeyFile)
+			err = server.ListenAndServeTLS(nm.config.TLSCertFile, nm.config.TLSKeyFile)
 		} else {
-			err = nm.server.ListenAndServe()
+			err = server.ListenAndServe()
 		}
 
 		if err != nil && err != http.ErrServerClosed {
 			nm.logger.Error("HTTP server error", core.Field{Key: "error", Value: err})
 		}
-	}()
+		return nil
+	})
+}
 
-	return nil
+// discoveryBindAddress returns the host the discovery socket binds to
+func discoveryBindAddress(config NetworkConfig) string {
+	if config.DiscoveryBindAddress != "" {
+		return config.DiscoveryBindAddress
+	}
+	return config.Host
 }
 
+// startDiscoveryServer listens for discovery requests until the manager is
+// stopped or ctx is cancelled. It does nothing if the server is running.
 func (nm *NetworkManager) startDiscoveryServer(ctx context.Context) error {
-	if nm.discoveryServer == nil {
+	server := nm.discoveryServer
+	if server == nil {
 		return nil
 	}
 
-	// Start UDP discovery server
-	go func() {
-		addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", nm.discoveryServer.port))
-		if err != nil {
-			nm.logger.Error("Failed to resolve UDP address", core.Field{Key: "error", Value: err})
-			return
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if server.conn != nil {
+		return nil
+	}
+
+	listenAddr := net.JoinHostPort(server.bindAddr, strconv.Itoa(server.port))
+	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to resolve UDP address: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on UDP: %w", err)
+	}
+	server.conn = conn
+	server.done = make(chan struct{})
+
+	nm.logger.Info("Discovery server started", core.Field{Key: "address", Value: conn.LocalAddr().String()})
+
+	done := server.done
+	err = nm.workerPoolLocked(ctx).Go("network-discovery", func(workerCtx context.Context) error {
+		// Serve until the caller's context ends or the workers stop
+		serveCtx, cancel := context.WithCancel(workerCtx)
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+
+		nm.serveDiscovery(serveCtx, server, conn, done)
+		return nil
+	})
+	if err != nil {
+		server.conn = nil
+		conn.Close()
+		return fmt.Errorf("failed to start discovery server: %w", err)
+	}
+	return nil
+}
+
+// serveDiscovery reads discovery requests from conn until it is closed
+func (nm *NetworkManager) serveDiscovery(ctx context.Context, server *DiscoveryServer, conn *net.UDPConn, done chan struct{}) {
+	defer close(done)
+
+	// Closing the socket is what unblocks the read below
+	stop := context.AfterFunc(ctx, func() {
+		server.mu.Lock()
+		if server.conn == conn {
+			server.conn = nil
 		}
+		server.mu.Unlock()
+		conn.Close()
+	})
+	defer stop()
 
-		conn, err := net.ListenUDP("udp", addr)
+	buffer := make([]byte, 1024)
+	for {
+		n, addr, err := conn.ReadFromUDP(buffer)
 		if err != nil {
-			nm.logger.Error("Failed to listen on UDP", core.Field{Key: "error", Value: err})
-			return
+			if errors.Is(err, net.ErrClosed) {
+				nm.logger.Info("Discovery server stopped")
+				return
+			}
+			continue
 		}
-		defer conn.Close()
 
-		nm.logger.Info("Discovery server started", core.Field{Key: "port", Value: nm.discoveryServer.port})
+		// Handle discovery request
+		data := append([]byte(nil), buffer[:n]...)
+		go nm.handleDiscoveryRequest(conn, addr, data)
+	}
+}
 
-		buffer := make([]byte, 1024)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				n, addr, err := conn.ReadFromUDP(buffer)
-				if err != nil {
-					continue
-				}
+// stopDiscoveryServer closes the discovery socket and waits for its read
+// loop to exit
+func (nm *NetworkManager) stopDiscoveryServer() {
+	server := nm.discoveryServer
+	if server == nil {
+		return
+	}
 
-				// Handle discovery request
-				go nm.handleDiscoveryRequest(conn, addr, buffer[:n])
-			}
-		}
-	}()
+	server.mu.Lock()
+	conn, done := server.conn, server.done
+	server.conn = nil
+	server.mu.Unlock()
 
-	return nil
+	if conn == nil {
+		return
+	}
+	conn.Close()
+	<-done
 }
 
 func (nm *NetworkManager) broadcastDiscovery(ctx context.Context) ([]core.Peer, error) {
-	// Broadcast UDP discovery message
-	conn, err := net.Dial("udp", fmt.Sprintf("255.255.255.255:%d", nm.config.DiscoveryPort))
+	// Listen on an ephemeral port so responses from any peer reach us
+	conn, err := net.ListenUDP("udp", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
 
-	message := map[string]interface{}{
-		"type": "discovery",
-		"peer": nm.localPeer,
+	data, err := json.Marshal(nm.discoveryPayload("discovery"))
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := json.Marshal(message)
-	if err != nil {
+	broadcastAddr := &net.UDPAddr{IP: net.IPv4bcast, Port: nm.config.DiscoveryPort}
+	if _, err := conn.WriteToUDP(data, broadcastAddr); err != nil {
 		return nil, err
 	}
 
-	_, err = conn.Write(data)
-	if err != nil {
+	if err := conn.SetReadDeadline(time.Now().Add(nm.discoveryTimeout())); err != nil {
 		return nil, err
 	}
 
-	// Wait for responses (simplified implementation)
-	time.Sleep(time.Second * 2)
+	// Unblock the read loop as soon as the context is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
 
+	// Collect responses until the timeout, cancellation or enough peers
+	buffer := make([]byte, 4096)
+	for {
+		if target := nm.config.DiscoveryTargetPeers; target > 0 && nm.discoveredCount() >= target {
+			break
+		}
+
+		n, addr, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+
+		var response discoveryMessage
+		if err := json.Unmarshal(buffer[:n], &response); err != nil {
+			continue
+		}
+		if response.Type != "discovery_response" {
+			continue
+		}
+
+		nm.recordDiscoveredPeer(response, addr)
+	}
+
+	return nm.discoveredPeers(), ctx.Err()
+}
+
+// discoveryTimeout returns how long a discovery round waits for answers
+func (nm *NetworkManager) discoveryTimeout() time.Duration {
+	if nm.config.DiscoveryTimeout > 0 {
+		return nm.config.DiscoveryTimeout
+	}
+	return DefaultDiscoveryTimeout
+}
+
+// discoveredPeers returns the peers found by discovery so far
+func (nm *NetworkManager) discoveredPeers() []core.Peer {
+	if nm.discoveryServer == nil {
+		return nil
+	}
 	nm.discoveryServer.mu.RLock()
 	defer nm.discoveryServer.mu.RUnlock()
 
@@ -504,8 +920,15 @@ func (nm *NetworkManager) broadcastDiscovery(ctx context.Context) ([]core.Peer,
 	for _, peer := range nm.discoveryServer.peers {
 		peers = append(peers, *peer)
 	}
+	return peers
+}
 
-	return peers, nil
+// discoveredCount returns the number of peers found by discovery so far
+func (nm *NetworkManager) discoveredCount() int {
+	nm.discoveryServer.mu.RLock()
+	defer nm.discoveryServer.mu.RUnlock()
+
+	return len(nm.discoveryServer.peers)
 }
 
 func (nm *NetworkManager) keepAliveRoutine(ctx context.Context) {
@@ -523,21 +946,38 @@ func (nm *NetworkManager) keepAliveRoutine(ctx context.Context) {
 }
 
 func (nm *NetworkManager) performKeepAlive(ctx context.Context) {
-	nm.mu.RLock()
-	peers := make([]*core.Peer, 0, len(nm.peers))
-	for _, peer := range nm.peers {
-		peers = append(peers, peer)
+	// Peers still answering mDNS queries count as seen
+	if nm.usesMDNS() {
+		if found, err := nm.browseMDNS(ctx); err == nil {
+			nm.mu.Lock()
+			for _, peer := range nm.discoveredPeers() {
+				for _, id := range found {
+					if peer.ID == id {
+						peer := peer
+						nm.addPeer(&peer)
+					}
+				}
+			}
+			nm.mu.Unlock()
+		}
 	}
-	nm.mu.RUnlock()
 
 	// Remove stale peers
-	staleThreshold := time.Now().Add(-nm.config.KeepAliveInterval * 3)
+	staleThreshold := time.Now().Add(-nm.config.KeepAliveInterval * 3).Unix()
 
-	for _, peer := range peers {
-		if peer.LastSeen.Before(staleThreshold) {
-			nm.removePeer(peer.ID)
+	nm.mu.RLock()
+	var stale []string
+	for id, peer := range nm.peers {
+		if peer.LastSeen < staleThreshold {
+			stale = append(stale, id)
 		}
 	}
+	nm.mu.RUnlock()
+
+	for _, id := range stale {
+		nm.removePeer(id)
+		nm.forgetDiscoveredPeer(id)
+	}
 }
 
 func (nm *NetworkManager) removePeer(peerID string) {
@@ -554,50 +994,157 @@ func (nm *NetworkManager) removePeer(peerID string) {
 		channel.Close()
 		delete(nm.channels, peerID)
 	}
+	nm.forgetPeerKey(peerID)
 
 	delete(nm.peers, peerID)
 
 	// Publish peer left event
-	event := core.Event{
-		ID:        generateID(),
-		Type:      core.EventPeerLeft,
-		Source:    "network",
-		Data:      *peer,
-		Timestamp: time.Now(),
-	}
-
-	if err := nm.eventBus.Publish(context.Background(), "network", event); err != nil {
+	if err := nm.publishPeerEvent(core.EventPeerLeft, peer); err != nil {
 		nm.logger.Warn("Failed to publish peer left event", core.Field{Key: "error", Value: err})
 	}
 
 	nm.logger.Info("Peer removed", core.Field{Key: "peerID", Value: peerID})
 }
 
+// publishPeerEvent publishes a peer joined or left event for peer
+func (nm *NetworkManager) publishPeerEvent(eventType string, peer *core.Peer) error {
+	if nm.eventBus == nil {
+		return nil
+	}
+	return nm.eventBus.Publish(core.Event{
+		ID:     generateID(),
+		Type:   eventType,
+		Source: "network",
+		Data: map[string]interface{}{
+			"id":      peer.ID,
+			"name":    peer.Name,
+			"address": peer.Address,
+			"port":    peer.Port,
+		},
+		Timestamp: time.Now().Unix(),
+	})
+}
+
 // HTTP handlers
 func (nm *NetworkManager) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	peerID := r.Header.Get(peerIDHeader)
+	if !nm.acceptsInbound(peerID) {
+		nm.logger.Warn("Inbound peer rejected",
+			core.Field{Key: "peerID", Value: peerID},
+			core.Field{Key: "reason", Value: ErrPeerLimitReached.Error()},
+		)
+		http.Error(w, fmt.Sprintf("%s (limit %d)", ErrPeerLimitReached, nm.config.MaxPeers), http.StatusServiceUnavailable)
+		return
+	}
+
+	// X-Peer-ID is only the client's claim, so without a token proving it
+	// the handshake can't replace the key agreed with that peer
+	if err := nm.acceptPeerKey(peerID, r.Header, nm.authenticatedPeer(r, peerID)); err != nil {
+		nm.logger.Warn("Peer key rejected",
+			core.Field{Key: "peerID", Value: peerID},
+			core.Field{Key: "error", Value: err},
+		)
+		if errors.Is(err, core.ErrUnauthorized) {
+			http.Error(w, "peer key not accepted", http.StatusUnauthorized)
+		} else {
+			http.Error(w, "invalid peer key", http.StatusBadRequest)
+		}
+		return
+	}
+	responseHeader := http.Header{}
+	nm.offerPeerKey(responseHeader)
+
+	// Peers connect without an Origin header; browsers only from this host
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: core.WebSocketOriginChecker(nil, true),
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		nm.logger.Error("Failed to upgrade WebSocket", core.Field{Key: "error", Value: err})
 		return
 	}
 	defer conn.Close()
 
+	// A frame over the limit fails the read and closes the connection
+	conn.SetReadLimit(nm.maxMessageSize())
+
 	// Handle WebSocket messages
 	for {
-		var message core.Message
-		if err := conn.ReadJSON(&message); err != nil {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				nm.logger.Warn("Peer message too large, closing connection",
+					core.Field{Key: "peerID", Value: peerID},
+					core.Field{Key: "limit", Value: nm.maxMessageSize()},
+				)
+			}
 			break
 		}
 
+		// Messages are encrypted as SecureChannelImpl.Send encrypted them
+		if nm.security != nil {
+			if data, err = nm.security.Decrypt(data, peerID); err != nil {
+				nm.logger.Warn("Undecryptable peer message dropped",
+					core.Field{Key: "peerID", Value: peerID},
+					core.Field{Key: "error", Value: err},
+				)
+				continue
+			}
+		}
+
+		var message core.Message
+		if err := json.Unmarshal(data, &message); err != nil {
+			nm.logger.Warn("Malformed peer message dropped",
+				core.Field{Key: "peerID", Value: peerID},
+				core.Field{Key: "error", Value: err},
+			)
+			continue
+		}
+
+		if err := nm.validateMessage(&message, peerID); err != nil {
+			nm.logger.Warn("Invalid peer message dropped",
+				core.Field{Key: "peerID", Value: peerID},
+				core.Field{Key: "error", Value: err},
+			)
+			continue
+		}
+
 		// Process message
 		go nm.processMessage(r.Context(), message)
 	}
 }
 
+func (nm *NetworkManager) maxMessageSize() int64 {
+	if nm.config.MaxMessageSize > 0 {
+		return nm.config.MaxMessageSize
+	}
+	return DefaultMaxMessageSize
+}
+
+// validateMessage checks a message received from peerID before it is
+// dispatched. A message must have a type and come from a known peer, the
+// one that sent it; a missing From is taken to be the sender.
+func (nm *NetworkManager) validateMessage(message *core.Message, peerID string) error {
+	if message.Type == "" {
+		return errors.New("message has no type")
+	}
+	if message.From == "" {
+		message.From = peerID
+	}
+	if message.From != peerID {
+		return fmt.Errorf("message from %q sent by peer %q", message.From, peerID)
+	}
+
+	nm.mu.RLock()
+	_, known := nm.peers[message.From]
+	nm.mu.RUnlock()
+	if !known {
+		return fmt.Errorf("message from unknown peer %q", message.From)
+	}
+	return nil
+}
+
 func (nm *NetworkManager) handleDiscovery(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(nm.localPeer)
@@ -612,25 +1159,97 @@ func (nm *NetworkManager) handlePeerInfo(w http.ResponseWriter, r *http.Request)
 }
 
 func (nm *NetworkManager) handleDiscoveryRequest(conn *net.UDPConn, addr *net.UDPAddr, data []byte) {
-	var request map[string]interface{}
+	var request discoveryMessage
 	if err := json.Unmarshal(data, &request); err != nil {
 		return
 	}
 
-	if request["type"] == "discovery" {
-		// Respond with our peer info
-		response := map[string]interface{}{
-			"type": "discovery_response",
-			"peer": nm.localPeer,
-		}
+	if request.Type != "discovery" || request.Peer.ID == nm.localPeer.ID {
+		return
+	}
 
-		responseData, err := json.Marshal(response)
-		if err != nil {
-			return
+	// Ignore peers we can't talk to
+	if !nm.recordDiscoveredPeer(request, addr) {
+		return
+	}
+
+	// Respond with our peer info
+	responseData, err := json.Marshal(nm.discoveryPayload("discovery_response"))
+	if err != nil {
+		return
+	}
+
+	conn.WriteToUDP(responseData, addr)
+}
+
+// discoveryPayload builds a discovery message describing the local peer
+func (nm *NetworkManager) discoveryPayload(messageType string) discoveryMessage {
+	return discoveryMessage{
+		Type:            messageType,
+		Peer:            *nm.localPeer,
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    nm.capabilities(),
+	}
+}
+
+// recordDiscoveredPeer stores the peer described by a discovery message if it
+// is compatible, reporting whether it was accepted
+func (nm *NetworkManager) recordDiscoveredPeer(message discoveryMessage, from *net.UDPAddr) bool {
+	peer := message.Peer
+	if peer.ID == "" || peer.ID == nm.localPeer.ID || nm.discoveryServer == nil {
+		return false
+	}
+
+	// Peers listening on all interfaces advertise an unspecified address;
+	// reach them at the address the datagram came from
+	if ip := net.ParseIP(peer.Address); from != nil && (ip == nil || ip.IsUnspecified()) {
+		peer.Address = from.IP.String()
+	}
+
+	if err := nm.checkCompatibility(message); err != nil {
+		nm.logger.Debug("Ignoring incompatible peer",
+			core.Field{Key: "peerID", Value: peer.ID},
+			core.Field{Key: "reason", Value: err.Error()},
+		)
+		return false
+	}
+
+	nm.discoveryServer.mu.Lock()
+	nm.discoveryServer.peers[peer.ID] = &peer
+	nm.discoveryServer.mu.Unlock()
+
+	return true
+}
+
+// checkCompatibility rejects peers with a different major protocol version or
+// missing a required capability
+func (nm *NetworkManager) checkCompatibility(message discoveryMessage) error {
+	if message.ProtocolVersion == "" {
+		return fmt.Errorf("protocol version not advertised")
+	}
+	if majorVersion(message.ProtocolVersion) != majorVersion(ProtocolVersion) {
+		return fmt.Errorf("protocol version %s is incompatible with %s", message.ProtocolVersion, ProtocolVersion)
+	}
+
+	advertised := make(map[string]bool, len(message.Capabilities))
+	for _, capability := range message.Capabilities {
+		advertised[capability] = true
+	}
+	for _, required := range nm.config.RequiredCapabilities {
+		if !advertised[required] {
+			return fmt.Errorf("missing required capability %q", required)
 		}
+	}
 
-		conn.WriteToUDP(responseData, addr)
+	return nil
+}
+
+// capabilities returns the capabilities advertised by the local peer
+func (nm *NetworkManager) capabilities() []string {
+	if len(nm.config.Capabilities) > 0 {
+		return nm.config.Capabilities
 	}
+	return DefaultCapabilities
 }
 
 func (nm *NetworkManager) processMessage(ctx context.Context, message core.Message) {
@@ -694,11 +1313,17 @@ func (c *SecureChannelImpl) Close() error {
 
 // Helper functions
 func generatePeerID() string {
-	return fmt.Sprintf("peer-%d", time.Now().UnixNano())
+	return "peer-" + core.NewID()
 }
 
 func generateID() string {
-	return fmt.Sprintf("id-%d", time.Now().UnixNano())
+	return core.NewID()
+}
+
+// majorVersion returns the major component of a semantic version string
+func majorVersion(version string) string {
+	version = strings.TrimPrefix(version, "v")
+	return strings.SplitN(version, ".", 2)[0]
 }
 
 func getHostname() (string, error) {
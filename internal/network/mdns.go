@@ -0,0 +1,405 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Discovery methods selectable with NetworkConfig.DiscoveryMethod
+const (
+	DiscoveryMethodBroadcast = "broadcast"
+	DiscoveryMethodMDNS      = "mdns"
+	DiscoveryMethodBoth      = "both"
+)
+
+// MDNSServiceType is the DNS-SD service type peers advertise and browse for
+const MDNSServiceType = "_noplacelike._tcp"
+
+const (
+	mdnsDomain = "local."
+	mdnsTTL    = 120
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// mdnsService is one advertised instance of a DNS-SD service
+type mdnsService struct {
+	Instance string
+	Host     string
+	Port     int
+	IPs      []net.IP
+	TXT      map[string]string
+	// Source is the address the advertisement was received from
+	Source net.IP
+}
+
+// mdnsResponder advertises and browses DNS-SD services. multicastResponder
+// is used by default; tests can substitute one that doesn't need multicast.
+type mdnsResponder interface {
+	// Advertise answers queries for service in the background until ctx is
+	// cancelled
+	Advertise(ctx context.Context, serviceType string, service mdnsService) error
+	// Browse queries for instances of serviceType and collects the answers
+	// received before timeout
+	Browse(ctx context.Context, serviceType string, timeout time.Duration) ([]mdnsService, error)
+}
+
+// multicastResponder implements mdnsResponder over IPv4 multicast DNS
+type multicastResponder struct{}
+
+func (multicastResponder) Advertise(ctx context.Context, serviceType string, service mdnsService) error {
+	response, err := buildMDNSResponse(serviceType, service)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return fmt.Errorf("failed to join mDNS group: %w", err)
+	}
+	context.AfterFunc(ctx, func() { conn.Close() })
+
+	serviceName := serviceType + "." + mdnsDomain
+	go func() {
+		defer conn.Close()
+		buffer := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if !isMDNSQueryFor(buffer[:n], serviceName) {
+				continue
+			}
+			// Queries from a port other than 5353 come from simple
+			// resolvers that expect a unicast reply
+			dest := mdnsGroup
+			if from.Port != mdnsGroup.Port {
+				dest = from
+			}
+			conn.WriteToUDP(response, dest)
+		}
+	}()
+
+	return nil
+}
+
+func (multicastResponder) Browse(ctx context.Context, serviceType string, timeout time.Duration) ([]mdnsService, error) {
+	serviceName := serviceType + "." + mdnsDomain
+	query, err := buildMDNSQuery(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(query, mdnsGroup); err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	collector := newMDNSCollector(serviceName)
+	buffer := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		collector.add(buffer[:n], from.IP)
+	}
+
+	return collector.services(), ctx.Err()
+}
+
+// buildMDNSQuery builds a PTR query for serviceName
+func buildMDNSQuery(serviceName string) ([]byte, error) {
+	name, err := dnsmessage.NewName(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}
+	return msg.Pack()
+}
+
+// buildMDNSResponse builds the PTR answer for service with its SRV, TXT and
+// A records as additionals
+func buildMDNSResponse(serviceType string, service mdnsService) ([]byte, error) {
+	serviceName, err := dnsmessage.NewName(serviceType + "." + mdnsDomain)
+	if err != nil {
+		return nil, err
+	}
+	instanceName, err := dnsmessage.NewName(service.Instance + "." + serviceType + "." + mdnsDomain)
+	if err != nil {
+		return nil, err
+	}
+	hostName, err := dnsmessage.NewName(service.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	header := func(name dnsmessage.Name) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: mdnsTTL}
+	}
+
+	txt := make([]string, 0, len(service.TXT))
+	for key, value := range service.TXT {
+		txt = append(txt, key+"="+value)
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{
+			{Header: header(serviceName), Body: &dnsmessage.PTRResource{PTR: instanceName}},
+		},
+		Additionals: []dnsmessage.Resource{
+			{Header: header(instanceName), Body: &dnsmessage.SRVResource{Target: hostName, Port: uint16(service.Port)}},
+			{Header: header(instanceName), Body: &dnsmessage.TXTResource{TXT: txt}},
+		},
+	}
+	for _, ip := range service.IPs {
+		if ip4 := ip.To4(); ip4 != nil {
+			var a [4]byte
+			copy(a[:], ip4)
+			msg.Additionals = append(msg.Additionals, dnsmessage.Resource{
+				Header: header(hostName),
+				Body:   &dnsmessage.AResource{A: a},
+			})
+		}
+	}
+
+	return msg.Pack()
+}
+
+// isMDNSQueryFor reports whether packet is a query asking for serviceName
+func isMDNSQueryFor(packet []byte, serviceName string) bool {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(packet)
+	if err != nil || header.Response {
+		return false
+	}
+	questions, err := parser.AllQuestions()
+	if err != nil {
+		return false
+	}
+	for _, q := range questions {
+		if (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) && strings.EqualFold(q.Name.String(), serviceName) {
+			return true
+		}
+	}
+	return false
+}
+
+// mdnsCollector assembles services from the records of mDNS responses
+type mdnsCollector struct {
+	serviceName string
+	instances   map[string]*mdnsService
+	hosts       map[string][]net.IP
+}
+
+func newMDNSCollector(serviceName string) *mdnsCollector {
+	return &mdnsCollector{
+		serviceName: serviceName,
+		instances:   make(map[string]*mdnsService),
+		hosts:       make(map[string][]net.IP),
+	}
+}
+
+func (c *mdnsCollector) instance(name string, source net.IP) *mdnsService {
+	key := strings.ToLower(name)
+	service, ok := c.instances[key]
+	if !ok {
+		instance := strings.TrimSuffix(name, "."+c.serviceName)
+		service = &mdnsService{Instance: instance, TXT: map[string]string{}}
+		c.instances[key] = service
+	}
+	if service.Source == nil {
+		service.Source = source
+	}
+	return service
+}
+
+// add records the resources of one response packet
+func (c *mdnsCollector) add(packet []byte, source net.IP) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(packet)
+	if err != nil || !header.Response {
+		return
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	var resources []dnsmessage.Resource
+	answers, err := parser.AllAnswers()
+	if err != nil {
+		return
+	}
+	resources = append(resources, answers...)
+	if err := parser.SkipAllAuthorities(); err == nil {
+		if additionals, err := parser.AllAdditionals(); err == nil {
+			resources = append(resources, additionals...)
+		}
+	}
+
+	for _, r := range resources {
+		name := r.Header.Name.String()
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if strings.EqualFold(name, c.serviceName) {
+				c.instance(body.PTR.String(), source)
+			}
+		case *dnsmessage.SRVResource:
+			if strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(c.serviceName)) {
+				service := c.instance(name, source)
+				service.Host = body.Target.String()
+				service.Port = int(body.Port)
+			}
+		case *dnsmessage.TXTResource:
+			if strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(c.serviceName)) {
+				service := c.instance(name, source)
+				for _, entry := range body.TXT {
+					if key, value, ok := strings.Cut(entry, "="); ok {
+						service.TXT[key] = value
+					}
+				}
+			}
+		case *dnsmessage.AResource:
+			c.hosts[strings.ToLower(name)] = append(c.hosts[strings.ToLower(name)], net.IP(body.A[:]))
+		}
+	}
+}
+
+// services returns the complete instances collected so far
+func (c *mdnsCollector) services() []mdnsService {
+	services := make([]mdnsService, 0, len(c.instances))
+	for _, service := range c.instances {
+		if service.Port == 0 {
+			continue
+		}
+		service.IPs = c.hosts[strings.ToLower(service.Host)]
+		services = append(services, *service)
+	}
+	return services
+}
+
+// usesBroadcast reports whether UDP broadcast discovery is enabled
+func (nm *NetworkManager) usesBroadcast() bool {
+	method := nm.config.DiscoveryMethod
+	return method == "" || method == DiscoveryMethodBroadcast || method == DiscoveryMethodBoth
+}
+
+// usesMDNS reports whether mDNS discovery is enabled
+func (nm *NetworkManager) usesMDNS() bool {
+	method := nm.config.DiscoveryMethod
+	return method == DiscoveryMethodMDNS || method == DiscoveryMethodBoth
+}
+
+// startMDNSAdvertisement advertises the local peer until the network manager
+// stops. It is a no-op if the advertisement is already running.
+func (nm *NetworkManager) startMDNSAdvertisement(ctx context.Context) error {
+	if nm.mdnsStop != nil {
+		return nil
+	}
+
+	advertiseCtx, cancel := context.WithCancel(ctx)
+	if err := nm.mdns.Advertise(advertiseCtx, MDNSServiceType, nm.mdnsServiceInfo()); err != nil {
+		cancel()
+		return err
+	}
+	nm.mdnsStop = cancel
+	return nil
+}
+
+// mdnsServiceInfo describes the local peer as a DNS-SD service
+func (nm *NetworkManager) mdnsServiceInfo() mdnsService {
+	var ips []net.IP
+	if ip := net.ParseIP(nm.config.Host); ip != nil && !ip.IsUnspecified() {
+		ips = []net.IP{ip}
+	} else if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+	}
+
+	return mdnsService{
+		Instance: nm.localPeer.ID,
+		Host:     nm.localPeer.ID + "." + mdnsDomain,
+		Port:     nm.config.Port,
+		IPs:      ips,
+		TXT: map[string]string{
+			"id":   nm.localPeer.ID,
+			"name": nm.localPeer.Name,
+			"v":    ProtocolVersion,
+			"caps": strings.Join(nm.capabilities(), ","),
+		},
+	}
+}
+
+// browseMDNS looks for peers advertising MDNSServiceType and records the
+// compatible ones, returning their IDs
+func (nm *NetworkManager) browseMDNS(ctx context.Context) ([]string, error) {
+	services, err := nm.mdns.Browse(ctx, MDNSServiceType, nm.discoveryTimeout())
+
+	found := make([]string, 0, len(services))
+	for _, service := range services {
+		peer := core.Peer{
+			ID:   service.TXT["id"],
+			Name: service.TXT["name"],
+			Port: service.Port,
+		}
+		if len(service.IPs) > 0 {
+			peer.Address = service.IPs[0].String()
+		}
+
+		var capabilities []string
+		if caps := service.TXT["caps"]; caps != "" {
+			capabilities = strings.Split(caps, ",")
+		}
+
+		message := discoveryMessage{
+			Type:            "mdns",
+			Peer:            peer,
+			ProtocolVersion: service.TXT["v"],
+			Capabilities:    capabilities,
+		}
+		if nm.recordDiscoveredPeer(message, &net.UDPAddr{IP: service.Source}) {
+			found = append(found, peer.ID)
+		}
+	}
+
+	return found, err
+}
+
+// forgetDiscoveredPeer drops a peer from the discovery cache so it is only
+// added again once it is rediscovered
+func (nm *NetworkManager) forgetDiscoveredPeer(peerID string) {
+	if nm.discoveryServer == nil {
+		return
+	}
+	nm.discoveryServer.mu.Lock()
+	delete(nm.discoveryServer.peers, peerID)
+	nm.discoveryServer.mu.Unlock()
+}
@@ -0,0 +1,157 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// fakeResponder answers mDNS browses with fixed services. When release is
+// set, Browse closes browsing and waits for release before answering.
+type fakeResponder struct {
+	services []mdnsService
+	browsing chan struct{}
+	release  chan struct{}
+}
+
+func (f *fakeResponder) Advertise(context.Context, string, mdnsService) error { return nil }
+
+func (f *fakeResponder) Browse(ctx context.Context, _ string, _ time.Duration) ([]mdnsService, error) {
+	if f.release != nil {
+		close(f.browsing)
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return f.services, nil
+}
+
+// newMDNSManager creates a manager discovering peers through responder
+func newMDNSManager(t *testing.T, responder mdnsResponder) *NetworkManager {
+	t.Helper()
+	nm, err := NewNetworkManager(NetworkConfig{
+		EnableDiscovery: true,
+		DiscoveryMethod: DiscoveryMethodMDNS,
+	}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+	nm.mdns = responder
+	t.Cleanup(func() { nm.Stop(context.Background()) })
+	return nm
+}
+
+// mdnsPeer describes a compatible peer as it is advertised over mDNS
+func mdnsPeer(id string, ips ...net.IP) mdnsService {
+	return mdnsService{
+		Instance: id,
+		Host:     id + "." + mdnsDomain,
+		Port:     7000,
+		IPs:      ips,
+		TXT: map[string]string{
+			"id":   id,
+			"name": id,
+			"v":    ProtocolVersion,
+			"caps": strings.Join(DefaultCapabilities, ","),
+		},
+		Source: net.IPv4(192, 168, 1, 9),
+	}
+}
+
+func TestMDNSDiscovery(t *testing.T) {
+	incompatible := mdnsPeer("peer-new")
+	incompatible.TXT["v"] = "2.0.0"
+	anonymous := mdnsPeer("peer-anon")
+	delete(anonymous.TXT, "id")
+
+	tests := []struct {
+		name     string
+		service  mdnsService
+		wantAddr string
+	}{
+		{"advertised address", mdnsPeer("peer-a", net.IPv4(10, 0, 0, 5)), "10.0.0.5"},
+		{"sender address", mdnsPeer("peer-b"), "192.168.1.9"},
+		{"incompatible version", incompatible, ""},
+		{"no peer ID", anonymous, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nm := newMDNSManager(t, &fakeResponder{services: []mdnsService{tt.service}})
+			peers, err := nm.DiscoverPeers(context.Background())
+			if err != nil {
+				t.Fatalf("DiscoverPeers: %v", err)
+			}
+			if tt.wantAddr == "" {
+				if len(peers) != 0 {
+					t.Errorf("discovered %+v, want no peers", peers)
+				}
+				return
+			}
+			if len(peers) != 1 || peers[0].ID != tt.service.TXT["id"] || peers[0].Address != tt.wantAddr || peers[0].Port != 7000 {
+				t.Errorf("discovered %+v, want %s at %s:7000", peers, tt.service.TXT["id"], tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestMDNSBrowseDoesNotBlockPeers(t *testing.T) {
+	responder := &fakeResponder{
+		services: []mdnsService{mdnsPeer("peer-a", net.IPv4(10, 0, 0, 5))},
+		browsing: make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	nm := newMDNSManager(t, responder)
+	// Stopping the manager waits for a browse that is still blocked
+	var releaseOnce sync.Once
+	release := func() { releaseOnce.Do(func() { close(responder.release) }) }
+	t.Cleanup(release)
+
+	type result struct {
+		peers []core.Peer
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		peers, err := nm.DiscoverPeers(context.Background())
+		results <- result{peers, err}
+	}()
+	select {
+	case <-responder.browsing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("mDNS browse never started")
+	}
+
+	// The peer table stays usable while the browse waits for answers
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		nm.RegisterPeer(core.Peer{ID: "peer-manual", Address: "10.0.0.6", Port: 7000})
+		nm.GetPeers()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("peer table blocked by the mDNS browse")
+	}
+
+	release()
+	res := <-results
+	if res.err != nil {
+		t.Fatalf("DiscoverPeers: %v", res.err)
+	}
+	// Browse results are merged with the peers added meanwhile
+	ids := make(map[string]bool)
+	for _, peer := range res.peers {
+		ids[peer.ID] = true
+	}
+	if len(ids) != 2 || !ids["peer-a"] || !ids["peer-manual"] {
+		t.Errorf("peers after discovery = %+v, want peer-a and peer-manual", res.peers)
+	}
+}
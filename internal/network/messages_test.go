@@ -0,0 +1,175 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// dialPeerSocket connects to listener's WebSocket endpoint as the peer
+// with peerID, without encryption
+func dialPeerSocket(t *testing.T, listener *NetworkManager, peerID string) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(listener.handleWebSocket))
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{peerIDHeader: {peerID}})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestValidateMessage(t *testing.T) {
+	nm := newTestManager(t, nil)
+	if err := nm.RegisterPeer(core.Peer{ID: "peer-1", Address: "127.0.0.1", Port: 8080}); err != nil {
+		t.Fatalf("RegisterPeer: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		message  core.Message
+		sender   string
+		wantFrom string
+		wantErr  bool
+	}{
+		{"from the sender", core.Message{Type: "ping", From: "peer-1"}, "peer-1", "peer-1", false},
+		{"from filled in", core.Message{Type: "ping"}, "peer-1", "peer-1", false},
+		{"no type", core.Message{From: "peer-1"}, "peer-1", "", true},
+		{"spoofed sender", core.Message{Type: "ping", From: "peer-2"}, "peer-1", "", true},
+		{"unknown peer", core.Message{Type: "ping"}, "stranger", "", true},
+		{"no peer ID", core.Message{Type: "ping"}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.message
+			err := nm.validateMessage(&message, tt.sender)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateMessage error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && message.From != tt.wantFrom {
+				t.Errorf("From = %q, want %q", message.From, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestMalformedPeerMessagesDropped(t *testing.T) {
+	listener := newTestManager(t, nil)
+	if err := listener.RegisterPeer(core.Peer{ID: "peer-1", Address: "127.0.0.1", Port: 8080}); err != nil {
+		t.Fatalf("RegisterPeer: %v", err)
+	}
+	pings, others := make(chan core.Message, 10), make(chan core.Message, 10)
+	listener.RegisterMessageHandler("ping", func(ctx context.Context, message core.Message) error {
+		pings <- message
+		return nil
+	})
+	listener.RegisterMessageHandler("other", func(ctx context.Context, message core.Message) error {
+		others <- message
+		return nil
+	})
+	conn := dialPeerSocket(t, listener, "peer-1")
+
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"not JSON", `{"type":`},
+		{"wrong field type", `{"type":5}`},
+		{"no type", `{"from":"peer-1"}`},
+		{"spoofed sender", `{"type":"other","from":"peer-2"}`},
+	}
+	for _, tt := range tests {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.frame)); err != nil {
+			t.Fatalf("%s: write: %v", tt.name, err)
+		}
+		// The connection stays open, so a valid message still gets through
+		if err := conn.WriteJSON(core.Message{Type: "ping", Data: map[string]interface{}{"after": tt.name}}); err != nil {
+			t.Fatalf("%s: connection closed after a malformed message: %v", tt.name, err)
+		}
+		select {
+		case message := <-pings:
+			if message.Data["after"] != tt.name || message.From != "peer-1" {
+				t.Errorf("%s: received %+v", tt.name, message)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: valid message not delivered after a malformed one", tt.name)
+		}
+	}
+	select {
+	case message := <-others:
+		t.Errorf("invalid message dispatched: %+v", message)
+	default:
+	}
+}
+
+func TestOversizedPeerMessageClosesConnection(t *testing.T) {
+	const limit = 256
+	listener, err := NewNetworkManager(NetworkConfig{Host: "127.0.0.1", MaxMessageSize: limit}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+	if err := listener.RegisterPeer(core.Peer{ID: "peer-1", Address: "127.0.0.1", Port: 8080}); err != nil {
+		t.Fatalf("RegisterPeer: %v", err)
+	}
+	received := make(chan core.Message, 1)
+	listener.RegisterMessageHandler("ping", func(ctx context.Context, message core.Message) error {
+		received <- message
+		return nil
+	})
+	conn := dialPeerSocket(t, listener, "peer-1")
+
+	// A message within the limit is delivered
+	if err := conn.WriteJSON(core.Message{Type: "ping"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message within the limit not delivered")
+	}
+
+	big := core.Message{Type: "ping", Data: map[string]interface{}{"blob": strings.Repeat("x", 4*limit)}}
+	if err := conn.WriteJSON(big); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseMessageTooBig {
+		t.Fatalf("read after an oversized message = %v, want close %d", err, websocket.CloseMessageTooBig)
+	}
+	select {
+	case message := <-received:
+		t.Errorf("oversized message dispatched: %d bytes of data", len(message.Data["blob"].(string)))
+	default:
+	}
+}
+
+func TestMaxMessageSizeDefault(t *testing.T) {
+	tests := []struct {
+		configured int64
+		want       int64
+	}{
+		{0, DefaultMaxMessageSize},
+		{-1, DefaultMaxMessageSize},
+		{4096, 4096},
+	}
+	for _, tt := range tests {
+		nm, err := NewNetworkManager(NetworkConfig{MaxMessageSize: tt.configured}, nil, nil, nopLogger{})
+		if err != nil {
+			t.Fatalf("NewNetworkManager: %v", err)
+		}
+		if got := nm.maxMessageSize(); got != tt.want {
+			t.Errorf("MaxMessageSize %d: limit = %d, want %d", tt.configured, got, tt.want)
+		}
+	}
+}
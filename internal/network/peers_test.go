@@ -0,0 +1,88 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestDiscoveredPeersRespectMaxPeers(t *testing.T) {
+	nm, err := NewNetworkManager(NetworkConfig{
+		EnableDiscovery: true,
+		DiscoveryMethod: DiscoveryMethodMDNS,
+		MaxPeers:        2,
+	}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var services []mdnsService
+	for i := 0; i < 3; i++ {
+		services = append(services, mdnsService{
+			Port: 7000 + i,
+			IPs:  []net.IP{net.IPv4(127, 0, 0, 1)},
+			TXT:  map[string]string{"id": fmt.Sprintf("peer-%d", i), "v": ProtocolVersion},
+		})
+	}
+	nm.mdns = &fakeResponder{services: services}
+
+	if _, err := nm.DiscoverPeers(context.Background()); err != nil {
+		t.Fatalf("DiscoverPeers: %v", err)
+	}
+	if peers := nm.GetPeers(); len(peers) != 2 {
+		t.Errorf("%d peers added, want the limit of 2", len(peers))
+	}
+
+	err = nm.RegisterPeer(core.Peer{ID: "manual", Address: "127.0.0.1", Port: 7100})
+	if !errors.Is(err, ErrPeerLimitReached) {
+		t.Errorf("RegisterPeer beyond the limit = %v, want %v", err, ErrPeerLimitReached)
+	}
+}
+
+func TestInboundPeersRespectMaxPeers(t *testing.T) {
+	listener, err := NewNetworkManager(NetworkConfig{Host: "127.0.0.1", MaxPeers: 1}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := listener.RegisterPeer(core.Peer{ID: "known", Address: "127.0.0.1", Port: 7000}); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(listener.handleWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name       string
+		peerID     string
+		wantStatus int
+	}{
+		{"known peer", "known", http.StatusSwitchingProtocols},
+		{"new peer", "stranger", http.StatusServiceUnavailable},
+		{"anonymous", "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.peerID != "" {
+				header.Set(peerIDHeader, tt.peerID)
+			}
+			conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+			if conn != nil {
+				conn.Close()
+			}
+			if resp == nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
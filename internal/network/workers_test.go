@@ -0,0 +1,57 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStopWaitsForWorkers(t *testing.T) {
+	nm, err := NewNetworkManager(NetworkConfig{
+		Host:                 "127.0.0.1",
+		EnableDiscovery:      true,
+		DiscoveryBindAddress: "127.0.0.1",
+		DiscoveryPort:        freeUDPPort(t),
+		KeepAliveInterval:    time.Minute,
+	}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+	// Start's context outlives the manager, so only Stop ends the workers
+	if err := nm.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	nm.mu.Lock()
+	err = nm.startDiscoveryServer(context.Background())
+	workers := nm.workers
+	nm.mu.Unlock()
+	if err != nil {
+		t.Fatalf("startDiscoveryServer: %v", err)
+	}
+
+	running := workers.Running()
+	for _, name := range []string{"network-keepalive", "network-discovery"} {
+		if running[name] != 1 {
+			t.Errorf("Running = %v, want one %s worker", running, name)
+		}
+	}
+
+	if err := nm.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if got := workers.Running(); len(got) != 0 {
+		t.Errorf("workers %v still running after Stop", got)
+	}
+
+	// A restart gets a fresh pool
+	if err := nm.Start(context.Background()); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	t.Cleanup(func() { nm.Stop(context.Background()) })
+	nm.mu.Lock()
+	restarted := nm.workers
+	nm.mu.Unlock()
+	if restarted == nil || restarted == workers || restarted.Running()["network-keepalive"] != 1 {
+		t.Error("keep-alive not running in a new worker pool after a restart")
+	}
+}
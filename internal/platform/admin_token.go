@@ -0,0 +1,50 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// AdminRole is the role of the token written by WriteAdminToken. It should
+// map to a permission set including platform:admin in SecurityConfig.Roles.
+const AdminRole = "admin"
+
+// WriteAdminToken issues a token pair for the local administrator and writes
+// it as JSON to SecurityConfig.AdminTokenFile, readable only by the current
+// user. It returns the path written, or "" when no file is configured.
+func (p *Platform) WriteAdminToken() (string, error) {
+	path := p.Config().Security.AdminTokenFile
+	if path == "" {
+		return "", nil
+	}
+
+	pair, err := p.securityManager.GenerateTokenPair(&core.User{
+		ID:       AdminRole,
+		Username: AdminRole,
+		Roles:    []string{AdminRole},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to issue admin token: %w", err)
+	}
+	data, err := json.MarshalIndent(pair, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path = expandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create admin token directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write admin token: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
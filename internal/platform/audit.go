@@ -0,0 +1,122 @@
+package platform
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// AuditEventTypes lists the events recorded in the audit log
+var AuditEventTypes = []string{
+	"auth.token_issued",
+	"auth.token_rejected",
+	"auth.forbidden",
+	"auth.token_revoked",
+}
+
+// auditLog records security-relevant events to the logger and, when a file
+// is configured, appends them as JSON lines. Large event payloads are spilled
+// to side files next to the log.
+type auditLog struct {
+	mu     sync.Mutex
+	file   *os.File
+	spill  *eventSpill
+	logger core.Logger
+}
+
+// newAuditLog opens path for appending. An empty path only logs.
+func newAuditLog(path string, logger core.Logger) (*auditLog, error) {
+	a := &auditLog{logger: logger}
+	if path == "" {
+		return a, nil
+	}
+
+	path = expandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	a.file = f
+	a.spill = newEventSpill(auditSpillDir(path), DefaultEventSpillThreshold)
+	return a, nil
+}
+
+// auditSpillDir returns the directory holding spilled payloads for the audit
+// log at path
+func auditSpillDir(path string) string {
+	return path + ".spill"
+}
+
+// Record writes a single audit event
+func (a *auditLog) Record(event core.Event) error {
+	a.logger.Info("Audit event",
+		core.Field{Key: "type", Value: event.Type},
+		core.Field{Key: "data", Value: event.Data},
+	)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+
+	event, err := a.spill.Spill(event)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = a.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying file, if any
+func (a *auditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// ReplayAuditLog calls fn for each event in the audit log at path, oldest
+// first. Spilled payloads are loaded only as each event is replayed.
+func ReplayAuditLog(path string, fn func(core.Event) error) error {
+	path = expandHome(path)
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	spill := newEventSpill(auditSpillDir(path), DefaultEventSpillThreshold)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var event core.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("failed to decode audit event: %w", err)
+		}
+		if event, err = spill.Load(event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
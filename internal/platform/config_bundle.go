@@ -0,0 +1,138 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// RedactedValue replaces secrets in redacted configuration exports
+const RedactedValue = "[redacted]"
+
+// ExportConfig returns a copy of the current configuration. When redact is
+// true, secrets are replaced with RedactedValue.
+func (p *Platform) ExportConfig(redact bool) (*PlatformConfig, error) {
+	exported, err := cloneConfig(p.Config())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export config: %w", err)
+	}
+
+	if redact && exported.Security.JWTSecret != "" {
+		exported.Security.JWTSecret = RedactedValue
+	}
+
+	return exported, nil
+}
+
+// ImportConfig validates config and applies it, restarting plugins as Reload
+// does. A redacted JWT secret keeps the current one. Security and network
+// settings used to build core services take effect on the next restart, and
+// the imported config is not written to disk.
+func (p *Platform) ImportConfig(ctx context.Context, config *PlatformConfig) error {
+	if config == nil {
+		return fmt.Errorf("%w: config is required", core.ErrInvalidConfig)
+	}
+
+	imported, err := cloneConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to import config: %w", err)
+	}
+	if imported.Security.JWTSecret == RedactedValue {
+		imported.Security.JWTSecret = p.Config().Security.JWTSecret
+	}
+
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	if err := p.applyConfig(ctx, imported, "import"); err != nil {
+		if errors.Is(err, core.ErrInvalidConfig) {
+			return err
+		}
+		return fmt.Errorf("failed to apply config: %w", err)
+	}
+
+	p.logger.Info("Platform configuration imported")
+	return nil
+}
+
+// ValidateConfig checks a configuration for values the platform can't run
+// with. All problems are reported together, wrapped in core.ErrInvalidConfig.
+func ValidateConfig(config *PlatformConfig) error {
+	var errs []error
+
+	if config.Name == "" {
+		errs = append(errs, fmt.Errorf("name is required"))
+	}
+	if config.Network.Port < 0 || config.Network.Port > 65535 {
+		errs = append(errs, fmt.Errorf("network port %d out of range", config.Network.Port))
+	}
+	if config.Network.DiscoveryPort < 0 || config.Network.DiscoveryPort > 65535 {
+		errs = append(errs, fmt.Errorf("discovery port %d out of range", config.Network.DiscoveryPort))
+	}
+	if config.Network.MaxPeers < 0 {
+		errs = append(errs, fmt.Errorf("maxPeers must not be negative"))
+	}
+	if config.Network.EnableTLS && (config.Network.TLSCertFile == "" || config.Network.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("tlsCertFile and tlsKeyFile are required when TLS is enabled"))
+	}
+
+	if !validOverflowPolicy(config.Events.OverflowPolicy) {
+		errs = append(errs, fmt.Errorf("unknown events overflowPolicy %q", config.Events.OverflowPolicy))
+	}
+	if config.Events.BufferSize < 0 || config.Events.BlockTimeout < 0 || config.Events.HistorySize < 0 {
+		errs = append(errs, fmt.Errorf("events bufferSize, blockTimeout and historySize must not be negative"))
+	}
+
+	security := config.Security
+	for _, proxy := range security.TrustedProxies {
+		if !validProxy(proxy) {
+			errs = append(errs, fmt.Errorf("trustedProxies entry %q is not an IP address or CIDR", proxy))
+		}
+	}
+	if security.TokenExpiry < 0 || security.RefreshTokenExpiry < 0 {
+		errs = append(errs, fmt.Errorf("token expiry must not be negative"))
+	}
+	if usesJWTSecret(security) {
+		if !security.AutoGenerateSecret {
+			if err := checkJWTSecret(security.JWTSecret); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	} else if security.JWTAlgorithm != JWTAlgorithmRS256 && security.JWTAlgorithm != JWTAlgorithmES256 {
+		errs = append(errs, fmt.Errorf("unsupported jwtAlgorithm %q", security.JWTAlgorithm))
+	} else if security.JWTPrivateKeyFile == "" && security.JWTPublicKeyFile == "" {
+		errs = append(errs, fmt.Errorf("%s requires jwtPrivateKeyFile or jwtPublicKeyFile", security.JWTAlgorithm))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", core.ErrInvalidConfig, errors.Join(errs...))
+	}
+	return nil
+}
+
+// cloneConfig deep-copies a configuration via its JSON form
+func cloneConfig(config *PlatformConfig) (*PlatformConfig, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone PlatformConfig
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// validProxy reports whether proxy is an IP address or CIDR range
+func validProxy(proxy string) bool {
+	if _, err := netip.ParsePrefix(proxy); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(proxy)
+	return err == nil
+}
@@ -0,0 +1,22 @@
+package platform
+
+import "testing"
+
+func TestValidateConfigTrustedProxies(t *testing.T) {
+	tests := []struct {
+		proxies []string
+		valid   bool
+	}{
+		{nil, true},
+		{[]string{"127.0.0.1", "10.0.0.0/8", "::1", "fd00::/8"}, true},
+		{[]string{"proxy.example"}, false},
+		{[]string{"10.0.0.0/33"}, false},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		cfg.Security.TrustedProxies = tt.proxies
+		if err := ValidateConfig(cfg); (err == nil) != tt.valid {
+			t.Errorf("ValidateConfig(%v) = %v, want valid %v", tt.proxies, err, tt.valid)
+		}
+	}
+}
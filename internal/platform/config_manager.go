@@ -0,0 +1,200 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// ConfigReloadedEvent is published after a new configuration is applied.
+// Its data holds the source of the change and the config version.
+const ConfigReloadedEvent = "config.reloaded"
+
+// configManagerImpl implements core.ConfigManager over the platform's live
+// configuration, so changes made through it go through the same validation
+// and plugin restart as Reload and ImportConfig
+type configManagerImpl struct {
+	platform *Platform
+}
+
+// NewConfigManager creates a config manager for p
+func NewConfigManager(p *Platform) (core.ConfigManager, error) {
+	return &configManagerImpl{platform: p}, nil
+}
+
+// Get returns the value at key as it appears in the JSON form of the
+// config, or nil if there is no such key
+func (m *configManagerImpl) Get(key string) interface{} {
+	tree, err := configTree(m.platform.Config())
+	if err != nil {
+		return nil
+	}
+
+	var value interface{} = tree
+	for _, name := range configKeyPath(key) {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok = object[name]; !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+// Set changes the value at key and applies the result. The change is
+// rejected, leaving the config untouched, if the key is unknown or the new
+// config doesn't validate.
+func (m *configManagerImpl) Set(key string, value interface{}) error {
+	return m.platform.SetConfigValue(m.platform.ctx, key, value)
+}
+
+func (m *configManagerImpl) Reload() error {
+	return m.platform.Reload(m.platform.ctx)
+}
+
+func (m *configManagerImpl) Save() error {
+	return m.platform.SaveConfig()
+}
+
+// SetConfigValue changes the config value at key, a dotted path of JSON
+// field names, and applies the new config
+func (p *Platform) SetConfigValue(ctx context.Context, key string, value interface{}) error {
+	path := configKeyPath(key)
+	if len(path) == 0 {
+		return fmt.Errorf("%w: config key is required", core.ErrInvalidConfig)
+	}
+
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	tree, err := configTree(p.Config())
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	object := tree
+	for _, name := range path[:len(path)-1] {
+		child, ok := object[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: unknown config key %q", core.ErrInvalidConfig, key)
+		}
+		object = child
+	}
+	last := path[len(path)-1]
+	if _, ok := object[last]; !ok {
+		return fmt.Errorf("%w: unknown config key %q", core.ErrInvalidConfig, key)
+	}
+	object[last] = value
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", core.ErrInvalidConfig, key, err)
+	}
+	var config PlatformConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%w: %s: %w", core.ErrInvalidConfig, key, err)
+	}
+
+	return p.applyConfig(ctx, &config, "set")
+}
+
+// SetConfigSaver sets the function used by SaveConfig to persist
+// configuration
+func (p *Platform) SetConfigSaver(saver func(*PlatformConfig) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configSaver = saver
+}
+
+// SaveConfig persists the current configuration with the config saver. The
+// watched config file, if the saver writes it, isn't reloaded as a result.
+func (p *Platform) SaveConfig() error {
+	p.mu.RLock()
+	saver := p.configSaver
+	config := p.config
+	p.mu.RUnlock()
+
+	if saver == nil {
+		return fmt.Errorf("no config saver set")
+	}
+	if err := saver(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	p.configFile.remember()
+	return nil
+}
+
+// applyConfig validates config and makes it current, restarting plugins if
+// the platform is running. If the restart fails, the previous config is put
+// back. source names what triggered the change in the published event.
+// Callers must hold p.reloadMu.
+func (p *Platform) applyConfig(ctx context.Context, config *PlatformConfig, source string) error {
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	previous := p.config
+	started := p.started
+	p.mu.RUnlock()
+
+	if !started {
+		p.mu.Lock()
+		p.config = config
+		p.version = config.Version
+		p.mu.Unlock()
+	} else {
+		running := p.runningPlugins()
+		if err := p.restartPlugins(ctx, config, running); err != nil {
+			if rollbackErr := p.restartPlugins(ctx, previous, running); rollbackErr != nil {
+				p.logger.Error("Failed to restore previous config",
+					core.Field{Key: "error", Value: rollbackErr},
+				)
+			}
+			return err
+		}
+	}
+
+	event := core.Event{
+		ID:     generateID(),
+		Type:   ConfigReloadedEvent,
+		Source: "platform",
+		Data: core.WithRequestIDData(ctx, map[string]interface{}{
+			"source":  source,
+			"version": config.Version,
+		}),
+		Timestamp: time.Now().Unix(),
+	}
+	if err := p.eventBus.Publish(event); err != nil {
+		p.logger.Warn("Failed to publish config reloaded event", core.Field{Key: "error", Value: err})
+	}
+	return nil
+}
+
+// configTree returns the JSON form of config as nested maps
+func configTree(config *PlatformConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// configKeyPath splits a dotted config key into field names
+func configKeyPath(key string) []string {
+	key = strings.Trim(key, ".")
+	if key == "" {
+		return nil
+	}
+	return strings.Split(key, ".")
+}
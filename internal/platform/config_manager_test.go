@@ -0,0 +1,177 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestConfigManagerSetGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"number", "network.port", 9090, float64(9090), false},
+		{"string", "network.host", "127.0.0.1", "127.0.0.1", false},
+		{"bool", "network.enableDiscovery", true, true, false},
+		{"surrounding dots", ".network.maxPeers.", 7, float64(7), false},
+		{"unknown key", "network.nope", 1, nil, true},
+		{"unknown section", "nope.port", 1, nil, true},
+		{"empty key", "", 1, nil, true},
+		{"wrong type", "network.port", "high", float64(0), true},
+		{"invalid value", "network.port", 70000, float64(0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			manager, _ := NewConfigManager(p)
+
+			err := manager.Set(tt.key, tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, core.ErrInvalidConfig) {
+					t.Fatalf("Set error = %v, want ErrInvalidConfig", err)
+				}
+				if tt.key == "" {
+					return
+				}
+				if got := manager.Get(tt.key); got != tt.want {
+					t.Fatalf("Get after rejected Set = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if got := manager.Get(tt.key); got != tt.want {
+				t.Fatalf("Get = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// configFile stores a PlatformConfig as JSON at path, standing in for the
+// config file main reads and writes
+type configFile struct {
+	t     *testing.T
+	path  string
+	loads atomic.Int32
+}
+
+func (f *configFile) write(cfg *PlatformConfig) {
+	f.t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(f.path, data, 0600); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *configFile) load() (*PlatformConfig, error) {
+	f.loads.Add(1)
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg PlatformConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// newConfigFilePlatform returns a platform loading and saving its config
+// through a file
+func newConfigFilePlatform(t *testing.T) (*Platform, *configFile) {
+	t.Helper()
+	p := newTestPlatform(t, nil)
+	t.Cleanup(p.cancel)
+	file := &configFile{t: t, path: filepath.Join(t.TempDir(), "config.json")}
+	file.write(p.Config())
+	p.SetConfigLoader(file.load)
+	p.SetConfigSaver(func(cfg *PlatformConfig) error {
+		file.write(cfg)
+		return nil
+	})
+	return p, file
+}
+
+func TestReloadPicksUpEditedValues(t *testing.T) {
+	p, file := newConfigFilePlatform(t)
+	manager, _ := NewConfigManager(p)
+
+	edited := *p.Config()
+	edited.Network.Port = 9191
+	file.write(&edited)
+
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := manager.Get("network.port"); got != float64(9191) {
+		t.Fatalf("port after reload = %v, want 9191", got)
+	}
+
+	// An invalid edit is rejected and the current config kept
+	edited.Network.Port = -1
+	file.write(&edited)
+	if err := manager.Reload(); !errors.Is(err, core.ErrInvalidConfig) {
+		t.Fatalf("Reload of invalid config = %v, want ErrInvalidConfig", err)
+	}
+	if got := p.Config().Network.Port; got != 9191 {
+		t.Fatalf("port after rejected reload = %d, want 9191", got)
+	}
+}
+
+func TestConfigWatchIgnoresOwnWrites(t *testing.T) {
+	p, file := newConfigFilePlatform(t)
+	if err := p.WatchConfigFile(file.path); err != nil {
+		t.Fatalf("WatchConfigFile: %v", err)
+	}
+	quiet := func() {
+		time.Sleep(4 * configWatchDelay)
+	}
+
+	// A value set and saved is kept, with no reload of the saved file
+	if err := p.SetConfigValue(p.ctx, "network.maxPeers", 5); err != nil {
+		t.Fatalf("SetConfigValue: %v", err)
+	}
+	if err := p.SaveConfig(); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	quiet()
+	if n := file.loads.Load(); n != 0 {
+		t.Fatalf("saving reloaded the config %d times", n)
+	}
+
+	// Rewriting the same content doesn't reload either
+	file.write(p.Config())
+	quiet()
+	if n := file.loads.Load(); n != 0 {
+		t.Fatalf("an unchanged write reloaded the config %d times", n)
+	}
+
+	// An edit is reloaded
+	edited := *p.Config()
+	edited.Network.MaxPeers = 9
+	file.write(&edited)
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Config().Network.MaxPeers != 9 {
+		if time.Now().After(deadline) {
+			t.Fatalf("edit not reloaded, maxPeers = %d", p.Config().Network.MaxPeers)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := file.loads.Load(); n != 1 {
+		t.Fatalf("edit reloaded the config %d times, want 1", n)
+	}
+}
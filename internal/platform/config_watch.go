@@ -0,0 +1,136 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// configWatchDelay is how long the config file has to stay quiet before a
+// change to it is reloaded, since editors often write a file in several
+// steps
+const configWatchDelay = 250 * time.Millisecond
+
+// configFileState remembers the content of the watched config file as the
+// platform last wrote or applied it, so the watcher can tell the platform's
+// own writes from edits
+type configFileState struct {
+	mu   sync.Mutex
+	path string
+	hash []byte
+}
+
+// watch starts tracking the file at path as it is now
+func (s *configFileState) watch(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = path
+	s.hash = fileHash(path)
+}
+
+// remember records the file's current content as the platform's own
+func (s *configFileState) remember() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		s.hash = fileHash(s.path)
+	}
+}
+
+// changed reports whether the file differs from the content last seen, and
+// remembers its current content
+func (s *configFileState) changed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash := fileHash(s.path)
+	if hash != nil && bytes.Equal(hash, s.hash) {
+		return false
+	}
+	s.hash = hash
+	return true
+}
+
+// fileHash returns the SHA-256 of the file at path, or nil if it can't be
+// read
+func fileHash(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// WatchConfigFile reloads the configuration whenever the file at path
+// changes, until the platform stops. The file's directory is watched rather
+// than the file, so editors that replace the file on save are followed.
+// Writes by SaveConfig, and writes that leave the content as it was, don't
+// trigger a reload.
+func (p *Platform) WatchConfigFile(path string) error {
+	path = filepath.Clean(expandHome(path))
+	p.configFile.watch(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	err = p.workers.Go("config-watch", func(ctx context.Context) error {
+		p.watchConfig(ctx, path, watcher)
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	return nil
+}
+
+func (p *Platform) watchConfig(ctx context.Context, path string, watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == path && event.Op.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				pending = time.After(configWatchDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			p.logger.Warn("Config file watch error", core.Field{Key: "error", Value: err})
+		case <-pending:
+			pending = nil
+			if !p.configFile.changed() {
+				continue
+			}
+			if err := p.Reload(ctx); err != nil {
+				p.logger.Warn("Failed to reload changed config file",
+					core.Field{Key: "path", Value: path},
+					core.Field{Key: "error", Value: err},
+				)
+				continue
+			}
+			p.logger.Info("Reloaded changed config file", core.Field{Key: "path", Value: path})
+		}
+	}
+}
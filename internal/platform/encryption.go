@@ -0,0 +1,224 @@
+package platform
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptionAlgoAESGCM is the only supported payload encryption algorithm and
+// the default when EncryptionAlgo is empty
+const EncryptionAlgoAESGCM = "AES-256-GCM"
+
+// peerKeyLabel separates keys derived from a peer handshake from any other
+// use of the same ECDH secret
+const peerKeyLabel = "noplacelike peer payload key"
+
+// defaultEncryptionSalt salts the shared key derivation when
+// SecurityConfig.EncryptionSalt is empty
+const defaultEncryptionSalt = "noplacelike shared payload key"
+
+// scrypt cost parameters for deriving the shared key from EncryptionKey
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// payloadCipher encrypts peer payloads with AES-256-GCM. Each peer uses the
+// key agreed through an X25519 handshake if there is one, otherwise the key
+// derived from the configured shared EncryptionKey.
+type payloadCipher struct {
+	mu      sync.RWMutex
+	enabled bool
+	shared  cipher.AEAD
+	private *ecdh.PrivateKey
+	peers   map[string]cipher.AEAD
+}
+
+// newPayloadCipher builds the cipher for config. With encryption disabled
+// payloads pass through unchanged.
+func newPayloadCipher(config SecurityConfig) (*payloadCipher, error) {
+	if !config.EnableEncryption {
+		return &payloadCipher{}, nil
+	}
+	if algo := config.EncryptionAlgo; algo != "" && !strings.EqualFold(algo, EncryptionAlgoAESGCM) {
+		return nil, fmt.Errorf("%w: unsupported encryption algorithm %q", core.ErrInvalidConfig, algo)
+	}
+
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	pc := &payloadCipher{
+		enabled: true,
+		private: private,
+		peers:   make(map[string]cipher.AEAD),
+	}
+	if config.EncryptionKey != "" {
+		key, err := deriveSharedKey(config.EncryptionKey, config.EncryptionSalt)
+		if err != nil {
+			return nil, err
+		}
+		if pc.shared, err = newGCM(key); err != nil {
+			return nil, err
+		}
+	}
+	return pc, nil
+}
+
+// deriveSharedKey stretches the EncryptionKey passphrase into an AES-256 key
+// with scrypt, so a weak passphrase can't be brute-forced cheaply
+func deriveSharedKey(passphrase, salt string) ([]byte, error) {
+	if salt == "" {
+		salt = defaultEncryptionSalt
+	}
+	key, err := scrypt.Key([]byte(passphrase), []byte(salt), scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// publicKey returns the local X25519 public key to send to peers, or nil
+// when encryption is disabled
+func (pc *payloadCipher) publicKey() []byte {
+	if pc.private == nil {
+		return nil
+	}
+	return pc.private.PublicKey().Bytes()
+}
+
+// establish derives the key shared with peerID from its X25519 public key
+func (pc *payloadCipher) establish(peerID string, peerPublic []byte) error {
+	if !pc.enabled {
+		return fmt.Errorf("%w: encryption is disabled", core.ErrInvalidRequest)
+	}
+	remote, err := ecdh.X25519().NewPublicKey(peerPublic)
+	if err != nil {
+		return fmt.Errorf("%w: invalid peer public key: %v", core.ErrInvalidRequest, err)
+	}
+	secret, err := pc.private.ECDH(remote)
+	if err != nil {
+		return fmt.Errorf("failed to agree key with peer %s: %w", peerID, err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(peerKeyLabel))
+	gcm, err := newGCM(mac.Sum(nil))
+	if err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	pc.peers[peerID] = gcm
+	pc.mu.Unlock()
+	return nil
+}
+
+// forget drops the handshake key for peerID
+func (pc *payloadCipher) forget(peerID string) {
+	pc.mu.Lock()
+	delete(pc.peers, peerID)
+	pc.mu.Unlock()
+}
+
+func (pc *payloadCipher) aead(peerID string) (cipher.AEAD, error) {
+	pc.mu.RLock()
+	gcm, ok := pc.peers[peerID]
+	pc.mu.RUnlock()
+	if ok {
+		return gcm, nil
+	}
+	if pc.shared != nil {
+		return pc.shared, nil
+	}
+	return nil, fmt.Errorf("%w: no encryption key for peer %s", core.ErrUnauthorized, peerID)
+}
+
+// seal encrypts data for peerID. The output is the random nonce followed by
+// the ciphertext and tag.
+func (pc *payloadCipher) seal(data []byte, peerID string) ([]byte, error) {
+	if !pc.enabled {
+		return data, nil
+	}
+	gcm, err := pc.aead(peerID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts data produced by seal, rejecting anything that was tampered
+// with
+func (pc *payloadCipher) open(data []byte, peerID string) ([]byte, error) {
+	if !pc.enabled {
+		return data, nil
+	}
+	gcm, err := pc.aead(peerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, core.ErrDecryptionFailed
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, core.ErrDecryptionFailed
+	}
+	return plain, nil
+}
+
+// Encrypt encrypts a payload for peerID. With encryption disabled the data is
+// returned as is.
+func (s *securityManagerImpl) Encrypt(data []byte, peerID string) ([]byte, error) {
+	return s.cipher.seal(data, peerID)
+}
+
+// Decrypt reverses Encrypt for a payload received from peerID
+func (s *securityManagerImpl) Decrypt(data []byte, peerID string) ([]byte, error) {
+	return s.cipher.open(data, peerID)
+}
+
+// EncryptionPublicKey returns the X25519 public key peers use to agree a
+// payload key with this node
+func (s *securityManagerImpl) EncryptionPublicKey() []byte {
+	return s.cipher.publicKey()
+}
+
+// EstablishPeerKey agrees a payload key with peerID from its X25519 public
+// key. It takes precedence over the shared EncryptionKey for that peer.
+func (s *securityManagerImpl) EstablishPeerKey(peerID string, peerPublic []byte) error {
+	return s.cipher.establish(peerID, peerPublic)
+}
+
+// ForgetPeerKey drops the key agreed with peerID
+func (s *securityManagerImpl) ForgetPeerKey(peerID string) {
+	s.cipher.forget(peerID)
+}
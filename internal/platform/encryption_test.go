@@ -0,0 +1,91 @@
+package platform
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/network"
+)
+
+func TestSharedKeyDerivation(t *testing.T) {
+	sender, err := newPayloadCipher(SecurityConfig{EnableEncryption: true, EncryptionKey: "passphrase", EncryptionSalt: "site"})
+	if err != nil {
+		t.Fatalf("newPayloadCipher: %v", err)
+	}
+	sealed, err := sender.seal([]byte("hello"), "peer-1")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		salt    string
+		wantErr error
+	}{
+		{"same passphrase and salt", "passphrase", "site", nil},
+		{"other salt", "passphrase", "other", core.ErrDecryptionFailed},
+		{"default salt", "passphrase", "", core.ErrDecryptionFailed},
+		{"other passphrase", "passphrase2", "site", core.ErrDecryptionFailed},
+	}
+	for _, tt := range tests {
+		receiver, err := newPayloadCipher(SecurityConfig{EnableEncryption: true, EncryptionKey: tt.key, EncryptionSalt: tt.salt})
+		if err != nil {
+			t.Fatalf("%s: newPayloadCipher: %v", tt.name, err)
+		}
+		plain, err := receiver.open(sealed, "peer-1")
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: open error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && string(plain) != "hello" {
+			t.Errorf("%s: open = %q, want %q", tt.name, plain, "hello")
+		}
+	}
+
+	// Each salt derives its own key
+	key, err := deriveSharedKey("passphrase", "")
+	if err != nil {
+		t.Fatalf("deriveSharedKey: %v", err)
+	}
+	if salted, _ := deriveSharedKey("passphrase", "site"); bytes.Equal(key, salted) {
+		t.Error("salt did not change the derived key")
+	}
+}
+
+func TestPeerKeyExchange(t *testing.T) {
+	config := SecurityConfig{EnableEncryption: true, JWTSecret: "0123456789abcdef0123456789abcdef"}
+	newManager := func() network.PeerKeyExchanger {
+		sm, err := NewSecurityManager(config, nopLogger{})
+		if err != nil {
+			t.Fatalf("NewSecurityManager: %v", err)
+		}
+		kx, ok := sm.(network.PeerKeyExchanger)
+		if !ok {
+			t.Fatal("security manager does not implement network.PeerKeyExchanger")
+		}
+		return kx
+	}
+	a, b := newManager(), newManager()
+	if err := a.EstablishPeerKey("b", b.EncryptionPublicKey()); err != nil {
+		t.Fatalf("EstablishPeerKey: %v", err)
+	}
+	if err := b.EstablishPeerKey("a", a.EncryptionPublicKey()); err != nil {
+		t.Fatalf("EstablishPeerKey: %v", err)
+	}
+
+	sealed, err := a.(core.SecurityManager).Encrypt([]byte("hello"), "b")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if plain, err := b.(core.SecurityManager).Decrypt(sealed, "a"); err != nil || string(plain) != "hello" {
+		t.Fatalf("Decrypt = %q, %v, want hello", plain, err)
+	}
+
+	// Without a shared key, a forgotten peer can't be encrypted for
+	a.ForgetPeerKey("b")
+	if _, err := a.(core.SecurityManager).Encrypt([]byte("hello"), "b"); !errors.Is(err, core.ErrUnauthorized) {
+		t.Errorf("Encrypt after ForgetPeerKey error = %v, want %v", err, core.ErrUnauthorized)
+	}
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	ctx := context.Background()
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { p.Stop(context.Background()) })
+	if err := p.LoadPlugin(ctx, &testPlugin{id: "base"}); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"start twice", func() error { return p.Start(ctx) }, core.ErrAlreadyRunning},
+		{"load twice", func() error { return p.LoadPlugin(ctx, &testPlugin{id: "base"}) }, core.ErrPluginExists},
+		{"missing dependency", func() error {
+			return p.LoadPlugin(ctx, &testPlugin{id: "child", deps: []string{"nope"}})
+		}, core.ErrMissingDependency},
+		{"get missing plugin", func() error { _, err := p.GetPlugin("nope"); return err }, core.ErrPluginNotFound},
+		{"start missing plugin", func() error { return p.StartPlugin(ctx, "nope") }, core.ErrPluginNotFound},
+		{"stop missing plugin", func() error { return p.StopPlugin(ctx, "nope") }, core.ErrPluginNotFound},
+		{"reinitialize missing plugin", func() error { return p.ReinitializePlugin("nope") }, core.ErrPluginNotFound},
+		{"unload missing plugin", func() error { return p.UnloadPlugin(ctx, "nope") }, core.ErrPluginNotFound},
+		{"cascade missing plugin", func() error { _, err := p.UnloadPluginCascade(ctx, "nope"); return err }, core.ErrPluginNotFound},
+		{"get missing resource", func() error {
+			_, err := p.ResourceManager().GetResource(ctx, "nope")
+			return err
+		}, core.ErrResourceNotFound},
+		{"register resource without ID", func() error {
+			return p.ResourceManager().RegisterResource(core.NewMemoryResource("", "memory", nil, nil))
+		}, core.ErrInvalidRequest},
+		{"get missing service", func() error { _, err := p.ServiceManager().GetService("nope"); return err }, core.ErrServiceNotFound},
+		{"register nil service", func() error { return p.ServiceManager().RegisterService(nil) }, core.ErrInvalidRequest},
+		{"unsubscribe unknown", func() error {
+			return p.GetEventBus().Unsubscribe(core.Subscription{ID: 999, EventType: "test"})
+		}, core.ErrSubscriptionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
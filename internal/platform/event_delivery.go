@@ -0,0 +1,270 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// Overflow policies for a subscriber whose queue is full
+const (
+	// OverflowDropOldest discards the oldest queued event to make room
+	OverflowDropOldest = "drop-oldest"
+	// OverflowDropNew discards the event being published
+	OverflowDropNew = "drop-new"
+	// OverflowBlock waits for room, for at most EventsConfig.BlockTimeout,
+	// and then discards the event being published
+	OverflowBlock = "block"
+)
+
+// Event delivery defaults used when EventsConfig leaves a value unset
+const (
+	DefaultEventBufferSize   = 256
+	DefaultEventBlockTimeout = time.Second
+)
+
+// Event bus metrics. The published and handler error counters are labeled
+// with the event type.
+const (
+	// eventsDroppedMetric counts events a subscriber never received because
+	// its queue was full
+	eventsDroppedMetric      = "events_dropped_total"
+	eventsPublishedMetric    = "events_published_total"
+	eventHandlerErrorsMetric = "event_handler_errors_total"
+	eventSubscribersMetric   = "event_subscribers"
+)
+
+// maxEventTypeLabels caps the event types the event metrics are labeled
+// with. Clients can publish events of any type, so types seen after the
+// cap are counted under otherEventType.
+const (
+	maxEventTypeLabels = 100
+	otherEventType     = "other"
+)
+
+// EventsConfig contains event bus settings. Each subscriber gets a queue of
+// BufferSize events drained by its own goroutine, so a slow handler only
+// holds up itself; OverflowPolicy decides what happens when it falls behind.
+type EventsConfig struct {
+	BufferSize     int           `json:"bufferSize"`
+	OverflowPolicy string        `json:"overflowPolicy"` // drop-oldest, drop-new or block
+	BlockTimeout   time.Duration `json:"blockTimeout"`
+	// HistorySize is how many recent events are kept for replay
+	HistorySize int `json:"historySize"`
+}
+
+// validOverflowPolicy reports whether policy is one the event bus knows;
+// empty selects the default
+func validOverflowPolicy(policy string) bool {
+	switch policy {
+	case "", OverflowDropOldest, OverflowDropNew, OverflowBlock:
+		return true
+	}
+	return false
+}
+
+// withDefaults fills in unset values
+func (c EventsConfig) withDefaults() EventsConfig {
+	if c.BufferSize <= 0 {
+		c.BufferSize = DefaultEventBufferSize
+	}
+	if c.OverflowPolicy == "" {
+		c.OverflowPolicy = OverflowDropOldest
+	}
+	if c.BlockTimeout <= 0 {
+		c.BlockTimeout = DefaultEventBlockTimeout
+	}
+	if c.HistorySize <= 0 {
+		c.HistorySize = DefaultEventHistorySize
+	}
+	return c
+}
+
+// LosslessSubscriber is implemented by event buses that can deliver every
+// event to a handler, whatever the overflow policy
+type LosslessSubscriber interface {
+	// SubscribeLossless subscribes handler like Subscribe, except that
+	// publishers wait for room in its queue rather than drop events, and
+	// events still queued when the bus stops are delivered first
+	SubscribeLossless(eventType string, handler core.EventHandler) (core.Subscription, error)
+}
+
+// queuedEvent is an event waiting in a subscriber's queue
+type queuedEvent struct {
+	ctx   context.Context
+	event core.Event
+}
+
+// newSubscriber creates a subscriber and starts the goroutine that delivers
+// its queued events in order
+func (e *eventBusImpl) newSubscriber(id uint64, handle func(context.Context, core.Event) error, lossless bool) eventSubscriber {
+	sub := eventSubscriber{
+		id:        id,
+		handle:    handle,
+		queue:     make(chan queuedEvent, e.config.BufferSize),
+		done:      make(chan struct{}),
+		closeOnce: new(sync.Once),
+		lossless:  lossless,
+	}
+	e.wg.Add(1)
+	go e.run(sub)
+	return sub
+}
+
+func (e *eventBusImpl) run(sub eventSubscriber) {
+	defer e.wg.Done()
+	for {
+		select {
+		case qe := <-sub.queue:
+			e.deliver(sub, qe)
+		case <-sub.done:
+			if sub.lossless {
+				e.drain(sub)
+			}
+			return
+		}
+	}
+}
+
+func (e *eventBusImpl) deliver(sub eventSubscriber, qe queuedEvent) {
+	if err := sub.handle(qe.ctx, qe.event); err != nil {
+		e.countEvent(eventHandlerErrorsMetric, qe.event.Type)
+	}
+}
+
+// drain delivers the events left in sub's queue
+func (e *eventBusImpl) drain(sub eventSubscriber) {
+	for {
+		select {
+		case qe := <-sub.queue:
+			e.deliver(sub, qe)
+		default:
+			return
+		}
+	}
+}
+
+// close stops delivery. Events still queued are discarded, unless the
+// subscriber is lossless. It may be called more than once.
+func (s eventSubscriber) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// enqueue hands an event to each subscriber according to the overflow
+// policy. It never waits longer than the block timeout per subscriber.
+func (e *eventBusImpl) enqueue(ctx context.Context, subs []eventSubscriber, event core.Event) {
+	e.countEvent(eventsPublishedMetric, event.Type)
+
+	qe := queuedEvent{ctx: ctx, event: event}
+	for _, sub := range subs {
+		if !e.offer(sub, qe) {
+			e.dropped(sub, event)
+		}
+	}
+}
+
+// offer queues qe for sub, reporting false if an event was discarded
+func (e *eventBusImpl) offer(sub eventSubscriber, qe queuedEvent) bool {
+	select {
+	case sub.queue <- qe:
+		return true
+	default:
+	}
+
+	if sub.lossless {
+		select {
+		case sub.queue <- qe:
+			return true
+		case <-sub.done:
+			return false
+		}
+	}
+
+	switch e.config.OverflowPolicy {
+	case OverflowDropNew:
+		return false
+	case OverflowBlock:
+		timer := time.NewTimer(e.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case sub.queue <- qe:
+			return true
+		case <-sub.done:
+			return true
+		case <-timer.C:
+			return false
+		}
+	default:
+		// Make room by discarding the oldest event. The subscriber may
+		// drain the queue meanwhile, so only count what was discarded.
+		for {
+			select {
+			case sub.queue <- qe:
+				return true
+			default:
+			}
+			select {
+			case oldest := <-sub.queue:
+				e.dropped(sub, oldest.event)
+			default:
+			}
+		}
+	}
+}
+
+// countEvent increments metric for eventType
+func (e *eventBusImpl) countEvent(metric, eventType string) {
+	if e.metrics == nil {
+		return
+	}
+	e.metrics.CounterVec(metric, "type").With(map[string]string{"type": e.typeLabel(eventType)}).Inc()
+}
+
+// typeLabel returns the metric label for eventType: the type itself if it
+// is one of the first maxEventTypeLabels seen, otherEventType if not
+func (e *eventBusImpl) typeLabel(eventType string) string {
+	e.labelsMu.Lock()
+	defer e.labelsMu.Unlock()
+	if _, ok := e.typeLabels[eventType]; ok {
+		return eventType
+	}
+	if len(e.typeLabels) >= maxEventTypeLabels {
+		return otherEventType
+	}
+	if e.typeLabels == nil {
+		e.typeLabels = make(map[string]struct{})
+	}
+	e.typeLabels[eventType] = struct{}{}
+	return eventType
+}
+
+// subscribersChangedLocked reports the number of subscribers. The caller
+// holds e.mu.
+func (e *eventBusImpl) subscribersChangedLocked() {
+	if e.metrics == nil {
+		return
+	}
+	count := 0
+	for _, subs := range e.subs {
+		count += len(subs)
+	}
+	for _, p := range e.patterns {
+		count += len(p.subs)
+	}
+	e.metrics.Gauge(eventSubscribersMetric).Set(float64(count))
+}
+
+func (e *eventBusImpl) dropped(sub eventSubscriber, event core.Event) {
+	e.droppedTotal.Add(1)
+	if e.metrics != nil {
+		e.metrics.Counter(eventsDroppedMetric).Inc()
+	}
+	if e.logger != nil {
+		e.logger.Debug("Event dropped for slow subscriber",
+			core.Field{Key: "subscription", Value: sub.id},
+			core.Field{Key: "type", Value: event.Type},
+			core.Field{Key: "policy", Value: e.config.OverflowPolicy})
+	}
+}
@@ -0,0 +1,371 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// slowHandler records the sequence numbers it sees, waiting for release
+// before handling the first
+type slowHandler struct {
+	mu      sync.Mutex
+	seen    []uint64
+	release chan struct{}
+}
+
+func newSlowHandler() *slowHandler {
+	return &slowHandler{release: make(chan struct{})}
+}
+
+func (h *slowHandler) handle(event core.Event) error {
+	<-h.release
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seen = append(h.seen, event.Seq)
+	return nil
+}
+
+func (h *slowHandler) received() []uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]uint64(nil), h.seen...)
+}
+
+func newTestBus(t *testing.T, config EventsConfig) *eventBusImpl {
+	t.Helper()
+	bus, err := NewEventBus(config, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewEventBus: %v", err)
+	}
+	return bus.(*eventBusImpl)
+}
+
+func TestLosslessSubscriberKeepsUpWithSlowConsumer(t *testing.T) {
+	const events = 50
+	for _, policy := range []string{OverflowDropOldest, OverflowDropNew, OverflowBlock} {
+		t.Run(policy, func(t *testing.T) {
+			bus := newTestBus(t, EventsConfig{BufferSize: 2, OverflowPolicy: policy, BlockTimeout: time.Millisecond})
+			lossless, lossy := newSlowHandler(), newSlowHandler()
+			bus.SubscribeLossless("audit.test", lossless.handle)
+			bus.Subscribe("audit.test", lossy.handle)
+
+			published := make(chan struct{})
+			go func() {
+				defer close(published)
+				for i := 0; i < events; i++ {
+					bus.Publish(core.Event{Type: "audit.test"})
+				}
+			}()
+
+			// The lossy subscriber overflows while both are stuck; the
+			// publisher waits on the lossless one
+			time.Sleep(50 * time.Millisecond)
+			close(lossy.release)
+			close(lossless.release)
+			<-published
+
+			if err := bus.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			got := lossless.received()
+			if len(got) != events {
+				t.Fatalf("lossless subscriber got %d events, want %d", len(got), events)
+			}
+			for i, seq := range got {
+				if seq != uint64(i+1) {
+					t.Fatalf("event %d has seq %d, want in order", i, seq)
+				}
+			}
+			// Under the block policy the publisher waiting on the lossless
+			// subscriber gives the other time to catch up
+			if n := len(lossy.received()); policy != OverflowBlock && n >= events {
+				t.Errorf("lossy subscriber got all %d events; the test didn't overflow it", n)
+			}
+		})
+	}
+}
+
+func TestEventBusStopWaitsForSubscribers(t *testing.T) {
+	bus := newTestBus(t, EventsConfig{})
+	handler := newSlowHandler()
+	bus.SubscribeLossless("a", handler.handle)
+	bus.Subscribe("b", func(core.Event) error { return nil })
+	bus.SubscribeWithContext(context.Background(), "c.*", func(context.Context, core.Event) error { return nil })
+	for i := 0; i < 5; i++ {
+		bus.Publish(core.Event{Type: "a"})
+	}
+
+	// A handler that doesn't return holds Stop until its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop with a stuck handler = %v, want deadline exceeded", err)
+	}
+
+	close(handler.release)
+	if err := bus.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := len(handler.received()); n != 5 {
+		t.Fatalf("lossless subscriber got %d queued events before Stop returned, want 5", n)
+	}
+
+	// Unsubscribing after Stop must not close the subscriber again
+	if err := bus.Unsubscribe(core.Subscription{EventType: "b", ID: 2}); err != nil {
+		t.Fatalf("Unsubscribe after Stop: %v", err)
+	}
+}
+
+func TestAuditLogRecordsEveryEvent(t *testing.T) {
+	const events = 200
+	path := filepath.Join(t.TempDir(), "audit.log")
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Events = EventsConfig{BufferSize: 1, OverflowPolicy: OverflowDropOldest}
+		cfg.Security.EnableAuditLog = true
+		cfg.Security.AuditLogFile = path
+	})
+
+	for i := 0; i < events; i++ {
+		if err := p.PublishEvent("auth.forbidden", map[string]interface{}{"n": i}); err != nil {
+			t.Fatalf("PublishEvent: %v", err)
+		}
+	}
+	if err := p.eventBus.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := p.audit.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		lines++
+	}
+	if lines != events {
+		t.Fatalf("audit log has %d entries, want %d", lines, events)
+	}
+}
+
+func TestEventTypeLabelsAreCapped(t *testing.T) {
+	tests := []struct {
+		name      string
+		types     int
+		wantLabel int
+		wantOther bool
+	}{
+		{"under cap", 10, 10, false},
+		{"at cap", maxEventTypeLabels, maxEventTypeLabels, false},
+		{"over cap", 3 * maxEventTypeLabels, maxEventTypeLabels + 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := NewMetricsCollector(MetricsConfig{Enabled: true}, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewMetricsCollector: %v", err)
+			}
+			bus, err := NewEventBus(EventsConfig{}, metrics, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewEventBus: %v", err)
+			}
+			for i := 0; i < tt.types; i++ {
+				bus.Publish(core.Event{Type: fmt.Sprintf("client.%d", i)})
+			}
+
+			labels := make(map[string]bool)
+			for _, series := range metrics.(*metricsCollectorImpl).counterVec[eventsPublishedMetric].snapshot() {
+				labels[series.Labels["type"]] = true
+			}
+			if len(labels) != tt.wantLabel {
+				t.Errorf("got %d type labels, want %d", len(labels), tt.wantLabel)
+			}
+			if labels[otherEventType] != tt.wantOther {
+				t.Errorf("%q label present = %v, want %v", otherEventType, labels[otherEventType], tt.wantOther)
+			}
+		})
+	}
+}
+
+func TestUnsubscribeRemovesOnlyThatHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		published string
+	}{
+		{"event type", "plugin.started", "plugin.started"},
+		{"pattern", "plugin.*", "plugin.started"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newTestBus(t, EventsConfig{})
+			var keptCalls sync.WaitGroup
+			var mu sync.Mutex
+			removedSeen := 0
+			removed, err := bus.Subscribe(tt.eventType, func(core.Event) error {
+				mu.Lock()
+				removedSeen++
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+			keptCalls.Add(1)
+			if _, err := bus.Subscribe(tt.eventType, func(core.Event) error {
+				keptCalls.Done()
+				return nil
+			}); err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+
+			if err := bus.Unsubscribe(removed); err != nil {
+				t.Fatalf("Unsubscribe: %v", err)
+			}
+			bus.Publish(core.Event{Type: tt.published})
+			keptCalls.Wait()
+			// Stop drains every subscriber, so a delivery to the removed
+			// handler would have happened by the time it returns
+			if err := bus.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if removedSeen != 0 {
+				t.Errorf("unsubscribed handler got %d events", removedSeen)
+			}
+
+			if err := bus.Unsubscribe(removed); !errors.Is(err, core.ErrSubscriptionNotFound) {
+				t.Errorf("second Unsubscribe = %v, want ErrSubscriptionNotFound", err)
+			}
+		})
+	}
+}
+
+func TestUnsubscribeUnknownSubscription(t *testing.T) {
+	bus := newTestBus(t, EventsConfig{})
+	sub, err := bus.Subscribe("plugin.started", func(core.Event) error { return nil })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		sub  core.Subscription
+	}{
+		{"unknown ID", core.Subscription{EventType: sub.EventType, ID: sub.ID + 1}},
+		{"other event type", core.Subscription{EventType: "plugin.stopped", ID: sub.ID}},
+		{"other pattern", core.Subscription{EventType: "plugin.*", ID: sub.ID}},
+	}
+	for _, tt := range tests {
+		if err := bus.Unsubscribe(tt.sub); !errors.Is(err, core.ErrSubscriptionNotFound) {
+			t.Errorf("%s: Unsubscribe = %v, want ErrSubscriptionNotFound", tt.name, err)
+		}
+	}
+	if err := bus.Unsubscribe(sub); err != nil {
+		t.Errorf("Unsubscribe of the real subscription: %v", err)
+	}
+}
+
+func TestSubscribeWithContextUnsubscribesWhenDone(t *testing.T) {
+	bus := newTestBus(t, EventsConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := bus.SubscribeWithContext(ctx, "plugin.*", func(context.Context, core.Event) error { return nil })
+	if err != nil {
+		t.Fatalf("SubscribeWithContext: %v", err)
+	}
+	other, err := bus.Subscribe("plugin.*", func(core.Event) error { return nil })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		bus.mu.RLock()
+		n := len(bus.patterns["plugin.*"].subs)
+		bus.mu.RUnlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d subscribers left after the context was cancelled, want 1", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := bus.Unsubscribe(sub); !errors.Is(err, core.ErrSubscriptionNotFound) {
+		t.Errorf("Unsubscribe of the cancelled subscription = %v", err)
+	}
+	if err := bus.Unsubscribe(other); err != nil {
+		t.Errorf("Unsubscribe of the other subscription: %v", err)
+	}
+}
+
+func TestEventBusMetrics(t *testing.T) {
+	metrics, err := NewMetricsCollector(MetricsConfig{Enabled: true}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMetricsCollector: %v", err)
+	}
+	bus, err := NewEventBus(EventsConfig{}, metrics, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewEventBus: %v", err)
+	}
+	subscribers := metrics.Gauge(eventSubscribersMetric)
+
+	// Lossless, so Stop runs every handler before the counts are read
+	impl := bus.(*eventBusImpl)
+	ok, _ := impl.SubscribeLossless("file.uploaded", func(core.Event) error { return nil })
+	failing, _ := impl.SubscribeLossless("file.*", func(core.Event) error { return errors.New("handler failed") })
+	if got := subscribers.Get(); got != 2 {
+		t.Errorf("%v subscribers after subscribing, want 2", got)
+	}
+
+	published := []string{"file.uploaded", "file.uploaded", "file.deleted", "peer.joined"}
+	for _, eventType := range published {
+		bus.Publish(core.Event{Type: eventType})
+	}
+	if err := bus.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	tests := []struct {
+		metric    string
+		eventType string
+		want      float64
+	}{
+		{eventsPublishedMetric, "file.uploaded", 2},
+		{eventsPublishedMetric, "file.deleted", 1},
+		// Counted even though nothing is subscribed to it
+		{eventsPublishedMetric, "peer.joined", 1},
+		{eventHandlerErrorsMetric, "file.uploaded", 2},
+		{eventHandlerErrorsMetric, "file.deleted", 1},
+		{eventHandlerErrorsMetric, "peer.joined", 0},
+	}
+	for _, tt := range tests {
+		counter := metrics.CounterVec(tt.metric, "type").With(map[string]string{"type": tt.eventType})
+		if got := counter.Get(); got != tt.want {
+			t.Errorf("%s{type=%q} = %v, want %v", tt.metric, tt.eventType, got, tt.want)
+		}
+	}
+
+	bus.Unsubscribe(ok)
+	if got := subscribers.Get(); got != 1 {
+		t.Errorf("%v subscribers after unsubscribing one, want 1", got)
+	}
+	bus.Unsubscribe(failing)
+	if got := subscribers.Get(); got != 0 {
+		t.Errorf("%v subscribers after unsubscribing both, want 0", got)
+	}
+}
@@ -0,0 +1,96 @@
+package platform
+
+import (
+	"context"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// DefaultEventHistorySize is how many recent events the event bus keeps for
+// replay when EventsConfig.HistorySize is unset
+const DefaultEventHistorySize = 256
+
+// recordedEvent is a published event kept for replay, with the topic it was
+// published to
+type recordedEvent struct {
+	topic string
+	event core.Event
+}
+
+// EventHistory is implemented by event buses that keep recent events
+type EventHistory interface {
+	// EventsSince returns the retained events with a sequence number after
+	// since whose topic matches one of types, oldest first. No types
+	// matches every event.
+	EventsSince(since uint64, types ...string) []core.Event
+	// LastSeq returns the sequence number of the latest event published,
+	// or 0 if there is none. Numbering starts over with every process.
+	LastSeq() uint64
+}
+
+// record numbers event and keeps it in the history, returning the numbered
+// event and the handlers it goes to. Numbering and snapshotting the handlers
+// under one lock means a subscriber that reads the history after subscribing
+// misses nothing in between.
+func (e *eventBusImpl) record(topic string, event core.Event) (core.Event, []eventSubscriber) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.seq++
+	event.Seq = e.seq
+
+	size := e.config.HistorySize
+	if len(e.history) < size {
+		e.history = append(e.history, recordedEvent{topic: topic, event: event})
+	} else if size > 0 {
+		e.history[e.historyNext] = recordedEvent{topic: topic, event: event}
+		e.historyNext = (e.historyNext + 1) % size
+	}
+	return event, e.handlersForLocked(topic)
+}
+
+// publish records event under topic and queues it for its subscribers
+func (e *eventBusImpl) publish(ctx context.Context, topic string, event core.Event) {
+	event, subs := e.record(topic, event)
+	e.enqueue(ctx, subs, event)
+}
+
+func (e *eventBusImpl) EventsSince(since uint64, types ...string) []core.Event {
+	matches := TopicMatcher(types...)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ordered := append(append([]recordedEvent{}, e.history[e.historyNext:]...), e.history[:e.historyNext]...)
+	events := make([]core.Event, 0, len(ordered))
+	for _, recorded := range ordered {
+		if recorded.event.Seq > since && matches(recorded.topic) {
+			events = append(events, recorded.event)
+		}
+	}
+	return events
+}
+
+func (e *eventBusImpl) LastSeq() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.seq
+}
+
+// EventsSince returns retained events newer than since whose topic matches
+// one of types, for replay to clients that reconnect
+func (p *Platform) EventsSince(since uint64, types ...string) []core.Event {
+	if history, ok := p.eventBus.(EventHistory); ok {
+		return history.EventsSince(since, types...)
+	}
+	return nil
+}
+
+// LastEventSeq returns the sequence number of the latest event published
+// since the process started, or 0 if there is none
+func (p *Platform) LastEventSeq() uint64 {
+	if history, ok := p.eventBus.(EventHistory); ok {
+		return history.LastSeq()
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package platform
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// seqs returns the sequence numbers of events
+func seqs(events []core.Event) []uint64 {
+	out := make([]uint64, 0, len(events))
+	for _, event := range events {
+		out = append(out, event.Seq)
+	}
+	return out
+}
+
+func TestEventHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		publish int
+		since   uint64
+		types   []string
+		want    []uint64
+	}{
+		{"everything retained", 10, 4, 0, nil, []uint64{1, 2, 3, 4}},
+		{"newer than the cursor", 10, 4, 2, nil, []uint64{3, 4}},
+		{"cursor at the latest", 10, 4, 4, nil, []uint64{}},
+		{"oldest dropped", 3, 5, 0, nil, []uint64{3, 4, 5}},
+		{"oldest dropped after wrapping twice", 3, 8, 0, nil, []uint64{6, 7, 8}},
+		{"exact type", 10, 6, 0, []string{"test.odd"}, []uint64{1, 3, 5}},
+		{"pattern and cursor", 10, 6, 2, []string{"test.*"}, []uint64{3, 4, 5, 6}},
+		{"type and cursor", 10, 6, 3, []string{"test.even"}, []uint64{4, 6}},
+		{"filter after dropping", 3, 6, 0, []string{"test.odd"}, []uint64{5}},
+		{"no match", 10, 4, 0, []string{"other"}, []uint64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newTestBus(t, EventsConfig{HistorySize: tt.size})
+			if got := bus.LastSeq(); got != 0 {
+				t.Fatalf("LastSeq before publishing = %d, want 0", got)
+			}
+			for i := 1; i <= tt.publish; i++ {
+				eventType := "test.even"
+				if i%2 == 1 {
+					eventType = "test.odd"
+				}
+				bus.Publish(core.Event{ID: fmt.Sprint(i), Type: eventType})
+			}
+
+			events := bus.EventsSince(tt.since, tt.types...)
+			if got := seqs(events); !slices.Equal(got, tt.want) {
+				t.Errorf("EventsSince(%d, %v) = %v, want %v", tt.since, tt.types, got, tt.want)
+			}
+			// Each event keeps what was published along with its number
+			for _, event := range events {
+				if event.ID != fmt.Sprint(event.Seq) {
+					t.Errorf("event %d has ID %s", event.Seq, event.ID)
+				}
+			}
+			if got := bus.LastSeq(); got != uint64(tt.publish) {
+				t.Errorf("LastSeq = %d, want %d", got, tt.publish)
+			}
+		})
+	}
+}
+
+func TestEventHistoryDefaultSize(t *testing.T) {
+	bus := newTestBus(t, EventsConfig{})
+	for i := 0; i < DefaultEventHistorySize+10; i++ {
+		bus.Publish(core.Event{Type: "test"})
+	}
+	events := bus.EventsSince(0)
+	if len(events) != DefaultEventHistorySize {
+		t.Fatalf("%d events retained, want %d", len(events), DefaultEventHistorySize)
+	}
+	if events[0].Seq != 11 {
+		t.Errorf("oldest retained event is %d, want 11", events[0].Seq)
+	}
+}
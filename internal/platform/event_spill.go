@@ -0,0 +1,85 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// DefaultEventSpillThreshold is the encoded size of event Data above which
+// persisted events store their Data in a side file
+const DefaultEventSpillThreshold = 64 * 1024 // 64KB
+
+// spillRefKey marks event Data that was spilled to a side file
+const spillRefKey = "$spillRef"
+
+// eventSpill stores large event payloads outside the main event stream so
+// persisting and replaying events stays cheap
+type eventSpill struct {
+	dir       string
+	threshold int
+}
+
+// newEventSpill spills payloads larger than threshold bytes into dir. A
+// non-positive threshold uses DefaultEventSpillThreshold.
+func newEventSpill(dir string, threshold int) *eventSpill {
+	if threshold <= 0 {
+		threshold = DefaultEventSpillThreshold
+	}
+	return &eventSpill{dir: dir, threshold: threshold}
+}
+
+// Spill returns event unchanged if its Data is small, otherwise writes the
+// Data to a side file and returns a copy referencing it
+func (s *eventSpill) Spill(event core.Event) (core.Event, error) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return event, fmt.Errorf("failed to encode event data: %w", err)
+	}
+	if len(data) <= s.threshold {
+		return event, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return event, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	id := event.ID
+	if id == "" {
+		id = generateID()
+	}
+	name := filepath.Base(id) + ".json"
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0600); err != nil {
+		return event, fmt.Errorf("failed to spill event data: %w", err)
+	}
+
+	event.Data = map[string]interface{}{
+		spillRefKey: name,
+		"size":      len(data),
+	}
+	return event, nil
+}
+
+// Load returns event with spilled Data read back from its side file. Events
+// that were not spilled are returned unchanged.
+func (s *eventSpill) Load(event core.Event) (core.Event, error) {
+	name, ok := event.Data[spillRefKey].(string)
+	if !ok {
+		return event, nil
+	}
+
+	raw, err := os.ReadFile(filepath.Join(s.dir, filepath.Base(name)))
+	if err != nil {
+		return event, fmt.Errorf("failed to load spilled data for event %s: %w", event.ID, err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return event, fmt.Errorf("failed to decode spilled data for event %s: %w", event.ID, err)
+	}
+	event.Data = data
+	return event, nil
+}
@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// listingEvent returns an event whose Data holds a listing of n file names
+func listingEvent(id string, n int) core.Event {
+	files := make([]interface{}, n)
+	for i := range files {
+		files[i] = strings.Repeat("f", 16)
+	}
+	return core.Event{ID: id, Type: "file.listed", Data: map[string]interface{}{"dir": "/srv", "files": files}}
+}
+
+func TestEventSpill(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     core.Event
+		wantSpill bool
+	}{
+		{"small event", listingEvent("small", 2), false},
+		{"large event", listingEvent("large", 100), true},
+		{"large event without ID", listingEvent("", 100), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spill := newEventSpill(t.TempDir(), 512)
+			stored, err := spill.Spill(tt.event)
+			if err != nil {
+				t.Fatalf("Spill: %v", err)
+			}
+			if _, spilled := stored.Data[spillRefKey]; spilled != tt.wantSpill {
+				t.Fatalf("spilled = %v, want %v (data %v)", spilled, tt.wantSpill, stored.Data)
+			}
+
+			loaded, err := spill.Load(stored)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(loaded.Data, tt.event.Data) {
+				t.Errorf("loaded data = %v, want %v", loaded.Data, tt.event.Data)
+			}
+		})
+	}
+}
+
+func TestEventSpillMissingSideFile(t *testing.T) {
+	spill := newEventSpill(t.TempDir(), 512)
+	stored, err := spill.Spill(listingEvent("gone", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(spill.dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spill.Load(stored); err == nil {
+		t.Error("Load succeeded without the side file")
+	}
+}
+
+func TestAuditLogReplaysSpilledEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := newAuditLog(path, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The large listing is well over DefaultEventSpillThreshold
+	events := []core.Event{
+		listingEvent("first", 2),
+		listingEvent("large", DefaultEventSpillThreshold/16),
+		listingEvent("last", 2),
+	}
+	for _, event := range events {
+		if err := audit.Record(event); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The log itself only holds a reference to the large payload
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > DefaultEventSpillThreshold {
+		t.Errorf("audit log is %d bytes, want the large payload spilled", info.Size())
+	}
+	if entries, _ := os.ReadDir(auditSpillDir(path)); len(entries) != 1 {
+		t.Errorf("%d spill files, want 1", len(entries))
+	}
+
+	var replayed []core.Event
+	if err := ReplayAuditLog(path, func(event core.Event) error {
+		replayed = append(replayed, event)
+		return nil
+	}); err != nil {
+		t.Fatalf("ReplayAuditLog: %v", err)
+	}
+	if len(replayed) != len(events) {
+		t.Fatalf("replayed %d events, want %d", len(replayed), len(events))
+	}
+	for i, event := range replayed {
+		if event.ID != events[i].ID || !reflect.DeepEqual(event.Data, events[i].Data) {
+			t.Errorf("event %d = %s, want %s with its original data", i, event.ID, events[i].ID)
+		}
+	}
+}
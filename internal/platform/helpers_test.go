@@ -0,0 +1,59 @@
+package platform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/logger"
+)
+
+// testSecret is a JWT secret long enough to pass validation
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{})                    {}
+func (nopLogger) Info(string, ...interface{})                     {}
+func (nopLogger) Warn(string, ...interface{})                     {}
+func (nopLogger) Error(string, ...interface{})                    {}
+func (nopLogger) Fatal(string, ...interface{})                    {}
+func (nopLogger) WithFields(map[string]interface{}) logger.Logger { return nopLogger{} }
+
+// testConfig returns a config with a signing secret, the admin and operator
+// roles, temporary storage directories and a peer endpoint on a loopback
+// port of the system's choosing
+func testConfig(t *testing.T) *PlatformConfig {
+	t.Helper()
+	return &PlatformConfig{
+		Name:    "test",
+		Version: "test",
+		Network: NetworkConfig{Host: "127.0.0.1"},
+		Security: SecurityConfig{
+			JWTSecret:   testSecret,
+			TokenExpiry: time.Hour,
+			Roles: map[string][]string{
+				"admin":    {"platform:admin", "plugins:start", "plugins:stop"},
+				"operator": {"plugins:start", "plugins:stop"},
+			},
+		},
+		Storage: StorageConfig{
+			UploadDir:   t.TempDir(),
+			DownloadDir: t.TempDir(),
+		},
+	}
+}
+
+// newTestPlatform creates a platform from testConfig after letting configure
+// adjust it
+func newTestPlatform(t *testing.T, configure func(*PlatformConfig)) *Platform {
+	t.Helper()
+	cfg := testConfig(t)
+	if configure != nil {
+		configure(cfg)
+	}
+	p, err := NewPlatform(cfg, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewPlatform: %v", err)
+	}
+	return p
+}
@@ -0,0 +1,266 @@
+package platform
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// Supported JWT signing algorithms
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmES256 = "ES256"
+)
+
+// jwtKeys holds the key material for the configured signing algorithm. For
+// HS256 only secret is set; for RS256/ES256 publicKey is always set and
+// privateKey is nil on nodes that only verify tokens.
+type jwtKeys struct {
+	alg        string
+	secret     []byte
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+	keyID      string
+}
+
+// loadJWTKeys loads the keys for config.JWTAlgorithm. The private key file
+// may be omitted on verify-only nodes; the public key is then required. If
+// only the private key is given, the public key is derived from it.
+func loadJWTKeys(config SecurityConfig, secret []byte) (*jwtKeys, error) {
+	alg := config.JWTAlgorithm
+	if alg == "" {
+		alg = JWTAlgorithmHS256
+	}
+
+	keys := &jwtKeys{alg: alg}
+	switch alg {
+	case JWTAlgorithmHS256:
+		keys.secret = secret
+		return keys, nil
+	case JWTAlgorithmRS256, JWTAlgorithmES256:
+	default:
+		return nil, fmt.Errorf("%w: unsupported JWT algorithm %q", core.ErrInvalidConfig, alg)
+	}
+
+	if config.JWTPrivateKeyFile != "" {
+		signer, err := readPrivateKey(expandHome(config.JWTPrivateKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT private key: %w", err)
+		}
+		keys.privateKey = signer
+		keys.publicKey = signer.Public()
+	}
+	if config.JWTPublicKeyFile != "" {
+		publicKey, err := readPublicKey(expandHome(config.JWTPublicKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT public key: %w", err)
+		}
+		keys.publicKey = publicKey
+	}
+	if keys.publicKey == nil {
+		return nil, fmt.Errorf("%w: %s requires jwtPrivateKeyFile or jwtPublicKeyFile", core.ErrInvalidConfig, alg)
+	}
+
+	if err := checkKeyType(alg, keys.publicKey); err != nil {
+		return nil, err
+	}
+	if keys.privateKey != nil {
+		// A private key from another pair would sign tokens that fail to
+		// verify, here and everywhere the public key is published
+		if err := checkKeyType(alg, keys.privateKey.Public()); err != nil {
+			return nil, err
+		}
+		public, ok := keys.privateKey.Public().(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !public.Equal(keys.publicKey) {
+			return nil, fmt.Errorf("%w: JWT private key doesn't match the public key", core.ErrInvalidConfig)
+		}
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(keys.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JWT public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	keys.keyID = base64.RawURLEncoding.EncodeToString(sum[:12])
+
+	return keys, nil
+}
+
+// checkKeyType returns an error unless key is a public key usable with alg:
+// RSA for RS256, P-256 ECDSA for ES256
+func checkKeyType(alg string, key crypto.PublicKey) error {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg != JWTAlgorithmRS256 {
+			return fmt.Errorf("%w: RSA key configured for %s", core.ErrInvalidConfig, alg)
+		}
+	case *ecdsa.PublicKey:
+		if alg != JWTAlgorithmES256 || key.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: ES256 requires a P-256 key", core.ErrInvalidConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key type %T for %s", core.ErrInvalidConfig, key, alg)
+	}
+	return nil
+}
+
+// sign returns the signature of signingInput
+func (k *jwtKeys) sign(signingInput string) ([]byte, error) {
+	if k.alg == JWTAlgorithmHS256 {
+		mac := hmac.New(sha256.New, k.secret)
+		_, _ = mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	}
+
+	if k.privateKey == nil {
+		return nil, fmt.Errorf("no private key configured for %s signing", k.alg)
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+
+	if k.alg == JWTAlgorithmRS256 {
+		return k.privateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+
+	// ES256 signatures are the fixed-size concatenation r || s
+	key, ok := k.privateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%T can't sign %s", k.privateKey, k.alg)
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig, nil
+}
+
+// verify checks sig over signingInput. Tokens must use exactly the configured
+// algorithm, so unknown algorithms and algorithm substitution are rejected.
+func (k *jwtKeys) verify(alg, signingInput string, sig []byte) bool {
+	if alg != k.alg {
+		return false
+	}
+
+	switch k.alg {
+	case JWTAlgorithmHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		_, _ = mac.Write([]byte(signingInput))
+		return hmac.Equal(sig, mac.Sum(nil))
+	case JWTAlgorithmRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(k.publicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], sig) == nil
+	case JWTAlgorithmES256:
+		if len(sig) != 64 {
+			return false
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k.publicKey.(*ecdsa.PublicKey), digest[:], r, s)
+	default:
+		return false
+	}
+}
+
+// JWKS returns the public verification key as a JSON Web Key Set. HS256
+// deployments have no public key, so the set is empty.
+func (k *jwtKeys) JWKS() map[string]interface{} {
+	keys := make([]map[string]interface{}, 0, 1)
+	enc := base64.RawURLEncoding
+
+	switch key := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		keys = append(keys, map[string]interface{}{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": k.alg,
+			"kid": k.keyID,
+			"n":   enc.EncodeToString(key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	case *ecdsa.PublicKey:
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		keys = append(keys, map[string]interface{}{
+			"kty": "EC",
+			"use": "sig",
+			"alg": k.alg,
+			"kid": k.keyID,
+			"crv": "P-256",
+			"x":   enc.EncodeToString(x),
+			"y":   enc.EncodeToString(y),
+		})
+	}
+
+	return map[string]interface{}{"keys": keys}
+}
+
+// readPrivateKey parses a PEM encoded PKCS#1, PKCS#8 or SEC 1 private key
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key format in %s", path)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// readPublicKey parses a PEM encoded PKIX or PKCS#1 public key
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported public key format in %s", path)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,229 @@
+package platform
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// writeKeyPair writes key's PKCS#8 private key and PKIX public key as PEM
+// files in dir, returning their paths
+func writeKeyPair(t *testing.T, dir, name string, key crypto.Signer) (private, public string) {
+	t.Helper()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	private = filepath.Join(dir, name+".key")
+	public = filepath.Join(dir, name+".pub")
+	if err := os.WriteFile(private, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return private, public
+}
+
+func TestLoadJWTKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherP256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	rsaPriv, rsaPub := writeKeyPair(t, dir, "rsa", rsaKey)
+	p256Priv, p256Pub := writeKeyPair(t, dir, "p256", p256Key)
+	_, otherP256Pub := writeKeyPair(t, dir, "other-p256", otherP256Key)
+	p384Priv, p384Pub := writeKeyPair(t, dir, "p384", p384Key)
+	edPriv, edPub := writeKeyPair(t, dir, "ed25519", edKey)
+
+	tests := []struct {
+		name            string
+		alg             string
+		private, public string
+		wantErr         bool
+		canSign         bool
+	}{
+		{"RS256 private key", JWTAlgorithmRS256, rsaPriv, "", false, true},
+		{"RS256 public key", JWTAlgorithmRS256, "", rsaPub, false, false},
+		{"RS256 key pair", JWTAlgorithmRS256, rsaPriv, rsaPub, false, true},
+		{"ES256 private key", JWTAlgorithmES256, p256Priv, "", false, true},
+		{"ES256 key pair", JWTAlgorithmES256, p256Priv, p256Pub, false, true},
+		{"ES256 public key", JWTAlgorithmES256, "", p256Pub, false, false},
+		{"ES256 with P-384 private key", JWTAlgorithmES256, p384Priv, "", true, false},
+		{"ES256 with P-384 public key", JWTAlgorithmES256, "", p384Pub, true, false},
+		{"ES256 with RSA key", JWTAlgorithmES256, rsaPriv, "", true, false},
+		{"RS256 with EC key", JWTAlgorithmRS256, p256Priv, "", true, false},
+		{"ES256 with Ed25519 key", JWTAlgorithmES256, edPriv, "", true, false},
+		{"ES256 with Ed25519 public key", JWTAlgorithmES256, "", edPub, true, false},
+		{"RSA private key, EC public key", JWTAlgorithmRS256, rsaPriv, p256Pub, true, false},
+		{"EC private key, RSA public key", JWTAlgorithmES256, p256Priv, rsaPub, true, false},
+		{"mismatched P-256 pair", JWTAlgorithmES256, p256Priv, otherP256Pub, true, false},
+		{"P-384 private key, P-256 public key", JWTAlgorithmES256, p384Priv, p256Pub, true, false},
+		{"no key", JWTAlgorithmES256, "", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := loadJWTKeys(SecurityConfig{
+				JWTAlgorithm:      tt.alg,
+				JWTPrivateKeyFile: tt.private,
+				JWTPublicKeyFile:  tt.public,
+			}, nil)
+			if tt.wantErr {
+				if !errors.Is(err, core.ErrInvalidConfig) {
+					t.Fatalf("loadJWTKeys error = %v, want ErrInvalidConfig", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadJWTKeys: %v", err)
+			}
+
+			sig, err := keys.sign("header.payload")
+			if !tt.canSign {
+				if err == nil {
+					t.Fatal("verify-only keys signed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			if !keys.verify(tt.alg, "header.payload", sig) {
+				t.Fatal("signature doesn't verify")
+			}
+			if keys.verify(tt.alg, "header.tampered", sig) {
+				t.Fatal("signature verifies other input")
+			}
+		})
+	}
+}
+
+// forgeToken re-signs token's payload under header alg with key as an HMAC
+// secret; an empty key leaves the signature empty
+func forgeToken(t *testing.T, token, alg string, key []byte) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", token)
+	}
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString([]byte(`{"alg":"`+alg+`","typ":"JWT"}`)) + "." + parts[1]
+	if len(key) == 0 {
+		return signingInput + "."
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestAsymmetricTokens(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaPriv, rsaPub := writeKeyPair(t, dir, "rsa", rsaKey)
+	p256Priv, p256Pub := writeKeyPair(t, dir, "p256", p256Key)
+
+	tests := []struct {
+		alg             string
+		private, public string
+		kty             string
+	}{
+		{JWTAlgorithmRS256, rsaPriv, rsaPub, "RSA"},
+		{JWTAlgorithmES256, p256Priv, p256Pub, "EC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			signer, err := NewSecurityManager(SecurityConfig{EnableAuth: true, TokenExpiry: time.Hour, JWTAlgorithm: tt.alg, JWTPrivateKeyFile: tt.private}, nopLogger{})
+			if err != nil {
+				t.Fatalf("signer: %v", err)
+			}
+			// Peers verify with only the public key
+			verifier, err := NewSecurityManager(SecurityConfig{EnableAuth: true, TokenExpiry: time.Hour, JWTAlgorithm: tt.alg, JWTPublicKeyFile: tt.public}, nopLogger{})
+			if err != nil {
+				t.Fatalf("verifier: %v", err)
+			}
+
+			token, err := signer.GenerateToken(&core.User{ID: "alice"})
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			if info, err := verifier.ValidateToken(context.Background(), token); err != nil || !info.Valid || info.UserID != "alice" {
+				t.Fatalf("verifier ValidateToken = %+v, %v", info, err)
+			}
+			if _, err := verifier.GenerateToken(&core.User{ID: "mallory"}); err == nil {
+				t.Error("verify-only manager minted a token")
+			}
+
+			// Tokens under any other alg are rejected, including HS256
+			// keyed with the published public key
+			publicPEM, err := os.ReadFile(tt.public)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, forged := range map[string]string{
+				"none":             forgeToken(t, token, "none", nil),
+				"HS256 public key": forgeToken(t, token, JWTAlgorithmHS256, publicPEM),
+				"unknown alg":      forgeToken(t, token, "XS512", publicPEM),
+			} {
+				if info, _ := verifier.ValidateToken(context.Background(), forged); info.Valid {
+					t.Errorf("%s token accepted", name)
+				}
+			}
+
+			jwks := verifier.(interface{ JWKS() map[string]interface{} }).JWKS()
+			keys, _ := jwks["keys"].([]map[string]interface{})
+			if len(keys) != 1 || keys[0]["kty"] != tt.kty || keys[0]["alg"] != tt.alg || keys[0]["kid"] == "" {
+				t.Errorf("JWKS = %v, want one %s key for %s", jwks, tt.kty, tt.alg)
+			}
+		})
+	}
+}
+
+func TestHS256TokensWithoutKeys(t *testing.T) {
+	security, err := NewSecurityManager(SecurityConfig{EnableAuth: true, TokenExpiry: time.Hour, JWTSecret: testSecret}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := security.GenerateToken(&core.User{ID: "alice"})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if info, err := security.ValidateToken(context.Background(), token); err != nil || !info.Valid {
+		t.Fatalf("ValidateToken = %+v, %v", info, err)
+	}
+	if info, _ := security.ValidateToken(context.Background(), forgeToken(t, token, JWTAlgorithmHS256, []byte("wrong secret"))); info.Valid {
+		t.Error("token signed with another secret accepted")
+	}
+	if info, _ := security.ValidateToken(context.Background(), forgeToken(t, token, "none", nil)); info.Valid {
+		t.Error("unsigned token accepted")
+	}
+	// There is no public key to publish
+	jwks := security.(interface{ JWKS() map[string]interface{} }).JWKS()
+	if keys, _ := jwks["keys"].([]map[string]interface{}); len(keys) != 0 {
+		t.Errorf("JWKS = %v, want no keys", jwks)
+	}
+}
@@ -0,0 +1,155 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultHistogramBuckets are the bucket upper bounds used when
+// MetricsConfig.HistogramBuckets is empty. They suit latencies in
+// milliseconds.
+var DefaultHistogramBuckets = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// histogramImpl counts observations into fixed buckets, so its memory does
+// not grow with the number of observations. counts[i] is the number of
+// values in (bounds[i-1], bounds[i]]; the last count is for values above
+// every bound.
+type histogramImpl struct {
+	mu     sync.RWMutex
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogramImpl {
+	return &histogramImpl{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// histogramBounds returns sorted, de-duplicated bucket bounds
+func histogramBounds(configured []float64) []float64 {
+	if len(configured) == 0 {
+		configured = DefaultHistogramBuckets
+	}
+	bounds := append([]float64(nil), configured...)
+	sort.Float64s(bounds)
+
+	unique := bounds[:0]
+	for i, bound := range bounds {
+		if math.IsNaN(bound) || math.IsInf(bound, 1) || (i > 0 && bound == bounds[i-1]) {
+			continue
+		}
+		unique = append(unique, bound)
+	}
+	return unique
+}
+
+func (h *histogramImpl) Observe(v float64) {
+	h.mu.Lock()
+	h.counts[sort.SearchFloat64s(h.bounds, v)]++
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+func (h *histogramImpl) Reset() {
+	h.mu.Lock()
+	h.counts = make([]uint64, len(h.bounds)+1)
+	h.count = 0
+	h.sum = 0
+	h.mu.Unlock()
+}
+
+// quantileLocked estimates the q-quantile by interpolating linearly within
+// the bucket it falls in. Values in the overflow bucket are reported as the
+// highest bound. The caller holds h.mu.
+func (h *histogramImpl) quantileLocked(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.count)
+	var seen uint64
+	for i, n := range h.counts {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		if i == len(h.bounds) {
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = h.bounds[i-1]
+		}
+		upper := h.bounds[i]
+		return lower + (upper-lower)*(rank-float64(seen))/float64(n)
+	}
+	if len(h.bounds) == 0 {
+		return h.sum / float64(h.count)
+	}
+	return h.bounds[len(h.bounds)-1]
+}
+
+// HistogramBucket is the cumulative count of observations at or below LE,
+// which is "+Inf" for the last bucket
+type HistogramBucket struct {
+	LE    string `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// HistogramSnapshot is a histogram as exported in JSON
+type HistogramSnapshot struct {
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum"`
+	P50     float64           `json:"p50"`
+	P90     float64           `json:"p90"`
+	P99     float64           `json:"p99"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+func (h *histogramImpl) snapshot() HistogramSnapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	snapshot := HistogramSnapshot{
+		Count:   h.count,
+		Sum:     h.sum,
+		P50:     h.quantileLocked(0.5),
+		P90:     h.quantileLocked(0.9),
+		P99:     h.quantileLocked(0.99),
+		Buckets: make([]HistogramBucket, 0, len(h.counts)),
+	}
+	var cumulative uint64
+	for i, n := range h.counts {
+		cumulative += n
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = strconv.FormatFloat(h.bounds[i], 'g', -1, 64)
+		}
+		snapshot.Buckets = append(snapshot.Buckets, HistogramBucket{LE: le, Count: cumulative})
+	}
+	return snapshot
+}
+
+// exportJSON renders the histogram for the JSON metrics export
+func (h *histogramImpl) exportJSON() string {
+	data, err := json.Marshal(h.snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// exportText renders the histogram for the plain text metrics export
+func (h *histogramImpl) exportText() string {
+	s := h.snapshot()
+	return fmt.Sprintf("count=%d sum=%v p50=%v p90=%v p99=%v", s.Count, s.Sum, s.P50, s.P90, s.P99)
+}
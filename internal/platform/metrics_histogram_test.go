@@ -0,0 +1,152 @@
+package platform
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestHistogramBounds(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured []float64
+		want       []float64
+	}{
+		{"default", nil, DefaultHistogramBuckets},
+		{"sorted", []float64{50, 5, 10}, []float64{5, 10, 50}},
+		{"duplicates", []float64{10, 5, 10, 5}, []float64{5, 10}},
+		{"NaN and +Inf dropped", []float64{math.Inf(1), 1, math.NaN(), 2}, []float64{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := histogramBounds(tt.configured); !slices.Equal(got, tt.want) {
+				t.Errorf("histogramBounds(%v) = %v, want %v", tt.configured, got, tt.want)
+			}
+		})
+	}
+
+	// The configured slice is left alone
+	configured := []float64{3, 1, 2}
+	histogramBounds(configured)
+	if !slices.Equal(configured, []float64{3, 1, 2}) {
+		t.Errorf("configured bounds changed to %v", configured)
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	h := newHistogram([]float64{10, 20, 50})
+	// 1 to 100 once each
+	for v := 1; v <= 100; v++ {
+		h.Observe(float64(v))
+	}
+
+	s := h.snapshot()
+	if s.Count != 100 || s.Sum != 5050 {
+		t.Errorf("count = %d, sum = %v, want 100 and 5050", s.Count, s.Sum)
+	}
+	want := []HistogramBucket{{"10", 10}, {"20", 20}, {"50", 50}, {"+Inf", 100}}
+	if !slices.Equal(s.Buckets, want) {
+		t.Errorf("buckets = %v, want %v", s.Buckets, want)
+	}
+
+	// Values on a bound count towards its bucket
+	h = newHistogram([]float64{1, 2})
+	h.Observe(1)
+	h.Observe(2)
+	h.Observe(2.5)
+	if got := h.snapshot().Buckets; !slices.Equal(got, []HistogramBucket{{"1", 1}, {"2", 2}, {"+Inf", 3}}) {
+		t.Errorf("buckets = %v", got)
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	decile := []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	tests := []struct {
+		name          string
+		bounds        []float64
+		values        []float64
+		p50, p90, p99 float64
+	}{
+		{"empty", decile, nil, 0, 0, 0},
+		{"uniform", decile, rangeValues(1, 100), 50, 90, 99},
+		{"one bucket", decile, []float64{41, 42, 43, 44}, 45, 49, 49.9},
+		{"skewed", decile, append(rangeValues(1, 10), 95), 5.5, 9.9, 98.9},
+		{"overflow", []float64{1, 2}, []float64{100, 200, 300}, 2, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHistogram(tt.bounds)
+			for _, v := range tt.values {
+				h.Observe(v)
+			}
+			s := h.snapshot()
+			for _, q := range []struct {
+				name      string
+				got, want float64
+			}{{"p50", s.P50, tt.p50}, {"p90", s.P90, tt.p90}, {"p99", s.P99, tt.p99}} {
+				if math.Abs(q.got-q.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", q.name, q.got, q.want)
+				}
+			}
+		})
+	}
+}
+
+// rangeValues returns from, from+1, ... to
+func rangeValues(from, to int) []float64 {
+	var values []float64
+	for v := from; v <= to; v++ {
+		values = append(values, float64(v))
+	}
+	return values
+}
+
+func TestHistogramReset(t *testing.T) {
+	h := newHistogram([]float64{10})
+	h.Observe(5)
+	h.Observe(50)
+	h.Reset()
+	s := h.snapshot()
+	if s.Count != 0 || s.Sum != 0 || s.P50 != 0 {
+		t.Errorf("after Reset: %+v", s)
+	}
+	for _, bucket := range s.Buckets {
+		if bucket.Count != 0 {
+			t.Errorf("bucket %s = %d after Reset", bucket.LE, bucket.Count)
+		}
+	}
+}
+
+func TestHistogramExport(t *testing.T) {
+	collector, err := NewMetricsCollector(MetricsConfig{Enabled: true, HistogramBuckets: []float64{100, 10}}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMetricsCollector: %v", err)
+	}
+	latency := collector.Histogram("latency_ms")
+	for _, v := range []float64{1, 5, 50, 500} {
+		latency.Observe(v)
+	}
+
+	data, err := collector.Export("json")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var exported struct {
+		Histograms map[string]HistogramSnapshot `json:"histograms"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	s, ok := exported.Histograms["latency_ms"]
+	if !ok {
+		t.Fatalf("latency_ms missing from %s", data)
+	}
+	want := []HistogramBucket{{"10", 2}, {"100", 3}, {"+Inf", 4}}
+	if s.Count != 4 || s.Sum != 556 || !slices.Equal(s.Buckets, want) {
+		t.Errorf("exported %+v, want count 4, sum 556 and buckets %v", s, want)
+	}
+	if s.P50 <= 0 || s.P50 > 10 {
+		t.Errorf("p50 = %v, want it within the first bucket", s.P50)
+	}
+}
@@ -0,0 +1,68 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// ErrMetricsDisabled is returned when exporting from or resetting a
+// disabled collector
+var ErrMetricsDisabled = errors.New("metrics disabled")
+
+// ErrMetricNotFound is returned when resetting a metric that doesn't exist
+var ErrMetricNotFound = errors.New("metric not found")
+
+// noopMetricsCollector is used when metrics are disabled. Every metric it
+// hands out discards updates, so recording costs next to nothing.
+type noopMetricsCollector struct{}
+
+// noopMetric implements all metric types as no-ops
+type noopMetric struct{}
+
+var noop = noopMetric{}
+
+func (noopMetricsCollector) Name() string                     { return "metrics" }
+func (noopMetricsCollector) Start(ctx context.Context) error  { return nil }
+func (noopMetricsCollector) Stop(ctx context.Context) error   { return nil }
+func (noopMetricsCollector) IsHealthy() bool                  { return true }
+func (noopMetricsCollector) Counter(name string) core.Counter { return noop }
+func (noopMetricsCollector) Gauge(name string) core.Gauge     { return noop }
+func (noopMetricsCollector) Histogram(name string) core.Histogram {
+	return noop
+}
+func (noopMetricsCollector) Timer(name string) core.Timer { return noop }
+func (noopMetricsCollector) CounterVec(name string, labels ...string) core.CounterVec {
+	return noop
+}
+func (noopMetricsCollector) Export(format string) ([]byte, error) {
+	return nil, ErrMetricsDisabled
+}
+func (noopMetricsCollector) Reset(names ...string) error {
+	return ErrMetricsDisabled
+}
+func (noopMetricsCollector) Configuration() core.ConfigSchema {
+	return core.ConfigSchema{Properties: map[string]core.PropertySchema{}}
+}
+func (noopMetricsCollector) Health() core.HealthStatus {
+	return core.HealthStatus{
+		Status:    core.HealthStatusHealthy,
+		Timestamp: time.Now(),
+		Details:   map[string]interface{}{"enabled": false},
+	}
+}
+
+func (noopMetric) Inc()                      {}
+func (noopMetric) Dec()                      {}
+func (noopMetric) Add(delta float64)         {}
+func (noopMetric) Sub(delta float64)         {}
+func (noopMetric) Set(value float64)         {}
+func (noopMetric) Get() float64              { return 0 }
+func (noopMetric) Observe(value float64)     {}
+func (noopMetric) Reset()                    {}
+func (noopMetric) Start() core.TimerInstance { return noop }
+func (noopMetric) Stop()                     {}
+
+func (noopMetric) With(labels map[string]string) core.Counter { return noop }
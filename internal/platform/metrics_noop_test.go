@@ -0,0 +1,44 @@
+package platform
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDisabledMetricsAreNoop(t *testing.T) {
+	collector, err := NewMetricsCollector(MetricsConfig{Enabled: false}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMetricsCollector: %v", err)
+	}
+	if _, ok := collector.(noopMetricsCollector); !ok {
+		t.Fatalf("collector = %T, want the no-op collector", collector)
+	}
+
+	counter := collector.Counter("requests")
+	counter.Inc()
+	counter.Add(5)
+	gauge := collector.Gauge("connections")
+	gauge.Set(3)
+	collector.Histogram("latency").Observe(12)
+	collector.Timer("handle").Start().Stop()
+	collector.CounterVec("requests_by_path", "path").With(map[string]string{"path": "/"}).Inc()
+	if counter.Get() != 0 || gauge.Get() != 0 {
+		t.Errorf("counter = %v, gauge = %v, want nothing recorded", counter.Get(), gauge.Get())
+	}
+
+	if _, err := collector.Export("json"); !errors.Is(err, ErrMetricsDisabled) {
+		t.Errorf("Export error = %v, want %v", err, ErrMetricsDisabled)
+	}
+	if err := collector.Reset(); !errors.Is(err, ErrMetricsDisabled) {
+		t.Errorf("Reset error = %v, want %v", err, ErrMetricsDisabled)
+	}
+}
+
+func TestMetricsEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		p := newTestPlatform(t, func(cfg *PlatformConfig) { cfg.Metrics.Enabled = enabled })
+		if got := p.MetricsEnabled(); got != enabled {
+			t.Errorf("MetricsEnabled with Enabled %v = %v", enabled, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package platform
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestMetricsReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		reset   []string
+		wantErr error
+		// zeroed lists the metrics expected to read zero afterwards
+		zeroed map[string]bool
+	}{
+		{"everything", nil, nil, map[string]bool{"requests": true, "requests_by_path": true, "connections": true, "latency_ms": true}},
+		{"one counter", []string{"requests"}, nil, map[string]bool{"requests": true}},
+		{"counter vector", []string{"requests_by_path"}, nil, map[string]bool{"requests_by_path": true}},
+		{"gauge and histogram", []string{"connections", "latency_ms"}, nil, map[string]bool{"connections": true, "latency_ms": true}},
+		{"unknown metric", []string{"nope"}, ErrMetricNotFound, map[string]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector, err := NewMetricsCollector(MetricsConfig{Enabled: true}, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewMetricsCollector: %v", err)
+			}
+			counter := collector.Counter("requests")
+			byPath := collector.CounterVec("requests_by_path", "path").With(map[string]string{"path": "/"})
+			gauge := collector.Gauge("connections")
+			histogram := collector.Histogram("latency_ms")
+			counter.Add(3)
+			byPath.Add(2)
+			gauge.Set(5)
+			histogram.Observe(12)
+
+			if err := collector.Reset(tt.reset...); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reset(%v) = %v, want %v", tt.reset, err, tt.wantErr)
+			}
+			values := map[string]float64{
+				"requests":         counter.Get(),
+				"requests_by_path": byPath.Get(),
+				"connections":      gauge.Get(),
+				"latency_ms":       float64(collector.(*metricsCollectorImpl).histograms["latency_ms"].snapshot().Count),
+			}
+			for name, value := range values {
+				if zeroed := value == 0; zeroed != tt.zeroed[name] {
+					t.Errorf("%s = %v after Reset(%v), want zeroed = %v", name, value, tt.reset, tt.zeroed[name])
+				}
+			}
+
+			// Metrics handed out before the reset keep counting
+			counter.Inc()
+			if got := collector.Counter("requests").Get(); got != counter.Get() {
+				t.Errorf("requests = %v through the collector, %v through the old handle", got, counter.Get())
+			}
+		})
+	}
+}
+
+func TestMetricsResetConcurrent(t *testing.T) {
+	collector, err := NewMetricsCollector(MetricsConfig{Enabled: true}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMetricsCollector: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				collector.Counter("requests").Inc()
+				collector.Histogram("latency_ms").Observe(float64(j))
+				collector.CounterVec("requests_by_path", "path").With(map[string]string{"path": "/"}).Inc()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				collector.Reset()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := collector.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if got := collector.Counter("requests").Get(); got != 0 {
+		t.Errorf("requests = %v after the final Reset", got)
+	}
+}
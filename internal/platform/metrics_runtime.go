@@ -0,0 +1,46 @@
+package platform
+
+import (
+	"runtime"
+	"time"
+)
+
+// DefaultMetricsInterval is how often runtime metrics are sampled when
+// MetricsConfig.Interval is not set
+const DefaultMetricsInterval = 15 * time.Second
+
+// sampleRuntime records the process's goroutine count, memory use and last
+// GC pause as gauges
+func (m *metricsCollectorImpl) sampleRuntime() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	m.Gauge("go_goroutines").Set(float64(runtime.NumGoroutine()))
+	m.Gauge("go_heap_alloc_bytes").Set(float64(stats.HeapAlloc))
+	m.Gauge("go_heap_inuse_bytes").Set(float64(stats.HeapInuse))
+	m.Gauge("go_sys_bytes").Set(float64(stats.Sys))
+	m.Gauge("go_gc_count").Set(float64(stats.NumGC))
+	// PauseNs is a ring buffer; the latest pause is the one before NumGC
+	var pause uint64
+	if stats.NumGC > 0 {
+		pause = stats.PauseNs[(stats.NumGC+255)%256]
+	}
+	m.Gauge("go_gc_pause_seconds").Set(time.Duration(pause).Seconds())
+}
+
+// runSampler samples runtime metrics every interval until stop is closed
+func (m *metricsCollectorImpl) runSampler(interval time.Duration, stop <-chan struct{}) {
+	m.sampleRuntime()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sampleRuntime()
+		case <-stop:
+			return
+		}
+	}
+}
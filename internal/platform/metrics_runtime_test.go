@@ -0,0 +1,76 @@
+package platform
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRuntimeMetricsSampler(t *testing.T) {
+	const interval = 10 * time.Millisecond
+	collector, err := NewMetricsCollector(MetricsConfig{Enabled: true, Interval: interval}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMetricsCollector: %v", err)
+	}
+	// So there is a GC pause to report
+	runtime.GC()
+	if err := collector.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { collector.Stop(context.Background()) })
+
+	gauges := []string{"go_goroutines", "go_heap_alloc_bytes", "go_heap_inuse_bytes", "go_sys_bytes", "go_gc_count", "go_gc_pause_seconds"}
+	// waitForGauges waits for every gauge to have been set to a non-zero value
+	waitForGauges := func(t *testing.T) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for _, name := range gauges {
+			for collector.Gauge(name).Get() <= 0 {
+				if time.Now().After(deadline) {
+					t.Fatalf("gauge %s = %v, want it sampled", name, collector.Gauge(name).Get())
+				}
+				time.Sleep(interval)
+			}
+		}
+	}
+	waitForGauges(t)
+
+	// Samples keep coming every interval
+	for _, name := range gauges {
+		collector.Gauge(name).Set(0)
+	}
+	waitForGauges(t)
+
+	// And stop with the collector
+	if err := collector.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	time.Sleep(2 * interval)
+	collector.Gauge("go_goroutines").Set(0)
+	time.Sleep(5 * interval)
+	if got := collector.Gauge("go_goroutines").Get(); got != 0 {
+		t.Errorf("go_goroutines sampled as %v after Stop", got)
+	}
+}
+
+func TestRuntimeMetricsDefaultInterval(t *testing.T) {
+	collector, err := NewMetricsCollector(MetricsConfig{Enabled: true}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMetricsCollector: %v", err)
+	}
+	if err := collector.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer collector.Stop(context.Background())
+
+	// The first sample is taken straight away rather than after
+	// DefaultMetricsInterval
+	deadline := time.Now().Add(5 * time.Second)
+	for collector.Gauge("go_goroutines").Get() <= 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("runtime metrics not sampled on Start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
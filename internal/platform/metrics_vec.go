@@ -0,0 +1,113 @@
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// counterVecImpl holds one counter per combination of label values, keyed
+// by the values joined in label order
+type counterVecImpl struct {
+	mu     sync.RWMutex
+	labels []string
+	series map[string]*labeledCounter
+}
+
+// labeledCounter is one series of a counter vector
+type labeledCounter struct {
+	counterImpl
+	values []string
+}
+
+// labelSeparator joins label values into a series key. It can't appear in
+// valid UTF-8, so distinct value lists never share a key.
+const labelSeparator = "\xff"
+
+func newCounterVec(labels []string) *counterVecImpl {
+	return &counterVecImpl{
+		labels: append([]string(nil), labels...),
+		series: make(map[string]*labeledCounter),
+	}
+}
+
+func (v *counterVecImpl) With(labels map[string]string) core.Counter {
+	values := make([]string, len(v.labels))
+	for i, label := range v.labels {
+		values[i] = labels[label]
+	}
+	key := strings.Join(values, labelSeparator)
+
+	v.mu.RLock()
+	c, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.series[key]; ok {
+		return c
+	}
+	c = &labeledCounter{values: values}
+	v.series[key] = c
+	return c
+}
+
+// reset zeroes every series. Series are kept, since callers may hold them.
+func (v *counterVecImpl) reset() {
+	v.mu.RLock()
+	for _, c := range v.series {
+		c.reset()
+	}
+	v.mu.RUnlock()
+}
+
+// CounterSeries is one labeled series of a counter vector as exported in
+// JSON
+type CounterSeries struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// snapshot returns every series, ordered by label values
+func (v *counterVecImpl) snapshot() []CounterSeries {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	series := make([]CounterSeries, 0, len(keys))
+	for _, key := range keys {
+		c := v.series[key]
+		labels := make(map[string]string, len(v.labels))
+		for i, label := range v.labels {
+			labels[label] = c.values[i]
+		}
+		series = append(series, CounterSeries{Labels: labels, Value: c.Get()})
+	}
+	v.mu.RUnlock()
+	return series
+}
+
+// FormatLabels renders labels in Prometheus form, such as
+// {method="GET",status="200"}, with names in sorted order
+func FormatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,146 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/network"
+)
+
+// PeerMessageType is the type of the messages SendMessage and
+// BroadcastMessage send
+const PeerMessageType = "message"
+
+// networkManager adapts network.NetworkManager, which takes contexts and
+// structured messages, to core.NetworkManager. Calls without a context are
+// bounded by the configured network timeout.
+type networkManager struct {
+	*network.NetworkManager
+	config NetworkConfig
+}
+
+// NewNetworkManager creates the network manager the platform runs with,
+// backed by the network package and honouring config
+func NewNetworkManager(config NetworkConfig, security core.SecurityManager, eventBus core.EventBus, logger core.Logger) (core.NetworkManager, error) {
+	nm, err := newNetworkManager(config, security, eventBus, logger, nil)
+	if err != nil {
+		return nil, err
+	}
+	return nm, nil
+}
+
+// newNetworkManager is NewNetworkManager with the manager's background
+// goroutines run within workers, if set
+func newNetworkManager(config NetworkConfig, security core.SecurityManager, eventBus core.EventBus, logger core.Logger, workers *core.WorkerPool) (*networkManager, error) {
+	nm, err := network.NewNetworkManager(config, security, eventBus, logger)
+	if err != nil {
+		return nil, err
+	}
+	if workers != nil {
+		nm.UseWorkerPool(workers)
+	}
+	return &networkManager{NetworkManager: nm, config: config}, nil
+}
+
+func (n *networkManager) Name() string { return "network" }
+
+func (n *networkManager) IsHealthy() bool { return true }
+
+func (n *networkManager) Health() core.HealthStatus {
+	return core.HealthStatus{Status: core.HealthStatusHealthy, Timestamp: time.Now()}
+}
+
+func (n *networkManager) Configuration() core.ConfigSchema {
+	return core.ConfigSchema{Properties: map[string]core.PropertySchema{}}
+}
+
+// context returns a context bounded by the network timeout, if one is set
+func (n *networkManager) context() (context.Context, context.CancelFunc) {
+	if n.config.Timeout > 0 {
+		return context.WithTimeout(context.Background(), n.config.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// DiscoverPeers runs a discovery round; it waits at most the discovery
+// timeout for answers
+func (n *networkManager) DiscoverPeers() ([]core.Peer, error) {
+	return n.NetworkManager.DiscoverPeers(context.Background())
+}
+
+func (n *networkManager) ListPeers() []core.Peer { return n.GetPeers() }
+
+// ConnectToPeer registers the peer at address, "host" or "host:port", with
+// the network port assumed when none is given
+func (n *networkManager) ConnectToPeer(address string) (core.Peer, error) {
+	host, port := address, n.config.Port
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		if port, err = strconv.Atoi(p); err != nil {
+			return core.Peer{}, fmt.Errorf("%w: invalid peer port %q", core.ErrInvalidRequest, p)
+		}
+		host = h
+	}
+	if host == "" {
+		return core.Peer{}, fmt.Errorf("%w: peer address is empty", core.ErrInvalidRequest)
+	}
+
+	peer := core.Peer{
+		ID:       "peer-" + core.NewID(),
+		Address:  host,
+		Port:     port,
+		Name:     address,
+		Status:   "connected",
+		Metadata: map[string]interface{}{},
+	}
+	if err := n.RegisterPeer(peer); err != nil {
+		return core.Peer{}, err
+	}
+	for _, registered := range n.GetPeers() {
+		if registered.ID == peer.ID {
+			return registered, nil
+		}
+	}
+	return peer, nil
+}
+
+// SendMessage sends message, a JSON object, to the peer as the data of a
+// PeerMessageType message
+func (n *networkManager) SendMessage(peerID string, message []byte) error {
+	msg, err := peerMessage(message)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := n.context()
+	defer cancel()
+	return n.NetworkManager.SendMessage(ctx, peerID, msg)
+}
+
+// BroadcastMessage is SendMessage to every known peer
+func (n *networkManager) BroadcastMessage(message []byte) error {
+	msg, err := peerMessage(message)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := n.context()
+	defer cancel()
+	return n.NetworkManager.BroadcastMessage(ctx, msg)
+}
+
+// peerMessage wraps data, a JSON object, in a message
+func peerMessage(data []byte) (core.Message, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return core.Message{}, fmt.Errorf("%w: peer messages must be JSON objects: %v", core.ErrInvalidRequest, err)
+	}
+	return core.Message{
+		ID:        core.NewID(),
+		Type:      PeerMessageType,
+		Timestamp: time.Now().Unix(),
+		Data:      fields,
+	}, nil
+}
@@ -0,0 +1,276 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/network"
+)
+
+func TestNetworkManagerConnectToPeer(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		wantAddr string
+		wantPort int
+		wantErr  error
+	}{
+		{"host and port", "192.0.2.10:9000", "192.0.2.10", 9000, nil},
+		{"host only", "192.0.2.11", "192.0.2.11", 8080, nil},
+		{"ipv6", "[2001:db8::1]:9001", "2001:db8::1", 9001, nil},
+		{"bad port", "192.0.2.12:http", "", 0, core.ErrInvalidRequest},
+		{"empty", "", "", 0, core.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nm, err := NewNetworkManager(NetworkConfig{Port: 8080}, nil, nil, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewNetworkManager: %v", err)
+			}
+			peer, err := nm.ConnectToPeer(tt.address)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if peer.Address != tt.wantAddr || peer.Port != tt.wantPort {
+				t.Errorf("peer at %s:%d, want %s:%d", peer.Address, peer.Port, tt.wantAddr, tt.wantPort)
+			}
+			if peers := nm.ListPeers(); len(peers) != 1 || peers[0].ID != peer.ID {
+				t.Errorf("ListPeers = %+v, want the connected peer", peers)
+			}
+		})
+	}
+}
+
+func TestNetworkManagerHonoursConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxPeers int
+		connect  int
+		want     int
+	}{
+		{"unlimited", 0, 3, 3},
+		{"below limit", 5, 3, 3},
+		{"at limit", 2, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newTestBus(t, EventsConfig{})
+			joined := make(chan core.Event, tt.connect)
+			bus.Subscribe(core.EventPeerJoined, func(e core.Event) error {
+				joined <- e
+				return nil
+			})
+			nm, err := NewNetworkManager(NetworkConfig{Port: 8080, MaxPeers: tt.maxPeers}, nil, bus, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewNetworkManager: %v", err)
+			}
+
+			for i := 0; i < tt.connect; i++ {
+				_, err := nm.ConnectToPeer("192.0.2.1")
+				if i < tt.want && err != nil {
+					t.Fatalf("ConnectToPeer %d: %v", i, err)
+				}
+				if i >= tt.want && !errors.Is(err, network.ErrPeerLimitReached) {
+					t.Fatalf("ConnectToPeer %d: got error %v, want %v", i, err, network.ErrPeerLimitReached)
+				}
+			}
+			if got := len(nm.GetPeers()); got != tt.want {
+				t.Errorf("%d peers, want %d", got, tt.want)
+			}
+			for i := 0; i < tt.want; i++ {
+				if e := <-joined; e.Data["id"] == "" {
+					t.Errorf("peer joined event without an id: %+v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkManagerMessages(t *testing.T) {
+	nm, err := NewNetworkManager(NetworkConfig{}, nil, nil, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewNetworkManager: %v", err)
+	}
+	peers, err := nm.DiscoverPeers()
+	if err != nil || len(peers) != 0 {
+		t.Errorf("DiscoverPeers with discovery disabled = %v, %v", peers, err)
+	}
+
+	tests := []struct {
+		name    string
+		peerID  string
+		message string
+		wantErr error
+	}{
+		{"not JSON", "peer-1", "hello", core.ErrInvalidRequest},
+		{"not an object", "peer-1", `["a"]`, core.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		if err := nm.SendMessage(tt.peerID, []byte(tt.message)); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: SendMessage got error %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if err := nm.SendMessage("missing", []byte(`{"action":"sync"}`)); err == nil {
+		t.Error("SendMessage to an unknown peer succeeded")
+	}
+}
+
+func TestPlatformRunsNetworkManager(t *testing.T) {
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Network = NetworkConfig{Host: "127.0.0.1", Port: 7200, Timeout: time.Second}
+	})
+	joined := make(chan core.Event, 1)
+	if _, err := p.eventBus.Subscribe(core.EventPeerJoined, func(e core.Event) error {
+		joined <- e
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { p.Stop(context.Background()) })
+
+	// Both accessors hand out the network package's manager
+	nm, ok := p.GetNetworkManager().(*networkManager)
+	if !ok || nm.NetworkManager == nil {
+		t.Fatalf("platform runs %T, want the network package's manager", p.GetNetworkManager())
+	}
+	if p.NetworkManager() != core.NetworkManager(nm) {
+		t.Error("NetworkManager and GetNetworkManager return different managers")
+	}
+	if !nm.IsHealthy() || nm.Health().Status != core.HealthStatusHealthy {
+		t.Errorf("network manager health = %+v", nm.Health())
+	}
+
+	peer, err := nm.ConnectToPeer("192.0.2.20")
+	if err != nil {
+		t.Fatalf("ConnectToPeer: %v", err)
+	}
+	if peer.Port != 7200 {
+		t.Errorf("peer port = %d, want the configured 7200", peer.Port)
+	}
+	if peers := nm.ListPeers(); len(peers) != 1 || peers[0].ID != peer.ID {
+		t.Errorf("ListPeers = %+v, want the connected peer", peers)
+	}
+	// Peer events reach the platform's event bus
+	select {
+	case e := <-joined:
+		if e.Data["id"] != peer.ID || e.Source != "network" {
+			t.Errorf("peer joined event = %+v, want one from the network for %s", e, peer.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no peer joined event on the platform's bus")
+	}
+
+	if err := nm.SendMessage(peer.ID, []byte("hello")); !errors.Is(err, core.ErrInvalidRequest) {
+		t.Errorf("SendMessage with a non-JSON message = %v, want %v", err, core.ErrInvalidRequest)
+	}
+}
+
+func TestPlatformConfigReachesManagers(t *testing.T) {
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Network = NetworkConfig{Port: 7100, MaxPeers: 1, Timeout: time.Second, Capabilities: []string{"clipboard"}}
+		cfg.Security.Roles["viewer"] = []string{"resources:read"}
+	})
+
+	// The network package takes the very same struct, with no conversion
+	var networkConfig network.NetworkConfig = p.Config().Network
+	nm := p.NetworkManager().(*networkManager)
+	if !reflect.DeepEqual(nm.config, networkConfig) {
+		t.Errorf("network manager config = %+v, want %+v", nm.config, networkConfig)
+	}
+	// and honours it: MaxPeers caps the peers it keeps
+	for _, address := range []string{"192.0.2.1", "192.0.2.2"} {
+		nm.ConnectToPeer(address)
+	}
+	if peers := nm.ListPeers(); len(peers) != 1 || peers[0].Port != 7100 {
+		t.Errorf("peers = %+v, want one on the configured port", peers)
+	}
+
+	// The security manager expands the configured roles
+	token, err := p.SecurityManager().GenerateToken(&core.User{ID: "v", Roles: []string{"viewer"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := p.SecurityManager().ValidateToken(context.Background(), token)
+	if err != nil || !info.Valid || !slices.Equal(info.Permissions, []string{"resources:read"}) {
+		t.Errorf("ValidateToken = %+v, %v, want the viewer role's permissions", info, err)
+	}
+}
+
+// freePorts returns a loopback TCP port and a UDP port nothing is listening on
+func freePorts(t *testing.T) (tcpPort, udpPort int) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return l.Addr().(*net.TCPAddr).Port, conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestPlatformStopReleasesNetwork(t *testing.T) {
+	peerPort, discoveryPort := freePorts(t)
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Network = NetworkConfig{
+			Host:                 "127.0.0.1",
+			Port:                 peerPort,
+			EnableDiscovery:      true,
+			DiscoveryBindAddress: "127.0.0.1",
+			DiscoveryPort:        discoveryPort,
+			DiscoveryTimeout:     10 * time.Millisecond,
+			KeepAliveInterval:    time.Minute,
+		}
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// Start serves the peer endpoint and discovery holds its socket
+	peerAddr := net.JoinHostPort("127.0.0.1", strconv.Itoa(peerPort))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", peerAddr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("peer endpoint not served after Start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	discoveryAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: discoveryPort}
+	if conn, err := net.ListenUDP("udp", discoveryAddr); err == nil {
+		conn.Close()
+		t.Fatal("discovery socket not bound after Start")
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if _, err := net.Dial("tcp", peerAddr); err == nil {
+		t.Error("peer endpoint still served after Stop")
+	}
+	conn, err := net.ListenUDP("udp", discoveryAddr)
+	if err != nil {
+		t.Fatalf("discovery port still in use after Stop: %v", err)
+	}
+	conn.Close()
+}
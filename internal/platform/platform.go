@@ -3,13 +3,19 @@ package platform
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nathfavour/noplacelike.go/internal/core"
@@ -17,9 +23,16 @@ import (
 
 // Platform represents the main NoPlaceLike platform instance
 type Platform struct {
-	mu     sync.RWMutex
-	ctx    context.Context
-	cancel context.CancelFunc
+	mu       sync.RWMutex
+	reloadMu sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	// Configuration
+	config       *PlatformConfig
+	configLoader func() (*PlatformConfig, error)
+	configSaver  func(*PlatformConfig) error
+	configFile   configFileState
 
 	// Core managers
 	serviceManager  core.ServiceManager
@@ -30,13 +43,26 @@ type Platform struct {
 	eventBus        core.EventBus
 	metrics         core.MetricsCollector
 	logger          core.Logger
+	audit           *auditLog
+
+	// Background goroutines that Stop waits for
+	workers *core.WorkerPool
 
 	// Plugin system
 	plugins    map[string]core.Plugin
 	pluginDeps map[string][]string
-
-	// Platform state
+	// Optional plugins that failed to load, by name
+	failedPlugins map[string]string
+	// Lifecycle state of each loaded plugin
+	pluginStates map[string]PluginStatus
+	// Event types each plugin subscribed to, guarded by subsMu since plugins
+	// subscribe from Start while p.mu is held
+	pluginSubs map[string]map[uint64]string
+	subsMu     sync.Mutex
+
+	// Platform state. stopping is set while shutdown runs without p.mu.
 	started   bool
+	stopping  bool
 	startTime time.Time
 	version   string
 	buildInfo BuildInfo
@@ -74,38 +100,43 @@ type PlatformConfig struct {
 
 	// Metrics settings
 	Metrics MetricsConfig `json:"metrics"`
+
+	// Event bus settings
+	Events EventsConfig `json:"events"`
+
+	// Storage settings
+	Storage StorageConfig `json:"storage"`
 }
 
 // NetworkConfig contains network-related settings
-type NetworkConfig struct {
-	Host              string        `json:"host"`
-	Port              int           `json:"port"`
-	EnableDiscovery   bool          `json:"enableDiscovery"`
-	DiscoveryPort     int           `json:"discoveryPort"`
-	DiscoveryInterval time.Duration `json:"discoveryInterval"`
-	MaxPeers          int           `json:"maxPeers"`
-	Timeout           time.Duration `json:"timeout"`
-	KeepAliveInterval time.Duration `json:"keepAliveInterval"`
-	EnableTLS         bool          `json:"enableTLS"`
-	TLSCertFile       string        `json:"tlsCertFile"`
-	TLSKeyFile        string        `json:"tlsKeyFile"`
-}
+type NetworkConfig = core.NetworkConfig
 
 // SecurityConfig contains security-related settings
-type SecurityConfig struct {
-	EnableAuth       bool          `json:"enableAuth"`
-	AuthMethod       string        `json:"authMethod"`
-	TokenExpiry      time.Duration `json:"tokenExpiry"`
-	EnableEncryption bool          `json:"enableEncryption"`
-	EncryptionAlgo   string        `json:"encryptionAlgo"`
-	MaxLoginAttempts int           `json:"maxLoginAttempts"`
-	LockoutDuration  time.Duration `json:"lockoutDuration"`
-	AllowedPeers     []string      `json:"allowedPeers"`
-	BlockedPeers     []string      `json:"blockedPeers"`
-	// JWT settings (HS256)
-	JWTSecret   string   `json:"jwtSecret"`
-	JWTIssuer   string   `json:"jwtIssuer"`
-	JWTAudience []string `json:"jwtAudience"`
+type SecurityConfig = core.SecurityConfig
+
+// MinJWTSecretLength is the minimum HS256 secret length accepted. It applies
+// whether or not auth is enabled, since admin routes check tokens regardless.
+const MinJWTSecretLength = 32
+
+// defaultJWTSecrets are placeholder secrets shipped in sample configs, which
+// anyone could use to mint tokens
+var defaultJWTSecrets = map[string]bool{
+	"change-me": true,
+	"changeme":  true,
+	"secret":    true,
+}
+
+// checkJWTSecret reports why secret can't sign HS256 tokens, or nil
+func checkJWTSecret(secret string) error {
+	switch {
+	case secret == "":
+		return fmt.Errorf("JWTSecret empty")
+	case defaultJWTSecrets[strings.ToLower(secret)]:
+		return fmt.Errorf("JWTSecret is the default %q", secret)
+	case len(secret) < MinJWTSecretLength:
+		return fmt.Errorf("JWTSecret must be at least %d bytes, got %d", MinJWTSecretLength, len(secret))
+	}
+	return nil
 }
 
 // PerformanceConfig contains performance-related settings
@@ -122,13 +153,7 @@ type PerformanceConfig struct {
 }
 
 // PluginsConfig contains plugin-related settings
-type PluginsConfig struct {
-	EnablePlugins bool     `json:"enablePlugins"`
-	PluginDirs    []string `json:"pluginDirs"`
-	AutoLoad      []string `json:"autoLoad"`
-	Disabled      []string `json:"disabled"`
-	Sandbox       bool     `json:"sandbox"`
-}
+type PluginsConfig = core.PluginsConfig
 
 // LoggingConfig contains logging-related settings
 type LoggingConfig struct {
@@ -149,6 +174,24 @@ type MetricsConfig struct {
 	RetentionTime   time.Duration `json:"retentionTime"`
 	ExportFormat    string        `json:"exportFormat"`
 	EnableProfiling bool          `json:"enableProfiling"`
+	// RequireAuth protects the metrics endpoint with a token; Permission,
+	// if set, is additionally required
+	RequireAuth bool   `json:"requireAuth"`
+	Permission  string `json:"permission"`
+	// HistogramBuckets are the bucket upper bounds for histograms,
+	// DefaultHistogramBuckets if empty
+	HistogramBuckets []float64 `json:"histogramBuckets"`
+}
+
+// DefaultMetricsEndpoint is where metrics are served when
+// MetricsConfig.Endpoint is empty
+const DefaultMetricsEndpoint = "/api/platform/metrics"
+
+// StorageConfig contains filesystem-related settings
+type StorageConfig struct {
+	UploadDir    string   `json:"uploadDir"`
+	DownloadDir  string   `json:"downloadDir"`
+	AllowedPaths []string `json:"allowedPaths"`
 }
 
 // NewPlatform creates a new platform instance
@@ -158,33 +201,41 @@ func NewPlatform(config *PlatformConfig, logger core.Logger) (*Platform, error)
 	p := &Platform{
 		ctx:        ctx,
 		cancel:     cancel,
+		workers:    core.NewWorkerPool(ctx),
+		config:     config,
 		plugins:    make(map[string]core.Plugin),
 		pluginDeps: make(map[string][]string),
 		version:    config.Version,
 		buildInfo:  getBuildInfo(),
 		logger:     logger,
+
+		failedPlugins: make(map[string]string),
+		pluginStates:  make(map[string]PluginStatus),
+		pluginSubs:    make(map[string]map[uint64]string),
 	}
 
 	// Initialize core managers (implementations would be in separate files)
 	var err error
 
-	if p.configManager, err = NewConfigManager(config); err != nil {
+	if p.configManager, err = NewConfigManager(p); err != nil {
 		return nil, fmt.Errorf("failed to initialize config manager: %w", err)
 	}
 
-	if p.eventBus, err = NewEventBus(p.logger); err != nil {
-		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
-	}
-
 	if p.metrics, err = NewMetricsCollector(config.Metrics, p.logger); err != nil {
 		return nil, fmt.Errorf("failed to initialize metrics collector: %w", err)
 	}
 
+	if p.eventBus, err = NewEventBus(config.Events, p.metrics, p.logger); err != nil {
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
 	if p.securityManager, err = NewSecurityManager(config.Security, p.logger); err != nil {
 		return nil, fmt.Errorf("failed to initialize security manager: %w", err)
 	}
 
-	if p.networkManager, err = NewNetworkManager(config.Network, p.securityManager, p.eventBus, p.logger); err != nil {
+	// Discovery and keep-alive run in the platform's pool, so Stop waits
+	// for them
+	if p.networkManager, err = newNetworkManager(config.Network, p.securityManager, p.eventBus, p.logger, p.workers); err != nil {
 		return nil, fmt.Errorf("failed to initialize network manager: %w", err)
 	}
 
@@ -196,6 +247,22 @@ func NewPlatform(config *PlatformConfig, logger core.Logger) (*Platform, error)
 		return nil, fmt.Errorf("failed to initialize service manager: %w", err)
 	}
 
+	if config.Security.EnableAuditLog {
+		if p.audit, err = newAuditLog(config.Security.AuditLogFile, p.logger); err != nil {
+			return nil, fmt.Errorf("failed to initialize audit log: %w", err)
+		}
+		// The audit trail must be complete, so it is never dropped from
+		subscribe := p.eventBus.Subscribe
+		if lossless, ok := p.eventBus.(LosslessSubscriber); ok {
+			subscribe = lossless.SubscribeLossless
+		}
+		for _, eventType := range AuditEventTypes {
+			if _, err := subscribe(eventType, p.audit.Record); err != nil {
+				return nil, fmt.Errorf("failed to subscribe audit log: %w", err)
+			}
+		}
+	}
+
 	return p, nil
 }
 
@@ -205,15 +272,28 @@ func (p *Platform) Start(ctx context.Context) error {
 	p.mu.Lock()
 	if p.started {
 		p.mu.Unlock()
-		return fmt.Errorf("platform already started")
+		return core.ErrAlreadyRunning
+	}
+	if p.stopping {
+		p.mu.Unlock()
+		return fmt.Errorf("%w: platform is stopping", core.ErrInvalidState)
 	}
 	p.mu.Unlock()
 
+	// Verify preconditions before starting anything
+	if err := p.SelfCheck(); err != nil {
+		return fmt.Errorf("startup self-check failed: %w", err)
+	}
+
 	p.logger.Info("Starting NoPlaceLike platform",
 		core.Field{Key: "version", Value: p.version},
 		core.Field{Key: "buildTime", Value: p.buildInfo.BuildTime},
 	)
 
+	if err := p.eventBus.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start event bus: %w", err)
+	}
+
 	// Start core services (may call back into platform with read locks)
 	if err := p.serviceManager.StartAll(ctx); err != nil {
 		return fmt.Errorf("failed to start services: %w", err)
@@ -232,13 +312,17 @@ func (p *Platform) Start(ctx context.Context) error {
 	p.mu.Unlock()
 
 	// Start any preloaded plugins
+	timeout := p.pluginTimeout()
 	for name, plugin := range preloaded {
-		if err := plugin.Start(ctx); err != nil {
+		if err := callPlugin(ctx, name, "start", timeout, plugin.Start); err != nil {
+			p.setPluginState(name, core.PluginStateFailed, err)
 			p.logger.Warn("Failed to start preloaded plugin",
 				core.Field{Key: "plugin", Value: name},
 				core.Field{Key: "error", Value: err},
 			)
+			continue
 		}
+		p.setPluginState(name, core.PluginStateStarted, nil)
 	}
 
 	// Load and start plugins from configured directories
@@ -246,7 +330,11 @@ func (p *Platform) Start(ctx context.Context) error {
 		p.logger.Warn("Failed to load some plugins", core.Field{Key: "error", Value: err})
 	}
 
-	// Start network discovery
+	// Serve peers and keep their connections alive until shutdown, then
+	// start network discovery
+	if err := p.networkManager.Start(p.ctx); err != nil {
+		p.logger.Warn("Failed to start network manager", core.Field{Key: "error", Value: err})
+	}
 	if _, err := p.networkManager.DiscoverPeers(); err != nil {
 		p.logger.Warn("Failed to start peer discovery", core.Field{Key: "error", Value: err})
 	}
@@ -273,25 +361,80 @@ func (p *Platform) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop gracefully shuts down the platform
+// Stop gracefully shuts down the platform, then waits for its background
+// workers to exit or for ctx to be done
 func (p *Platform) Stop(ctx context.Context) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	if err := p.shutdown(ctx); err != nil {
+		return err
+	}
 
+	// Event handlers may need p.mu too. Queued audit events are delivered
+	// before the audit log closes.
+	if err := p.eventBus.Stop(ctx); err != nil {
+		p.logger.Warn("Failed to stop event bus", core.Field{Key: "error", Value: err})
+	}
+	if p.audit != nil {
+		if err := p.audit.Close(); err != nil {
+			p.logger.Warn("Failed to close audit log", core.Field{Key: "error", Value: err})
+		}
+	}
+
+	// Workers may need p.mu to finish, so they are waited for without it
+	if err := p.workers.Stop(ctx); err != nil {
+		p.logger.Warn("Background workers did not stop cleanly", core.Field{Key: "error", Value: err})
+		return err
+	}
+	return nil
+}
+
+// shutdown stops plugins and core services and cancels the platform context.
+// Plugins and services are stopped without p.mu held, since requests they
+// are draining may call back into the platform.
+func (p *Platform) shutdown(ctx context.Context) error {
+	p.mu.Lock()
 	if !p.started {
+		p.mu.Unlock()
 		return fmt.Errorf("platform not started")
 	}
+	p.started = false
+	p.stopping = true
+
+	// Claim the running plugins so StartPlugin and StopPlugin keep off them,
+	// dependents ahead of their dependencies
+	var names []string
+	var plugins []core.Plugin
+	order := p.pluginOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		if p.pluginStates[name].State != core.PluginStateStarted {
+			continue
+		}
+		p.setPluginStateLocked(name, core.PluginStateStopping, nil)
+		names = append(names, name)
+		plugins = append(plugins, p.plugins[name])
+	}
+	timeout := p.pluginTimeoutLocked()
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.stopping = false
+		p.mu.Unlock()
+	}()
 
 	p.logger.Info("Stopping NoPlaceLike platform")
 
 	// Stop plugins first
-	for name, plugin := range p.plugins {
-		if err := plugin.Stop(ctx); err != nil {
+	for i, plugin := range plugins {
+		if err := callPlugin(ctx, names[i], "stop", timeout, plugin.Stop); err != nil {
+			p.setPluginState(names[i], core.PluginStateFailed, err)
 			p.logger.Warn("Failed to stop plugin",
-				core.Field{Key: "plugin", Value: name},
+				core.Field{Key: "plugin", Value: names[i]},
 				core.Field{Key: "error", Value: err},
 			)
+			continue
 		}
+		p.setPluginState(names[i], core.PluginStateStopped, nil)
 	}
 
 	// Stop core services
@@ -299,49 +442,66 @@ func (p *Platform) Stop(ctx context.Context) error {
 		p.logger.Warn("Failed to stop all services", core.Field{Key: "error", Value: err})
 	}
 
-	p.started = false
+	// Stop serving peers and release the discovery socket
+	if err := p.networkManager.Stop(ctx); err != nil {
+		p.logger.Warn("Failed to stop network manager", core.Field{Key: "error", Value: err})
+	}
+
 	p.cancel()
 
 	p.logger.Info("NoPlaceLike platform stopped")
 	return nil
 }
 
-// LoadPlugin loads a plugin into the platform
+// LoadPlugin loads a plugin into the platform. Plugins are keyed by ID(),
+// which dependencies, lookups and events refer to; Name() is only shown to
+// users. Loading a second plugin with an ID already in use fails with
+// core.ErrPluginExists.
 func (p *Platform) LoadPlugin(ctx context.Context, plugin core.Plugin) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	name := plugin.Name()
+	name := plugin.ID()
+	if name == "" {
+		return fmt.Errorf("%w: plugin %q has no ID", core.ErrInvalidRequest, plugin.Name())
+	}
 
-	if _, exists := p.plugins[name]; exists {
-		return fmt.Errorf("plugin %s already loaded", name)
+	if existing, exists := p.plugins[name]; exists {
+		return fmt.Errorf("%w: ID %s is already used by %s", core.ErrPluginExists, name, existing.Name())
 	}
 
 	// Check dependencies
 	deps := plugin.Dependencies()
 	for _, dep := range deps {
 		if _, exists := p.plugins[dep]; !exists {
-			return fmt.Errorf("plugin %s depends on %s which is not loaded", name, dep)
+			return fmt.Errorf("%w: plugin %s depends on %s", core.ErrMissingDependency, name, dep)
 		}
 	}
 
 	// Initialize plugin
-	if err := plugin.Initialize(p); err != nil {
+	timeout := p.pluginTimeoutLocked()
+	if err := callPlugin(context.Background(), name, "initialize", timeout, func(context.Context) error { return plugin.Initialize(p.apiFor(plugin)) }); err != nil {
 		return fmt.Errorf("failed to initialize plugin %s: %w", name, err)
 	}
 
-	// Start plugin if platform is running
+	p.plugins[name] = plugin
+	p.pluginDeps[name] = deps
+
+	// Start plugin if platform is running. A plugin that fails to start
+	// stays loaded as failed, so it can be seen and re-initialized.
 	if p.started {
-		if err := plugin.Start(ctx); err != nil {
+		if err := callPlugin(ctx, name, "start", timeout, plugin.Start); err != nil {
+			p.setPluginStateLocked(name, core.PluginStateFailed, err)
 			return fmt.Errorf("failed to start plugin %s: %w", name, err)
 		}
+		p.setPluginStateLocked(name, core.PluginStateStarted, nil)
+	} else {
+		p.setPluginStateLocked(name, core.PluginStateInitialized, nil)
 	}
 
-	p.plugins[name] = plugin
-	p.pluginDeps[name] = deps
-
 	p.logger.Info("Plugin loaded successfully",
 		core.Field{Key: "plugin", Value: name},
+		core.Field{Key: "name", Value: plugin.Name()},
 		core.Field{Key: "version", Value: plugin.Version()},
 	)
 
@@ -350,7 +510,7 @@ func (p *Platform) LoadPlugin(ctx context.Context, plugin core.Plugin) error {
 		ID:        generateID(),
 		Type:      "plugin.loaded",
 		Source:    "platform",
-		Data:      map[string]interface{}{"name": name, "version": plugin.Version()},
+		Data:      core.WithRequestIDData(ctx, map[string]interface{}{"id": name, "name": plugin.Name(), "version": plugin.Version()}),
 		Timestamp: time.Now().Unix(),
 	}
 
@@ -361,27 +521,75 @@ func (p *Platform) LoadPlugin(ctx context.Context, plugin core.Plugin) error {
 	return nil
 }
 
-// UnloadPlugin removes a plugin from the platform
+// UnloadPlugin removes the plugin with the given ID from the platform
 func (p *Platform) UnloadPlugin(ctx context.Context, name string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	plugin, exists := p.plugins[name]
-	if !exists {
-		return fmt.Errorf("plugin %s not found", name)
+	if _, exists := p.plugins[name]; !exists {
+		return fmt.Errorf("%w: %s", core.ErrPluginNotFound, name)
 	}
 
 	// Check if other plugins depend on this one
 	for pluginName, deps := range p.pluginDeps {
 		for _, dep := range deps {
 			if dep == name {
-				return fmt.Errorf("cannot unload plugin %s: plugin %s depends on it", name, pluginName)
+				return fmt.Errorf("%w: cannot unload plugin %s: plugin %s depends on it", core.ErrPluginInUse, name, pluginName)
 			}
 		}
 	}
 
+	p.unloadPluginLocked(ctx, name)
+	return nil
+}
+
+// UnloadPluginCascade unloads a plugin along with every plugin that depends
+// on it, directly or not, dependents first. It returns the names of the
+// unloaded plugins in the order they were unloaded.
+func (p *Platform) UnloadPluginCascade(ctx context.Context, name string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.plugins[name]; !exists {
+		return nil, fmt.Errorf("%w: %s", core.ErrPluginNotFound, name)
+	}
+
+	// Collect the dependency subtree rooted at name
+	subtree := map[string]bool{name: true}
+	for grown := true; grown; {
+		grown = false
+		for pluginName, deps := range p.pluginDeps {
+			if subtree[pluginName] {
+				continue
+			}
+			for _, dep := range deps {
+				if subtree[dep] {
+					subtree[pluginName] = true
+					grown = true
+					break
+				}
+			}
+		}
+	}
+
+	// Walk the dependency order backwards so dependents go first
+	order := p.pluginOrder()
+	unloaded := make([]string, 0, len(subtree))
+	for i := len(order) - 1; i >= 0; i-- {
+		if subtree[order[i]] {
+			p.unloadPluginLocked(ctx, order[i])
+			unloaded = append(unloaded, order[i])
+		}
+	}
+	return unloaded, nil
+}
+
+// unloadPluginLocked stops and removes a plugin. The caller holds p.mu and
+// has checked that nothing depends on it.
+func (p *Platform) unloadPluginLocked(ctx context.Context, name string) {
 	// Stop plugin
-	if err := plugin.Stop(ctx); err != nil {
+	plugin := p.plugins[name]
+	if err := callPlugin(ctx, name, "stop", p.pluginTimeoutLocked(), plugin.Stop); err != nil {
 		p.logger.Warn("Failed to stop plugin",
 			core.Field{Key: "plugin", Value: name},
 			core.Field{Key: "error", Value: err},
@@ -390,6 +598,8 @@ func (p *Platform) UnloadPlugin(ctx context.Context, name string) error {
 
 	delete(p.plugins, name)
 	delete(p.pluginDeps, name)
+	delete(p.pluginStates, name)
+	p.forgetSubscriptions(name)
 
 	p.logger.Info("Plugin unloaded", core.Field{Key: "plugin", Value: name})
 
@@ -398,25 +608,23 @@ func (p *Platform) UnloadPlugin(ctx context.Context, name string) error {
 		ID:        generateID(),
 		Type:      "plugin.unloaded",
 		Source:    "platform",
-		Data:      map[string]interface{}{"name": name},
+		Data:      core.WithRequestIDData(ctx, map[string]interface{}{"id": name, "name": plugin.Name()}),
 		Timestamp: time.Now().Unix(),
 	}
 
 	if err := p.eventBus.Publish(event); err != nil {
 		p.logger.Warn("Failed to publish plugin unloaded event", core.Field{Key: "error", Value: err})
 	}
-
-	return nil
 }
 
-// GetPlugin retrieves a loaded plugin by name
+// GetPlugin retrieves a loaded plugin by ID
 func (p *Platform) GetPlugin(name string) (core.Plugin, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
 	plugin, exists := p.plugins[name]
 	if !exists {
-		return nil, fmt.Errorf("plugin %s not found", name)
+		return nil, fmt.Errorf("%w: %s", core.ErrPluginNotFound, name)
 	}
 
 	return plugin, nil
@@ -468,7 +676,7 @@ func (p *Platform) Health() core.HealthStatus {
 	}
 
 	status := core.HealthStatusHealthy
-	if unhealthyServices > 0 || unhealthyPlugins > 0 {
+	if unhealthyServices > 0 || unhealthyPlugins > 0 || len(p.failedPlugins) > 0 {
 		if unhealthyServices > len(serviceHealth)/2 || unhealthyPlugins > len(p.plugins)/2 {
 			status = core.HealthStatusUnhealthy
 		} else {
@@ -485,11 +693,404 @@ func (p *Platform) Health() core.HealthStatus {
 			"servicesUnhealthy": unhealthyServices,
 			"pluginsTotal":      len(p.plugins),
 			"pluginsUnhealthy":  unhealthyPlugins,
+			"pluginsFailed":     len(p.failedPlugins),
 			"version":           p.version,
 		},
 	}
 }
 
+// Readiness reports whether the platform can serve traffic. On top of the
+// overall health, every core (auto-loaded) and required plugin is checked:
+// a missing or unhealthy required plugin makes the platform unhealthy, while
+// other core plugins only degrade it.
+func (p *Platform) Readiness() core.HealthStatus {
+	health := p.Health()
+	if health.Status == core.HealthStatusUnhealthy {
+		return health
+	}
+
+	config := p.Config()
+	names := make([]string, 0, len(config.Plugins.AutoLoad)+len(config.Plugins.Required))
+	names = append(names, config.Plugins.AutoLoad...)
+	names = append(names, config.Plugins.Required...)
+
+	p.mu.RLock()
+	checks := make(map[string]core.ComponentHealth, len(names))
+	for _, name := range names {
+		if _, checked := checks[name]; checked {
+			continue
+		}
+		check := core.ComponentHealth{Status: core.HealthStatusHealthy}
+		if plugin, loaded := p.plugins[name]; !loaded {
+			check.Status = core.HealthStatusUnhealthy
+			check.Error = "plugin not loaded"
+			if reason, failed := p.failedPlugins[name]; failed {
+				check.Error = reason
+			}
+		} else if pluginHealth := plugin.Health(); pluginHealth.Status != core.HealthStatusHealthy {
+			check.Status = pluginHealth.Status
+			check.Error = pluginHealth.Error
+		}
+		checks[name] = check
+	}
+	p.mu.RUnlock()
+
+	for name, check := range checks {
+		if check.Status == core.HealthStatusHealthy {
+			continue
+		}
+		if p.IsPluginRequired(name) {
+			health.Status = core.HealthStatusUnhealthy
+			health.Error = fmt.Sprintf("required plugin %s is %s", name, check.Status)
+		} else if health.Status == core.HealthStatusHealthy {
+			health.Status = core.HealthStatusDegraded
+		}
+	}
+
+	health.Checks = checks
+	return health
+}
+
+// MetricsEnabled reports whether metrics collection is enabled
+func (p *Platform) MetricsEnabled() bool {
+	_, disabled := p.metrics.(noopMetricsCollector)
+	return !disabled
+}
+
+// IsPluginRequired reports whether a plugin is listed in Plugins.Required
+func (p *Platform) IsPluginRequired(name string) bool {
+	config := p.Config()
+	if config == nil {
+		return false
+	}
+	for _, required := range config.Plugins.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPluginFailure records that an optional plugin failed to load. The
+// platform keeps running but reports itself as degraded.
+func (p *Platform) RecordPluginFailure(name string, err error) {
+	p.mu.Lock()
+	p.failedPlugins[name] = err.Error()
+	p.mu.Unlock()
+
+	p.logger.Warn("Optional plugin failed to load; continuing without it",
+		core.Field{Key: "plugin", Value: name},
+		core.Field{Key: "error", Value: err},
+	)
+}
+
+// FailedPlugins returns the optional plugins that failed to load and why
+func (p *Platform) FailedPlugins() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	failed := make(map[string]string, len(p.failedPlugins))
+	for name, reason := range p.failedPlugins {
+		failed[name] = reason
+	}
+	return failed
+}
+
+// SelfCheck validates the preconditions the platform needs to run: storage
+// directories must be writable, allowed paths must exist and a JWT secret must
+// be strong enough to sign tokens.
+func (p *Platform) SelfCheck() error {
+	config := p.Config()
+	if config == nil {
+		return fmt.Errorf("%w: platform config is nil", core.ErrInvalidConfig)
+	}
+
+	var errs []error
+
+	if usesJWTSecret(config.Security) && !config.Security.AutoGenerateSecret {
+		if err := checkJWTSecret(config.Security.JWTSecret); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	dirs := []struct{ name, path string }{
+		{"upload", config.Storage.UploadDir},
+		{"download", config.Storage.DownloadDir},
+	}
+	for _, dir := range dirs {
+		if dir.path == "" {
+			continue
+		}
+		if err := checkWritableDir(expandHome(dir.path)); err != nil {
+			errs = append(errs, fmt.Errorf("%s dir %s is not writable: %w", dir.name, dir.path, err))
+		}
+	}
+
+	for _, path := range config.Storage.AllowedPaths {
+		info, err := os.Stat(expandHome(path))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("allowed path %s does not exist: %w", path, err))
+			continue
+		}
+		if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("allowed path %s is not a directory", path))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// PathAllowed reports whether path lies inside one of the configured
+// allowed paths, or the upload and download dirs when none are configured.
+// Symlinks are resolved first, so a link can't lead outside them.
+func (p *Platform) PathAllowed(path string) bool {
+	target, err := filepath.EvalSymlinks(expandHome(path))
+	if err != nil {
+		return false
+	}
+	if target, err = filepath.Abs(target); err != nil {
+		return false
+	}
+
+	storage := p.Config().Storage
+	roots := storage.AllowedPaths
+	if len(roots) == 0 {
+		roots = []string{storage.UploadDir, storage.DownloadDir}
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		root, err := filepath.EvalSymlinks(expandHome(root))
+		if err != nil {
+			continue
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, target)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWritableDir creates dir if needed and verifies a file can be written to it
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".npl-selfcheck-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// Reload re-reads the platform configuration and restarts the running
+// plugins in dependency order. Core services (including the HTTP server) keep running,
+// so in-flight requests are not dropped. A re-read config that doesn't
+// validate is rejected and the current one kept.
+func (p *Platform) Reload(ctx context.Context) error {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	p.mu.RLock()
+	loader := p.configLoader
+	p.mu.RUnlock()
+
+	if loader == nil {
+		return p.restartPlugins(ctx, nil, p.runningPlugins())
+	}
+
+	config, err := loader()
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	if err := p.applyConfig(ctx, config, "reload"); err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	return nil
+}
+
+// runningPlugins returns the names of the plugins that are started
+func (p *Platform) runningPlugins() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var names []string
+	for name, status := range p.pluginStates {
+		if status.State == core.PluginStateStarted {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// restartPlugins applies config (if not nil) and restarts the named plugins
+// in dependency order, normally those runningPlugins returned, so a plugin
+// an operator stopped stays stopped. Named plugins that are stopped or
+// failed, as a failed restart leaves them, are initialized and started
+// without being stopped first. Callers must hold p.reloadMu.
+func (p *Platform) restartPlugins(ctx context.Context, config *PlatformConfig, names []string) error {
+	p.mu.RLock()
+	started := p.started
+	p.mu.RUnlock()
+
+	if !started {
+		return fmt.Errorf("platform not started")
+	}
+
+	p.logger.Info("Reloading NoPlaceLike platform")
+
+	if config != nil {
+		p.mu.Lock()
+		p.config = config
+		p.version = config.Version
+		p.mu.Unlock()
+	}
+
+	// Claim the plugins so StartPlugin and StopPlugin keep off them until
+	// they are running again
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	p.mu.Lock()
+	var order []string
+	var plugins []core.Plugin
+	running := make(map[string]bool)
+	for _, name := range p.pluginOrder() {
+		if !wanted[name] {
+			continue
+		}
+		switch p.pluginStates[name].State {
+		case core.PluginStateStarted:
+			running[name] = true
+		case core.PluginStateStopped, core.PluginStateFailed:
+		default:
+			continue
+		}
+		p.setPluginStateLocked(name, core.PluginStateStopping, nil)
+		order = append(order, name)
+		plugins = append(plugins, p.plugins[name])
+	}
+	timeout := p.pluginTimeoutLocked()
+	p.mu.Unlock()
+
+	// Stop dependents before their dependencies
+	for i := len(plugins) - 1; i >= 0; i-- {
+		if !running[order[i]] {
+			continue
+		}
+		if err := callPlugin(ctx, order[i], "stop", timeout, plugins[i].Stop); err != nil {
+			p.logger.Warn("Failed to stop plugin during reload",
+				core.Field{Key: "plugin", Value: order[i]},
+				core.Field{Key: "error", Value: err},
+			)
+		}
+	}
+
+	// Re-initialize and start dependencies before their dependents. Starts
+	// derive from the platform context rather than the caller's.
+	for i, plugin := range plugins {
+		err := callPlugin(context.Background(), order[i], "initialize", timeout, func(context.Context) error { return plugin.Initialize(p.apiFor(plugin)) })
+		if err != nil {
+			err = fmt.Errorf("failed to initialize plugin %s: %w", order[i], err)
+		} else {
+			p.setPluginState(order[i], core.PluginStateStarting, nil)
+			if err = callPlugin(p.ctx, order[i], "start", timeout, plugin.Start); err != nil {
+				err = fmt.Errorf("failed to start plugin %s: %w", order[i], err)
+			}
+		}
+		if err != nil {
+			// Release the plugins not reached
+			p.mu.Lock()
+			p.setPluginStateLocked(order[i], core.PluginStateFailed, err)
+			for _, name := range order[i+1:] {
+				p.setPluginStateLocked(name, core.PluginStateStopped, nil)
+			}
+			p.mu.Unlock()
+			return err
+		}
+		p.setPluginState(order[i], core.PluginStateStarted, nil)
+	}
+
+	event := core.Event{
+		ID:        generateID(),
+		Type:      "platform.reloaded",
+		Source:    "platform",
+		Data:      core.WithRequestIDData(ctx, map[string]interface{}{"plugins": order}),
+		Timestamp: time.Now().Unix(),
+	}
+
+	if err := p.eventBus.Publish(event); err != nil {
+		p.logger.Warn("Failed to publish platform reloaded event", core.Field{Key: "error", Value: err})
+	}
+
+	p.logger.Info("NoPlaceLike platform reloaded", core.Field{Key: "plugins", Value: len(order)})
+	return nil
+}
+
+// SetConfigLoader sets the function used by Reload to re-read configuration
+func (p *Platform) SetConfigLoader(loader func() (*PlatformConfig, error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configLoader = loader
+}
+
+// Config returns the current platform configuration
+func (p *Platform) Config() *PlatformConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config
+}
+
+// pluginOrder returns loaded plugin names sorted so that every plugin comes
+// after its dependencies. Callers must hold p.mu.
+func (p *Platform) pluginOrder() []string {
+	names := make([]string, 0, len(p.plugins))
+	for name := range p.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	visited := make(map[string]bool, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range p.pluginDeps[name] {
+			if _, ok := p.plugins[dep]; ok {
+				visit(dep)
+			}
+		}
+		order = append(order, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+	return order
+}
+
 // Managers provide access to core platform managers
 func (p *Platform) ServiceManager() core.ServiceManager   { return p.serviceManager }
 func (p *Platform) NetworkManager() core.NetworkManager   { return p.networkManager }
@@ -499,6 +1100,7 @@ func (p *Platform) ConfigManager() core.ConfigManager     { return p.configManag
 func (p *Platform) EventBus() core.EventBus               { return p.eventBus }
 func (p *Platform) Metrics() core.MetricsCollector        { return p.metrics }
 func (p *Platform) Logger() core.Logger                   { return p.logger }
+func (p *Platform) Workers() *core.WorkerPool             { return p.workers }
 
 // Implement core.PlatformAPI interface
 func (p *Platform) GetEventBus() core.EventBus {
@@ -533,6 +1135,51 @@ func (p *Platform) GetHealthChecker() core.HealthChecker {
 	return nil // TODO: implement if you have a health checker in your platform
 }
 
+// PublishEvent publishes an event attributed to the platform itself. Plugins
+// receive a PlatformAPI whose PublishEvent attributes events to them instead.
+func (p *Platform) PublishEvent(eventType string, data map[string]interface{}) error {
+	return p.publishEventAs(context.Background(), "platform", eventType, data)
+}
+
+// PublishEventContext is PublishEvent for events published while handling a
+// request; the request ID carried by ctx is added to data
+func (p *Platform) PublishEventContext(ctx context.Context, eventType string, data map[string]interface{}) error {
+	return p.publishEventAs(ctx, "platform", eventType, data)
+}
+
+func (p *Platform) publishEventAs(ctx context.Context, source, eventType string, data map[string]interface{}) error {
+	return p.eventBus.Publish(core.Event{
+		ID:        generateID(),
+		Type:      eventType,
+		Source:    source,
+		Timestamp: time.Now().Unix(),
+		Data:      core.WithRequestIDData(ctx, data),
+	})
+}
+
+// pluginAPI is the PlatformAPI handed to a plugin. It stamps the plugin's ID
+// on the events it publishes so plugins can't spoof each other.
+type pluginAPI struct {
+	*Platform
+	pluginID string
+}
+
+// PublishEvent publishes an event attributed to the plugin
+func (a *pluginAPI) PublishEvent(eventType string, data map[string]interface{}) error {
+	return a.publishEventAs(context.Background(), a.pluginID, eventType, data)
+}
+
+// PublishEventContext publishes an event attributed to the plugin, tagged
+// with the request ID carried by ctx
+func (a *pluginAPI) PublishEventContext(ctx context.Context, eventType string, data map[string]interface{}) error {
+	return a.publishEventAs(ctx, a.pluginID, eventType, data)
+}
+
+// apiFor returns the PlatformAPI given to plugin
+func (p *Platform) apiFor(plugin core.Plugin) core.PlatformAPI {
+	return &pluginAPI{Platform: p, pluginID: plugin.ID()}
+}
+
 // loadPlugins loads plugins from configured directories
 func (p *Platform) loadPlugins(ctx context.Context) error {
 	// Plugin loading implementation would go here
@@ -542,8 +1189,7 @@ func (p *Platform) loadPlugins(ctx context.Context) error {
 
 // generateID generates a unique identifier
 func generateID() string {
-	// Implementation would generate a UUID or similar unique ID
-	return fmt.Sprintf("id-%d", time.Now().UnixNano())
+	return core.NewID()
 }
 
 // getBuildInfo returns build information
@@ -559,30 +1205,54 @@ func getBuildInfo() BuildInfo {
 // Placeholder functions for manager creation (these would be implemented in separate files)
 func NewLogger(config LoggingConfig) (core.Logger, error) { return nil, fmt.Errorf("not implemented") }
 
-// Minimal stub config manager
-
-type stubConfigManager struct{}
-
-// Implement core.ConfigManager methods as no-ops or defaults
-// Add more methods if your interface requires them
-
-func (s *stubConfigManager) Reload() error                     { return nil }
-func (s *stubConfigManager) Save() error                       { return nil }
-func (s *stubConfigManager) Get(key string) interface{}        { return nil }
-func (s *stubConfigManager) Set(key string, value interface{}) {}
-
-func NewConfigManager(config *PlatformConfig) (core.ConfigManager, error) {
-	return &stubConfigManager{}, nil
-}
-
 // --- Implementations for core managers and services ---
 
 // EventBus implementation
 type eventBusImpl struct {
-	mu      sync.RWMutex
-	subs    map[string][]func(context.Context, core.Event) error
-	started bool
-	logger  core.Logger
+	mu   sync.RWMutex
+	subs map[string][]eventSubscriber
+	// patterns holds subscriptions to glob patterns such as "file.*", each
+	// compiled once. Exact types and "*" stay in subs.
+	patterns map[string]*patternSubscribers
+	nextID   uint64
+	started  bool
+	config   EventsConfig
+	metrics  core.MetricsCollector
+	logger   core.Logger
+
+	// seq numbers published events; history keeps the latest of them in a
+	// ring buffer where historyNext is the oldest entry once it is full
+	seq         uint64
+	history     []recordedEvent
+	historyNext int
+
+	droppedTotal atomic.Uint64
+
+	// wg tracks the subscriber goroutines, which Stop waits for
+	wg sync.WaitGroup
+
+	// typeLabels are the event types metrics are labeled with
+	labelsMu   sync.Mutex
+	typeLabels map[string]struct{}
+}
+
+// eventSubscriber is a handler together with the ID of its subscription and
+// the queue its events wait in
+type eventSubscriber struct {
+	id     uint64
+	handle func(context.Context, core.Event) error
+	queue  chan queuedEvent
+	done   chan struct{}
+	// closeOnce guards closing done, as Unsubscribe and Stop both may
+	closeOnce *sync.Once
+	// lossless subscribers never have events dropped
+	lossless bool
+}
+
+// patternSubscribers are the handlers subscribed to one topic pattern
+type patternSubscribers struct {
+	match *regexp.Regexp
+	subs  []eventSubscriber
 }
 
 func (e *eventBusImpl) Name() string { return "event-bus" }
@@ -591,17 +1261,41 @@ func (e *eventBusImpl) Start(ctx context.Context) error {
 	e.mu.Lock()
 	e.started = true
 	if e.subs == nil {
-		e.subs = make(map[string][]func(context.Context, core.Event) error)
+		e.subs = make(map[string][]eventSubscriber)
 	}
 	e.mu.Unlock()
 	return nil
 }
 
+// Stop ends delivery to every subscriber and waits, until ctx is done, for
+// their goroutines to exit. Lossless subscribers get their queued events
+// first.
 func (e *eventBusImpl) Stop(ctx context.Context) error {
 	e.mu.Lock()
 	e.started = false
+	for _, subs := range e.subs {
+		for _, sub := range subs {
+			sub.close()
+		}
+	}
+	for _, p := range e.patterns {
+		for _, sub := range p.subs {
+			sub.close()
+		}
+	}
 	e.mu.Unlock()
-	return nil
+
+	stopped := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event subscribers did not stop: %w", ctx.Err())
+	}
 }
 
 func (e *eventBusImpl) IsHealthy() bool {
@@ -620,6 +1314,11 @@ func (e *eventBusImpl) Health() core.HealthStatus {
 	return core.HealthStatus{
 		Status:    status,
 		Timestamp: time.Now(),
+		Details: map[string]interface{}{
+			"overflowPolicy": e.config.OverflowPolicy,
+			"bufferSize":     e.config.BufferSize,
+			"droppedEvents":  e.droppedTotal.Load(),
+		},
 	}
 }
 
@@ -627,64 +1326,116 @@ func (e *eventBusImpl) Configuration() core.ConfigSchema {
 	return core.ConfigSchema{Properties: map[string]core.PropertySchema{}}
 }
 
+// Publish queues event for every matching subscriber and returns without
+// waiting for the handlers to run
 func (e *eventBusImpl) Publish(event core.Event) error {
-	e.mu.RLock()
-	handlers := append([]func(context.Context, core.Event) error{}, e.subs[event.Type]...)
-	starHandlers := append([]func(context.Context, core.Event) error{}, e.subs["*"]...)
-	e.mu.RUnlock()
-
-	for _, h := range handlers {
-		_ = h(context.Background(), event)
-	}
-	for _, h := range starHandlers {
-		_ = h(context.Background(), event)
-	}
+	e.publish(context.Background(), event.Type, event)
 	return nil
 }
 
 func (e *eventBusImpl) PublishToTopic(ctx context.Context, topic string, event core.Event) error {
 	// Treat topic as event type channel
-	e.mu.RLock()
-	handlers := append([]func(context.Context, core.Event) error{}, e.subs[topic]...)
-	starHandlers := append([]func(context.Context, core.Event) error{}, e.subs["*"]...)
-	e.mu.RUnlock()
+	e.publish(ctx, topic, event)
+	return nil
+}
 
-	for _, h := range handlers {
-		_ = h(ctx, event)
+// handlersForLocked returns the handlers subscribed to topic exactly, then
+// those whose pattern matches it, then the catch-all handlers. The caller
+// holds e.mu.
+func (e *eventBusImpl) handlersForLocked(topic string) []eventSubscriber {
+	handlers := append([]eventSubscriber{}, e.subs[topic]...)
+	for _, p := range e.patterns {
+		if p.match.MatchString(topic) {
+			handlers = append(handlers, p.subs...)
+		}
 	}
-	for _, h := range starHandlers {
-		_ = h(ctx, event)
+	return append(handlers, e.subs["*"]...)
+}
+
+func (e *eventBusImpl) Subscribe(eventType string, handler core.EventHandler) (core.Subscription, error) {
+	return e.subscribe(eventType, func(ctx context.Context, ev core.Event) error { return handler(ev) }, false), nil
+}
+
+func (e *eventBusImpl) SubscribeLossless(eventType string, handler core.EventHandler) (core.Subscription, error) {
+	return e.subscribe(eventType, func(ctx context.Context, ev core.Event) error { return handler(ev) }, true), nil
+}
+
+// SubscribeWithContext subscribes handler until ctx is done
+func (e *eventBusImpl) SubscribeWithContext(ctx context.Context, eventType string, handler func(context.Context, core.Event) error) (core.Subscription, error) {
+	sub := e.subscribe(eventType, handler, false)
+	if done := ctx.Done(); done != nil {
+		go func() {
+			<-done
+			e.Unsubscribe(sub)
+		}()
 	}
-	return nil
+	return sub, nil
 }
 
-func (e *eventBusImpl) Subscribe(eventType string, handler core.EventHandler) error {
+func (e *eventBusImpl) subscribe(eventType string, handle func(context.Context, core.Event) error, lossless bool) core.Subscription {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if e.subs == nil {
-		e.subs = make(map[string][]func(context.Context, core.Event) error)
+		e.subs = make(map[string][]eventSubscriber)
 	}
-	wrapped := func(ctx context.Context, ev core.Event) error { return handler(ev) }
-	e.subs[eventType] = append(e.subs[eventType], wrapped)
-	return nil
+	e.nextID++
+	sub := e.newSubscriber(e.nextID, handle, lossless)
+	if isTopicPattern(eventType) {
+		if e.patterns == nil {
+			e.patterns = make(map[string]*patternSubscribers)
+		}
+		p, ok := e.patterns[eventType]
+		if !ok {
+			p = &patternSubscribers{match: compileTopicPattern(eventType)}
+			e.patterns[eventType] = p
+		}
+		p.subs = append(p.subs, sub)
+	} else {
+		e.subs[eventType] = append(e.subs[eventType], sub)
+	}
+	e.subscribersChangedLocked()
+	return core.Subscription{EventType: eventType, ID: e.nextID}
 }
 
-func (e *eventBusImpl) SubscribeWithContext(ctx context.Context, eventType string, handler func(context.Context, core.Event) error) error {
+// Unsubscribe removes only the handler sub refers to
+func (e *eventBusImpl) Unsubscribe(sub core.Subscription) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	if e.subs == nil {
-		e.subs = make(map[string][]func(context.Context, core.Event) error)
+
+	if isTopicPattern(sub.EventType) {
+		if p, ok := e.patterns[sub.EventType]; ok {
+			if subs, removed := withoutSubscriber(p.subs, sub.ID); removed != nil {
+				removed.close()
+				p.subs = subs
+				if len(subs) == 0 {
+					delete(e.patterns, sub.EventType)
+				}
+				e.subscribersChangedLocked()
+				return nil
+			}
+		}
+	} else if subs, removed := withoutSubscriber(e.subs[sub.EventType], sub.ID); removed != nil {
+		removed.close()
+		e.subs[sub.EventType] = subs
+		if len(subs) == 0 {
+			delete(e.subs, sub.EventType)
+		}
+		e.subscribersChangedLocked()
+		return nil
 	}
-	e.subs[eventType] = append(e.subs[eventType], handler)
-	return nil
+	return fmt.Errorf("%w: %d to %s", core.ErrSubscriptionNotFound, sub.ID, sub.EventType)
 }
 
-func (e *eventBusImpl) Unsubscribe(eventType string, handler core.EventHandler) error {
-	// Minimal implementation: clear all subscribers for the eventType
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	delete(e.subs, eventType)
-	return nil
+// withoutSubscriber returns a copy of subs without the subscriber with id,
+// leaving subs itself intact for publishers that are iterating over it, and
+// the subscriber removed, if any
+func withoutSubscriber(subs []eventSubscriber, id uint64) ([]eventSubscriber, *eventSubscriber) {
+	for i, s := range subs {
+		if s.id == id {
+			return append(subs[:i:i], subs[i+1:]...), &s
+		}
+	}
+	return subs, nil
 }
 
 // Metrics implementation
@@ -696,6 +1447,7 @@ type counterImpl struct {
 func (c *counterImpl) Inc()              { c.Add(1) }
 func (c *counterImpl) Add(delta float64) { c.mu.Lock(); c.value += delta; c.mu.Unlock() }
 func (c *counterImpl) Get() float64      { c.mu.RLock(); defer c.mu.RUnlock(); return c.value }
+func (c *counterImpl) reset()            { c.mu.Lock(); c.value = 0; c.mu.Unlock() }
 
 type gaugeImpl struct {
 	mu    sync.RWMutex
@@ -709,18 +1461,6 @@ func (g *gaugeImpl) Add(delta float64) { g.mu.Lock(); g.value += delta; g.mu.Unl
 func (g *gaugeImpl) Sub(delta float64) { g.Add(-delta) }
 func (g *gaugeImpl) Get() float64      { g.mu.RLock(); defer g.mu.RUnlock(); return g.value }
 
-type histogramImpl struct {
-	mu     sync.RWMutex
-	values []float64
-}
-
-func (h *histogramImpl) Observe(v float64) {
-	h.mu.Lock()
-	h.values = append(h.values, v)
-	h.mu.Unlock()
-}
-func (h *histogramImpl) Reset() { h.mu.Lock(); h.values = nil; h.mu.Unlock() }
-
 type timerInstanceImpl struct {
 	start time.Time
 	rec   func(duration time.Duration)
@@ -757,9 +1497,16 @@ type metricsCollectorImpl struct {
 	started    bool
 	logger     core.Logger
 	counters   map[string]*counterImpl
+	counterVec map[string]*counterVecImpl
 	gauges     map[string]*gaugeImpl
 	histograms map[string]*histogramImpl
 	timers     map[string]*timerImpl
+	buckets    []float64 // upper bounds for new histograms
+
+	// interval is how often runtime metrics are sampled; closing
+	// stopSampler ends sampling
+	interval    time.Duration
+	stopSampler chan struct{}
 }
 
 func (m *metricsCollectorImpl) Name() string { return "metrics" }
@@ -769,6 +1516,9 @@ func (m *metricsCollectorImpl) Start(ctx context.Context) error {
 	if m.counters == nil {
 		m.counters = map[string]*counterImpl{}
 	}
+	if m.counterVec == nil {
+		m.counterVec = map[string]*counterVecImpl{}
+	}
 	if m.gauges == nil {
 		m.gauges = map[string]*gaugeImpl{}
 	}
@@ -778,12 +1528,24 @@ func (m *metricsCollectorImpl) Start(ctx context.Context) error {
 	if m.timers == nil {
 		m.timers = map[string]*timerImpl{}
 	}
+	if m.stopSampler == nil {
+		interval := m.interval
+		if interval <= 0 {
+			interval = DefaultMetricsInterval
+		}
+		m.stopSampler = make(chan struct{})
+		go m.runSampler(interval, m.stopSampler)
+	}
 	m.mu.Unlock()
 	return nil
 }
 func (m *metricsCollectorImpl) Stop(ctx context.Context) error {
 	m.mu.Lock()
 	m.started = false
+	if m.stopSampler != nil {
+		close(m.stopSampler)
+		m.stopSampler = nil
+	}
 	m.mu.Unlock()
 	return nil
 }
@@ -814,6 +1576,16 @@ func (m *metricsCollectorImpl) Counter(name string) core.Counter {
 	m.counters[name] = c
 	return c
 }
+func (m *metricsCollectorImpl) CounterVec(name string, labels ...string) core.CounterVec {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.counterVec[name]; ok {
+		return v
+	}
+	v := newCounterVec(labels)
+	m.counterVec[name] = v
+	return v
+}
 func (m *metricsCollectorImpl) Gauge(name string) core.Gauge {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -830,7 +1602,7 @@ func (m *metricsCollectorImpl) Histogram(name string) core.Histogram {
 	if h, ok := m.histograms[name]; ok {
 		return h
 	}
-	h := &histogramImpl{}
+	h := newHistogram(m.buckets)
 	m.histograms[name] = h
 	return h
 }
@@ -840,12 +1612,59 @@ func (m *metricsCollectorImpl) Timer(name string) core.Timer {
 	if t, ok := m.timers[name]; ok {
 		return t
 	}
-	h := &histogramImpl{}
+	h := newHistogram(m.buckets)
 	t := &timerImpl{h: h}
 	m.histograms[name+"_duration_ms"] = h
 	m.timers[name] = t
 	return t
 }
+
+// Reset zeroes metrics in place rather than removing them, so counters and
+// histograms already handed out keep working
+func (m *metricsCollectorImpl) Reset(names ...string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(names) == 0 {
+		for _, c := range m.counters {
+			c.reset()
+		}
+		for _, v := range m.counterVec {
+			v.reset()
+		}
+		for _, g := range m.gauges {
+			g.Set(0)
+		}
+		for _, h := range m.histograms {
+			h.Reset()
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		found := false
+		if c, ok := m.counters[name]; ok {
+			c.reset()
+			found = true
+		}
+		if v, ok := m.counterVec[name]; ok {
+			v.reset()
+			found = true
+		}
+		if g, ok := m.gauges[name]; ok {
+			g.Set(0)
+			found = true
+		}
+		if h, ok := m.histograms[name]; ok {
+			h.Reset()
+			found = true
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrMetricNotFound, name)
+		}
+	}
+	return nil
+}
 func (m *metricsCollectorImpl) Export(format string) ([]byte, error) {
 	// Minimal text/JSON-like export without extra imports
 	m.mu.RLock()
@@ -865,6 +1684,18 @@ func (m *metricsCollectorImpl) Export(format string) ([]byte, error) {
 			s += fmt.Sprintf("%q:%v", k, v.Get())
 		}
 		s += "},"
+		// labeled counters
+		s += "\"counterVecs\":{"
+		first = true
+		for k, v := range m.counterVec {
+			if !first {
+				s += ","
+			}
+			first = false
+			series, _ := json.Marshal(v.snapshot())
+			s += fmt.Sprintf("%q:%s", k, series)
+		}
+		s += "},"
 		// gauges
 		s += "\"gauges\":{"
 		first = true
@@ -876,7 +1707,7 @@ func (m *metricsCollectorImpl) Export(format string) ([]byte, error) {
 			s += fmt.Sprintf("%q:%v", k, v.Get())
 		}
 		s += "},"
-		// histograms (export count only)
+		// histograms
 		s += "\"histograms\":{"
 		first = true
 		for k, v := range m.histograms {
@@ -884,11 +1715,7 @@ func (m *metricsCollectorImpl) Export(format string) ([]byte, error) {
 				s += ","
 			}
 			first = false
-			count := 0
-			if v.values != nil {
-				count = len(v.values)
-			}
-			s += fmt.Sprintf("%q:{\"count\":%d}", k, count)
+			s += fmt.Sprintf("%q:%s", k, v.exportJSON())
 		}
 		s += "}"
 		s += "}"
@@ -901,30 +1728,39 @@ func (m *metricsCollectorImpl) Export(format string) ([]byte, error) {
 	for k, v := range m.counters {
 		out += fmt.Sprintf("  - %s=%v\n", k, v.Get())
 	}
+	for k, v := range m.counterVec {
+		for _, series := range v.snapshot() {
+			out += fmt.Sprintf("  - %s%s=%v\n", k, FormatLabels(series.Labels), series.Value)
+		}
+	}
 	out += " gauges:\n"
 	for k, v := range m.gauges {
 		out += fmt.Sprintf("  - %s=%v\n", k, v.Get())
 	}
 	out += " histograms:\n"
 	for k, v := range m.histograms {
-		count := 0
-		if v.values != nil {
-			count = len(v.values)
-		}
-		out += fmt.Sprintf("  - %s count=%d\n", k, count)
+		out += fmt.Sprintf("  - %s %s\n", k, v.exportText())
 	}
 	return []byte(out), nil
 }
 
 // Security manager implementation
 type securityManagerImpl struct {
-	mu          sync.RWMutex
-	started     bool
-	logger      core.Logger
-	tokenExpiry time.Duration
-	secret      []byte
-	issuer      string
-	audience    []string
+	mu            sync.RWMutex
+	started       bool
+	logger        core.Logger
+	tokenExpiry   time.Duration
+	refreshExpiry time.Duration
+	keys          *jwtKeys
+	issuer        string
+	audience      []string
+	roles         map[string][]string
+	// revoked maps a revoked jti to the time its token expires, after which
+	// the entry is no longer needed
+	revoked map[string]time.Time
+	// refreshTokens maps the jti of each unused refresh token to its expiry
+	refreshTokens map[string]time.Time
+	cipher        *payloadCipher
 }
 
 func (s *securityManagerImpl) Name() string { return "security" }
@@ -969,20 +1805,170 @@ func (s *securityManagerImpl) Authorize(user *core.User, resource string, action
 	return true
 }
 
+// Token types carried in the "typ" claim
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
 func (s *securityManagerImpl) GenerateToken(user *core.User) (string, error) {
+	token, _, err := s.issueToken(user, tokenTypeAccess, s.tokenExpiry)
+	return token, err
+}
+
+// GenerateRefreshToken issues a long-lived refresh token for user. Refresh
+// tokens are only accepted by RefreshToken, never as access tokens, and each
+// can be exchanged once.
+func (s *securityManagerImpl) GenerateRefreshToken(user *core.User) (string, error) {
+	token, jti, err := s.issueToken(user, tokenTypeRefresh, s.refreshExpiry)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, exp := range s.refreshTokens {
+		if now.After(exp) {
+			delete(s.refreshTokens, id)
+		}
+	}
+	s.refreshTokens[jti] = now.Add(s.refreshExpiry)
+	return token, nil
+}
+
+// GenerateTokenPair issues a short-lived access token and a refresh token
+// for user
+func (s *securityManagerImpl) GenerateTokenPair(user *core.User) (*core.TokenPair, error) {
+	access, err := s.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.GenerateRefreshToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return &core.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresAt:    time.Now().Add(s.tokenExpiry).Unix(),
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. The old
+// refresh token is consumed, so replaying it after rotation fails.
+func (s *securityManagerImpl) RefreshToken(ctx context.Context, refreshToken string) (*core.TokenPair, error) {
+	claims, ok := s.parseClaims(refreshToken)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid refresh token", core.ErrUnauthorized)
+	}
+	if typ, _ := claims["typ"].(string); typ != tokenTypeRefresh {
+		return nil, fmt.Errorf("%w: not a refresh token", core.ErrUnauthorized)
+	}
+	jti, _ := claims["jti"].(string)
+	if s.isRevoked(jti) {
+		return nil, fmt.Errorf("%w: refresh token revoked", core.ErrUnauthorized)
+	}
+
+	s.mu.Lock()
+	_, outstanding := s.refreshTokens[jti]
+	delete(s.refreshTokens, jti)
+	s.mu.Unlock()
+	if !outstanding {
+		return nil, fmt.Errorf("%w: refresh token already used", core.ErrUnauthorized)
+	}
+
+	sub, _ := claims["sub"].(string)
+	return s.GenerateTokenPair(&core.User{
+		ID:          sub,
+		Username:    sub,
+		Roles:       claimStrings(claims, "roles"),
+		Permissions: claimStrings(claims, "perms"),
+	})
+}
+
+// RevokeToken marks the token with the given jti as revoked. Since the
+// token's expiry is unknown, the entry is kept for the longest token lifetime.
+func (s *securityManagerImpl) RevokeToken(jti string) error {
+	if jti == "" {
+		return fmt.Errorf("%w: empty token id", core.ErrInvalidRequest)
+	}
+	lifetime := s.tokenExpiry
+	if s.refreshExpiry > lifetime {
+		lifetime = s.refreshExpiry
+	}
+	s.revoke(jti, time.Now().Add(lifetime))
+	return nil
+}
+
+// RevokeTokenString revokes a signed token until its own expiry. It returns
+// the token's subject so callers can check ownership.
+func (s *securityManagerImpl) RevokeTokenString(token string) (string, error) {
+	claims, ok := s.parseClaims(token)
+	if !ok {
+		return "", fmt.Errorf("%w: invalid token", core.ErrUnauthorized)
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return "", fmt.Errorf("%w: token has no id", core.ErrInvalidRequest)
+	}
+	sub, _ := claims["sub"].(string)
+	s.revoke(jti, time.Unix(claimInt(claims, "exp"), 0))
+	return sub, nil
+}
+
+// revoke adds jti to the blocklist until expireAt and drops entries whose
+// tokens have already expired
+func (s *securityManagerImpl) revoke(jti string, expireAt time.Time) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, id)
+		}
+	}
+	s.revoked[jti] = expireAt
+}
+
+func (s *securityManagerImpl) isRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	exp, ok := s.revoked[jti]
+	return ok && time.Now().Before(exp)
+}
+
+// issueToken signs a JWT of the given type for user with the configured
+// algorithm and returns it with its jti
+func (s *securityManagerImpl) issueToken(user *core.User, typ string, expiry time.Duration) (string, string, error) {
 	if user == nil || user.ID == "" {
-		return "", fmt.Errorf("invalid user")
+		return "", "", fmt.Errorf("invalid user")
 	}
 	header := map[string]interface{}{
-		"alg": "HS256",
+		"alg": s.keys.alg,
 		"typ": "JWT",
 	}
+	if s.keys.keyID != "" {
+		header["kid"] = s.keys.keyID
+	}
 	now := time.Now()
-	exp := now.Add(s.tokenExpiry)
+	exp := now.Add(expiry)
+	jti := generateID()
 	claims := map[string]interface{}{
 		"sub": user.ID,
 		"iat": now.Unix(),
 		"exp": exp.Unix(),
+		"jti": jti,
+		"typ": typ,
+	}
+	if len(user.Roles) > 0 {
+		claims["roles"] = user.Roles
+	}
+	if len(user.Permissions) > 0 {
+		claims["perms"] = user.Permissions
 	}
 	if s.issuer != "" {
 		claims["iss"] = s.issuer
@@ -997,11 +1983,11 @@ func (s *securityManagerImpl) GenerateToken(user *core.User) (string, error) {
 
 	hb, err := json.Marshal(header)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	cb, err := json.Marshal(claims)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	enc := base64.RawURLEncoding
@@ -1009,12 +1995,18 @@ func (s *securityManagerImpl) GenerateToken(user *core.User) (string, error) {
 	c64 := enc.EncodeToString(cb)
 	signingInput := h64 + "." + c64
 
-	mac := hmac.New(sha256.New, s.secret)
-	_, _ = mac.Write([]byte(signingInput))
-	sig := mac.Sum(nil)
+	sig, err := s.keys.sign(signingInput)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign token: %w", err)
+	}
 	s64 := enc.EncodeToString(sig)
 
-	return signingInput + "." + s64, nil
+	return signingInput + "." + s64, jti, nil
+}
+
+// JWKS returns the public keys peers can use to verify tokens
+func (s *securityManagerImpl) JWKS() map[string]interface{} {
+	return s.keys.JWKS()
 }
 
 func (s *securityManagerImpl) ValidatePermissions(userID string, permissions []string) bool {
@@ -1024,48 +2016,122 @@ func (s *securityManagerImpl) ValidatePermissions(userID string, permissions []s
 }
 
 func (s *securityManagerImpl) ValidateToken(ctx context.Context, token string) (*core.TokenInfo, error) {
-	if token == "" {
+	claims, ok := s.parseClaims(token)
+	if !ok {
+		return &core.TokenInfo{Valid: false}, nil
+	}
+
+	// Refresh tokens must not be usable as access tokens
+	if typ, _ := claims["typ"].(string); typ == tokenTypeRefresh {
+		return &core.TokenInfo{Valid: false}, nil
+	}
+	if jti, _ := claims["jti"].(string); s.isRevoked(jti) {
 		return &core.TokenInfo{Valid: false}, nil
 	}
+
+	userID := ""
+	if sub, _ := claims["sub"].(string); sub != "" {
+		userID = sub
+	}
+	expireAt := claimInt(claims, "exp")
+
+	return &core.TokenInfo{
+		Valid:       true,
+		UserID:      userID,
+		PeerID:      userID,
+		Permissions: s.tokenPermissions(claims),
+		ExpireAt:    expireAt,
+	}, nil
+}
+
+// tokenPermissions returns the de-duplicated permissions granted by the
+// token's roles claim followed by those listed in its perms claim
+func (s *securityManagerImpl) tokenPermissions(claims map[string]interface{}) []string {
+	perms := []string{}
+	seen := map[string]bool{}
+	add := func(perm string) {
+		if perm != "" && !seen[perm] {
+			seen[perm] = true
+			perms = append(perms, perm)
+		}
+	}
+	for _, role := range claimStrings(claims, "roles") {
+		for _, perm := range s.roles[role] {
+			add(perm)
+		}
+	}
+	for _, perm := range claimStrings(claims, "perms") {
+		add(perm)
+	}
+	return perms
+}
+
+// claimInt reads a numeric claim such as exp
+func claimInt(claims map[string]interface{}, key string) int64 {
+	switch t := claims[key].(type) {
+	case float64:
+		return int64(t)
+	case int64:
+		return t
+	}
+	return 0
+}
+
+// claimStrings reads a string array claim
+func claimStrings(claims map[string]interface{}, key string) []string {
+	raw, ok := claims[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if str, ok := v.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
+}
+
+// parseClaims verifies the token signature and standard claims (exp, nbf,
+// iss, aud) and returns the decoded claims
+func (s *securityManagerImpl) parseClaims(token string) (map[string]interface{}, bool) {
+	if token == "" {
+		return nil, false
+	}
 	// Expect "header.payload.signature"
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
-		return &core.TokenInfo{Valid: false}, nil
+		return nil, false
 	}
 
 	enc := base64.RawURLEncoding
 	headerJSON, err := enc.DecodeString(parts[0])
 	if err != nil {
-		return &core.TokenInfo{Valid: false}, nil
+		return nil, false
 	}
 	var header map[string]interface{}
 	_ = json.Unmarshal(headerJSON, &header)
-	if alg, _ := header["alg"].(string); alg != "HS256" {
-		return &core.TokenInfo{Valid: false}, nil
-	}
+	alg, _ := header["alg"].(string)
 
 	payloadJSON, err := enc.DecodeString(parts[1])
 	if err != nil {
-		return &core.TokenInfo{Valid: false}, nil
+		return nil, false
 	}
 
 	// Verify signature
 	signingInput := parts[0] + "." + parts[1]
-	mac := hmac.New(sha256.New, s.secret)
-	_, _ = mac.Write([]byte(signingInput))
-	expected := mac.Sum(nil)
 	sig, err := enc.DecodeString(parts[2])
 	if err != nil {
-		return &core.TokenInfo{Valid: false}, nil
+		return nil, false
 	}
-	if !hmac.Equal(sig, expected) {
-		return &core.TokenInfo{Valid: false}, nil
+	if !s.keys.verify(alg, signingInput, sig) {
+		return nil, false
 	}
 
 	// Parse claims
 	var claims map[string]interface{}
 	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
-		return &core.TokenInfo{Valid: false}, nil
+		return nil, false
 	}
 
 	now := time.Now().Unix()
@@ -1074,11 +2140,11 @@ func (s *securityManagerImpl) ValidateToken(ctx context.Context, token string) (
 		switch t := v.(type) {
 		case float64:
 			if int64(t) < now {
-				return &core.TokenInfo{Valid: false}, nil
+				return nil, false
 			}
 		case int64:
 			if t < now {
-				return &core.TokenInfo{Valid: false}, nil
+				return nil, false
 			}
 		}
 	}
@@ -1087,18 +2153,18 @@ func (s *securityManagerImpl) ValidateToken(ctx context.Context, token string) (
 		switch t := v.(type) {
 		case float64:
 			if int64(t) > now {
-				return &core.TokenInfo{Valid: false}, nil
+				return nil, false
 			}
 		case int64:
 			if t > now {
-				return &core.TokenInfo{Valid: false}, nil
+				return nil, false
 			}
 		}
 	}
 	// iss
 	if s.issuer != "" {
 		if iss, _ := claims["iss"].(string); iss != s.issuer {
-			return &core.TokenInfo{Valid: false}, nil
+			return nil, false
 		}
 	}
 	// aud
@@ -1127,112 +2193,15 @@ func (s *securityManagerImpl) ValidateToken(ctx context.Context, token string) (
 			}
 		} else {
 			// missing aud but required
-			return &core.TokenInfo{Valid: false}, nil
+			return nil, false
 		}
 		if !okAud {
-			return &core.TokenInfo{Valid: false}, nil
-		}
-	}
-
-	userID := ""
-	if sub, _ := claims["sub"].(string); sub != "" {
-		userID = sub
-	}
-
-	expireAt := int64(0)
-	if v, ok := claims["exp"]; ok {
-		switch t := v.(type) {
-		case float64:
-			expireAt = int64(t)
-		case int64:
-			expireAt = t
+			return nil, false
 		}
 	}
 
-	return &core.TokenInfo{
-		Valid:       true,
-		UserID:      userID,
-		PeerID:      userID,
-		Permissions: []string{},
-		ExpireAt:    expireAt,
-	}, nil
-}
-
-// Network manager implementation
-type networkManagerImpl struct {
-	mu      sync.RWMutex
-	started bool
-	logger  core.Logger
-	peers   map[string]core.Peer
-}
-
-func (n *networkManagerImpl) Name() string { return "network" }
-func (n *networkManagerImpl) Start(ctx context.Context) error {
-	n.mu.Lock()
-	n.started = true
-	if n.peers == nil {
-		n.peers = map[string]core.Peer{}
-	}
-	n.mu.Unlock()
-	return nil
-}
-func (n *networkManagerImpl) Stop(ctx context.Context) error {
-	n.mu.Lock()
-	n.started = false
-	n.mu.Unlock()
-	return nil
-}
-func (n *networkManagerImpl) IsHealthy() bool { n.mu.RLock(); defer n.mu.RUnlock(); return n.started }
-func (n *networkManagerImpl) Health() core.HealthStatus {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	status := core.HealthStatusHealthy
-	if !n.started {
-		status = core.HealthStatusUnhealthy
-	}
-	return core.HealthStatus{Status: status, Timestamp: time.Now()}
-}
-func (n *networkManagerImpl) Configuration() core.ConfigSchema {
-	return core.ConfigSchema{Properties: map[string]core.PropertySchema{}}
-}
-
-func (n *networkManagerImpl) DiscoverPeers() ([]core.Peer, error) {
-	return n.GetPeers(), nil
-}
-func (n *networkManagerImpl) GetPeers() []core.Peer {
-	n.mu.RLock()
-	defer n.mu.RUnlock()
-	out := make([]core.Peer, 0, len(n.peers))
-	for _, p := range n.peers {
-		out = append(out, p)
-	}
-	return out
-}
-func (n *networkManagerImpl) ConnectToPeer(address string) (core.Peer, error) {
-	n.mu.Lock()
-	defer n.mu.Unlock()
-	if n.peers == nil {
-		n.peers = map[string]core.Peer{}
-	}
-	id := fmt.Sprintf("peer-%d", time.Now().UnixNano())
-	p := core.Peer{
-		ID:       id,
-		Address:  address,
-		Name:     address,
-		Status:   "connected",
-		Metadata: map[string]interface{}{},
-		LastSeen: time.Now().Unix(),
-	}
-	n.peers[id] = p
-	return p, nil
-}
-func (n *networkManagerImpl) ListPeers() []core.Peer { return n.GetPeers() }
-func (n *networkManagerImpl) SendMessage(peerID string, message []byte) error {
-	_ = peerID
-	_ = message
-	return nil
+	return claims, true
 }
-func (n *networkManagerImpl) BroadcastMessage(message []byte) error { _ = message; return nil }
 
 // Resource manager implementation
 type resourceManagerImpl struct {
@@ -1275,7 +2244,7 @@ func (r *resourceManagerImpl) Configuration() core.ConfigSchema {
 
 func (r *resourceManagerImpl) RegisterResource(resource core.Resource) error {
 	if resource == nil || resource.ID() == "" {
-		return fmt.Errorf("invalid resource")
+		return fmt.Errorf("%w: resource has no ID", core.ErrInvalidRequest)
 	}
 	r.mu.Lock()
 	r.resources[resource.ID()] = resource
@@ -1295,7 +2264,7 @@ func (r *resourceManagerImpl) GetResource(ctx context.Context, id string) (core.
 	res, ok := r.resources[id]
 	r.mu.RUnlock()
 	if !ok {
-		return nil, fmt.Errorf("resource not found")
+		return nil, fmt.Errorf("%w: %s", core.ErrResourceNotFound, id)
 	}
 	return res, nil
 }
@@ -1305,17 +2274,9 @@ func (r *resourceManagerImpl) ListResources(ctx context.Context, filter core.Res
 	defer r.mu.RUnlock()
 	out := make([]core.Resource, 0, len(r.resources))
 	for _, res := range r.resources {
-		if filter.Type != "" && res.Type() != filter.Type {
-			continue
-		}
-		if filter.Name != "" {
-			if name, ok := res.GetMetadata()["name"].(string); ok {
-				if name != filter.Name {
-					continue
-				}
-			}
+		if filter.Matches(res) {
+			out = append(out, res)
 		}
-		out = append(out, res)
 	}
 	return out, nil
 }
@@ -1335,10 +2296,14 @@ func (m *memoryResourceStream) Read() ([]byte, error) {
 func (m *memoryResourceStream) Close() error { return nil }
 
 func (r *resourceManagerImpl) StreamResource(ctx context.Context, id string) (core.ResourceStream, error) {
-	// Minimal streaming: return a single-chunk stream
-	if _, err := r.GetResource(ctx, id); err != nil {
+	res, err := r.GetResource(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	if streamable, ok := res.(core.StreamableResource); ok {
+		return streamable.Stream(ctx)
+	}
+	// Minimal streaming: return a single-chunk stream
 	return &memoryResourceStream{}, nil
 }
 
@@ -1394,7 +2359,7 @@ func (s *serviceManagerImpl) GetService(name string) (core.Service, error) {
 	if svc, ok := s.services[name]; ok {
 		return svc, nil
 	}
-	return nil, fmt.Errorf("service %s not found", name)
+	return nil, fmt.Errorf("%w: %s", core.ErrServiceNotFound, name)
 }
 
 func (s *serviceManagerImpl) Configuration() core.ConfigSchema {
@@ -1403,7 +2368,7 @@ func (s *serviceManagerImpl) Configuration() core.ConfigSchema {
 
 func (s *serviceManagerImpl) RegisterService(service core.Service) error {
 	if service == nil || service.Name() == "" {
-		return fmt.Errorf("invalid service")
+		return fmt.Errorf("%w: service has no name", core.ErrInvalidRequest)
 	}
 	s.mu.Lock()
 	if s.services == nil {
@@ -1414,37 +2379,122 @@ func (s *serviceManagerImpl) RegisterService(service core.Service) error {
 	return nil
 }
 
-func NewEventBus(logger core.Logger) (core.EventBus, error) {
+func NewEventBus(config EventsConfig, metrics core.MetricsCollector, logger core.Logger) (core.EventBus, error) {
+	if !validOverflowPolicy(config.OverflowPolicy) {
+		return nil, fmt.Errorf("%w: unknown event overflow policy %q", core.ErrInvalidConfig, config.OverflowPolicy)
+	}
 	return &eventBusImpl{
-		logger: logger,
-		subs:   map[string][]func(context.Context, core.Event) error{},
+		config:  config.withDefaults(),
+		metrics: metrics,
+		logger:  logger,
+		subs:    map[string][]eventSubscriber{},
 	}, nil
 }
 func NewMetricsCollector(config MetricsConfig, logger core.Logger) (core.MetricsCollector, error) {
+	if !config.Enabled {
+		return noopMetricsCollector{}, nil
+	}
 	return &metricsCollectorImpl{
 		logger:     logger,
 		counters:   map[string]*counterImpl{},
+		counterVec: map[string]*counterVecImpl{},
 		gauges:     map[string]*gaugeImpl{},
 		histograms: map[string]*histogramImpl{},
 		timers:     map[string]*timerImpl{},
+		buckets:    histogramBounds(config.HistogramBuckets),
+		interval:   config.Interval,
 	}, nil
 }
 func NewSecurityManager(config SecurityConfig, logger core.Logger) (core.SecurityManager, error) {
+	usesSecret := usesJWTSecret(config)
+
+	secret := []byte(config.JWTSecret)
+	if usesSecret && config.AutoGenerateSecret && (len(secret) == 0 || defaultJWTSecrets[strings.ToLower(config.JWTSecret)]) {
+		if len(secret) > 0 {
+			logger.Warn("Ignoring default JWTSecret, using a generated one", core.Field{Key: "file", Value: config.JWTSecretFile})
+		}
+		var err error
+		if secret, err = loadOrGenerateSecret(config.JWTSecretFile); err != nil {
+			return nil, fmt.Errorf("failed to generate JWT secret: %w", err)
+		}
+	}
+
+	// Admin routes accept HS256 tokens even with auth disabled, so a weak
+	// secret is refused either way
+	if usesSecret {
+		if err := checkJWTSecret(string(secret)); err != nil {
+			return nil, fmt.Errorf("%w: %w", core.ErrInvalidConfig, err)
+		}
+	}
+
+	keys, err := loadJWTKeys(config, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads, err := newPayloadCipher(config)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiry := config.RefreshTokenExpiry
+	if refreshExpiry <= 0 {
+		refreshExpiry = 7 * 24 * time.Hour
+	}
+
 	sm := &securityManagerImpl{
-		logger:      logger,
-		tokenExpiry: config.TokenExpiry,
-		secret:      []byte(config.JWTSecret),
-		issuer:      config.JWTIssuer,
-		audience:    config.JWTAudience,
+		logger:        logger,
+		tokenExpiry:   config.TokenExpiry,
+		refreshExpiry: refreshExpiry,
+		keys:          keys,
+		issuer:        config.JWTIssuer,
+		audience:      config.JWTAudience,
+		roles:         config.Roles,
+		revoked:       map[string]time.Time{},
+		refreshTokens: map[string]time.Time{},
+		cipher:        payloads,
 	}
 	return sm, nil
 }
-func NewNetworkManager(config NetworkConfig, security core.SecurityManager, eventBus core.EventBus, logger core.Logger) (core.NetworkManager, error) {
-	return &networkManagerImpl{
-		logger: logger,
-		peers:  map[string]core.Peer{},
-	}, nil
+
+// usesJWTSecret reports whether tokens are signed with the shared JWTSecret
+// (HS256) rather than a key pair
+func usesJWTSecret(config SecurityConfig) bool {
+	return config.JWTAlgorithm == "" || config.JWTAlgorithm == JWTAlgorithmHS256
+}
+
+// loadOrGenerateSecret reads a previously generated secret from path, or
+// creates a new random one and persists it there. An empty path yields a
+// secret that only lives for the lifetime of the process.
+func loadOrGenerateSecret(path string) ([]byte, error) {
+	if path != "" {
+		path = expandHome(path)
+		if data, err := os.ReadFile(path); err == nil {
+			if secret := strings.TrimSpace(string(data)); len(secret) >= MinJWTSecretLength {
+				return []byte(secret), nil
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	buf := make([]byte, MinJWTSecretLength)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret := []byte(hex.EncodeToString(buf))
+
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, secret, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return secret, nil
 }
+
 func NewResourceManager(network core.NetworkManager, security core.SecurityManager, eventBus core.EventBus, logger core.Logger) (core.ResourceManager, error) {
 	return &resourceManagerImpl{
 		logger:    logger,
@@ -0,0 +1,210 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestSelfCheck(t *testing.T) {
+	// file is a regular file, which can be neither a storage dir nor an
+	// allowed path
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name    string
+		edit    func(*PlatformConfig)
+		wantErr string
+	}{
+		{"valid", func(*PlatformConfig) {}, ""},
+		{"auth without secret", func(c *PlatformConfig) {
+			c.Security.EnableAuth = true
+			c.Security.JWTSecret = ""
+		}, "JWTSecret empty"},
+		{"default secret without auth", func(c *PlatformConfig) {
+			c.Security.EnableAuth = false
+			c.Security.JWTSecret = "change-me"
+		}, `JWTSecret is the default "change-me"`},
+		{"auth with generated secret", func(c *PlatformConfig) {
+			c.Security.EnableAuth = true
+			c.Security.JWTSecret = ""
+			c.Security.AutoGenerateSecret = true
+		}, ""},
+		{"upload dir not writable", func(c *PlatformConfig) { c.Storage.UploadDir = file }, "upload dir " + file + " is not writable"},
+		{"download dir not writable", func(c *PlatformConfig) { c.Storage.DownloadDir = file }, "download dir " + file + " is not writable"},
+		{"allowed path missing", func(c *PlatformConfig) { c.Storage.AllowedPaths = []string{missing} }, "allowed path " + missing + " does not exist"},
+		{"allowed path not a directory", func(c *PlatformConfig) { c.Storage.AllowedPaths = []string{file} }, "allowed path " + file + " is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			// Break the config after construction so only the self-check
+			// sees it
+			tt.edit(p.Config())
+
+			err := p.SelfCheck()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("SelfCheck: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SelfCheck error = %v, want %q", err, tt.wantErr)
+			}
+
+			// Start fails fast with the same error
+			if err := p.Start(context.Background()); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				p.Stop(context.Background())
+				t.Errorf("Start error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelfCheckReportsEveryFailure(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	config := p.Config()
+	config.Security.EnableAuth = true
+	config.Security.JWTSecret = ""
+	config.Storage.AllowedPaths = []string{filepath.Join(t.TempDir(), "missing")}
+
+	err := p.SelfCheck()
+	if err == nil {
+		t.Fatal("SelfCheck passed a broken config")
+	}
+	for _, want := range []string{"JWTSecret empty", "does not exist"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("SelfCheck error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestPublishEventSource(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { p.Stop(context.Background()) })
+
+	received := make(chan core.Event, 1)
+	if _, err := p.GetEventBus().Subscribe("test.published", func(event core.Event) error {
+		received <- event
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	clipboard, files := &testPlugin{id: "clipboard"}, &testPlugin{id: "files"}
+	for _, plugin := range []core.Plugin{clipboard, files} {
+		if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := core.WithRequestID(context.Background(), "req-1")
+
+	tests := []struct {
+		name          string
+		publish       func(data map[string]interface{}) error
+		wantSource    string
+		wantRequestID interface{}
+	}{
+		{"plugin", func(data map[string]interface{}) error {
+			return p.apiFor(clipboard).PublishEvent("test.published", data)
+		}, "clipboard", nil},
+		{"plugin with request", func(data map[string]interface{}) error {
+			return p.apiFor(files).PublishEventContext(ctx, "test.published", data)
+		}, "files", "req-1"},
+		{"platform", func(data map[string]interface{}) error {
+			return p.PublishEvent("test.published", data)
+		}, "platform", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A source in the data doesn't change who the event is from
+			if err := tt.publish(map[string]interface{}{"source": "spoofed"}); err != nil {
+				t.Fatalf("publish: %v", err)
+			}
+			var event core.Event
+			select {
+			case event = <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("event not delivered")
+			}
+			if event.Source != tt.wantSource {
+				t.Errorf("source = %q, want %q", event.Source, tt.wantSource)
+			}
+			if event.ID == "" || event.Timestamp == 0 {
+				t.Errorf("event ID %q, timestamp %d, want both set", event.ID, event.Timestamp)
+			}
+			if got := event.Data[core.RequestIDDataKey]; got != tt.wantRequestID {
+				t.Errorf("request ID = %v, want %v", got, tt.wantRequestID)
+			}
+		})
+	}
+}
+
+func TestPathAllowed(t *testing.T) {
+	allowed, outside := t.TempDir(), t.TempDir()
+	for _, dir := range []string{filepath.Join(allowed, "sub"), filepath.Join(outside, "sub")} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "out")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(allowed, "sub"), filepath.Join(outside, "in")); err != nil {
+		t.Fatal(err)
+	}
+	// A sibling sharing the allowed directory's name as a prefix
+	sibling := allowed + "-other"
+	if err := os.Mkdir(sibling, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		allowedPaths []string
+		path         string
+		want         bool
+	}{
+		{"allowed root", []string{allowed}, allowed, true},
+		{"inside", []string{allowed}, filepath.Join(allowed, "sub"), true},
+		{"outside", []string{allowed}, filepath.Join(outside, "sub"), false},
+		{"dot-dot", []string{allowed}, filepath.Join(allowed, "sub", "..", "..", filepath.Base(outside)), false},
+		{"prefix sibling", []string{allowed}, sibling, false},
+		{"symlink leading out", []string{allowed}, filepath.Join(allowed, "out", "sub"), false},
+		{"symlink leading in", []string{allowed}, filepath.Join(outside, "in"), true},
+		{"missing", []string{allowed}, filepath.Join(allowed, "nope"), false},
+		{"second allowed path", []string{outside, allowed}, filepath.Join(allowed, "sub"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *PlatformConfig) {
+				cfg.Storage.AllowedPaths = tt.allowedPaths
+			})
+			if got := p.PathAllowed(tt.path); got != tt.want {
+				t.Errorf("PathAllowed(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// Without allowed paths, the storage directories are allowed
+	p := newTestPlatform(t, nil)
+	if !p.PathAllowed(p.Config().Storage.UploadDir) || !p.PathAllowed(p.Config().Storage.DownloadDir) {
+		t.Error("storage directories not allowed by default")
+	}
+	if p.PathAllowed(allowed) {
+		t.Error("other directory allowed with no allowed paths configured")
+	}
+}
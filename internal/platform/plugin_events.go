@@ -0,0 +1,94 @@
+package platform
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// pluginEventBus is the event bus handed to a plugin. It records what the
+// plugin subscribes to so the platform can report it.
+type pluginEventBus struct {
+	core.EventBus
+	platform *Platform
+	pluginID string
+}
+
+// GetEventBus returns the event bus as seen by the plugin
+func (a *pluginAPI) GetEventBus() core.EventBus {
+	return &pluginEventBus{EventBus: a.eventBus, platform: a.Platform, pluginID: a.pluginID}
+}
+
+func (b *pluginEventBus) Subscribe(eventType string, handler core.EventHandler) (core.Subscription, error) {
+	sub, err := b.EventBus.Subscribe(eventType, handler)
+	if err != nil {
+		return sub, err
+	}
+	b.platform.addSubscription(b.pluginID, sub)
+	return sub, nil
+}
+
+func (b *pluginEventBus) SubscribeWithContext(ctx context.Context, eventType string, handler func(context.Context, core.Event) error) (core.Subscription, error) {
+	sub, err := b.EventBus.SubscribeWithContext(ctx, eventType, handler)
+	if err != nil {
+		return sub, err
+	}
+	b.platform.addSubscription(b.pluginID, sub)
+	if done := ctx.Done(); done != nil {
+		go func() {
+			<-done
+			b.platform.removeSubscription(b.pluginID, sub)
+		}()
+	}
+	return sub, nil
+}
+
+func (b *pluginEventBus) Unsubscribe(sub core.Subscription) error {
+	if err := b.EventBus.Unsubscribe(sub); err != nil {
+		return err
+	}
+	b.platform.removeSubscription(b.pluginID, sub)
+	return nil
+}
+
+func (p *Platform) addSubscription(plugin string, sub core.Subscription) {
+	p.subsMu.Lock()
+	defer p.subsMu.Unlock()
+
+	subs := p.pluginSubs[plugin]
+	if subs == nil {
+		subs = make(map[uint64]string)
+		p.pluginSubs[plugin] = subs
+	}
+	subs[sub.ID] = sub.EventType
+}
+
+func (p *Platform) removeSubscription(plugin string, sub core.Subscription) {
+	p.subsMu.Lock()
+	delete(p.pluginSubs[plugin], sub.ID)
+	p.subsMu.Unlock()
+}
+
+func (p *Platform) forgetSubscriptions(plugin string) {
+	p.subsMu.Lock()
+	delete(p.pluginSubs, plugin)
+	p.subsMu.Unlock()
+}
+
+// PluginSubscriptions returns the event types a plugin is subscribed to
+func (p *Platform) PluginSubscriptions(name string) []string {
+	p.subsMu.Lock()
+	defer p.subsMu.Unlock()
+
+	seen := make(map[string]bool, len(p.pluginSubs[name]))
+	events := make([]string, 0, len(p.pluginSubs[name]))
+	for _, eventType := range p.pluginSubs[name] {
+		if !seen[eventType] {
+			seen[eventType] = true
+			events = append(events, eventType)
+		}
+	}
+	sort.Strings(events)
+	return events
+}
@@ -0,0 +1,72 @@
+package platform
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestPluginSubscriptions(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	plugin := &testPlugin{id: "listener"}
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatal(err)
+	}
+	bus := p.apiFor(plugin).GetEventBus()
+	handler := func(core.Event) error { return nil }
+
+	expect := func(want ...string) {
+		t.Helper()
+		if got := p.PluginSubscriptions("listener"); !slices.Equal(got, want) {
+			t.Errorf("subscriptions = %v, want %v", got, want)
+		}
+	}
+
+	expect()
+	files, err := bus.Subscribe("file.uploaded", handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each event type is listed once however many handlers it has
+	if _, err := bus.Subscribe("file.uploaded", handler); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bus.Subscribe("clipboard.changed", handler); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := bus.SubscribeWithContext(ctx, "peer.joined", func(context.Context, core.Event) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	expect("clipboard.changed", "file.uploaded", "peer.joined")
+
+	// Events other code subscribes to aren't the plugin's
+	if _, err := p.eventBus.Subscribe("platform.started", handler); err != nil {
+		t.Fatal(err)
+	}
+	expect("clipboard.changed", "file.uploaded", "peer.joined")
+
+	if err := bus.Unsubscribe(files); err != nil {
+		t.Fatal(err)
+	}
+	expect("clipboard.changed", "file.uploaded", "peer.joined")
+
+	// A subscription ends with its context
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for slices.Contains(p.PluginSubscriptions("listener"), "peer.joined") {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription kept after its context was cancelled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expect("clipboard.changed", "file.uploaded")
+
+	if err := p.UnloadPlugin(context.Background(), "listener"); err != nil {
+		t.Fatal(err)
+	}
+	expect()
+}
@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// displayNamePlugin is a testPlugin whose display name differs from its ID
+type displayNamePlugin struct {
+	*testPlugin
+	name string
+}
+
+func (dp *displayNamePlugin) Name() string { return dp.name }
+
+func TestLoadPluginKeyedByID(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	ctx := context.Background()
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { p.Stop(context.Background()) })
+
+	events := make(chan core.Event, 10)
+	if _, err := p.GetEventBus().Subscribe("plugin.loaded", func(event core.Event) error {
+		events <- event
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	fileManager := &displayNamePlugin{&testPlugin{id: "file-manager"}, "File Manager Plugin"}
+	if err := p.LoadPlugin(ctx, fileManager); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		plugin  core.Plugin
+		wantErr error
+	}{
+		{"same ID, other name", &displayNamePlugin{&testPlugin{id: "file-manager"}, "Another File Manager"}, core.ErrPluginExists},
+		{"same name, other ID", &displayNamePlugin{&testPlugin{id: "file-manager-2"}, "File Manager Plugin"}, nil},
+		{"no ID", &displayNamePlugin{&testPlugin{}, "Nameless"}, core.ErrInvalidRequest},
+		{"depends on the ID", &testPlugin{id: "thumbnails", deps: []string{"file-manager"}}, nil},
+		{"depends on the name", &testPlugin{id: "previews", deps: []string{"File Manager Plugin"}}, core.ErrMissingDependency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.LoadPlugin(ctx, tt.plugin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadPlugin = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A duplicate is reported with the plugin already holding the ID
+	err := p.LoadPlugin(ctx, &displayNamePlugin{&testPlugin{id: "file-manager"}, "Impostor"})
+	if err == nil || !strings.Contains(err.Error(), "File Manager Plugin") {
+		t.Errorf("duplicate ID error %v does not name the loaded plugin", err)
+	}
+
+	// Lookups go by ID only
+	if got, err := p.GetPlugin("file-manager"); err != nil || got != fileManager {
+		t.Errorf("GetPlugin by ID = %v, %v", got, err)
+	}
+	if _, err := p.GetPlugin("File Manager Plugin"); !errors.Is(err, core.ErrPluginNotFound) {
+		t.Errorf("GetPlugin by name = %v, want %v", err, core.ErrPluginNotFound)
+	}
+
+	select {
+	case event := <-events:
+		if event.Data["id"] != "file-manager" || event.Data["name"] != "File Manager Plugin" {
+			t.Errorf("plugin.loaded data = %v", event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no plugin.loaded event")
+	}
+}
@@ -0,0 +1,171 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// PluginStatus is the lifecycle state of a loaded plugin. Error holds the
+// reason for the last failure.
+type PluginStatus struct {
+	State core.PluginState `json:"state"`
+	Error string           `json:"error,omitempty"`
+	Since time.Time        `json:"since"`
+}
+
+// DefaultPluginTimeout bounds plugin lifecycle calls when the config does
+// not set a timeout
+const DefaultPluginTimeout = 30 * time.Second
+
+// pluginTimeoutLocked returns the timeout for plugin lifecycle calls. The
+// caller holds p.mu.
+func (p *Platform) pluginTimeoutLocked() time.Duration {
+	if p.config != nil && p.config.Plugins.LifecycleTimeout > 0 {
+		return p.config.Plugins.LifecycleTimeout
+	}
+	return DefaultPluginTimeout
+}
+
+func (p *Platform) pluginTimeout() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pluginTimeoutLocked()
+}
+
+// callPlugin runs op (initialize, start or stop) on the named plugin and
+// gives up once timeout has passed. call gets a context derived from ctx
+// that ends with the timeout and is cancelled when callPlugin returns, so a
+// plugin that honours it stops working on a call the platform gave up on;
+// plugins must not keep it past the call.
+func callPlugin(ctx context.Context, name, op string, timeout time.Duration, call func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- call(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: plugin %s did not %s within %s", core.ErrPluginTimeout, name, op, timeout)
+		}
+		return fmt.Errorf("plugin %s did not %s: %w", name, op, ctx.Err())
+	}
+}
+
+// setPluginStateLocked records a state change. The caller holds p.mu.
+func (p *Platform) setPluginStateLocked(name string, state core.PluginState, err error) {
+	status := PluginStatus{State: state, Since: time.Now()}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	p.pluginStates[name] = status
+}
+
+func (p *Platform) setPluginState(name string, state core.PluginState, err error) {
+	p.mu.Lock()
+	p.setPluginStateLocked(name, state, err)
+	p.mu.Unlock()
+}
+
+// PluginStatus returns the lifecycle state of a loaded plugin
+func (p *Platform) PluginStatus(name string) (PluginStatus, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status, ok := p.pluginStates[name]
+	return status, ok
+}
+
+// pluginFor returns the plugin called name after checking that it may move
+// to next
+func (p *Platform) pluginFor(name string, next core.PluginState) (core.Plugin, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pluginForLocked(name, next)
+}
+
+// claimPlugin is pluginFor also moving the plugin to next, so of two
+// concurrent calls only one gets past the check. The caller moves it on
+// from next when its work is done.
+func (p *Platform) claimPlugin(name string, next core.PluginState) (core.Plugin, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	plugin, err := p.pluginForLocked(name, next)
+	if err != nil {
+		return nil, err
+	}
+	p.setPluginStateLocked(name, next, nil)
+	return plugin, nil
+}
+
+// pluginForLocked is pluginFor for callers holding p.mu
+func (p *Platform) pluginForLocked(name string, next core.PluginState) (core.Plugin, error) {
+	plugin, exists := p.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", core.ErrPluginNotFound, name)
+	}
+	current := p.pluginStates[name].State
+	if !current.CanTransition(next) {
+		if current == core.PluginStateFailed {
+			return nil, fmt.Errorf("%w: plugin %s failed and must be re-initialized first", core.ErrInvalidState, name)
+		}
+		return nil, fmt.Errorf("%w: plugin %s cannot go from %s to %s", core.ErrInvalidState, name, current, next)
+	}
+	return plugin, nil
+}
+
+// StartPlugin starts a loaded plugin
+func (p *Platform) StartPlugin(ctx context.Context, name string) error {
+	plugin, err := p.claimPlugin(name, core.PluginStateStarting)
+	if err != nil {
+		return err
+	}
+	err = callPlugin(ctx, name, "start", p.pluginTimeout(), plugin.Start)
+	if err != nil {
+		p.setPluginState(name, core.PluginStateFailed, err)
+		return fmt.Errorf("failed to start plugin %s: %w", name, err)
+	}
+	p.setPluginState(name, core.PluginStateStarted, nil)
+	return nil
+}
+
+// StopPlugin stops a running plugin
+func (p *Platform) StopPlugin(ctx context.Context, name string) error {
+	plugin, err := p.claimPlugin(name, core.PluginStateStopping)
+	if err != nil {
+		return err
+	}
+	err = callPlugin(ctx, name, "stop", p.pluginTimeout(), plugin.Stop)
+	if err != nil {
+		p.setPluginState(name, core.PluginStateFailed, err)
+		return fmt.Errorf("failed to stop plugin %s: %w", name, err)
+	}
+	p.setPluginState(name, core.PluginStateStopped, nil)
+	return nil
+}
+
+// ReinitializePlugin initializes a stopped or failed plugin again so it can
+// be started
+func (p *Platform) ReinitializePlugin(name string) error {
+	plugin, err := p.pluginFor(name, core.PluginStateInitialized)
+	if err != nil {
+		return err
+	}
+	err = callPlugin(context.Background(), name, "initialize", p.pluginTimeout(), func(context.Context) error { return plugin.Initialize(p.apiFor(plugin)) })
+	if err != nil {
+		p.setPluginState(name, core.PluginStateFailed, err)
+		return fmt.Errorf("failed to initialize plugin %s: %w", name, err)
+	}
+	p.setPluginState(name, core.PluginStateInitialized, nil)
+	return nil
+}
@@ -0,0 +1,375 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// testPlugin is a plugin depending on deps whose start and stop run the
+// given functions and that counts its initializations
+type testPlugin struct {
+	id    string
+	deps  []string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+	inits atomic.Int32
+}
+
+func (tp *testPlugin) Start(ctx context.Context) error {
+	if tp.start == nil {
+		return nil
+	}
+	return tp.start(ctx)
+}
+
+func (tp *testPlugin) Stop(ctx context.Context) error {
+	if tp.stop == nil {
+		return nil
+	}
+	return tp.stop(ctx)
+}
+
+func (tp *testPlugin) IsHealthy() bool                        { return true }
+func (tp *testPlugin) Name() string                           { return tp.id }
+func (tp *testPlugin) Health() core.HealthStatus              { return core.HealthStatus{} }
+func (tp *testPlugin) Configuration() core.ConfigSchema       { return core.ConfigSchema{} }
+func (tp *testPlugin) ID() string                             { return tp.id }
+func (tp *testPlugin) Version() string                        { return "test" }
+func (tp *testPlugin) Dependencies() []string                 { return tp.deps }
+func (tp *testPlugin) Initialize(core.PlatformAPI) error      { tp.inits.Add(1); return nil }
+func (tp *testPlugin) Configure(map[string]interface{}) error { return nil }
+func (tp *testPlugin) Routes() []core.Route                   { return nil }
+func (tp *testPlugin) HandleEvent(core.Event) error           { return nil }
+
+// blockUntilCancelled returns a lifecycle call that blocks until its context
+// ends, reporting that on cancelled
+func blockUntilCancelled(cancelled chan<- struct{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}
+}
+
+func TestCallPluginCancelsBlockedCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    func(p *Platform, plugin core.Plugin) error
+		blockOn func(tp *testPlugin, cancelled chan<- struct{})
+	}{
+		{
+			name:    "start",
+			call:    func(p *Platform, plugin core.Plugin) error { return p.StartPlugin(context.Background(), plugin.ID()) },
+			blockOn: func(tp *testPlugin, cancelled chan<- struct{}) { tp.start = blockUntilCancelled(cancelled) },
+		},
+		{
+			name: "stop",
+			call: func(p *Platform, plugin core.Plugin) error {
+				if err := p.StartPlugin(context.Background(), plugin.ID()); err != nil {
+					return err
+				}
+				return p.StopPlugin(context.Background(), plugin.ID())
+			},
+			blockOn: func(tp *testPlugin, cancelled chan<- struct{}) { tp.stop = blockUntilCancelled(cancelled) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *PlatformConfig) {
+				cfg.Plugins.LifecycleTimeout = 20 * time.Millisecond
+			})
+			cancelled := make(chan struct{})
+			plugin := &testPlugin{id: "blocking"}
+			tt.blockOn(plugin, cancelled)
+			if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+				t.Fatalf("LoadPlugin: %v", err)
+			}
+
+			err := tt.call(p, plugin)
+			if !errors.Is(err, core.ErrPluginTimeout) {
+				t.Fatalf("got error %v, want %v", err, core.ErrPluginTimeout)
+			}
+			select {
+			case <-cancelled:
+			case <-time.After(time.Second):
+				t.Fatal("the plugin's context was not cancelled")
+			}
+			if status, _ := p.PluginStatus(plugin.ID()); status.State != core.PluginStateFailed {
+				t.Errorf("state = %s, want %s", status.State, core.PluginStateFailed)
+			}
+		})
+	}
+}
+
+func TestLoadPluginRecordsFailedStart(t *testing.T) {
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Plugins.LifecycleTimeout = 20 * time.Millisecond
+	})
+	ctx := context.Background()
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Stop(ctx)
+
+	cancelled := make(chan struct{})
+	plugin := &testPlugin{id: "blocking", start: blockUntilCancelled(cancelled)}
+	if err := p.LoadPlugin(ctx, plugin); !errors.Is(err, core.ErrPluginTimeout) {
+		t.Fatalf("LoadPlugin: got error %v, want %v", err, core.ErrPluginTimeout)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the plugin's context was not cancelled")
+	}
+	status, ok := p.PluginStatus(plugin.ID())
+	if !ok || status.State != core.PluginStateFailed {
+		t.Errorf("status = %+v, %v, want %s", status, ok, core.PluginStateFailed)
+	}
+}
+
+func TestPluginTransitions(t *testing.T) {
+	ctx := context.Background()
+	start := func(p *Platform, id string) error { return p.StartPlugin(ctx, id) }
+	stop := func(p *Platform, id string) error { return p.StopPlugin(ctx, id) }
+	tests := []struct {
+		name    string
+		steps   []func(p *Platform, id string) error
+		wantErr error
+		want    core.PluginState
+	}{
+		{
+			name:  "start",
+			steps: []func(*Platform, string) error{start},
+			want:  core.PluginStateStarted,
+		},
+		{
+			name:  "stop",
+			steps: []func(*Platform, string) error{start, stop},
+			want:  core.PluginStateStopped,
+		},
+		{
+			name:  "restart after stop",
+			steps: []func(*Platform, string) error{start, stop, start},
+			want:  core.PluginStateStarted,
+		},
+		{
+			name:    "start twice",
+			steps:   []func(*Platform, string) error{start, start},
+			wantErr: core.ErrInvalidState,
+			want:    core.PluginStateStarted,
+		},
+		{
+			name:    "stop before start",
+			steps:   []func(*Platform, string) error{stop},
+			wantErr: core.ErrInvalidState,
+			want:    core.PluginStateInitialized,
+		},
+		{
+			name:    "unknown plugin",
+			steps:   []func(*Platform, string) error{func(p *Platform, _ string) error { return p.StartPlugin(ctx, "missing") }},
+			wantErr: core.ErrPluginNotFound,
+			want:    core.PluginStateInitialized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			plugin := &testPlugin{id: "plugin"}
+			if err := p.LoadPlugin(ctx, plugin); err != nil {
+				t.Fatalf("LoadPlugin: %v", err)
+			}
+			var err error
+			for _, step := range tt.steps {
+				if err = step(p, plugin.id); err != nil {
+					break
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if status, _ := p.PluginStatus(plugin.id); status.State != tt.want {
+				t.Errorf("state = %s, want %s", status.State, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentStartsCallPluginOnce(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	release := make(chan struct{})
+	var starts atomic.Int32
+	plugin := &testPlugin{id: "plugin", start: func(context.Context) error {
+		starts.Add(1)
+		<-release
+		return nil
+	}}
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+
+	const callers = 8
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() { errs <- p.StartPlugin(context.Background(), plugin.id) }()
+	}
+	// All but the caller that claimed the plugin are turned away
+	for i := 0; i < callers-1; i++ {
+		if err := <-errs; !errors.Is(err, core.ErrInvalidState) {
+			t.Errorf("got error %v, want %v", err, core.ErrInvalidState)
+		}
+	}
+	if status, _ := p.PluginStatus(plugin.id); status.State != core.PluginStateStarting {
+		t.Errorf("state while starting = %s, want %s", status.State, core.PluginStateStarting)
+	}
+	close(release)
+	if err := <-errs; err != nil {
+		t.Errorf("StartPlugin: %v", err)
+	}
+	if n := starts.Load(); n != 1 {
+		t.Errorf("Start was called %d times, want 1", n)
+	}
+}
+
+func TestReloadLeavesStoppedPluginsStopped(t *testing.T) {
+	ctx := context.Background()
+	p := newTestPlatform(t, nil)
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Stop(ctx)
+
+	var runningStarts, stoppedStarts atomic.Int32
+	running := &testPlugin{id: "running", start: func(context.Context) error { runningStarts.Add(1); return nil }}
+	stopped := &testPlugin{id: "stopped", start: func(context.Context) error { stoppedStarts.Add(1); return nil }}
+	for _, plugin := range []*testPlugin{running, stopped} {
+		if err := p.LoadPlugin(ctx, plugin); err != nil {
+			t.Fatalf("LoadPlugin: %v", err)
+		}
+	}
+	if err := p.StopPlugin(ctx, stopped.id); err != nil {
+		t.Fatalf("StopPlugin: %v", err)
+	}
+
+	if err := p.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	// Each was started once on load
+	if n := runningStarts.Load(); n != 2 {
+		t.Errorf("running plugin started %d times, want 2", n)
+	}
+	if n := stoppedStarts.Load(); n != 1 {
+		t.Errorf("stopped plugin started %d times, want 1", n)
+	}
+	tests := []struct {
+		id   string
+		want core.PluginState
+	}{
+		{running.id, core.PluginStateStarted},
+		{stopped.id, core.PluginStateStopped},
+	}
+	for _, tt := range tests {
+		if status, _ := p.PluginStatus(tt.id); status.State != tt.want {
+			t.Errorf("%s: state = %s, want %s", tt.id, status.State, tt.want)
+		}
+	}
+}
+
+func TestReloadRereadsConfigAndReinitializesPlugins(t *testing.T) {
+	ctx := context.Background()
+	p, file := newConfigFilePlatform(t)
+	if err := p.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Stop(ctx)
+
+	plugin := &testPlugin{id: "reloaded"}
+	if err := p.LoadPlugin(ctx, plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	inits := plugin.inits.Load()
+
+	edited := *p.Config()
+	edited.Network.MaxPeers = 7
+	file.write(&edited)
+	loads := file.loads.Load()
+
+	if err := p.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if file.loads.Load() != loads+1 {
+		t.Errorf("config loaded %d times by Reload, want once", file.loads.Load()-loads)
+	}
+	if got := p.Config().Network.MaxPeers; got != 7 {
+		t.Errorf("MaxPeers after reload = %d, want 7", got)
+	}
+	if got := plugin.inits.Load(); got != inits+1 {
+		t.Errorf("plugin initialized %d times by Reload, want once", got-inits)
+	}
+	if status, _ := p.PluginStatus(plugin.id); status.State != core.PluginStateStarted {
+		t.Errorf("plugin state after reload = %s, want %s", status.State, core.PluginStateStarted)
+	}
+}
+
+func TestUnloadPluginCascade(t *testing.T) {
+	tests := []struct {
+		name         string
+		unload       string
+		cascade      bool
+		wantErr      error
+		wantUnloaded []string
+	}{
+		{"dependents refuse plain unload", "base", false, core.ErrPluginInUse, nil},
+		{"leaf unloads alone", "leaf", false, nil, []string{"leaf"}},
+		{"cascade from the root", "base", true, nil, []string{"leaf", "middle", "base"}},
+		{"cascade from the middle", "middle", true, nil, []string{"leaf", "middle"}},
+		{"cascade from a leaf", "leaf", true, nil, []string{"leaf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			var stopped []string
+			// base <- middle <- leaf, with other unrelated
+			for _, plugin := range []*testPlugin{
+				{id: "base"},
+				{id: "middle", deps: []string{"base"}},
+				{id: "leaf", deps: []string{"middle"}},
+				{id: "other"},
+			} {
+				id := plugin.id
+				plugin.stop = func(context.Context) error {
+					stopped = append(stopped, id)
+					return nil
+				}
+				if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var unloaded []string
+			var err error
+			if tt.cascade {
+				unloaded, err = p.UnloadPluginCascade(context.Background(), tt.unload)
+			} else if err = p.UnloadPlugin(context.Background(), tt.unload); err == nil {
+				unloaded = []string{tt.unload}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unload error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(unloaded, tt.wantUnloaded) || !slices.Equal(stopped, tt.wantUnloaded) {
+				t.Errorf("unloaded %v, stopped %v, want %v", unloaded, stopped, tt.wantUnloaded)
+			}
+			for _, id := range []string{"base", "middle", "leaf", "other"} {
+				_, err := p.GetPlugin(id)
+				if loaded := err == nil; loaded == slices.Contains(tt.wantUnloaded, id) {
+					t.Errorf("plugin %s loaded = %v", id, loaded)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package platform
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// tokenClaims decodes the claims of token without verifying it
+func tokenClaims(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", token)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+// editClaims returns token with its claims changed by edit, signed again
+// with testSecret
+func editClaims(t *testing.T, token string, edit func(claims map[string]interface{})) string {
+	t.Helper()
+	claims := tokenClaims(t, token)
+	edit(claims)
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return forgeToken(t, "."+base64.RawURLEncoding.EncodeToString(payload)+".", JWTAlgorithmHS256, []byte(testSecret))
+}
+
+func TestTokenPairClaims(t *testing.T) {
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Security.TokenExpiry = 15 * time.Minute
+		cfg.Security.RefreshTokenExpiry = 24 * time.Hour
+	})
+	pair, err := p.SecurityManager().GenerateTokenPair(&core.User{ID: "alice", Roles: []string{"operator"}})
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		token    string
+		typ      string
+		lifetime time.Duration
+	}{
+		{"access", pair.AccessToken, tokenTypeAccess, 15 * time.Minute},
+		{"refresh", pair.RefreshToken, tokenTypeRefresh, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		claims := tokenClaims(t, tt.token)
+		if claims["typ"] != tt.typ {
+			t.Errorf("%s token typ = %v, want %s", tt.name, claims["typ"], tt.typ)
+		}
+		exp := time.Unix(int64(claims["exp"].(float64)), 0)
+		if want := now.Add(tt.lifetime); exp.Before(want.Add(-time.Minute)) || exp.After(want.Add(time.Minute)) {
+			t.Errorf("%s token expires at %v, want about %v", tt.name, exp, want)
+		}
+	}
+	if tokenClaims(t, pair.AccessToken)["jti"] == tokenClaims(t, pair.RefreshToken)["jti"] {
+		t.Error("access and refresh tokens share a jti")
+	}
+	if exp := time.Unix(pair.ExpiresAt, 0); exp.After(now.Add(16 * time.Minute)) {
+		t.Errorf("pair expires at %v, want the access token's expiry", exp)
+	}
+
+	// Neither token stands in for the other
+	if info, err := p.SecurityManager().ValidateToken(context.Background(), pair.RefreshToken); err != nil || info.Valid {
+		t.Errorf("refresh token accepted as an access token: %+v, %v", info, err)
+	}
+	if _, err := p.SecurityManager().RefreshToken(context.Background(), pair.AccessToken); !errors.Is(err, core.ErrUnauthorized) {
+		t.Errorf("access token accepted as a refresh token: %v", err)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare returns the refresh token to exchange
+		prepare func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string
+		wantErr error
+	}{
+		{"unused", func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string {
+			return pair.RefreshToken
+		}, nil},
+		{"rotated token", func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string {
+			rotated, err := security.RefreshToken(context.Background(), pair.RefreshToken)
+			if err != nil {
+				t.Fatalf("first refresh: %v", err)
+			}
+			return rotated.RefreshToken
+		}, nil},
+		{"replayed after rotation", func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string {
+			if _, err := security.RefreshToken(context.Background(), pair.RefreshToken); err != nil {
+				t.Fatalf("first refresh: %v", err)
+			}
+			return pair.RefreshToken
+		}, core.ErrUnauthorized},
+		{"re-signed before expiry", func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string {
+			return editClaims(t, pair.RefreshToken, func(claims map[string]interface{}) {
+				claims["exp"] = time.Now().Add(time.Minute).Unix()
+			})
+		}, nil},
+		{"expired", func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string {
+			// Still outstanding, but past its expiry
+			return editClaims(t, pair.RefreshToken, func(claims map[string]interface{}) {
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			})
+		}, core.ErrUnauthorized},
+		{"tampered", func(t *testing.T, security core.SecurityManager, pair *core.TokenPair) string {
+			return pair.RefreshToken + "x"
+		}, core.ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			security := newTestPlatform(t, nil).SecurityManager()
+			pair, err := security.GenerateTokenPair(&core.User{ID: "alice", Roles: []string{"operator"}})
+			if err != nil {
+				t.Fatalf("GenerateTokenPair: %v", err)
+			}
+
+			refreshed, err := security.RefreshToken(context.Background(), tt.prepare(t, security, pair))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshToken error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if refreshed.RefreshToken == pair.RefreshToken || refreshed.AccessToken == pair.AccessToken {
+				t.Error("refresh returned a token it was given")
+			}
+			info, err := security.ValidateToken(context.Background(), refreshed.AccessToken)
+			if err != nil || !info.Valid || info.UserID != "alice" {
+				t.Errorf("refreshed access token = %+v, %v", info, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestValidateTokenExpandsRoles(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	security := p.SecurityManager()
+
+	tests := []struct {
+		name  string
+		user  *core.User
+		allow []string
+		deny  []string
+	}{
+		{"admin role", &core.User{ID: "root", Roles: []string{"admin"}}, []string{"platform:admin", "plugins:start"}, nil},
+		{"operator role", &core.User{ID: "op", Roles: []string{"operator"}}, []string{"plugins:start"}, []string{"platform:admin"}},
+		{"unknown role", &core.User{ID: "x", Roles: []string{"nobody"}}, nil, []string{"plugins:start"}},
+		{"role and permission", &core.User{ID: "y", Roles: []string{"operator"}, Permissions: []string{"resources:create"}}, []string{"plugins:stop", "resources:create"}, []string{"platform:admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := security.GenerateToken(tt.user)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			info, err := security.ValidateToken(context.Background(), token)
+			if err != nil || !info.Valid {
+				t.Fatalf("ValidateToken: %v, %+v", err, info)
+			}
+			for _, perm := range tt.allow {
+				if !slices.Contains(info.Permissions, perm) {
+					t.Errorf("permissions %v lack %s", info.Permissions, perm)
+				}
+			}
+			for _, perm := range tt.deny {
+				if slices.Contains(info.Permissions, perm) {
+					t.Errorf("permissions %v include %s", info.Permissions, perm)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenPermissionsClaim(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	security := p.SecurityManager()
+
+	tests := []struct {
+		name string
+		user *core.User
+		want []string
+	}{
+		{"permissions only", &core.User{ID: "a", Permissions: []string{"plugins:start"}}, []string{"plugins:start"}},
+		{"role then permissions", &core.User{ID: "b", Roles: []string{"operator"}, Permissions: []string{"resources:create"}}, []string{"plugins:start", "plugins:stop", "resources:create"}},
+		{"duplicates dropped", &core.User{ID: "c", Roles: []string{"operator", "admin"}, Permissions: []string{"plugins:start", "plugins:start"}}, []string{"plugins:start", "plugins:stop", "platform:admin"}},
+		{"blank permissions dropped", &core.User{ID: "d", Permissions: []string{"", "plugins:stop"}}, []string{"plugins:stop"}},
+		{"nothing granted", &core.User{ID: "e"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair, err := security.GenerateTokenPair(tt.user)
+			if err != nil {
+				t.Fatalf("GenerateTokenPair: %v", err)
+			}
+			info, err := security.ValidateToken(context.Background(), pair.AccessToken)
+			if err != nil || !slices.Equal(info.Permissions, tt.want) {
+				t.Fatalf("ValidateToken permissions = %v, %v, want %v", info.Permissions, err, tt.want)
+			}
+
+			// Refreshing keeps the permissions claim
+			refreshed, err := security.RefreshToken(context.Background(), pair.RefreshToken)
+			if err != nil {
+				t.Fatalf("RefreshToken: %v", err)
+			}
+			info, err = security.ValidateToken(context.Background(), refreshed.AccessToken)
+			if err != nil || !slices.Equal(info.Permissions, tt.want) {
+				t.Errorf("refreshed permissions = %v, %v, want %v", info.Permissions, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAdminToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "admin.token")
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Security.AdminTokenFile = path
+	})
+
+	written, err := p.WriteAdminToken()
+	if err != nil || written != path {
+		t.Fatalf("WriteAdminToken = %q, %v", written, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode = %o, want 600", mode)
+	}
+
+	data, _ := os.ReadFile(path)
+	var pair core.TokenPair
+	if err := json.Unmarshal(data, &pair); err != nil {
+		t.Fatal(err)
+	}
+	token, err := p.SecurityManager().ValidateToken(context.Background(), pair.AccessToken)
+	if err != nil || !token.Valid || !slices.Contains(token.Permissions, "platform:admin") {
+		t.Fatalf("admin token invalid or lacks platform:admin: %+v", token)
+	}
+}
+
+func TestWriteAdminTokenWithoutFile(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	if path, err := p.WriteAdminToken(); path != "" || err != nil {
+		t.Fatalf("WriteAdminToken = %q, %v", path, err)
+	}
+}
+
+func TestNewSecurityManagerSecretStrength(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SecurityConfig
+		wantErr bool
+	}{
+		{"auth with strong secret", SecurityConfig{EnableAuth: true, JWTSecret: testSecret}, false},
+		{"auth with empty secret", SecurityConfig{EnableAuth: true}, true},
+		{"auth with short secret", SecurityConfig{EnableAuth: true, JWTSecret: "short"}, true},
+		{"auth with generated secret", SecurityConfig{EnableAuth: true, AutoGenerateSecret: true}, false},
+		// Admin routes check tokens either way, so a weak secret is refused
+		// with auth off too
+		{"no auth with empty secret", SecurityConfig{}, true},
+		{"no auth with short secret", SecurityConfig{JWTSecret: "short"}, true},
+		{"no auth with default secret", SecurityConfig{JWTSecret: "change-me"}, true},
+		{"no auth with strong secret", SecurityConfig{JWTSecret: testSecret}, false},
+		{"default secret replaced by generated one", SecurityConfig{JWTSecret: "change-me", AutoGenerateSecret: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSecurityManager(tt.config, nopLogger{})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("NewSecurityManager error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, core.ErrInvalidConfig) {
+				t.Errorf("error %v does not wrap %v", err, core.ErrInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestAutoGeneratedSecretPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "jwt.secret")
+	config := SecurityConfig{EnableAuth: true, AutoGenerateSecret: true, JWTSecretFile: path, TokenExpiry: time.Hour}
+
+	first, err := NewSecurityManager(config, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("secret not persisted: %v", err)
+	}
+	if len(data) < MinJWTSecretLength {
+		t.Errorf("persisted secret is %d bytes, want at least %d", len(data), MinJWTSecretLength)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("secret file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A restart reuses the persisted secret, so earlier tokens stay valid
+	token, err := first.GenerateToken(&core.User{ID: "user"})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	second, err := NewSecurityManager(config, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewSecurityManager after restart: %v", err)
+	}
+	if info, err := second.ValidateToken(context.Background(), token); err != nil || !info.Valid {
+		t.Errorf("token rejected after restart: %v", err)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(data) {
+		t.Error("secret regenerated on restart")
+	}
+}
+
+func TestDefaultSecretReplaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.secret")
+	security, err := NewSecurityManager(SecurityConfig{
+		JWTSecret:          "change-me",
+		AutoGenerateSecret: true,
+		JWTSecretFile:      path,
+		TokenExpiry:        time.Hour,
+		Roles:              map[string][]string{"admin": {"platform:admin"}},
+	}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewSecurityManager: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) == "change-me" {
+		t.Fatalf("generated secret not persisted: %q, %v", data, err)
+	}
+
+	// Anyone who knows the default can sign an admin token with it
+	forger := &securityManagerImpl{
+		tokenExpiry: time.Hour,
+		keys:        &jwtKeys{alg: JWTAlgorithmHS256, secret: []byte("change-me")},
+		revoked:     map[string]time.Time{},
+	}
+	forged, err := forger.GenerateToken(&core.User{ID: "mallory", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if info, err := security.ValidateToken(context.Background(), forged); err == nil && info.Valid {
+		t.Error("token signed with the default secret accepted")
+	}
+}
@@ -0,0 +1,57 @@
+package platform
+
+import (
+	"regexp"
+	"strings"
+)
+
+// isTopicPattern reports whether a subscription's event type is a glob
+// pattern rather than an exact type. A lone "*" is the catch-all, which the
+// event bus handles separately.
+func isTopicPattern(eventType string) bool {
+	return eventType != "*" && strings.Contains(eventType, "*")
+}
+
+// compileTopicPattern turns a glob such as "file.*" or "*.error" into a
+// regular expression. "*" matches any run of characters, dots included, so
+// "file.*" also matches "file.upload.failed".
+func compileTopicPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// TopicMatcher returns a function reporting whether a topic matches any of
+// patterns, which may be exact types, globs or the catch-all "*". No
+// patterns matches everything.
+func TopicMatcher(patterns ...string) func(topic string) bool {
+	exact := make(map[string]bool, len(patterns))
+	var globs []*regexp.Regexp
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "" || pattern == "*":
+			return func(string) bool { return true }
+		case isTopicPattern(pattern):
+			globs = append(globs, compileTopicPattern(pattern))
+		default:
+			exact[pattern] = true
+		}
+	}
+	if len(patterns) == 0 {
+		return func(string) bool { return true }
+	}
+
+	return func(topic string) bool {
+		if exact[topic] {
+			return true
+		}
+		for _, glob := range globs {
+			if glob.MatchString(topic) {
+				return true
+			}
+		}
+		return false
+	}
+}
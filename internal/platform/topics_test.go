@@ -0,0 +1,120 @@
+package platform
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestTopicMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		topic    string
+		want     bool
+	}{
+		{"no patterns", nil, "plugin.loaded", true},
+		{"catch-all", []string{"*"}, "plugin.loaded", true},
+		{"catch-all among others", []string{"peer.joined", "*"}, "plugin.loaded", true},
+		{"exact match", []string{"plugin.loaded"}, "plugin.loaded", true},
+		{"exact mismatch", []string{"plugin.loaded"}, "plugin.started", false},
+		{"exact is not a prefix", []string{"plugin"}, "plugin.loaded", false},
+		{"suffix glob", []string{"plugin.*"}, "plugin.loaded", true},
+		{"suffix glob spans dots", []string{"file.*"}, "file.upload.failed", true},
+		{"suffix glob needs the dot", []string{"plugin.*"}, "plugins", false},
+		{"prefix glob", []string{"*.error"}, "network.peer.error", true},
+		{"prefix glob mismatch", []string{"*.error"}, "network.errors", false},
+		{"dots are literal", []string{"a.b*"}, "axb", false},
+		{"any of several", []string{"peer.joined", "plugin.*"}, "plugin.stopped", true},
+		{"none of several", []string{"peer.joined", "plugin.*"}, "file.uploaded", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TopicMatcher(tt.patterns...)(tt.topic); got != tt.want {
+				t.Errorf("TopicMatcher(%q)(%q) = %v, want %v", tt.patterns, tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventBusPatternSubscriptions(t *testing.T) {
+	bus := newTestBus(t, EventsConfig{})
+	var mu sync.Mutex
+	received := make(map[string][]string)
+	for _, pattern := range []string{"file.*", "*.error", "clip.*", "*", "file.uploaded"} {
+		pattern := pattern
+		// Lossless, so Stop delivers everything queued before returning
+		if _, err := bus.SubscribeLossless(pattern, func(event core.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[pattern] = append(received[pattern], event.Type)
+			return nil
+		}); err != nil {
+			t.Fatalf("SubscribeLossless(%q): %v", pattern, err)
+		}
+	}
+
+	published := []string{"file.uploaded", "file.deleted", "network.error", "clipboard.changed", "file.upload.error"}
+	for _, eventType := range published[:4] {
+		bus.Publish(core.Event{Type: eventType})
+	}
+	// Topics are matched the same way as event types
+	bus.PublishToTopic(context.Background(), published[4], core.Event{Type: published[4]})
+	if err := bus.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"file.*", []string{"file.uploaded", "file.deleted", "file.upload.error"}},
+		{"*.error", []string{"network.error", "file.upload.error"}},
+		{"clip.*", nil},
+		{"*", published},
+		{"file.uploaded", []string{"file.uploaded"}},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, tt := range tests {
+		if got := received[tt.pattern]; !slices.Equal(got, tt.want) {
+			t.Errorf("subscriber to %q got %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestEventBusCompilesPatternOnce(t *testing.T) {
+	bus := newTestBus(t, EventsConfig{})
+	first, _ := bus.Subscribe("file.*", func(core.Event) error { return nil })
+	second, _ := bus.Subscribe("file.*", func(core.Event) error { return nil })
+	bus.Subscribe("plugin.loaded", func(core.Event) error { return nil })
+
+	bus.mu.RLock()
+	patterns, match := len(bus.patterns), bus.patterns["file.*"].match
+	bus.mu.RUnlock()
+	if patterns != 1 {
+		t.Fatalf("%d patterns registered, want 1", patterns)
+	}
+
+	// The compiled pattern is kept while any subscriber remains
+	if err := bus.Unsubscribe(first); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	bus.mu.RLock()
+	if p := bus.patterns["file.*"]; p == nil || p.match != match {
+		t.Error("pattern was recompiled or dropped while still subscribed")
+	}
+	bus.mu.RUnlock()
+
+	if err := bus.Unsubscribe(second); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	if _, ok := bus.patterns["file.*"]; ok {
+		t.Error("pattern kept after its last subscriber left")
+	}
+}
@@ -0,0 +1,113 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStopWaitsForWorkers(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WatchConfigFile(configPath); err != nil {
+		t.Fatalf("WatchConfigFile: %v", err)
+	}
+
+	var exited atomic.Int32
+	for i := 0; i < 3; i++ {
+		err := p.workers.Go("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			exited.Add(1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Go: %v", err)
+		}
+	}
+	if got := p.workers.Running(); got["config-watch"] != 1 || got["slow"] != 3 {
+		t.Fatalf("Running = %v, want the config watcher and three slow workers", got)
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := exited.Load(); n != 3 {
+		t.Errorf("%d of 3 workers exited before Stop returned", n)
+	}
+	if got := p.workers.Running(); len(got) != 0 {
+		t.Errorf("workers %v still running after Stop", got)
+	}
+
+	// Nothing new starts once the platform has stopped
+	if err := p.WatchConfigFile(configPath); err == nil {
+		t.Error("WatchConfigFile succeeded after Stop")
+	}
+}
+
+func TestStopGivesUpOnStuckWorkers(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	if err := p.workers.Go("stuck", func(context.Context) error {
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := p.Stop(ctx); err == nil {
+		t.Error("Stop succeeded with a worker still running")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Stop took %v with a 50ms deadline", elapsed)
+	}
+}
+
+func TestStopWaitsForNetworkWorkers(t *testing.T) {
+	peerPort, discoveryPort := freePorts(t)
+	p := newTestPlatform(t, func(cfg *PlatformConfig) {
+		cfg.Network = NetworkConfig{
+			Host:                 "127.0.0.1",
+			Port:                 peerPort,
+			EnableDiscovery:      true,
+			DiscoveryBindAddress: "127.0.0.1",
+			DiscoveryPort:        discoveryPort,
+			DiscoveryTimeout:     10 * time.Millisecond,
+			KeepAliveInterval:    time.Minute,
+		}
+	})
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// The network manager's goroutines run in the platform's pool
+	running := p.Workers().Running()
+	for _, name := range []string{"network-keepalive", "network-discovery", "network-http"} {
+		if running[name] != 1 {
+			t.Errorf("Running = %v, want one %s worker", running, name)
+		}
+	}
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if got := p.Workers().Running(); len(got) != 0 {
+		t.Errorf("workers %v still running after Stop", got)
+	}
+}
@@ -3,17 +3,22 @@ package plugins
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 )
 
 // BasePlugin provides common plugin functionality
@@ -156,6 +161,23 @@ type FileManagerPlugin struct {
 	uploadDir   string
 	downloadDir string
 	maxFileSize int64
+	chunks      *chunkStore
+	platform    core.PlatformAPI
+	// filenameStrategy handles uploads whose name is already taken
+	filenameStrategy string
+	// rejectEmpty refuses zero-byte uploads
+	rejectEmpty bool
+	downloads   *downloadCounter
+	// sessionTTL is how long an idle chunked upload is kept
+	sessionTTL time.Duration
+	// staleTempAge is how old leftover temp files must be to be removed
+	// when the plugin starts
+	staleTempAge time.Duration
+	sweepStop    chan struct{}
+	// checkOrigin decides which browser origins may open the transfer socket
+	checkOrigin func(r *http.Request) bool
+	// buffers are reused to copy uploaded data to disk
+	buffers *core.BufferPool
 }
 
 // NewFileManagerPlugin creates a new file manager plugin
@@ -167,6 +189,19 @@ func NewFileManagerPlugin(uploadDir, downloadDir string, maxFileSize int64) *Fil
 		uploadDir:   uploadDir,
 		downloadDir: downloadDir,
 		maxFileSize: maxFileSize,
+		chunks:      newChunkStore(filepath.Join(uploadDir, ".partial"), DefaultChunkSize, maxFileSize),
+		buffers:     core.NewBufferPool(0),
+
+		filenameStrategy: DefaultFilenameStrategy,
+		sessionTTL:       DefaultUploadSessionTTL,
+		staleTempAge:     DefaultStaleTempAge,
+		checkOrigin:      core.WebSocketOriginChecker(nil, true),
+	}
+	plugin.chunks.buffers = plugin.buffers
+	if uploadDir != "" {
+		plugin.downloads = newDownloadCounter(filepath.Join(uploadDir, ".meta", "downloads.json"))
+	} else {
+		plugin.downloads = newDownloadCounter("")
 	}
 
 	// Register routes
@@ -177,8 +212,8 @@ func NewFileManagerPlugin(uploadDir, downloadDir string, maxFileSize int64) *Fil
 
 // Initialize sets up the file manager plugin
 func (p *FileManagerPlugin) Initialize(platform core.PlatformAPI) error {
-	// Optionally store platform reference if needed
-	return nil
+	p.platform = platform
+	return p.ensureDirectories()
 }
 
 func (p *FileManagerPlugin) setupRoutes() {
@@ -203,28 +238,145 @@ func (p *FileManagerPlugin) setupRoutes() {
 		Auth:    core.AuthRequirement{Required: false},
 	})
 
+	// http.ServeFile answers HEAD with the headers of the GET response
+	p.AddRoute(core.Route{
+		Method:  "HEAD",
+		Path:    "/files/:filename",
+		Handler: p.handleDownloadFile,
+		Auth:    core.AuthRequirement{Required: false},
+	})
+
+	p.AddRoute(core.Route{
+		Method:  "GET",
+		Path:    "/files/:filename/info",
+		Handler: p.handleFileInfo,
+		Auth:    core.AuthRequirement{Required: false},
+	})
+
 	p.AddRoute(core.Route{
 		Method:  "DELETE",
 		Path:    "/files/:filename",
 		Handler: p.handleDeleteFile,
 		Auth:    core.AuthRequirement{Required: false},
 	})
+
+	p.AddRoute(core.Route{
+		Method:      "POST",
+		Path:        "/uploads",
+		Handler:     p.handleCreateUpload,
+		Auth:        core.AuthRequirement{Required: false},
+		Description: "Start a chunked upload session",
+	})
+
+	p.AddRoute(core.Route{
+		Method:      "GET",
+		Path:        "/uploads/:id",
+		Handler:     p.handleUploadStatus,
+		Auth:        core.AuthRequirement{Required: false},
+		Description: "Report received and missing chunks of an upload",
+	})
+
+	p.AddRoute(core.Route{
+		Method:      "PUT",
+		Path:        "/uploads/:id/:chunk",
+		Handler:     p.handleUploadChunk,
+		Auth:        core.AuthRequirement{Required: false},
+		Description: "Upload one chunk of a chunked upload",
+	})
+
+	p.AddRoute(core.Route{
+		Method:      "POST",
+		Path:        "/uploads/:id/complete",
+		Handler:     p.handleCompleteUpload,
+		Auth:        core.AuthRequirement{Required: false},
+		Description: "Assemble and verify a chunked upload",
+	})
+
+	p.AddRoute(core.Route{
+		Method:      "DELETE",
+		Path:        "/uploads/:id",
+		Handler:     p.handleAbortUpload,
+		Auth:        core.AuthRequirement{Required: false},
+		Description: "Abort a chunked upload",
+	})
+
+	p.AddRoute(core.Route{
+		Method:      "GET",
+		Path:        "/transfer",
+		Handler:     p.handleTransfer,
+		Auth:        core.AuthRequirement{Required: false},
+		Description: "WebSocket file transfer with progress and pause/resume",
+	})
+}
+
+// Start starts the plugin and the sweeper for abandoned uploads, after
+// removing temp files that interrupted operations left behind
+func (p *FileManagerPlugin) Start(ctx context.Context) error {
+	if err := p.BasePlugin.Start(ctx); err != nil {
+		return err
+	}
+	removed := p.removeStaleTempFiles(p.staleTempAge)
+	if p.platform != nil {
+		for _, path := range removed {
+			p.platform.GetLogger().Info("Removed stale temp file", "path", path)
+		}
+		if len(removed) > 0 {
+			p.platform.GetLogger().Info("Cleaned up after interrupted operations", "removed", len(removed))
+		}
+	}
+	p.sweepStop = make(chan struct{})
+	go p.sweepUploads(p.sessionTTL, p.sweepStop)
+	return nil
+}
+
+// Stop stops the plugin and its upload sweeper
+func (p *FileManagerPlugin) Stop(ctx context.Context) error {
+	if err := p.BasePlugin.Stop(ctx); err != nil {
+		return err
+	}
+	if p.sweepStop != nil {
+		close(p.sweepStop)
+		p.sweepStop = nil
+	}
+	return nil
 }
 
+// ensureDirectories creates the upload and download directories and
+// replaces them with their symlink-resolved paths, so that they compare
+// equal to resolved file paths
 func (p *FileManagerPlugin) ensureDirectories() error {
-	dirs := []string{p.uploadDir, p.downloadDir}
+	dirs := []*string{&p.uploadDir, &p.downloadDir}
 
 	for _, dir := range dirs {
-		if dir != "" {
-			if err := os.MkdirAll(dir, 0755); err != nil {
+		if *dir != "" {
+			if err := os.MkdirAll(*dir, 0755); err != nil {
 				return err
 			}
+			resolved, err := filepath.EvalSymlinks(*dir)
+			if err != nil {
+				return err
+			}
+			*dir = resolved
 		}
 	}
+	if p.uploadDir != "" {
+		p.chunks.dir = filepath.Join(p.uploadDir, ".partial")
+	}
 
 	return nil
 }
 
+// inUploadDir reports whether path, with symlinks resolved, is inside the
+// upload directory
+func (p *FileManagerPlugin) inUploadDir(path string) bool {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(p.uploadDir, resolved)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func (p *FileManagerPlugin) handleListFiles(w http.ResponseWriter, r *http.Request) {
 	files, err := p.listFiles(p.uploadDir)
 	if err != nil {
@@ -249,30 +401,44 @@ func (p *FileManagerPlugin) handleUploadFile(w http.ResponseWriter, r *http.Requ
 
 	// Parse multipart form
 	err := r.ParseMultipartForm(p.maxFileSize)
+	if errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "Expected a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
 	file, header, err := r.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		http.Error(w, `No file provided: the form has no "file" part`, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, "No file provided", http.StatusBadRequest)
+		http.Error(w, "Failed to read uploaded file", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
-	// Save file
-	filename := p.sanitizeFilename(header.Filename)
-	filePath := filepath.Join(p.uploadDir, filename)
+	if header.Size == 0 && p.rejectEmpty {
+		http.Error(w, "Empty files are not accepted", http.StatusBadRequest)
+		return
+	}
 
-	dst, err := os.Create(filePath)
+	// Save file
+	dst, filename, err := createUploadFile(p.uploadDir, p.sanitizeFilename(header.Filename), p.filenameStrategy)
+	if errors.Is(err, ErrFileExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to create file", http.StatusInternalServerError)
 		return
 	}
 	defer dst.Close()
 
-	_, err = io.Copy(dst, file)
+	_, err = p.buffers.Copy(dst, file)
 	if err != nil {
 		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
@@ -316,12 +482,78 @@ func (p *FileManagerPlugin) handleDownloadFile(w http.ResponseWriter, r *http.Re
 		http.Error(w, "File not found", http.StatusNotFound)
 		return
 	}
+	if !p.inUploadDir(filePath) {
+		http.Error(w, "File is outside the upload directory", http.StatusForbidden)
+		return
+	}
+
+	// Count full downloads, not HEAD requests or resumed ranges
+	if r.Method == http.MethodGet && startsAtZero(r.Header.Get("Range")) {
+		p.recordDownload(filename)
+	}
 
 	// Serve file
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	http.ServeFile(w, r, filePath)
 }
 
+// handleFileInfo returns metadata and the download count for a file
+func (p *FileManagerPlugin) handleFileInfo(w http.ResponseWriter, r *http.Request) {
+	// Path ends in /files/:filename/info
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 2 {
+		http.Error(w, "No filename specified", http.StatusBadRequest)
+		return
+	}
+	filename := parts[len(parts)-2]
+
+	if filename == "" || strings.Contains(filename, "..") {
+		http.Error(w, "Invalid filename", http.StatusBadRequest)
+		return
+	}
+
+	filePath := filepath.Join(p.uploadDir, filename)
+	info, err := os.Stat(filePath)
+	if err != nil || info.IsDir() {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	if !p.inUploadDir(filePath) {
+		http.Error(w, "File is outside the upload directory", http.StatusForbidden)
+		return
+	}
+
+	response := map[string]interface{}{
+		"name":      info.Name(),
+		"size":      info.Size(),
+		"modified":  info.ModTime(),
+		"downloads": p.downloads.Count(filename),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// recordDownload counts a download of filename and updates the download
+// metric. Per-file counts stay in p.downloads: a metric per file name would
+// grow without bound.
+func (p *FileManagerPlugin) recordDownload(filename string) {
+	if _, err := p.downloads.Increment(filename); err != nil && p.platform != nil {
+		p.platform.GetLogger().Warn("Failed to persist download count", "file", filename, "error", err)
+	}
+	if p.platform != nil {
+		if metrics := p.platform.GetMetrics(); metrics != nil {
+			metrics.Counter("file_downloads_total").Inc()
+		}
+	}
+}
+
+// startsAtZero reports whether a Range header is absent or requests the
+// file from its first byte
+func startsAtZero(rangeHeader string) bool {
+	return rangeHeader == "" || strings.HasPrefix(rangeHeader, "bytes=0-")
+}
+
 func (p *FileManagerPlugin) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
 	// Extract filename from URL path
 	path := r.URL.Path
@@ -356,6 +588,7 @@ func (p *FileManagerPlugin) handleDeleteFile(w http.ResponseWriter, r *http.Requ
 		http.Error(w, "Failed to delete file", http.StatusInternalServerError)
 		return
 	}
+	p.downloads.Remove(filename)
 
 	response := map[string]interface{}{
 		"status":   "success",
@@ -389,9 +622,10 @@ func (p *FileManagerPlugin) listFiles(dir string) ([]map[string]interface{}, err
 		}
 
 		files = append(files, map[string]interface{}{
-			"name":     entry.Name(),
-			"size":     info.Size(),
-			"modified": info.ModTime(),
+			"name":      entry.Name(),
+			"size":      info.Size(),
+			"modified":  info.ModTime(),
+			"downloads": p.downloads.Count(entry.Name()),
 		})
 	}
 
@@ -424,7 +658,49 @@ func (p *FileManagerPlugin) Configuration() core.ConfigSchema {
 	return core.ConfigSchema{}
 }
 
+// Configure applies plugin settings. "filenameStrategy" selects how upload
+// name collisions are handled: overwrite, rename (default) or reject.
+// "rejectEmptyUploads" refuses zero-byte files. "uploadSessionTTL" (a
+// duration such as "12h") sets how long idle chunked uploads are kept, and
+// "staleTempAge" how old leftover temp files must be to be removed on start
+// ("0s" keeps the default).
+// "webSocketOrigins" lists the origins allowed to open the transfer socket
+// besides the same origin.
+// "copyBufferSize" sets the size in bytes of the buffers uploads are copied
+// through.
 func (p *FileManagerPlugin) Configure(config map[string]interface{}) error {
+	if size, ok := config["copyBufferSize"].(int); ok && size > 0 {
+		p.buffers = core.NewBufferPool(size)
+		p.chunks.buffers = p.buffers
+	}
+	if origins, ok := config["webSocketOrigins"].([]string); ok {
+		p.checkOrigin = core.WebSocketOriginChecker(origins, true)
+	}
+	if ttl, ok := config["uploadSessionTTL"].(string); ok && ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid upload session TTL %q", ttl)
+		}
+		p.sessionTTL = d
+	}
+	if age, ok := config["staleTempAge"].(string); ok && age != "" {
+		d, err := time.ParseDuration(age)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid stale temp file age %q", age)
+		}
+		if d > 0 {
+			p.staleTempAge = d
+		}
+	}
+	if rejectEmpty, ok := config["rejectEmptyUploads"].(bool); ok {
+		p.rejectEmpty = rejectEmpty
+	}
+	if strategy, ok := config["filenameStrategy"].(string); ok && strategy != "" {
+		if !validFilenameStrategy(strategy) {
+			return fmt.Errorf("invalid filename strategy %q", strategy)
+		}
+		p.filenameStrategy = strategy
+	}
 	return nil
 }
 
@@ -440,20 +716,71 @@ func (p *FileManagerPlugin) IsHealthy() bool {
 	return true
 }
 
+// DefaultMaxClipboardSize is the largest clipboard entry accepted, measured
+// on the decoded bytes for binary entries
+const DefaultMaxClipboardSize = 1024 * 1024
+
+// DefaultMaxClipboardHistoryBytes caps the total size of the entries kept in
+// a clipboard channel's history
+const DefaultMaxClipboardHistoryBytes = 10 * 1024 * 1024
+
+// clipboardSweepInterval is how often expired clipboard entries are removed
+const clipboardSweepInterval = 5 * time.Second
+
+// RedactedPlaceholder replaces clipboard content matching a redaction pattern
+const RedactedPlaceholder = "[redacted]"
+
+// DefaultClipboardChannel is the channel used when a request doesn't name one
+const DefaultClipboardChannel = "default"
+
+var channelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
 // ClipboardPlugin provides clipboard sharing capabilities
 type ClipboardPlugin struct {
 	*BasePlugin
-	clipboard  []ClipboardEntry
-	maxHistory int
+	platform core.PlatformAPI
+	// channels holds the history of each named clipboard channel. Devices
+	// only see the entries of the channels they use.
+	channels       map[string][]ClipboardEntry
+	maxHistory     int
+	maxContentSize int
+	// maxHistoryBytes caps the total size of a channel's entries; zero
+	// removes the cap
+	maxHistoryBytes int
+	// defaultTTL applies to entries set without a TTL; zero keeps them
+	defaultTTL time.Duration
+	// redactors match text that must not be synced, such as card numbers
+	// or API keys
+	redactors []*regexp.Regexp
+	// sweepInterval is how often the sweeper removes expired entries
+	sweepInterval time.Duration
+	// sweepStop ends the sweeper started by Start and sweepDone is closed
+	// once it has exited. Both are guarded by mu.
+	sweepStop chan struct{}
+	sweepDone chan struct{}
 }
 
-// ClipboardEntry represents a clipboard entry
+// ClipboardEntry represents a clipboard entry. Text is kept in Content;
+// binary entries such as images are kept base64-encoded in Data, with Type
+// holding their MIME type.
 type ClipboardEntry struct {
 	ID        string    `json:"id"`
+	Channel   string    `json:"channel"`
 	Content   string    `json:"content"`
+	Data      string    `json:"data,omitempty"`
 	Type      string    `json:"type"`
 	Source    string    `json:"source"`
 	Timestamp time.Time `json:"timestamp"`
+	// PlainText is a text/plain rendering of rich content (e.g. HTML), kept
+	// alongside the original so consumers can ask for either
+	PlainText string `json:"plainText,omitempty"`
+	// ExpiresAt is when the entry stops being served, if it expires
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// expired reports whether the entry has passed its expiry at now
+func (e ClipboardEntry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
 }
 
 // NewClipboardPlugin creates a new clipboard plugin
@@ -461,9 +788,13 @@ func NewClipboardPlugin(maxHistory int) *ClipboardPlugin {
 	base := NewBasePlugin("clipboard", "1.0.0", []string{})
 
 	plugin := &ClipboardPlugin{
-		BasePlugin: base,
-		clipboard:  make([]ClipboardEntry, 0),
-		maxHistory: maxHistory,
+		BasePlugin:     base,
+		channels:       make(map[string][]ClipboardEntry),
+		maxHistory:     maxHistory,
+		maxContentSize: DefaultMaxClipboardSize,
+		// Large entries hit this before the count cap
+		maxHistoryBytes: DefaultMaxClipboardHistoryBytes,
+		sweepInterval:   clipboardSweepInterval,
 	}
 
 	plugin.setupRoutes()
@@ -472,10 +803,95 @@ func NewClipboardPlugin(maxHistory int) *ClipboardPlugin {
 }
 
 func (p *ClipboardPlugin) Initialize(platform core.PlatformAPI) error {
-	// Optionally store platform reference if needed
+	p.platform = platform
+	return nil
+}
+
+// Start starts the plugin and its expiry sweeper
+func (p *ClipboardPlugin) Start(ctx context.Context) error {
+	if err := p.BasePlugin.Start(ctx); err != nil {
+		return err
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	p.mu.Lock()
+	p.sweepStop, p.sweepDone = stop, done
+	p.mu.Unlock()
+	go func() {
+		defer close(done)
+		p.sweepExpired(stop)
+	}()
+	return nil
+}
+
+// Stop stops the plugin and waits for its expiry sweeper to exit
+func (p *ClipboardPlugin) Stop(ctx context.Context) error {
+	if err := p.BasePlugin.Stop(ctx); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	stop, done := p.sweepStop, p.sweepDone
+	p.sweepStop, p.sweepDone = nil, nil
+	p.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		// The sweeper takes mu, so it must not be held while waiting
+		<-done
+	}
 	return nil
 }
 
+// sweepExpired removes expired entries from every channel until stop is
+// closed
+func (p *ClipboardPlugin) sweepExpired(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			for channel := range p.channels {
+				if live := p.liveEntries(channel, now); len(live) > 0 {
+					p.channels[channel] = live
+				} else {
+					delete(p.channels, channel)
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// liveEntries returns the entries of channel that have not expired at now.
+// The caller holds p.mu.
+func (p *ClipboardPlugin) liveEntries(channel string, now time.Time) []ClipboardEntry {
+	entries := p.channels[channel]
+	live := make([]ClipboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.expired(now) {
+			live = append(live, entry)
+		}
+	}
+	return live
+}
+
+// channelFromRequest returns the channel named by the "channel" query
+// parameter, defaulting to DefaultClipboardChannel. It writes a 400 response
+// and returns false if the name is invalid.
+func channelFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	channel := r.URL.Query().Get("channel")
+	if channel == "" {
+		return DefaultClipboardChannel, true
+	}
+	if !channelNamePattern.MatchString(channel) {
+		http.Error(w, "Invalid channel name", http.StatusBadRequest)
+		return "", false
+	}
+	return channel, true
+}
+
 func (p *ClipboardPlugin) setupRoutes() {
 	p.AddRoute(core.Route{
 		Method:  "GET",
@@ -506,84 +922,271 @@ func (p *ClipboardPlugin) setupRoutes() {
 	})
 }
 
+// handleGetClipboard returns the latest entry of the requested channel. With ?raw=true the entry
+// itself is written with its own Content-Type instead of as JSON, and with
+// ?as=text/plain rich text is returned as its plain text rendering.
 func (p *ClipboardPlugin) handleGetClipboard(w http.ResponseWriter, r *http.Request) {
+	channel, ok := channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	p.mu.RLock()
-	defer p.mu.RUnlock()
+	entries := p.liveEntries(channel, time.Now())
+	p.mu.RUnlock()
 
 	var latest *ClipboardEntry
-	if len(p.clipboard) > 0 {
-		latest = &p.clipboard[len(p.clipboard)-1]
+	if len(entries) > 0 {
+		latest = &entries[len(entries)-1]
+	}
+	count := len(entries)
+
+	if r.URL.Query().Get("raw") == "true" {
+		if latest == nil {
+			http.Error(w, "Clipboard is empty", http.StatusNotFound)
+			return
+		}
+		writeRawClipboard(w, *latest)
+		return
+	}
+
+	if as := r.URL.Query().Get("as"); as != "" && latest != nil && as != latest.Type {
+		if as != "text/plain" || latest.Data != "" {
+			http.Error(w, "Unsupported format: "+as, http.StatusNotAcceptable)
+			return
+		}
+		if latest.PlainText != "" {
+			latest.Content = latest.PlainText
+		}
+		latest.Type = "text/plain"
 	}
 
 	response := map[string]interface{}{
+		"channel": channel,
 		"content": latest,
-		"count":   len(p.clipboard),
+		"count":   count,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// writeRawClipboard writes the bytes of entry with its MIME type. The type
+// was chosen by whoever set the clipboard, so only images and plain text are
+// served as such; anything else is sent as a download.
+func writeRawClipboard(w http.ResponseWriter, entry ClipboardEntry) {
+	body := []byte(entry.Content)
+	contentType := entry.Type
+	if entry.Data != "" {
+		data, err := base64.StdEncoding.DecodeString(entry.Data)
+		if err != nil {
+			http.Error(w, "Stored clipboard data is corrupt", http.StatusInternalServerError)
+			return
+		}
+		body = data
+	} else if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	filetype.SetUntrustedHeaders(w.Header(), contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
+// handleSetClipboard stores a new entry in the requested channel: either
+// text in "content", or a base64 payload in "data" with its MIME type
+// declared in "type"
 func (p *ClipboardPlugin) handleSetClipboard(w http.ResponseWriter, r *http.Request) {
+	channel, ok := channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	var request struct {
-		Content string `json:"content"`
-		Type    string `json:"type"`
-		Source  string `json:"source"`
+		Content    string `json:"content"`
+		Data       string `json:"data"`
+		Type       string `json:"type"`
+		Source     string `json:"source"`
+		TTLSeconds int    `json:"ttlSeconds"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if request.TTLSeconds < 0 {
+		http.Error(w, "ttlSeconds must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	size := len(request.Content)
+	if request.Data != "" {
+		if request.Content != "" {
+			http.Error(w, "Send either content or data, not both", http.StatusBadRequest)
+			return
+		}
+		if request.Type == "" {
+			http.Error(w, "A content type is required for binary data", http.StatusBadRequest)
+			return
+		}
+		data, err := base64.StdEncoding.DecodeString(request.Data)
+		if err != nil {
+			http.Error(w, "Data is not valid base64", http.StatusBadRequest)
+			return
+		}
+		size = len(data)
+	}
+	if p.maxContentSize > 0 && size > p.maxContentSize {
+		http.Error(w, "Content too large", http.StatusRequestEntityTooLarge)
+		return
+	}
 
 	entry := ClipboardEntry{
-		ID:        fmt.Sprintf("clip-%d", time.Now().UnixNano()),
+		ID:        "clip-" + core.NewID(),
+		Channel:   channel,
 		Content:   request.Content,
+		Data:      request.Data,
 		Type:      request.Type,
 		Source:    request.Source,
 		Timestamp: time.Now(),
 	}
+	ttl := time.Duration(request.TTLSeconds) * time.Second
+	if ttl == 0 {
+		ttl = p.defaultTTL
+	}
+	if ttl > 0 {
+		expiresAt := entry.Timestamp.Add(ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+
+	// Sensitive text is kept as a placeholder and not broadcast. Binary
+	// entries aren't checked, as the patterns only apply to text.
+	redacted := entry.Data == "" && p.shouldRedact(entry.Content)
+	if redacted {
+		entry.Content, entry.Type = RedactedPlaceholder, "text/plain"
+	}
+	entry.PlainText = toPlainText(entry.Content, entry.Type)
 
 	p.mu.Lock()
-	p.clipboard = append(p.clipboard, entry)
+	history := append(p.channels[channel], entry)
 
 	// Trim history if needed
-	if len(p.clipboard) > p.maxHistory {
-		p.clipboard = p.clipboard[1:]
+	if len(history) > p.maxHistory {
+		history = history[1:]
 	}
+	history = evictHistoryBytes(history, p.maxHistoryBytes)
+	p.channels[channel] = history
+	count := len(history)
 	p.mu.Unlock()
 
+	if redacted {
+		p.publish(r.Context(), "clipboard.redacted", map[string]interface{}{
+			"id":      entry.ID,
+			"channel": channel,
+			"type":    request.Type,
+			"source":  entry.Source,
+			"size":    size,
+		})
+	} else {
+		p.publish(r.Context(), "clipboard.changed", map[string]interface{}{
+			"id":      entry.ID,
+			"channel": channel,
+			"content": entry.Content,
+			"data":    entry.Data,
+			"type":    entry.Type,
+			"source":  entry.Source,
+		})
+	}
+
 	response := map[string]interface{}{
-		"status": "success",
-		"id":     entry.ID,
-		"count":  len(p.clipboard),
+		"status":    "success",
+		"id":        entry.ID,
+		"channel":   channel,
+		"count":     count,
+		"expiresAt": entry.ExpiresAt,
+		"redacted":  redacted,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func (p *ClipboardPlugin) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+// evictHistoryBytes drops the oldest entries (at the start of history) until
+// the total size of their content is within maxBytes. The newest entry is
+// always kept.
+func evictHistoryBytes(history []ClipboardEntry, maxBytes int) []ClipboardEntry {
+	if maxBytes <= 0 {
+		return history
+	}
+
+	total := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		total += len(history[i].Content) + len(history[i].Data)
+		if total > maxBytes && i < len(history)-1 {
+			// Copy the kept entries so evicted content can be collected
+			return append([]ClipboardEntry(nil), history[i+1:]...)
+		}
+	}
+	return history
+}
+
+// shouldRedact reports whether content matches a redaction pattern
+func (p *ClipboardPlugin) shouldRedact(content string) bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	for _, re := range p.redactors {
+		if re.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// publish emits a clipboard event, if the plugin was initialized
+func (p *ClipboardPlugin) publish(ctx context.Context, eventType string, data map[string]interface{}) {
+	if p.platform == nil {
+		return
+	}
+	p.platform.PublishEventContext(ctx, eventType, data)
+}
+
+// handleGetHistory returns the live entries of the requested channel
+func (p *ClipboardPlugin) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	channel, ok := channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	p.mu.RLock()
+	history := p.liveEntries(channel, time.Now())
+	p.mu.RUnlock()
+
 	response := map[string]interface{}{
-		"history": p.clipboard,
-		"count":   len(p.clipboard),
+		"channel": channel,
+		"history": history,
+		"count":   len(history),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleClearHistory clears the requested channel, leaving the others
 func (p *ClipboardPlugin) handleClearHistory(w http.ResponseWriter, r *http.Request) {
+	channel, ok := channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	p.mu.Lock()
-	p.clipboard = make([]ClipboardEntry, 0)
+	delete(p.channels, channel)
 	p.mu.Unlock()
 
 	response := map[string]interface{}{
-		"status": "success",
-		"count":  0,
+		"status":  "success",
+		"channel": channel,
+		"count":   0,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -594,7 +1197,44 @@ func (p *ClipboardPlugin) Configuration() core.ConfigSchema {
 	return core.ConfigSchema{}
 }
 
+// Configure applies plugin settings. "maxContentSize" caps entry sizes,
+// "maxHistoryBytes" caps the total size of a channel's history (0 keeps the
+// default, negative removes the cap),
+// "defaultTTL" (a duration such as "1h") expires entries set without a TTL
+// and "redactionPatterns" lists regular expressions for text that is stored
+// as RedactedPlaceholder instead and never broadcast.
 func (p *ClipboardPlugin) Configure(config map[string]interface{}) error {
+	if patterns, ok := config["redactionPatterns"].([]string); ok {
+		redactors := make([]*regexp.Regexp, 0, len(patterns))
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid clipboard redaction pattern %q: %w", pattern, err)
+			}
+			redactors = append(redactors, re)
+		}
+		p.mu.Lock()
+		p.redactors = redactors
+		p.mu.Unlock()
+	}
+	if size, ok := config["maxContentSize"].(int); ok {
+		if size < 0 {
+			return fmt.Errorf("invalid clipboard max content size %d", size)
+		}
+		p.maxContentSize = size
+	}
+	if size, ok := config["maxHistoryBytes"].(int); ok && size != 0 {
+		p.mu.Lock()
+		p.maxHistoryBytes = max(size, 0)
+		p.mu.Unlock()
+	}
+	if ttl, ok := config["defaultTTL"].(string); ok && ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid clipboard default TTL %q", ttl)
+		}
+		p.defaultTTL = d
+	}
 	return nil
 }
 
@@ -652,8 +1292,9 @@ func (p *SystemInfoPlugin) setupRoutes() {
 func (p *SystemInfoPlugin) handleSystemInfo(w http.ResponseWriter, r *http.Request) {
 	info := map[string]interface{}{
 		"hostname": getHostname(),
-		"platform": "go",
-		"uptime":   time.Since(time.Now()).String(), // Placeholder
+		"platform": runtime.GOOS,
+		"arch":     runtime.GOARCH,
+		"uptime":   getUptimeInfo(),
 		"memory":   getMemoryInfo(),
 		"cpu":      getCPUInfo(),
 		"network":  getNetworkInfo(),
@@ -698,31 +1339,7 @@ func (p *SystemInfoPlugin) IsHealthy() bool {
 	return true
 }
 
-// Helper functions (these would be properly implemented)
 func getHostname() string {
 	hostname, _ := os.Hostname()
 	return hostname
 }
-
-func getMemoryInfo() map[string]interface{} {
-	return map[string]interface{}{
-		"total": "8GB", // Placeholder
-		"used":  "4GB", // Placeholder
-		"free":  "4GB", // Placeholder
-	}
-}
-
-func getCPUInfo() map[string]interface{} {
-	return map[string]interface{}{
-		"cores":       8,     // Placeholder
-		"usage":       "25%", // Placeholder
-		"temperature": "45C", // Placeholder
-	}
-}
-
-func getNetworkInfo() map[string]interface{} {
-	return map[string]interface{}{
-		"interfaces": []string{"eth0", "wlan0"}, // Placeholder
-		"active":     "wlan0",                   // Placeholder
-	}
-}
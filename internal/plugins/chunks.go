@@ -0,0 +1,352 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// DefaultChunkSize is the chunk size used for chunked transfers
+const DefaultChunkSize int64 = 1 << 20 // 1MB
+
+// DefaultUploadSessionTTL is how long an upload session may go without
+// receiving a chunk before it is discarded
+const DefaultUploadSessionTTL = 24 * time.Hour
+
+// Uploads are assembled into a file named with assemblyPattern in the
+// private assemblyDirName directory next to their destination, so files
+// left by an interrupted assembly can be told apart from users' files
+const (
+	assemblyDirName = ".nplk-tmp"
+	assemblyPattern = ".nplk-upload-*.partial"
+)
+
+// ErrChecksumMismatch is returned when an assembled upload does not match the
+// checksum given when it was created
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// uploadSession tracks the chunks received for a single chunked upload
+type uploadSession struct {
+	ID        string        `json:"id"`
+	Filename  string        `json:"filename"`
+	Size      int64         `json:"size"`
+	ChunkSize int64         `json:"chunkSize"`
+	Received  map[int]int64 `json:"-"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	// Checksum is the expected hex SHA-256 of the assembled file, if known
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// snapshot returns a copy of the session that can be read without the
+// store's lock. Received is left out; the store reports it through Progress,
+// Missing and ReceivedChunks.
+func (s *uploadSession) snapshot() *uploadSession {
+	snap := *s
+	snap.Received = nil
+	return &snap
+}
+
+// TotalChunks returns the number of chunks the upload is split into
+func (s *uploadSession) TotalChunks() int {
+	if s.Size == 0 {
+		return 0
+	}
+	return int((s.Size + s.ChunkSize - 1) / s.ChunkSize)
+}
+
+// chunkStore persists upload chunks on disk so transfers can be resumed
+type chunkStore struct {
+	mu          sync.Mutex
+	dir         string
+	chunkSize   int64
+	maxFileSize int64
+	sessions    map[string]*uploadSession
+	buffers     *core.BufferPool
+}
+
+// newChunkStore creates a chunk store that keeps partial uploads under dir
+func newChunkStore(dir string, chunkSize, maxFileSize int64) *chunkStore {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &chunkStore{
+		dir:         dir,
+		chunkSize:   chunkSize,
+		maxFileSize: maxFileSize,
+		sessions:    make(map[string]*uploadSession),
+		buffers:     core.NewBufferPool(0),
+	}
+}
+
+// Create starts a new upload session for a file of the given size. checksum
+// is the expected hex SHA-256 of the file, or "" if it isn't known.
+func (cs *chunkStore) Create(filename string, size int64, checksum string) (*uploadSession, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("filename is required")
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	}
+	if cs.maxFileSize > 0 && size > cs.maxFileSize {
+		return nil, fmt.Errorf("file size %d exceeds limit of %d bytes", size, cs.maxFileSize)
+	}
+
+	session := &uploadSession{
+		ID:        "upload-" + core.NewID(),
+		Filename:  filename,
+		Size:      size,
+		ChunkSize: cs.chunkSize,
+		Received:  make(map[int]int64),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Checksum:  strings.ToLower(checksum),
+	}
+
+	if err := os.MkdirAll(cs.sessionDir(session.ID), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload session: %w", err)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.sessions[session.ID] = session
+	return session.snapshot(), nil
+}
+
+// Get returns a snapshot of the session with the given ID
+func (cs *chunkStore) Get(id string) (*uploadSession, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	session, ok := cs.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	return session.snapshot(), true
+}
+
+// WriteChunk stores chunk index of the upload. Chunks may arrive in any order
+// and re-sending a chunk overwrites it.
+func (cs *chunkStore) WriteChunk(id string, index int, data []byte) error {
+	cs.mu.Lock()
+	session, ok := cs.sessions[id]
+	cs.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("upload %s not found", id)
+	}
+
+	total := session.TotalChunks()
+	if index < 0 || index >= total {
+		return fmt.Errorf("chunk %d out of range (0-%d)", index, total-1)
+	}
+
+	expected := session.ChunkSize
+	if index == total-1 {
+		expected = session.Size - int64(index)*session.ChunkSize
+	}
+	if int64(len(data)) != expected {
+		return fmt.Errorf("chunk %d has %d bytes, expected %d", index, len(data), expected)
+	}
+
+	if err := os.WriteFile(cs.chunkPath(id, index), data, 0644); err != nil {
+		return fmt.Errorf("failed to write chunk %d: %w", index, err)
+	}
+
+	cs.mu.Lock()
+	session.Received[index] = int64(len(data))
+	session.UpdatedAt = time.Now()
+	cs.mu.Unlock()
+
+	return nil
+}
+
+// Progress returns the number of bytes received so far
+func (cs *chunkStore) Progress(id string) int64 {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	session, ok := cs.sessions[id]
+	if !ok {
+		return 0
+	}
+	var received int64
+	for _, n := range session.Received {
+		received += n
+	}
+	return received
+}
+
+// Missing returns the indexes of chunks not yet received, in order
+func (cs *chunkStore) Missing(id string) []int {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	session, ok := cs.sessions[id]
+	if !ok {
+		return nil
+	}
+	missing := make([]int, 0)
+	for i := 0; i < session.TotalChunks(); i++ {
+		if _, ok := session.Received[i]; !ok {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// ReceivedChunks returns the indexes of chunks received so far, in order
+func (cs *chunkStore) ReceivedChunks(id string) []int {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	session, ok := cs.sessions[id]
+	if !ok {
+		return nil
+	}
+	received := make([]int, 0, len(session.Received))
+	for i := range session.Received {
+		received = append(received, i)
+	}
+	sort.Ints(received)
+	return received
+}
+
+// Assemble concatenates all chunks into the upload's file in dir, named
+// under strategy, and removes the session. It returns the name the file was
+// saved as. It fails if any chunk is missing or the total size or checksum
+// does not match.
+func (cs *chunkStore) Assemble(id, dir, strategy string) (string, int64, error) {
+	session, ok := cs.Get(id)
+	if !ok {
+		return "", 0, fmt.Errorf("upload %s not found", id)
+	}
+	if missing := cs.Missing(id); len(missing) > 0 {
+		return "", 0, fmt.Errorf("upload %s is missing %d chunks", id, len(missing))
+	}
+
+	tmpDir := filepath.Join(dir, assemblyDirName)
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	out, err := os.CreateTemp(tmpDir, assemblyPattern)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	tmp := out.Name()
+
+	hash := sha256.New()
+	var written int64
+	for i := 0; i < session.TotalChunks(); i++ {
+		in, err := os.Open(cs.chunkPath(id, i))
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return "", 0, fmt.Errorf("failed to read chunk %d: %w", i, err)
+		}
+		n, err := cs.buffers.Copy(io.MultiWriter(out, hash), in)
+		in.Close()
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return "", 0, fmt.Errorf("failed to assemble chunk %d: %w", i, err)
+		}
+		written += n
+	}
+
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return "", 0, fmt.Errorf("failed to write file: %w", err)
+	}
+	if written != session.Size {
+		os.Remove(tmp)
+		return "", 0, fmt.Errorf("assembled %d bytes, expected %d", written, session.Size)
+	}
+	if session.Checksum != "" {
+		if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, session.Checksum) {
+			os.Remove(tmp)
+			return "", 0, fmt.Errorf("%w: got sha256 %s, expected %s", ErrChecksumMismatch, sum, session.Checksum)
+		}
+	}
+	name, err := placeUpload(tmp, dir, session.Filename, strategy)
+	if err != nil {
+		os.Remove(tmp)
+		return "", 0, err
+	}
+
+	cs.Remove(id)
+	return name, written, nil
+}
+
+// placeUpload moves the assembled file tmp to its name in dir under
+// strategy. Unless strategy is FilenameStrategyOverwrite the name is first
+// claimed by createUploadFile, so the rename only replaces that claim.
+func placeUpload(tmp, dir, filename, strategy string) (string, error) {
+	name := filename
+	if strategy != FilenameStrategyOverwrite {
+		claim, claimed, err := createUploadFile(dir, filename, strategy)
+		if errors.Is(err, ErrFileExists) {
+			return "", err
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create file: %w", err)
+		}
+		claim.Close()
+		name = claimed
+	}
+
+	dest := filepath.Join(dir, name)
+	if err := os.Rename(tmp, dest); err != nil {
+		if strategy != FilenameStrategyOverwrite {
+			os.Remove(dest)
+		}
+		return "", fmt.Errorf("failed to finalize file: %w", err)
+	}
+	return name, nil
+}
+
+// Expire discards sessions that have not received a chunk within ttl and
+// returns their IDs
+func (cs *chunkStore) Expire(ttl time.Duration) []string {
+	cutoff := time.Now().Add(-ttl)
+
+	cs.mu.Lock()
+	var expired []string
+	for id, session := range cs.sessions {
+		if session.UpdatedAt.Before(cutoff) {
+			expired = append(expired, id)
+		}
+	}
+	cs.mu.Unlock()
+
+	for _, id := range expired {
+		cs.Remove(id)
+	}
+	return expired
+}
+
+// Remove discards a session and its stored chunks
+func (cs *chunkStore) Remove(id string) {
+	cs.mu.Lock()
+	delete(cs.sessions, id)
+	cs.mu.Unlock()
+
+	os.RemoveAll(cs.sessionDir(id))
+}
+
+func (cs *chunkStore) sessionDir(id string) string {
+	return filepath.Join(cs.dir, id)
+}
+
+func (cs *chunkStore) chunkPath(id string, index int) string {
+	return filepath.Join(cs.sessionDir(id), fmt.Sprintf("%06d.part", index))
+}
@@ -0,0 +1,144 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newChunkedPlugin returns a file manager splitting uploads into chunkSize
+// byte chunks
+func newChunkedPlugin(t *testing.T, chunkSize int64) *FileManagerPlugin {
+	t.Helper()
+	p := NewFileManagerPlugin(t.TempDir(), t.TempDir(), 1<<20)
+	p.chunks = newChunkStore(filepath.Join(p.uploadDir, ".chunks"), chunkSize, 0)
+	return p
+}
+
+// uploadStatus is the part of upload responses the tests check
+type uploadStatus struct {
+	ID       string `json:"id"`
+	Received int64  `json:"received"`
+	Missing  []int  `json:"missing"`
+}
+
+func serveUpload(handler http.HandlerFunc, method, path, body string) (*httptest.ResponseRecorder, uploadStatus) {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var status uploadStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	return rec, status
+}
+
+func TestChunkedUploadResume(t *testing.T) {
+	const content = "0123456789ab"
+	sum := sha256.Sum256([]byte(content))
+	tests := []struct {
+		name     string
+		checksum string
+		// order is the order chunks are sent in before resuming
+		order      []int
+		wantStatus int
+	}{
+		{"in order", hex.EncodeToString(sum[:]), []int{0, 1}, http.StatusOK},
+		{"out of order", hex.EncodeToString(sum[:]), []int{2, 0}, http.StatusOK},
+		{"checksum case-insensitive", strings.ToUpper(hex.EncodeToString(sum[:])), []int{1}, http.StatusOK},
+		{"no checksum", "", []int{2}, http.StatusOK},
+		{"checksum mismatch", strings.Repeat("0", 64), []int{0}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newChunkedPlugin(t, 4)
+			body := fmt.Sprintf(`{"filename":"resume.txt","size":%d,"checksum":%q}`, len(content), tt.checksum)
+			rec, created := serveUpload(p.handleCreateUpload, http.MethodPost, "/uploads", body)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+			}
+			base := "/uploads/" + created.ID
+			put := func(index int) {
+				t.Helper()
+				chunk := content[index*4 : index*4+4]
+				if rec, _ := serveUpload(p.handleUploadChunk, http.MethodPut, fmt.Sprintf("%s/%d", base, index), chunk); rec.Code != http.StatusOK {
+					t.Fatalf("chunk %d: status %d: %s", index, rec.Code, rec.Body)
+				}
+			}
+			for _, index := range tt.order {
+				put(index)
+			}
+
+			// Completing early reports what is still missing
+			rec, _ = serveUpload(p.handleCompleteUpload, http.MethodPost, base+"/complete", "")
+			if rec.Code != http.StatusConflict {
+				t.Fatalf("early complete: status %d, want %d", rec.Code, http.StatusConflict)
+			}
+
+			// Resume: ask which chunks are missing and send only those
+			_, status := serveUpload(p.handleUploadStatus, http.MethodGet, base, "")
+			if status.Received != int64(len(tt.order))*4 {
+				t.Errorf("received = %d, want %d", status.Received, len(tt.order)*4)
+			}
+			for _, index := range status.Missing {
+				if slices.Contains(tt.order, index) {
+					t.Errorf("chunk %d reported missing after it was sent", index)
+				}
+				put(index)
+			}
+
+			rec, _ = serveUpload(p.handleCompleteUpload, http.MethodPost, base+"/complete", "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("complete: status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				// The chunks are kept so the bad ones can be re-sent
+				if _, ok := p.chunks.Get(created.ID); !ok {
+					t.Error("session discarded after a checksum mismatch")
+				}
+				return
+			}
+			if data, err := os.ReadFile(filepath.Join(p.uploadDir, "resume.txt")); err != nil || string(data) != content {
+				t.Errorf("assembled file = %q, %v, want %q", data, err, content)
+			}
+		})
+	}
+}
+
+func TestConcurrentChunksAndStatus(t *testing.T) {
+	p := newChunkedPlugin(t, 1)
+	const content = "concurrent chunk upload"
+	sum := sha256.Sum256([]byte(content))
+	body := fmt.Sprintf(`{"filename":"c.txt","size":%d,"checksum":%q}`, len(content), hex.EncodeToString(sum[:]))
+	_, created := serveUpload(p.handleCreateUpload, http.MethodPost, "/uploads", body)
+	base := "/uploads/" + created.ID
+
+	var wg sync.WaitGroup
+	for i := len(content) - 1; i >= 0; i-- {
+		wg.Add(2)
+		go func(index int) {
+			defer wg.Done()
+			if rec, _ := serveUpload(p.handleUploadChunk, http.MethodPut, fmt.Sprintf("%s/%d", base, index), content[index:index+1]); rec.Code != http.StatusOK {
+				t.Errorf("chunk %d: status %d", index, rec.Code)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			serveUpload(p.handleUploadStatus, http.MethodGet, base, "")
+		}()
+	}
+	wg.Wait()
+
+	if rec, _ := serveUpload(p.handleCompleteUpload, http.MethodPost, base+"/complete", ""); rec.Code != http.StatusOK {
+		t.Fatalf("complete: status %d: %s", rec.Code, rec.Body)
+	}
+	if data, _ := os.ReadFile(filepath.Join(p.uploadDir, "c.txt")); string(data) != content {
+		t.Errorf("assembled file = %q, want %q", data, content)
+	}
+}
@@ -0,0 +1,46 @@
+package plugins
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlSkipBlocks = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlLineBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlTags       = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRuns      = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLineRuns  = regexp.MustCompile(`\n{3,}`)
+)
+
+// toPlainText derives a text/plain rendering of rich clipboard content. It
+// returns an empty string for content that is already plain text.
+func toPlainText(content, contentType string) string {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return htmlToPlainText(content)
+	default:
+		return ""
+	}
+}
+
+// htmlToPlainText strips markup from an HTML fragment, keeping line breaks
+// for block elements and decoding entities
+func htmlToPlainText(s string) string {
+	s = htmlSkipBlocks.ReplaceAllString(s, "")
+	s = htmlLineBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRuns.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLineRuns.ReplaceAllString(s, "\n\n")
+
+	return strings.TrimSpace(s)
+}
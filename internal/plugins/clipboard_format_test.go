@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToPlainText(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contentType string
+		want        string
+	}{
+		{"paragraphs", "<p>Hello <b>world</b></p><p>Second&nbsp;line</p>", "text/html", "Hello world\nSecond line"},
+		{"line breaks", "A<br>B<br/>C", "text/html; charset=utf-8", "A\nB\nC"},
+		{"scripts and styles dropped", "<style>p { color: red }</style><script>alert(1)</script>Text", "text/html", "Text"},
+		{"entities", "a &lt;b&gt; &amp; c", "TEXT/HTML", "a <b> & c"},
+		{"blank lines collapsed", "<div>x</div><div></div><div></div><div>y</div>", "text/html", "x\n\ny"},
+		{"xhtml", "<span>  spaced   out </span>", "application/xhtml+xml", "spaced out"},
+		{"already plain", "<b>not markup</b>", "text/plain", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toPlainText(tt.content, tt.contentType); got != tt.want {
+				t.Errorf("toPlainText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetClipboardAs(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contentType string
+		as          string
+		wantStatus  int
+		wantContent string
+		wantType    string
+	}{
+		{"html as plain text", "<p>Hi <i>there</i></p>", "text/html", "text/plain", http.StatusOK, "Hi there", "text/plain"},
+		{"html as stored", "<p>Hi</p>", "text/html", "", http.StatusOK, "<p>Hi</p>", "text/html"},
+		{"plain as plain", "just text", "text/plain", "text/plain", http.StatusOK, "just text", "text/plain"},
+		{"unsupported format", "<p>Hi</p>", "text/html", "application/pdf", http.StatusNotAcceptable, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewClipboardPlugin(10)
+			setClipboard(t, p, map[string]interface{}{"content": tt.content, "type": tt.contentType})
+
+			target := "/clipboard"
+			if tt.as != "" {
+				target += "?as=" + tt.as
+			}
+			rec := httptest.NewRecorder()
+			p.handleGetClipboard(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got struct {
+				Content ClipboardEntry `json:"content"`
+			}
+			if err := json.NewDecoder(strings.NewReader(rec.Body.String())).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Content.Content != tt.wantContent || got.Content.Type != tt.wantType {
+				t.Errorf("got %q as %s, want %q as %s", got.Content.Content, got.Content.Type, tt.wantContent, tt.wantType)
+			}
+		})
+	}
+}
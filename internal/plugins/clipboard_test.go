@@ -0,0 +1,406 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// publishedEvent is an event handed to recordingPlatform
+type publishedEvent struct {
+	eventType string
+	data      map[string]interface{}
+}
+
+// recordingPlatform records the events plugins publish
+type recordingPlatform struct {
+	core.PlatformAPI
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+func (r *recordingPlatform) PublishEventContext(ctx context.Context, eventType string, data map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, publishedEvent{eventType, data})
+	return nil
+}
+
+// newTestClipboard returns a clipboard plugin configured with config that
+// publishes to a recordingPlatform
+func newTestClipboard(t *testing.T, config map[string]interface{}) (*ClipboardPlugin, *recordingPlatform) {
+	t.Helper()
+	platform := &recordingPlatform{}
+	p := NewClipboardPlugin(50)
+	if err := p.Initialize(platform); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if err := p.Configure(config); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	return p, platform
+}
+
+// setClipboard posts body to the plugin's set handler
+func setClipboard(t *testing.T, p *ClipboardPlugin, body map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	p.handleSetClipboard(rec, httptest.NewRequest(http.MethodPost, "/clipboard", bytes.NewReader(data)))
+	return rec
+}
+
+// getRawClipboard fetches the latest entry with ?raw=true
+func getRawClipboard(p *ClipboardPlugin) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	p.handleGetClipboard(rec, httptest.NewRequest(http.MethodGet, "/clipboard?raw=true", nil))
+	return rec
+}
+
+func TestClipboardPNGRoundTrip(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewClipboardPlugin(10)
+	rec := setClipboard(t, p, map[string]interface{}{
+		"data": base64.StdEncoding.EncodeToString(buf.Bytes()),
+		"type": "image/png",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set status = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = getRawClipboard(p)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get status = %d", rec.Code)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), buf.Bytes()) {
+		t.Fatal("raw clipboard differs from the PNG set")
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "" {
+		t.Errorf("Content-Disposition = %q, want none for images", got)
+	}
+}
+
+func TestClipboardRawContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       map[string]interface{}
+		contentType string
+		attachment  bool
+	}{
+		{"text", map[string]interface{}{"content": "hello"}, "text/plain; charset=utf-8", true},
+		{"jpeg", map[string]interface{}{"data": "AAEC", "type": "image/jpeg"}, "image/jpeg", false},
+		{"html data", map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("<script>alert(1)</script>")), "type": "text/html"}, "application/octet-stream", true},
+		{"svg data", map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("<svg onload=alert(1)>")), "type": "image/svg+xml"}, "application/octet-stream", true},
+		{"html text", map[string]interface{}{"content": "<script>alert(1)</script>", "type": "text/html; charset=utf-8"}, "application/octet-stream", true},
+		{"malformed type", map[string]interface{}{"data": "AAEC", "type": "image/png;;"}, "application/octet-stream", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewClipboardPlugin(10)
+			if rec := setClipboard(t, p, tt.entry); rec.Code != http.StatusOK {
+				t.Fatalf("set status = %d: %s", rec.Code, rec.Body)
+			}
+			rec := getRawClipboard(p)
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q", got)
+			}
+			if got := rec.Header().Get("Content-Disposition") == "attachment"; got != tt.attachment {
+				t.Errorf("attachment = %v, want %v", got, tt.attachment)
+			}
+		})
+	}
+}
+
+func TestClipboardRedaction(t *testing.T) {
+	patterns := []string{`\b\d{4}([ -]?\d{4}){3}\b`, `sk-[A-Za-z0-9]{20,}`}
+	tests := []struct {
+		name     string
+		entry    map[string]interface{}
+		redacted bool
+	}{
+		{"card number", map[string]interface{}{"content": "pay with 4111 1111 1111 1111", "source": "laptop"}, true},
+		{"api key", map[string]interface{}{"content": "export KEY=sk-abcdefghijklmnopqrstuv", "type": "text/html"}, true},
+		{"plain text", map[string]interface{}{"content": "meeting at 10"}, false},
+		// Patterns only apply to text
+		{"binary", map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("4111 1111 1111 1111")), "type": "image/png"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, platform := newTestClipboard(t, map[string]interface{}{"redactionPatterns": patterns})
+			rec := setClipboard(t, p, tt.entry)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			var response struct {
+				Redacted bool `json:"redacted"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || response.Redacted != tt.redacted {
+				t.Errorf("response %s, want redacted %v", rec.Body, tt.redacted)
+			}
+
+			// Both the latest entry and the history hold the placeholder
+			rec = httptest.NewRecorder()
+			p.handleGetHistory(rec, httptest.NewRequest(http.MethodGet, "/clipboard/history", nil))
+			var history struct {
+				History []ClipboardEntry `json:"history"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil || len(history.History) != 1 {
+				t.Fatalf("history %s: %v", rec.Body, err)
+			}
+			content, _ := tt.entry["content"].(string)
+			wantStored, wantEvent := content, "clipboard.changed"
+			if tt.redacted {
+				wantStored, wantEvent = RedactedPlaceholder, "clipboard.redacted"
+			}
+			if got := history.History[0].Content; got != wantStored {
+				t.Errorf("stored %q, want %q", got, wantStored)
+			}
+
+			if len(platform.events) != 1 || platform.events[0].eventType != wantEvent {
+				t.Fatalf("published %+v, want one %s event", platform.events, wantEvent)
+			}
+			// Redacted content never leaves the device
+			if tt.redacted && strings.Contains(fmt.Sprint(platform.events[0].data), content) {
+				t.Errorf("%s event carries the content: %v", wantEvent, platform.events[0].data)
+			}
+		})
+	}
+}
+
+func TestClipboardInvalidRedactionPattern(t *testing.T) {
+	p := NewClipboardPlugin(10)
+	if err := p.Configure(map[string]interface{}{"redactionPatterns": []string{"("}}); err == nil {
+		t.Error("Configure accepted an invalid redaction pattern")
+	}
+}
+
+// serveClipboard sends a request to one of the plugin's handlers
+func serveClipboard(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestClipboardChannelsAreIsolated(t *testing.T) {
+	p, platform := newTestClipboard(t, nil)
+	for target, content := range map[string]string{
+		"/clipboard?channel=work": "work secret",
+		"/clipboard":              "home note",
+	} {
+		if rec := serveClipboard(p.handleSetClipboard, http.MethodPost, target, fmt.Sprintf(`{"content":%q}`, content)); rec.Code != http.StatusOK {
+			t.Fatalf("set %s: status %d: %s", target, rec.Code, rec.Body)
+		}
+	}
+
+	tests := []struct {
+		channel string
+		want    string
+	}{
+		{"work", "work secret"},
+		{DefaultClipboardChannel, "home note"},
+		{"", "home note"},
+		{"personal", ""},
+	}
+	for _, tt := range tests {
+		query := ""
+		if tt.channel != "" {
+			query = "?channel=" + tt.channel
+		}
+
+		rec := serveClipboard(p.handleGetClipboard, http.MethodGet, "/clipboard"+query, "")
+		var latest struct {
+			Content *ClipboardEntry `json:"content"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &latest); err != nil {
+			t.Fatalf("channel %q: decode %s: %v", tt.channel, rec.Body, err)
+		}
+		got := ""
+		if latest.Content != nil {
+			got = latest.Content.Content
+		}
+		if got != tt.want {
+			t.Errorf("channel %q content = %q, want %q", tt.channel, got, tt.want)
+		}
+
+		rec = serveClipboard(p.handleGetHistory, http.MethodGet, "/clipboard/history"+query, "")
+		var history struct {
+			History []ClipboardEntry `json:"history"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+			t.Fatalf("channel %q: decode %s: %v", tt.channel, rec.Body, err)
+		}
+		for _, entry := range history.History {
+			if entry.Content != tt.want {
+				t.Errorf("channel %q history has %q from another channel", tt.channel, entry.Content)
+			}
+		}
+	}
+
+	// Peers are told which channel changed
+	for _, event := range platform.events {
+		if event.data["content"] == "work secret" && event.data["channel"] != "work" {
+			t.Errorf("work content published on channel %v", event.data["channel"])
+		}
+	}
+
+	// Clearing one channel leaves the others
+	serveClipboard(p.handleClearHistory, http.MethodDelete, "/clipboard/history?channel=work", "")
+	rec := serveClipboard(p.handleGetHistory, http.MethodGet, "/clipboard/history", "")
+	if !strings.Contains(rec.Body.String(), "home note") {
+		t.Errorf("clearing the work channel cleared the default one: %s", rec.Body)
+	}
+
+	for _, handler := range []http.HandlerFunc{p.handleGetClipboard, p.handleSetClipboard, p.handleGetHistory, p.handleClearHistory} {
+		if rec := serveClipboard(handler, http.MethodGet, "/clipboard?channel=../etc", `{"content":"x"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("invalid channel name: status %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestClipboardHistoryByteCap(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxHistory int
+		maxBytes   int
+		sizes      []int
+		wantSizes  []int
+	}{
+		{"byte cap before count cap", 50, 100, []int{40, 40, 40, 40, 40}, []int{40, 40}},
+		{"oversized newest entry kept", 50, 100, []int{10, 150}, []int{150}},
+		{"count cap before byte cap", 2, 1000, []int{10, 10, 10}, []int{10, 10}},
+		{"default cap", 50, 0, []int{40, 40, 40, 40, 40}, []int{40, 40, 40, 40, 40}},
+		{"no byte cap", 50, -1, []int{40, 40, 40, 40, 40}, []int{40, 40, 40, 40, 40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestClipboard(t, map[string]interface{}{"maxHistoryBytes": tt.maxBytes})
+			p.maxHistory = tt.maxHistory
+			for i, size := range tt.sizes {
+				// Vary the first byte so each entry is distinct
+				content := string(rune('a'+i)) + strings.Repeat("x", size-1)
+				if rec := setClipboard(t, p, map[string]interface{}{"content": content}); rec.Code != http.StatusOK {
+					t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+				}
+			}
+
+			history := p.channels[DefaultClipboardChannel]
+			var sizes []int
+			for _, entry := range history {
+				sizes = append(sizes, len(entry.Content))
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.wantSizes) {
+				t.Fatalf("history sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+			// The newest entries are the ones kept
+			if last := len(tt.sizes) - 1; history[len(history)-1].Content[0] != byte('a'+last) {
+				t.Errorf("newest entry is %q, want the last one set", history[len(history)-1].Content[:1])
+			}
+		})
+	}
+}
+
+func TestClipboardEntryExpires(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaultTTL  string
+		wantExpired bool
+	}{
+		{"short TTL", "50ms", true},
+		{"no TTL", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestClipboard(t, map[string]interface{}{"defaultTTL": tt.defaultTTL})
+			setClipboard(t, p, map[string]interface{}{"content": "hunter2"})
+			time.Sleep(100 * time.Millisecond)
+
+			// Expired entries are hidden before the sweeper removes them
+			p.mu.RLock()
+			live := p.liveEntries(DefaultClipboardChannel, time.Now())
+			p.mu.RUnlock()
+			if got := len(live) == 0; got != tt.wantExpired {
+				t.Errorf("live entries %v, want expired %v", live, tt.wantExpired)
+			}
+
+			rec := serveClipboard(p.handleGetClipboard, http.MethodGet, "/clipboard", "")
+			if got := !strings.Contains(rec.Body.String(), "hunter2"); got != tt.wantExpired {
+				t.Errorf("GET /clipboard = %s, want expired %v", rec.Body, tt.wantExpired)
+			}
+			rec = serveClipboard(p.handleGetHistory, http.MethodGet, "/clipboard/history", "")
+			if got := !strings.Contains(rec.Body.String(), "hunter2"); got != tt.wantExpired {
+				t.Errorf("GET /clipboard/history = %s, want expired %v", rec.Body, tt.wantExpired)
+			}
+			if rec := getRawClipboard(p); (rec.Code == http.StatusNotFound) != tt.wantExpired {
+				t.Errorf("raw read status %d, want expired %v", rec.Code, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestClipboardSweeper(t *testing.T) {
+	p, _ := newTestClipboard(t, map[string]interface{}{"defaultTTL": "20ms"})
+	p.sweepInterval = 10 * time.Millisecond
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	setClipboard(t, p, map[string]interface{}{"content": "hunter2"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.RLock()
+		n := len(p.channels)
+		p.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired entry never swept")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	p.mu.RLock()
+	done := p.sweepDone
+	p.mu.RUnlock()
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Error("Stop returned before the sweeper exited")
+	}
+
+	// The plugin can be started again with a new sweeper
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop after restart: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxDownloadCounts bounds the files a downloadCounter keeps counts for
+const maxDownloadCounts = 10000
+
+// downloadCounter keeps per-file download counts, persisted as JSON so they
+// survive restarts. It holds at most limit files; counting a new file past
+// that forgets the least downloaded one.
+type downloadCounter struct {
+	mu     sync.Mutex
+	path   string
+	limit  int
+	counts map[string]int64
+}
+
+// newDownloadCounter loads the counts stored at path. A missing or unreadable
+// file starts the counts from zero.
+func newDownloadCounter(path string) *downloadCounter {
+	dc := &downloadCounter{
+		path:   path,
+		limit:  maxDownloadCounts,
+		counts: make(map[string]int64),
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &dc.counts); err != nil || dc.counts == nil {
+			dc.counts = make(map[string]int64)
+		}
+	}
+	return dc
+}
+
+// Increment records a download of filename and returns the new count
+func (dc *downloadCounter) Increment(filename string) (int64, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if _, ok := dc.counts[filename]; !ok && len(dc.counts) >= dc.limit {
+		dc.evictLocked()
+	}
+	dc.counts[filename]++
+	return dc.counts[filename], dc.save()
+}
+
+// evictLocked forgets the least downloaded file. The caller holds dc.mu.
+func (dc *downloadCounter) evictLocked() {
+	var victim string
+	least := int64(-1)
+	for name, count := range dc.counts {
+		if least < 0 || count < least || (count == least && name < victim) {
+			victim, least = name, count
+		}
+	}
+	delete(dc.counts, victim)
+}
+
+// Count returns how many times filename has been downloaded
+func (dc *downloadCounter) Count(filename string) int64 {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.counts[filename]
+}
+
+// Remove forgets the count for filename, e.g. after it is deleted
+func (dc *downloadCounter) Remove(filename string) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if _, ok := dc.counts[filename]; !ok {
+		return nil
+	}
+	delete(dc.counts, filename)
+	return dc.save()
+}
+
+// save writes the counts atomically. The caller holds dc.mu.
+func (dc *downloadCounter) save() error {
+	if dc.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(dc.counts)
+	if err != nil {
+		return fmt.Errorf("failed to encode download counts: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dc.path), 0755); err != nil {
+		return fmt.Errorf("failed to save download counts: %w", err)
+	}
+	tmp := dc.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save download counts: %w", err)
+	}
+	if err := os.Rename(tmp, dc.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save download counts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadCounterIncrement(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		downloads []string
+		want      map[string]int64
+	}{
+		{
+			name:      "counts each file",
+			limit:     10,
+			downloads: []string{"a.txt", "b.txt", "a.txt", "a.txt"},
+			want:      map[string]int64{"a.txt": 3, "b.txt": 1},
+		},
+		{
+			name:      "forgets the least downloaded file past the limit",
+			limit:     2,
+			downloads: []string{"a.txt", "a.txt", "b.txt", "c.txt"},
+			want:      map[string]int64{"a.txt": 2, "b.txt": 0, "c.txt": 1},
+		},
+		{
+			name:      "existing files don't evict",
+			limit:     2,
+			downloads: []string{"a.txt", "b.txt", "b.txt", "a.txt"},
+			want:      map[string]int64{"a.txt": 2, "b.txt": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "downloads.json")
+			dc := newDownloadCounter(path)
+			dc.limit = tt.limit
+			for _, name := range tt.downloads {
+				if _, err := dc.Increment(name); err != nil {
+					t.Fatalf("Increment(%q): %v", name, err)
+				}
+			}
+			if len(dc.counts) > tt.limit {
+				t.Errorf("kept %d counts, limit is %d", len(dc.counts), tt.limit)
+			}
+
+			// Counts survive a restart
+			reloaded := newDownloadCounter(path)
+			for name, want := range tt.want {
+				if got := dc.Count(name); got != want {
+					t.Errorf("Count(%q) = %d, want %d", name, got, want)
+				}
+				if got := reloaded.Count(name); got != want {
+					t.Errorf("reloaded Count(%q) = %d, want %d", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadHead(t *testing.T) {
+	p := NewFileManagerPlugin(t.TempDir(), t.TempDir(), 1<<20)
+	if err := os.WriteFile(filepath.Join(p.uploadDir, "a.txt"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method        string
+		target        string
+		status        int
+		contentLength string
+		body          string
+	}{
+		{http.MethodHead, "/files/a.txt", http.StatusOK, "10", ""},
+		{http.MethodGet, "/files/a.txt", http.StatusOK, "10", "0123456789"},
+		{http.MethodHead, "/files/missing.txt", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		p.handleDownloadFile(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+		if tt.contentLength != "" && rec.Header().Get("Content-Length") != tt.contentLength {
+			t.Errorf("%s %s: Content-Length = %q, want %q", tt.method, tt.target, rec.Header().Get("Content-Length"), tt.contentLength)
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+			t.Errorf("%s %s: body = %q, want %q", tt.method, tt.target, rec.Body, tt.body)
+		}
+	}
+
+	// Only the GET counts as a download
+	if got := p.downloads.Count("a.txt"); got != 1 {
+		t.Errorf("download count = %d, want 1", got)
+	}
+
+	registered := false
+	for _, route := range p.Routes() {
+		if route.Method == http.MethodHead && route.Path == "/files/:filename" {
+			registered = true
+		}
+	}
+	if !registered {
+		t.Error("no HEAD route for /files/:filename")
+	}
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Filename strategies decide what happens when an upload has the same name
+// as an existing file
+const (
+	// FilenameStrategyOverwrite replaces the existing file
+	FilenameStrategyOverwrite = "overwrite"
+	// FilenameStrategyRename saves the upload as "name (1).ext", "name (2).ext", ...
+	FilenameStrategyRename = "rename"
+	// FilenameStrategyReject refuses the upload
+	FilenameStrategyReject = "reject"
+)
+
+// DefaultFilenameStrategy is used when no strategy is configured
+const DefaultFilenameStrategy = FilenameStrategyRename
+
+// maxRenameAttempts bounds the numbered names tried by FilenameStrategyRename
+const maxRenameAttempts = 10000
+
+// ErrFileExists is returned when an upload collides with an existing file
+// under FilenameStrategyReject
+var ErrFileExists = errors.New("file already exists")
+
+// validFilenameStrategy reports whether strategy is one of the known strategies
+func validFilenameStrategy(strategy string) bool {
+	switch strategy {
+	case FilenameStrategyOverwrite, FilenameStrategyRename, FilenameStrategyReject:
+		return true
+	}
+	return false
+}
+
+// createUploadFile creates the file an upload called filename is saved as in
+// dir under strategy, returning it open for writing along with its name.
+// Unless strategy is FilenameStrategyOverwrite the name is claimed with
+// O_EXCL, so a file created since another upload looked is never replaced.
+func createUploadFile(dir, filename, strategy string) (*os.File, string, error) {
+	if strategy == FilenameStrategyOverwrite {
+		f, err := os.OpenFile(filepath.Join(dir, filename), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		return f, filename, err
+	}
+
+	f, err := createExclusive(dir, filename)
+	if !os.IsExist(err) {
+		return f, filename, err
+	}
+	if strategy == FilenameStrategyReject {
+		return nil, "", fmt.Errorf("%w: %s", ErrFileExists, filename)
+	}
+
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filename, ext)
+	for i := 1; i <= maxRenameAttempts; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		f, err := createExclusive(dir, candidate)
+		if !os.IsExist(err) {
+			return f, candidate, err
+		}
+	}
+	return nil, "", fmt.Errorf("%w: no free name for %s", ErrFileExists, filename)
+}
+
+func createExclusive(dir, filename string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, filename), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+}
@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// assembleUpload stores data as a single-chunk upload and assembles it into
+// dir under strategy
+func assembleUpload(store *chunkStore, dir, filename, data, strategy string) (string, error) {
+	session, err := store.Create(filename, int64(len(data)), "")
+	if err != nil {
+		return "", err
+	}
+	if err := store.WriteChunk(session.ID, 0, []byte(data)); err != nil {
+		return "", err
+	}
+	name, _, err := store.Assemble(session.ID, dir, strategy)
+	return name, err
+}
+
+func TestAssembleFilenameStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		existing []string
+		wantName string
+		wantErr  error
+	}{
+		{FilenameStrategyOverwrite, []string{"a.txt"}, "a.txt", nil},
+		{FilenameStrategyRename, nil, "a.txt", nil},
+		{FilenameStrategyRename, []string{"a.txt"}, "a (1).txt", nil},
+		{FilenameStrategyRename, []string{"a.txt", "a (1).txt"}, "a (2).txt", nil},
+		{FilenameStrategyReject, nil, "a.txt", nil},
+		{FilenameStrategyReject, []string{"a.txt"}, "", ErrFileExists},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s with %d existing", tt.strategy, len(tt.existing)), func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			store := newChunkStore(t.TempDir(), 1024, 0)
+
+			name, err := assembleUpload(store, dir, "a.txt", "new", tt.strategy)
+			if !errors.Is(err, tt.wantErr) || name != tt.wantName {
+				t.Fatalf("Assemble = %q, %v, want %q, %v", name, err, tt.wantName, tt.wantErr)
+			}
+			if err == nil {
+				if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != "new" {
+					t.Errorf("%s = %q, want the upload", name, data)
+				}
+			}
+			for _, existing := range tt.existing {
+				if existing == name {
+					continue
+				}
+				if data, _ := os.ReadFile(filepath.Join(dir, existing)); string(data) != "old" {
+					t.Errorf("existing %s = %q, want it untouched", existing, data)
+				}
+			}
+			if entries, _ := os.ReadDir(filepath.Join(dir, assemblyDirName)); len(entries) != 0 {
+				t.Errorf("assembly left %d temp files", len(entries))
+			}
+		})
+	}
+}
+
+func TestConcurrentAssembleSameName(t *testing.T) {
+	tests := []struct {
+		strategy    string
+		wantSuccess int
+	}{
+		{FilenameStrategyRename, 8},
+		{FilenameStrategyReject, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dir := t.TempDir()
+			store := newChunkStore(t.TempDir(), 1024, 0)
+
+			const uploads = 8
+			var (
+				wg    sync.WaitGroup
+				mu    sync.Mutex
+				names = make(map[string]string)
+			)
+			for i := 0; i < uploads; i++ {
+				data := fmt.Sprintf("upload %d", i)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					name, err := assembleUpload(store, dir, "same.txt", data, tt.strategy)
+					if errors.Is(err, ErrFileExists) {
+						return
+					}
+					if err != nil {
+						t.Errorf("Assemble: %v", err)
+						return
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					if other, taken := names[name]; taken {
+						t.Errorf("%q and %q both saved as %s", other, data, name)
+					}
+					names[name] = data
+				}()
+			}
+			wg.Wait()
+
+			if len(names) != tt.wantSuccess {
+				t.Fatalf("%d uploads saved, want %d", len(names), tt.wantSuccess)
+			}
+			for name, data := range names {
+				if got, _ := os.ReadFile(filepath.Join(dir, name)); string(got) != data {
+					t.Errorf("%s = %q, want %q", name, got, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package plugins
+
+import "time"
+
+// systemInfoUnavailable marks readings a build can't take, such as one
+// built with the nosysinfo tag
+const systemInfoUnavailable = "unavailable"
+
+// processStart is when this process started, for its uptime
+var processStart = time.Now()
+
+// getUptimeInfo reports process and host uptime in seconds
+func getUptimeInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"processSeconds": time.Since(processStart).Seconds(),
+		"hostSeconds":    hostUptime(),
+	}
+}
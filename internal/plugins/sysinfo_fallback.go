@@ -0,0 +1,23 @@
+//go:build nosysinfo || js || wasip1
+
+package plugins
+
+// getMemoryInfo is not supported without native system information
+func getMemoryInfo() map[string]interface{} {
+	return map[string]interface{}{"error": systemInfoUnavailable}
+}
+
+// getCPUInfo is not supported without native system information
+func getCPUInfo() map[string]interface{} {
+	return map[string]interface{}{"error": systemInfoUnavailable}
+}
+
+// getNetworkInfo is not supported without native system information
+func getNetworkInfo() map[string]interface{} {
+	return map[string]interface{}{"error": systemInfoUnavailable}
+}
+
+// hostUptime is not supported without native system information
+func hostUptime() interface{} {
+	return systemInfoUnavailable
+}
@@ -0,0 +1,19 @@
+//go:build nosysinfo || js || wasip1
+
+package plugins
+
+import "testing"
+
+func TestFallbackSystemInfo(t *testing.T) {
+	info := getSystemInfo(t)
+	for _, key := range []string{"memory", "cpu", "network"} {
+		section, _ := info[key].(map[string]interface{})
+		if section["error"] != systemInfoUnavailable {
+			t.Errorf("%s = %v, want it reported unavailable", key, section)
+		}
+	}
+	uptime, _ := info["uptime"].(map[string]interface{})
+	if uptime["hostSeconds"] != systemInfoUnavailable {
+		t.Errorf("hostSeconds = %v, want %q", uptime["hostSeconds"], systemInfoUnavailable)
+	}
+}
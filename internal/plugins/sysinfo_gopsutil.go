@@ -0,0 +1,84 @@
+//go:build !nosysinfo && !js && !wasip1
+
+package plugins
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// cpuSampleInterval is how long CPU usage is measured over per request
+const cpuSampleInterval = 200 * time.Millisecond
+
+// getMemoryInfo reports virtual memory in bytes
+func getMemoryInfo() map[string]interface{} {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{
+		"total":       vm.Total,
+		"used":        vm.Used,
+		"free":        vm.Free,
+		"available":   vm.Available,
+		"usedPercent": vm.UsedPercent,
+	}
+}
+
+// getCPUInfo reports core counts, overall usage and, where the OS has one,
+// the load average
+func getCPUInfo() map[string]interface{} {
+	info := map[string]interface{}{}
+	if cores, err := cpu.Counts(true); err == nil {
+		info["cores"] = cores
+	}
+	if physical, err := cpu.Counts(false); err == nil {
+		info["physicalCores"] = physical
+	}
+	if percent, err := cpu.Percent(cpuSampleInterval, false); err == nil && len(percent) > 0 {
+		info["usagePercent"] = percent[0]
+	}
+	if avg, err := load.Avg(); err == nil {
+		info["load1"] = avg.Load1
+		info["load5"] = avg.Load5
+		info["load15"] = avg.Load15
+	}
+	return info
+}
+
+// getNetworkInfo reports traffic counters per network interface
+func getNetworkInfo() map[string]interface{} {
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	interfaces := make([]map[string]interface{}, 0, len(counters))
+	for _, c := range counters {
+		interfaces = append(interfaces, map[string]interface{}{
+			"name":        c.Name,
+			"bytesSent":   c.BytesSent,
+			"bytesRecv":   c.BytesRecv,
+			"packetsSent": c.PacketsSent,
+			"packetsRecv": c.PacketsRecv,
+			"errIn":       c.Errin,
+			"errOut":      c.Errout,
+			"dropIn":      c.Dropin,
+			"dropOut":     c.Dropout,
+		})
+	}
+	return map[string]interface{}{"interfaces": interfaces}
+}
+
+// hostUptime returns the host's uptime in seconds
+func hostUptime() interface{} {
+	uptime, err := host.Uptime()
+	if err != nil {
+		return systemInfoUnavailable
+	}
+	return uptime
+}
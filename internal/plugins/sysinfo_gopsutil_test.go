@@ -0,0 +1,63 @@
+//go:build !nosysinfo && !js && !wasip1
+
+package plugins
+
+import "testing"
+
+func TestSystemInfoReadings(t *testing.T) {
+	info := getSystemInfo(t)
+	section := func(name string) map[string]interface{} {
+		s, _ := info[name].(map[string]interface{})
+		if s["error"] != nil {
+			t.Fatalf("%s: %v", name, s["error"])
+		}
+		return s
+	}
+	memory, cpu, uptime := section("memory"), section("cpu"), section("uptime")
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		min, max float64
+	}{
+		{"memory total", memory["total"], 1, 1 << 50},
+		{"memory used", memory["used"], 1, 1 << 50},
+		{"memory free", memory["free"], 0, 1 << 50},
+		{"memory used percent", memory["usedPercent"], 0, 100},
+		{"cpu cores", cpu["cores"], 1, 1 << 16},
+		{"cpu usage percent", cpu["usagePercent"], 0, 100},
+		{"host uptime", uptime["hostSeconds"], 1, 100 * 365 * 24 * 60 * 60},
+	}
+	for _, tt := range tests {
+		n, ok := tt.value.(float64)
+		if !ok {
+			t.Errorf("%s = %#v, want a number", tt.name, tt.value)
+			continue
+		}
+		if n < tt.min || n > tt.max {
+			t.Errorf("%s = %v, want between %v and %v", tt.name, n, tt.min, tt.max)
+		}
+	}
+	if used, total := memory["used"].(float64), memory["total"].(float64); used > total {
+		t.Errorf("memory used %v exceeds total %v", used, total)
+	}
+}
+
+func TestNetworkInterfaceCounters(t *testing.T) {
+	network, _ := getSystemInfo(t)["network"].(map[string]interface{})
+	interfaces, ok := network["interfaces"].([]interface{})
+	if !ok {
+		t.Fatalf("interfaces = %#v, want a list", network["interfaces"])
+	}
+	for _, entry := range interfaces {
+		iface, _ := entry.(map[string]interface{})
+		if name, _ := iface["name"].(string); name == "" {
+			t.Errorf("interface without a name: %v", iface)
+		}
+		for _, key := range []string{"bytesSent", "bytesRecv", "packetsSent", "packetsRecv"} {
+			if n, ok := iface[key].(float64); !ok || n < 0 {
+				t.Errorf("%v %s = %#v, want a count", iface["name"], key, iface[key])
+			}
+		}
+	}
+}
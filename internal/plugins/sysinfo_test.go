@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+// getSystemInfo serves the system info endpoint and decodes its response
+func getSystemInfo(t *testing.T) map[string]interface{} {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	NewSystemInfoPlugin().handleSystemInfo(rec, httptest.NewRequest(http.MethodGet, "/info", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var info map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	return info
+}
+
+func TestSystemInfoShape(t *testing.T) {
+	info := getSystemInfo(t)
+	if info["platform"] != runtime.GOOS || info["arch"] != runtime.GOARCH {
+		t.Errorf("platform = %v/%v, want %s/%s", info["platform"], info["arch"], runtime.GOOS, runtime.GOARCH)
+	}
+	for _, key := range []string{"uptime", "memory", "cpu", "network"} {
+		if _, ok := info[key].(map[string]interface{}); !ok {
+			t.Errorf("%s = %#v, want an object", key, info[key])
+		}
+	}
+}
+
+func TestProcessUptime(t *testing.T) {
+	uptime, _ := getSystemInfo(t)["uptime"].(map[string]interface{})
+	seconds, ok := uptime["processSeconds"].(float64)
+	if !ok {
+		t.Fatalf("processSeconds = %#v, want a number", uptime["processSeconds"])
+	}
+	// The process started before this test did, and not long before
+	if seconds <= 0 || seconds > 24*60*60 {
+		t.Errorf("processSeconds = %v", seconds)
+	}
+}
@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultStaleTempAge is how old a leftover temp file must be before the
+// startup sweep removes it
+const DefaultStaleTempAge = 24 * time.Hour
+
+// removeStaleTempFiles deletes what interrupted operations left in the
+// upload directory and hasn't been touched for maxAge: chunk directories of
+// upload sessions that no longer exist, files from unfinished assembly and
+// ".tmp" files from unfinished atomic writes. It returns the removed paths.
+func (p *FileManagerPlugin) removeStaleTempFiles(maxAge time.Duration) []string {
+	if p.uploadDir == "" {
+		return nil
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var removed []string
+
+	stale := func(path string) bool {
+		info, err := os.Lstat(path)
+		return err == nil && info.ModTime().Before(cutoff)
+	}
+	remove := func(path string) {
+		if err := os.RemoveAll(path); err == nil {
+			removed = append(removed, path)
+		}
+	}
+
+	// Chunks of upload sessions lost with a previous run
+	if entries, err := os.ReadDir(p.chunks.dir); err == nil {
+		for _, entry := range entries {
+			if _, live := p.chunks.Get(entry.Name()); live {
+				continue
+			}
+			if path := filepath.Join(p.chunks.dir, entry.Name()); stale(path) {
+				remove(path)
+			}
+		}
+	}
+
+	// Half-assembled uploads and half-written metadata. Only the names each
+	// operation uses are matched, in the private directories they use, so
+	// user files such as notes.tmp or draft.partial are left alone.
+	leftovers := []struct{ dir, pattern string }{
+		{filepath.Join(p.uploadDir, assemblyDirName), assemblyPattern},
+		{filepath.Join(p.uploadDir, ".meta"), "*.tmp"},
+	}
+	for _, leftover := range leftovers {
+		entries, err := os.ReadDir(leftover.dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if matched, _ := filepath.Match(leftover.pattern, entry.Name()); entry.IsDir() || !matched {
+				continue
+			}
+			if path := filepath.Join(leftover.dir, entry.Name()); stale(path) {
+				remove(path)
+			}
+		}
+	}
+
+	return removed
+}
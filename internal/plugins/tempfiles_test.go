@@ -0,0 +1,100 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRemoveStaleTempFiles(t *testing.T) {
+	uploadDir := t.TempDir()
+	p := NewFileManagerPlugin(uploadDir, t.TempDir(), 1<<20)
+	live, err := p.chunks.Create("live.bin", 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-2 * DefaultStaleTempAge)
+	tests := []struct {
+		path    string
+		dir     bool
+		aged    bool
+		removed bool
+	}{
+		// Leftovers of interrupted operations
+		{filepath.Join(assemblyDirName, ".nplk-upload-123.partial"), false, true, true},
+		{filepath.Join(".meta", "downloads.json.tmp"), false, true, true},
+		{filepath.Join(".partial", "dead-session"), true, true, true},
+		// Recent leftovers may belong to operations still running
+		{filepath.Join(assemblyDirName, ".nplk-upload-456.partial"), false, false, false},
+		{filepath.Join(".meta", "recent.tmp"), false, false, false},
+		{filepath.Join(".partial", "recent-session"), true, false, false},
+		// Live sessions are kept however old
+		{filepath.Join(".partial", live.ID), true, true, false},
+		// Users' files are never touched
+		{"draft.partial", false, true, false},
+		{"notes.tmp", false, true, false},
+		{filepath.Join(assemblyDirName, "draft.partial"), false, true, false},
+		{filepath.Join(".meta", "downloads.json"), false, true, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(uploadDir, tt.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if tt.dir {
+			err = os.MkdirAll(path, 0700)
+		} else {
+			err = os.WriteFile(path, []byte("data"), 0600)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.aged {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	removed := p.removeStaleTempFiles(DefaultStaleTempAge)
+	for _, tt := range tests {
+		path := filepath.Join(uploadDir, tt.path)
+		_, err := os.Stat(path)
+		if exists := err == nil; exists == tt.removed {
+			t.Errorf("%s: exists = %v, want removed %v", tt.path, exists, tt.removed)
+		}
+		if slices.Contains(removed, path) != tt.removed {
+			t.Errorf("%s: reported removed = %v, want %v", tt.path, !tt.removed, tt.removed)
+		}
+	}
+}
+
+func TestAssembleLeavesNoTempFiles(t *testing.T) {
+	uploadDir := t.TempDir()
+	p := NewFileManagerPlugin(uploadDir, t.TempDir(), 1<<20)
+	session, err := p.chunks.Create("hello.txt", 5, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.chunks.WriteChunk(session.ID, 0, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := p.chunks.Assemble(session.ID, uploadDir, FilenameStrategyReject); err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(uploadDir, "hello.txt")); err != nil || string(data) != "hello" {
+		t.Fatalf("assembled file = %q, %v", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(uploadDir, assemblyDirName))
+	if len(entries) != 0 {
+		t.Fatalf("assembly left %d temp files", len(entries))
+	}
+	info, err := os.Stat(filepath.Join(uploadDir, assemblyDirName))
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Fatalf("assembly directory = %v, %v, want private", info, err)
+	}
+}
@@ -0,0 +1,356 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// Transfer protocol message types. A transfer starts with an "offer" from the
+// client which the server answers with "accept". Uploads then send "chunk"
+// messages acknowledged by the server with "ack" and finish with "complete".
+// Downloads are driven the other way round: the server sends "chunk" and
+// waits for the client's "ack" before sending the next one. Either side of a
+// transfer can be halted with "pause" and continued with "resume".
+const (
+	transferOffer    = "offer"
+	transferAccept   = "accept"
+	transferChunk    = "chunk"
+	transferAck      = "ack"
+	transferPause    = "pause"
+	transferPaused   = "paused"
+	transferResume   = "resume"
+	transferResumed  = "resumed"
+	transferComplete = "complete"
+	transferError    = "error"
+)
+
+// transferMessage is a single frame of the WebSocket transfer protocol
+type transferMessage struct {
+	Type       string `json:"type"`
+	TransferID string `json:"transferId,omitempty"`
+	Direction  string `json:"direction,omitempty"` // "upload" or "download"
+	Filename   string `json:"filename,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	ChunkSize  int64  `json:"chunkSize,omitempty"`
+	Index      int    `json:"index"`
+	Data       []byte `json:"data,omitempty"`
+	Received   int64  `json:"received,omitempty"`
+	Missing    []int  `json:"missing,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (p *FileManagerPlugin) transferUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  64 * 1024,
+		WriteBufferSize: 64 * 1024,
+		CheckOrigin:     p.checkOrigin,
+	}
+}
+
+// transferConn holds the state of one WebSocket transfer connection
+type transferConn struct {
+	plugin *FileManagerPlugin
+	conn   *websocket.Conn
+	paused bool
+	// ctx is the upgraded request's context, carrying its request ID
+	ctx context.Context
+
+	// Upload state
+	uploadID string
+
+	// Download state
+	download     *os.File
+	downloadID   string
+	downloadName string
+	downloadSize int64
+}
+
+func (p *FileManagerPlugin) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	conn, err := p.transferUpgrader().Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Base64 inflates chunks by a third; leave room for the envelope
+	conn.SetReadLimit(p.chunks.chunkSize*2 + 4096)
+
+	t := &transferConn{plugin: p, conn: conn, ctx: r.Context()}
+	defer t.closeDownload()
+
+	for {
+		var msg transferMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if err := t.handle(msg); err != nil {
+			if writeErr := t.send(transferMessage{Type: transferError, TransferID: msg.TransferID, Index: msg.Index, Error: err.Error()}); writeErr != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t *transferConn) handle(msg transferMessage) error {
+	switch msg.Type {
+	case transferOffer:
+		if msg.Direction == "download" {
+			return t.offerDownload(msg)
+		}
+		return t.offerUpload(msg)
+
+	case transferChunk:
+		if t.paused {
+			return fmt.Errorf("transfer paused")
+		}
+		return t.receiveChunk(msg)
+
+	case transferAck:
+		if t.download == nil {
+			return fmt.Errorf("no download in progress")
+		}
+		if t.paused {
+			return nil
+		}
+		return t.sendChunk(msg.Index + 1)
+
+	case transferPause:
+		t.paused = true
+		return t.send(transferMessage{Type: transferPaused, TransferID: t.currentID(), Received: t.progress()})
+
+	case transferResume:
+		t.paused = false
+		if t.download != nil {
+			// The client tells us which chunk to continue from
+			return t.sendChunk(msg.Index)
+		}
+		return t.send(transferMessage{
+			Type:       transferResumed,
+			TransferID: t.uploadID,
+			Received:   t.progress(),
+			Missing:    t.plugin.chunks.Missing(t.uploadID),
+		})
+
+	case transferComplete:
+		return t.completeUpload()
+
+	default:
+		return fmt.Errorf("unknown message type %q", msg.Type)
+	}
+}
+
+// offerUpload creates a new upload session, or resumes an existing one when
+// the offer carries a known transferId
+func (t *transferConn) offerUpload(msg transferMessage) error {
+	store := t.plugin.chunks
+
+	if msg.TransferID != "" {
+		session, ok := store.Get(msg.TransferID)
+		if !ok {
+			return fmt.Errorf("upload %s not found", msg.TransferID)
+		}
+		t.uploadID = session.ID
+		t.paused = false
+		return t.send(transferMessage{
+			Type:       transferAccept,
+			TransferID: session.ID,
+			Direction:  "upload",
+			Filename:   session.Filename,
+			Size:       session.Size,
+			ChunkSize:  session.ChunkSize,
+			Received:   store.Progress(session.ID),
+			Missing:    store.Missing(session.ID),
+		})
+	}
+
+	if msg.Size == 0 && t.plugin.rejectEmpty {
+		return fmt.Errorf("empty files are not accepted")
+	}
+	filename := t.plugin.sanitizeFilename(msg.Filename)
+	session, err := store.Create(filename, msg.Size, "")
+	if err != nil {
+		return err
+	}
+	t.uploadID = session.ID
+	t.paused = false
+
+	return t.send(transferMessage{
+		Type:       transferAccept,
+		TransferID: session.ID,
+		Direction:  "upload",
+		Filename:   session.Filename,
+		Size:       session.Size,
+		ChunkSize:  session.ChunkSize,
+		Missing:    store.Missing(session.ID),
+	})
+}
+
+func (t *transferConn) receiveChunk(msg transferMessage) error {
+	if t.uploadID == "" {
+		return fmt.Errorf("no upload in progress")
+	}
+	store := t.plugin.chunks
+	if err := store.WriteChunk(t.uploadID, msg.Index, msg.Data); err != nil {
+		return err
+	}
+
+	session, _ := store.Get(t.uploadID)
+	received := store.Progress(t.uploadID)
+	t.plugin.publishTransferProgress(t.ctx, t.uploadID, "upload", session.Filename, received, session.Size)
+
+	return t.send(transferMessage{
+		Type:       transferAck,
+		TransferID: t.uploadID,
+		Index:      msg.Index,
+		Received:   received,
+		Size:       session.Size,
+	})
+}
+
+func (t *transferConn) completeUpload() error {
+	if t.uploadID == "" {
+		return fmt.Errorf("no upload in progress")
+	}
+	store := t.plugin.chunks
+	session, ok := store.Get(t.uploadID)
+	if !ok {
+		return fmt.Errorf("upload %s not found", t.uploadID)
+	}
+
+	filename, size, err := store.Assemble(session.ID, t.plugin.uploadDir, t.plugin.filenameStrategy)
+	if err != nil {
+		return err
+	}
+
+	id := t.uploadID
+	t.uploadID = ""
+	t.plugin.publishTransferProgress(t.ctx, id, "upload", filename, size, size)
+
+	return t.send(transferMessage{Type: transferComplete, TransferID: id, Filename: filename, Size: size})
+}
+
+// offerDownload opens the requested file and sends the first chunk, or the
+// chunk given by Index when resuming an earlier download
+func (t *transferConn) offerDownload(msg transferMessage) error {
+	filename := filepath.Base(msg.Filename)
+	if filename == "" || filename == "." || filename != msg.Filename {
+		return fmt.Errorf("invalid filename")
+	}
+
+	f, err := os.Open(filepath.Join(t.plugin.uploadDir, filename))
+	if err != nil {
+		return fmt.Errorf("file not found")
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return fmt.Errorf("file not found")
+	}
+
+	t.closeDownload()
+	t.download = f
+	t.downloadID = "download-" + core.NewID()
+	t.downloadName = filename
+	t.downloadSize = info.Size()
+	t.paused = false
+	if msg.Index == 0 {
+		t.plugin.recordDownload(filename)
+	}
+
+	if err := t.send(transferMessage{
+		Type:       transferAccept,
+		TransferID: t.downloadID,
+		Direction:  "download",
+		Filename:   filename,
+		Size:       t.downloadSize,
+		ChunkSize:  t.plugin.chunks.chunkSize,
+	}); err != nil {
+		return err
+	}
+
+	return t.sendChunk(msg.Index)
+}
+
+// sendChunk sends download chunk index, or "complete" once all were sent
+func (t *transferConn) sendChunk(index int) error {
+	chunkSize := t.plugin.chunks.chunkSize
+	chunks := int((t.downloadSize + chunkSize - 1) / chunkSize)
+	if index < 0 || index > chunks {
+		return fmt.Errorf("chunk %d out of range", index)
+	}
+
+	if index == chunks {
+		id, name, size := t.downloadID, t.downloadName, t.downloadSize
+		t.closeDownload()
+		t.plugin.publishTransferProgress(t.ctx, id, "download", name, size, size)
+		return t.send(transferMessage{Type: transferComplete, TransferID: id, Filename: name, Size: size})
+	}
+
+	offset := int64(index) * chunkSize
+	n := chunkSize
+	if remaining := t.downloadSize - offset; remaining < n {
+		n = remaining
+	}
+	buf := make([]byte, n)
+	if _, err := t.download.ReadAt(buf, offset); err != nil {
+		return fmt.Errorf("failed to read chunk %d: %w", index, err)
+	}
+
+	received := offset + n
+	t.plugin.publishTransferProgress(t.ctx, t.downloadID, "download", t.downloadName, received, t.downloadSize)
+
+	return t.send(transferMessage{
+		Type:       transferChunk,
+		TransferID: t.downloadID,
+		Index:      index,
+		Data:       buf,
+		Received:   received,
+		Size:       t.downloadSize,
+	})
+}
+
+func (t *transferConn) closeDownload() {
+	if t.download != nil {
+		t.download.Close()
+		t.download = nil
+	}
+}
+
+func (t *transferConn) currentID() string {
+	if t.download != nil {
+		return t.downloadID
+	}
+	return t.uploadID
+}
+
+func (t *transferConn) progress() int64 {
+	if t.uploadID != "" {
+		return t.plugin.chunks.Progress(t.uploadID)
+	}
+	return 0
+}
+
+func (t *transferConn) send(msg transferMessage) error {
+	return t.conn.WriteJSON(msg)
+}
+
+// publishTransferProgress emits a file.transfer.progress event
+func (p *FileManagerPlugin) publishTransferProgress(ctx context.Context, id, direction, filename string, received, size int64) {
+	if p.platform == nil {
+		return
+	}
+
+	p.platform.PublishEventContext(ctx, "file.transfer.progress", map[string]interface{}{
+		"transferId": id,
+		"direction":  direction,
+		"filename":   filename,
+		"received":   received,
+		"size":       size,
+	})
+}
@@ -0,0 +1,212 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialTransfer opens a transfer socket to p
+func dialTransfer(t *testing.T, p *FileManagerPlugin) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(p.handleTransfer))
+	t.Cleanup(server.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// exchange sends msg and returns the reply, failing unless it has type want
+func exchange(t *testing.T, conn *websocket.Conn, msg transferMessage, want string) transferMessage {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("send %s: %v", msg.Type, err)
+	}
+	return expectMessage(t, conn, want)
+}
+
+// expectMessage reads the next message, failing unless it has type want
+func expectMessage(t *testing.T, conn *websocket.Conn, want string) transferMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var reply transferMessage
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatalf("waiting for %s: %v", want, err)
+	}
+	if reply.Type != want {
+		t.Fatalf("got %+v, want a %s message", reply, want)
+	}
+	return reply
+}
+
+func TestTransferUploadWithPause(t *testing.T) {
+	const content = "0123456789ab"
+	tests := []struct {
+		name string
+		// reconnect drops the connection while paused and resumes the
+		// upload on a new one
+		reconnect bool
+	}{
+		{"same connection", false},
+		{"new connection", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newChunkedPlugin(t, 4)
+			conn := dialTransfer(t, p)
+			chunk := func(index int) transferMessage {
+				return transferMessage{Type: transferChunk, Index: index, Data: []byte(content[index*4 : index*4+4])}
+			}
+
+			accept := exchange(t, conn, transferMessage{Type: transferOffer, Direction: "upload", Filename: "up.txt", Size: int64(len(content))}, transferAccept)
+			if accept.ChunkSize != 4 || !slices.Equal(accept.Missing, []int{0, 1, 2}) {
+				t.Fatalf("accept = %+v", accept)
+			}
+			if ack := exchange(t, conn, chunk(0), transferAck); ack.Index != 0 || ack.Received != 4 {
+				t.Fatalf("ack = %+v", ack)
+			}
+
+			if paused := exchange(t, conn, transferMessage{Type: transferPause}, transferPaused); paused.Received != 4 {
+				t.Errorf("paused at %d bytes, want 4", paused.Received)
+			}
+			// Chunks sent while paused are refused
+			exchange(t, conn, chunk(1), transferError)
+
+			var resumed transferMessage
+			if tt.reconnect {
+				conn.Close()
+				conn = dialTransfer(t, p)
+				resumed = exchange(t, conn, transferMessage{Type: transferOffer, Direction: "upload", TransferID: accept.TransferID}, transferAccept)
+			} else {
+				resumed = exchange(t, conn, transferMessage{Type: transferResume}, transferResumed)
+			}
+			if resumed.Received != 4 || !slices.Equal(resumed.Missing, []int{1, 2}) {
+				t.Fatalf("resumed = %+v, want chunks 1 and 2 missing", resumed)
+			}
+
+			for _, index := range resumed.Missing {
+				exchange(t, conn, chunk(index), transferAck)
+			}
+			complete := exchange(t, conn, transferMessage{Type: transferComplete}, transferComplete)
+			if complete.Filename != "up.txt" || complete.Size != int64(len(content)) {
+				t.Errorf("complete = %+v", complete)
+			}
+			if data, err := os.ReadFile(filepath.Join(p.uploadDir, "up.txt")); err != nil || string(data) != content {
+				t.Errorf("uploaded file = %q, %v, want %q", data, err, content)
+			}
+		})
+	}
+}
+
+func TestTransferDownloadWithPause(t *testing.T) {
+	const content = "0123456789"
+	p := newChunkedPlugin(t, 4)
+	if err := os.WriteFile(filepath.Join(p.uploadDir, "down.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conn := dialTransfer(t, p)
+
+	accept := exchange(t, conn, transferMessage{Type: transferOffer, Direction: "download", Filename: "down.txt"}, transferAccept)
+	if accept.Size != int64(len(content)) || accept.ChunkSize != 4 {
+		t.Fatalf("accept = %+v", accept)
+	}
+	var received []byte
+	first := expectMessage(t, conn, transferChunk)
+	received = append(received, first.Data...)
+
+	exchange(t, conn, transferMessage{Type: transferPause}, transferPaused)
+	// An ack while paused sends nothing; the next message is the resumed chunk
+	if err := conn.WriteJSON(transferMessage{Type: transferAck, Index: 0}); err != nil {
+		t.Fatal(err)
+	}
+	next := exchange(t, conn, transferMessage{Type: transferResume, Index: 1}, transferChunk)
+	if next.Index != 1 || next.Received != 8 {
+		t.Fatalf("resumed chunk = %+v, want chunk 1", next)
+	}
+	received = append(received, next.Data...)
+
+	last := exchange(t, conn, transferMessage{Type: transferAck, Index: 1}, transferChunk)
+	received = append(received, last.Data...)
+	complete := exchange(t, conn, transferMessage{Type: transferAck, Index: 2}, transferComplete)
+	if complete.Filename != "down.txt" || complete.Size != int64(len(content)) {
+		t.Errorf("complete = %+v", complete)
+	}
+	if string(received) != content {
+		t.Errorf("downloaded %q, want %q", received, content)
+	}
+}
+
+func TestTransferDownloadSizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"partial last chunk", "0123456789"},
+		{"whole chunks", "01234567"},
+		{"single short chunk", "01"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newChunkedPlugin(t, 4)
+			if err := os.WriteFile(filepath.Join(p.uploadDir, "f.txt"), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			conn := dialTransfer(t, p)
+			exchange(t, conn, transferMessage{Type: transferOffer, Direction: "download", Filename: "f.txt"}, transferAccept)
+
+			var received []byte
+			for {
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				var msg transferMessage
+				if err := conn.ReadJSON(&msg); err != nil {
+					t.Fatal(err)
+				}
+				if msg.Type == transferComplete {
+					break
+				}
+				if msg.Type != transferChunk {
+					t.Fatalf("got %+v, want a chunk or complete", msg)
+				}
+				received = append(received, msg.Data...)
+				if err := conn.WriteJSON(transferMessage{Type: transferAck, Index: msg.Index}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if string(received) != tt.content {
+				t.Errorf("downloaded %q, want %q", received, tt.content)
+			}
+		})
+	}
+}
+
+func TestTransferRejectsBadOffers(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  transferMessage
+	}{
+		{"unknown upload", transferMessage{Type: transferOffer, Direction: "upload", TransferID: "missing"}},
+		{"download outside upload dir", transferMessage{Type: transferOffer, Direction: "download", Filename: "../secret"}},
+		{"missing download", transferMessage{Type: transferOffer, Direction: "download", Filename: "none.txt"}},
+		{"chunk without upload", transferMessage{Type: transferChunk, Data: []byte("x")}},
+		{"unknown type", transferMessage{Type: "bogus"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialTransfer(t, newChunkedPlugin(t, 4))
+			if reply := exchange(t, conn, tt.msg, transferError); reply.Error == "" {
+				t.Errorf("error reply has no message: %+v", reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// uploadRequest builds a multipart upload of data named filename
+func uploadRequest(t *testing.T, filename string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestConcurrentUploads(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		// maxMemory below size makes multipart spill files to disk
+		maxMemory int64
+	}{
+		{"in memory", 64 << 10, 1 << 20},
+		{"spilled to disk", 256 << 10, 1 << 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploadDir := t.TempDir()
+			p := NewFileManagerPlugin(uploadDir, t.TempDir(), tt.maxMemory)
+
+			const uploads = 16
+			var wg sync.WaitGroup
+			for i := 0; i < uploads; i++ {
+				name := fmt.Sprintf("file-%d.bin", i)
+				req := uploadRequest(t, name, bytes.Repeat([]byte{byte('a' + i)}, tt.size))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					rec := httptest.NewRecorder()
+					p.handleUploadFile(rec, req)
+					if rec.Code != http.StatusOK {
+						t.Errorf("%s: status %d: %s", name, rec.Code, strings.TrimSpace(rec.Body.String()))
+					}
+				}()
+			}
+			wg.Wait()
+
+			for i := 0; i < uploads; i++ {
+				name := fmt.Sprintf("file-%d.bin", i)
+				got, err := os.ReadFile(filepath.Join(uploadDir, name))
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if !bytes.Equal(got, bytes.Repeat([]byte{byte('a' + i)}, tt.size)) {
+					t.Errorf("%s: got %d bytes of wrong content", name, len(got))
+				}
+			}
+		})
+	}
+}
+
+func TestUploadMissingAndEmptyFiles(t *testing.T) {
+	noFilePart := func(t *testing.T) *http.Request {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("note", "no file here")
+		form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		return req
+	}
+	notMultipart := func(t *testing.T) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{"file":"x"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	file := func(data string) func(t *testing.T) *http.Request {
+		return func(t *testing.T) *http.Request { return uploadRequest(t, "f.txt", []byte(data)) }
+	}
+
+	tests := []struct {
+		name        string
+		request     func(t *testing.T) *http.Request
+		rejectEmpty bool
+		wantStatus  int
+		wantError   string
+	}{
+		{"missing file part", noFilePart, false, http.StatusBadRequest, `no "file" part`},
+		{"not multipart", notMultipart, false, http.StatusBadRequest, "multipart/form-data"},
+		{"empty file", file(""), false, http.StatusOK, ""},
+		{"empty file rejected", file(""), true, http.StatusBadRequest, "Empty files"},
+		{"non-empty file with empty files rejected", file("data"), true, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFileManagerPlugin(t.TempDir(), t.TempDir(), 1<<20)
+			if err := p.Configure(map[string]interface{}{"rejectEmptyUploads": tt.rejectEmpty}); err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			p.handleUploadFile(rec, tt.request(t))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantError) {
+				t.Errorf("body %q doesn't mention %q", rec.Body, tt.wantError)
+			}
+			_, err := os.Stat(filepath.Join(p.uploadDir, "f.txt"))
+			if saved := err == nil; saved != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("file saved = %v with status %d", saved, rec.Code)
+			}
+		})
+	}
+}
+
+func TestEmptyChunkedUploadsRejected(t *testing.T) {
+	p := newChunkedPlugin(t, 4)
+	if err := p.Configure(map[string]interface{}{"rejectEmptyUploads": true}); err != nil {
+		t.Fatal(err)
+	}
+	if rec, _ := serveUpload(p.handleCreateUpload, http.MethodPost, "/uploads", `{"filename":"e.txt","size":0}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("create empty upload: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	conn := dialTransfer(t, p)
+	exchange(t, conn, transferMessage{Type: transferOffer, Direction: "upload", Filename: "e.txt"}, transferError)
+}
+
+func TestSymlinkedUploadDir(t *testing.T) {
+	real := t.TempDir()
+	link := filepath.Join(t.TempDir(), "uploads")
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(real, "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewFileManagerPlugin(link, t.TempDir(), 1<<20)
+	if err := p.ensureDirectories(); err != nil {
+		t.Fatalf("ensureDirectories: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.uploadDir != resolved {
+		t.Errorf("upload dir = %s, want it resolved to %s", p.uploadDir, resolved)
+	}
+
+	rec := httptest.NewRecorder()
+	p.handleUploadFile(rec, uploadRequest(t, "a.txt", []byte("hello")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload: status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+	if got, err := os.ReadFile(filepath.Join(real, "a.txt")); err != nil || string(got) != "hello" {
+		t.Fatalf("uploaded file = %q, %v", got, err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+		status  int
+		body    string
+	}{
+		{"download", p.handleDownloadFile, "/files/a.txt", http.StatusOK, "hello"},
+		{"info", p.handleFileInfo, "/files/a.txt/info", http.StatusOK, ""},
+		{"download through a link out", p.handleDownloadFile, "/files/escape.txt", http.StatusForbidden, ""},
+		{"info through a link out", p.handleFileInfo, "/files/escape.txt/info", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body, tt.body)
+		}
+		if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("%s: served the file outside the upload directory", tt.name)
+		}
+	}
+}
+
+func TestEnsureDirectoriesUnderSymlink(t *testing.T) {
+	// The upload directory doesn't exist yet, but its parent is a link
+	real := t.TempDir()
+	link := filepath.Join(t.TempDir(), "data")
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewFileManagerPlugin(filepath.Join(link, "uploads"), filepath.Join(link, "downloads"), 1<<20)
+	if err := p.ensureDirectories(); err != nil {
+		t.Fatalf("ensureDirectories: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []struct{ got, want string }{
+		{p.uploadDir, filepath.Join(resolved, "uploads")},
+		{p.downloadDir, filepath.Join(resolved, "downloads")},
+		{p.chunks.dir, filepath.Join(resolved, "uploads", ".partial")},
+	} {
+		if dir.got != dir.want {
+			t.Errorf("directory = %s, want %s", dir.got, dir.want)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(real, "uploads")); err != nil || !info.IsDir() {
+		t.Errorf("upload directory not created: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chunked uploads over plain HTTP. A client creates a session with
+// POST /uploads, sends each chunk with PUT /uploads/:id/:chunk in any order
+// and finishes with POST /uploads/:id/complete. GET /uploads/:id reports the
+// missing chunks so an interrupted upload can be resumed.
+
+// uploadResponse describes an upload session to the client
+func (p *FileManagerPlugin) uploadResponse(session *uploadSession) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          session.ID,
+		"filename":    session.Filename,
+		"size":        session.Size,
+		"chunkSize":   session.ChunkSize,
+		"totalChunks": session.TotalChunks(),
+		"received":    p.chunks.Progress(session.ID),
+		"missing":     p.chunks.Missing(session.ID),
+		"createdAt":   session.CreatedAt,
+		"updatedAt":   session.UpdatedAt,
+	}
+}
+
+// uploadPathParts returns the path segments after "/uploads"
+func uploadPathParts(path string) []string {
+	_, rest, found := strings.Cut(path, "/uploads/")
+	if !found {
+		return nil
+	}
+	return strings.Split(strings.Trim(rest, "/"), "/")
+}
+
+func (p *FileManagerPlugin) handleCreateUpload(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+		Checksum string `json:"checksum"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Size == 0 && p.rejectEmpty {
+		http.Error(w, "Empty files are not accepted", http.StatusBadRequest)
+		return
+	}
+	if p.maxFileSize > 0 && req.Size > p.maxFileSize {
+		http.Error(w, fmt.Sprintf("File exceeds the %d byte limit", p.maxFileSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	session, err := p.chunks.Create(p.sanitizeFilename(req.Filename), req.Size, req.Checksum)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+session.ID)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p.uploadResponse(session))
+}
+
+func (p *FileManagerPlugin) handleUploadStatus(w http.ResponseWriter, r *http.Request) {
+	parts := uploadPathParts(r.URL.Path)
+	if len(parts) == 0 || parts[0] == "" {
+		http.Error(w, "No upload specified", http.StatusBadRequest)
+		return
+	}
+	session, ok := p.chunks.Get(parts[0])
+	if !ok {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p.uploadResponse(session))
+}
+
+func (p *FileManagerPlugin) handleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	parts := uploadPathParts(r.URL.Path)
+	if len(parts) != 2 {
+		http.Error(w, "Expected /uploads/:id/:chunk", http.StatusBadRequest)
+		return
+	}
+	session, ok := p.chunks.Get(parts[0])
+	if !ok {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return
+	}
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.Error(w, "Invalid chunk index", http.StatusBadRequest)
+		return
+	}
+
+	// Read one byte past the chunk size so oversized chunks are rejected
+	// rather than truncated
+	data, err := io.ReadAll(io.LimitReader(r.Body, session.ChunkSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read chunk", http.StatusBadRequest)
+		return
+	}
+	if err := p.chunks.WriteChunk(session.ID, index, data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	received := p.chunks.Progress(session.ID)
+	p.publishTransferProgress(r.Context(), session.ID, "upload", session.Filename, received, session.Size)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":       session.ID,
+		"chunk":    index,
+		"received": received,
+		"missing":  p.chunks.Missing(session.ID),
+	})
+}
+
+func (p *FileManagerPlugin) handleCompleteUpload(w http.ResponseWriter, r *http.Request) {
+	parts := uploadPathParts(r.URL.Path)
+	if len(parts) != 2 || parts[1] != "complete" {
+		http.Error(w, "Expected /uploads/:id/complete", http.StatusBadRequest)
+		return
+	}
+	session, ok := p.chunks.Get(parts[0])
+	if !ok {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return
+	}
+	if missing := p.chunks.Missing(session.ID); len(missing) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   fmt.Sprintf("upload is missing %d chunks", len(missing)),
+			"missing": missing,
+		})
+		return
+	}
+
+	filename, size, err := p.chunks.Assemble(session.ID, p.uploadDir, p.filenameStrategy)
+	if errors.Is(err, ErrFileExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, ErrChecksumMismatch) {
+		// The chunks are kept so the client can re-send the bad ones
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	p.publishTransferProgress(r.Context(), session.ID, "upload", filename, size, size)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"filename": filename,
+		"size":     size,
+		"status":   "success",
+	})
+}
+
+func (p *FileManagerPlugin) handleAbortUpload(w http.ResponseWriter, r *http.Request) {
+	parts := uploadPathParts(r.URL.Path)
+	if len(parts) == 0 || parts[0] == "" {
+		http.Error(w, "No upload specified", http.StatusBadRequest)
+		return
+	}
+	if _, ok := p.chunks.Get(parts[0]); !ok {
+		http.Error(w, "Upload not found", http.StatusNotFound)
+		return
+	}
+	p.chunks.Remove(parts[0])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sweepUploads discards abandoned upload sessions until stop is closed
+func (p *FileManagerPlugin) sweepUploads(ttl time.Duration, stop <-chan struct{}) {
+	interval := ttl / 4
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, id := range p.chunks.Expire(ttl) {
+				if p.platform != nil {
+					p.platform.GetLogger().Info("Discarded abandoned upload", "uploadId", id)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+// waitForAuditEvent polls the audit log at path for an event of eventType
+// and returns it along with the raw log
+func waitForAuditEvent(t *testing.T, path, eventType string) (core.Event, string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var event core.Event
+			if json.Unmarshal([]byte(line), &event) == nil && event.Type == eventType {
+				return event, string(data)
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s event in audit log:\n%s", eventType, data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuthFailuresAreAudited(t *testing.T) {
+	const badToken = "not.a.valid-token"
+	tests := []struct {
+		name       string
+		token      func(t *testing.T, p *platform.Platform) string
+		wantStatus int
+		wantEvent  string
+		wantUser   string
+	}{
+		{"missing permission", func(t *testing.T, p *platform.Platform) string { return issueToken(t, p, "op", "operator") },
+			http.StatusForbidden, "auth.forbidden", "op"},
+		{"invalid token", func(*testing.T, *platform.Platform) string { return badToken },
+			http.StatusUnauthorized, "auth.token_rejected", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Security.EnableAuditLog = true
+				cfg.Security.AuditLogFile = path
+			})
+			s := newTestService(t, HTTPConfig{}, p)
+
+			token := tt.token(t, p)
+			rec := do(s, http.MethodPost, "/api/platform/reload", nil, bearer(token))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			event, log := waitForAuditEvent(t, path, tt.wantEvent)
+			data := event.Data
+			if data["userId"] != tt.wantUser || data["path"] != "/api/platform/reload" || data["reason"] == "" || data["ip"] == "" {
+				t.Errorf("%s event data = %v", tt.wantEvent, data)
+			}
+			if strings.Contains(log, token) {
+				t.Error("audit log contains the presented token")
+			}
+		})
+	}
+}
+
+func TestPublishRejectsAuthEvents(t *testing.T) {
+	s := newTestService(t, HTTPConfig{}, nil)
+	tests := []struct {
+		eventType string
+		want      int
+	}{
+		{"auth.token_issued", http.StatusForbidden},
+		{"auth.anything", http.StatusForbidden},
+		{"custom.note", http.StatusOK},
+		// Only the auth. namespace is reserved
+		{"authors.updated", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/events/publish", map[string]interface{}{"id": core.NewID(), "type": tt.eventType}, nil)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestTokenRevocationIsAudited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+		cfg.Security.EnableAuditLog = true
+		cfg.Security.AuditLogFile = path
+	})
+	s := newTestService(t, HTTPConfig{}, p)
+
+	token := issueToken(t, p, "alice", "operator")
+	if rec := do(s, http.MethodPost, "/api/platform/token/revoke", map[string]string{"token": token}, bearer(token)); rec.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d: %s", rec.Code, rec.Body)
+	}
+	event, log := waitForAuditEvent(t, path, "auth.token_revoked")
+	if event.Data["userId"] != "alice" {
+		t.Errorf("auth.token_revoked event data = %v", event.Data)
+	}
+	if strings.Contains(log, token) {
+		t.Error("audit log contains the revoked token")
+	}
+}
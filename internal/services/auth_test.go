@@ -0,0 +1,42 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAuthExemptPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   HTTPConfig
+		path     string
+		token    bool
+		wantAuth bool
+	}{
+		{"probe liveness", HTTPConfig{}, "/healthz", false, false},
+		{"probe readiness", HTTPConfig{}, "/readyz", false, false},
+		{"scrape metrics", HTTPConfig{}, "/api/platform/metrics", false, false},
+		{"resources without token", HTTPConfig{}, "/api/resources", false, true},
+		{"resources with token", HTTPConfig{}, "/api/resources", true, false},
+		{"custom prefix", HTTPConfig{AuthExemptPaths: []string{"/api/resources/*"}}, "/api/resources", false, false},
+		{"custom list replaces defaults", HTTPConfig{AuthExemptPaths: []string{"/readyz"}}, "/healthz", false, true},
+		{"under base path", HTTPConfig{BasePath: "/npl"}, "/npl/healthz", false, false},
+		{"protected under base path", HTTPConfig{BasePath: "/npl"}, "/npl/api/resources", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			tt.config.EnableAuth = true
+			s := newTestService(t, tt.config, p)
+
+			var header http.Header
+			if tt.token {
+				header = bearer(issueToken(t, p, "user"))
+			}
+			rec := do(s, http.MethodGet, tt.path, nil, header)
+			if gotAuth := rec.Code == http.StatusUnauthorized; gotAuth != tt.wantAuth {
+				t.Fatalf("GET %s: status %d, want auth required %v", tt.path, rec.Code, tt.wantAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestBasePathRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		want     string
+	}{
+		{"root", "", ""},
+		{"prefix", "/noplacelike", "/noplacelike"},
+		{"prefix without slashes", "noplacelike", "/noplacelike"},
+		{"prefix with trailing slash", "/noplacelike/", "/noplacelike"},
+		{"nested prefix", "/apps/noplacelike", "/apps/noplacelike"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+			plugin := &routePlugin{id: "widgets", routes: []core.Route{{Method: http.MethodGet, Path: "/items", Handler: ok}}}
+			if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+				t.Fatalf("LoadPlugin: %v", err)
+			}
+			s := newTestService(t, HTTPConfig{BasePath: tt.basePath}, p)
+
+			for _, path := range []string{"/healthz", "/api/plugins", "/api/docs/json", "/plugins/widgets/items"} {
+				if rec := do(s, http.MethodGet, tt.want+path, nil, nil); rec.Code != http.StatusOK {
+					t.Errorf("GET %s: status %d, want %d", tt.want+path, rec.Code, http.StatusOK)
+				}
+				if tt.want == "" {
+					continue
+				}
+				// Nothing is served outside the prefix
+				if rec := do(s, http.MethodGet, path, nil, nil); rec.Code != http.StatusNotFound {
+					t.Errorf("GET %s outside the base path: status %d, want %d", path, rec.Code, http.StatusNotFound)
+				}
+			}
+		})
+	}
+}
+
+func TestBasePathLinks(t *testing.T) {
+	const base = "/noplacelike"
+	p := newTestPlatform(t, nil)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	plugin := &routePlugin{id: "widgets", routes: []core.Route{{Method: http.MethodGet, Path: "/items", Handler: ok}}}
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	s := newTestService(t, HTTPConfig{BasePath: base + "/"}, p)
+
+	var root struct {
+		Links map[string]string `json:"links"`
+	}
+	rec := do(s, http.MethodGet, base+"/", nil, nil)
+	if err := json.Unmarshal(rec.Body.Bytes(), &root); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if root.Links["docs"] != base+"/api/docs" || root.Links["health"] != base+"/health" {
+		t.Errorf("root links = %v, want them under %s", root.Links, base)
+	}
+
+	var spec struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+	}
+	rec = do(s, http.MethodGet, base+"/api/docs/json", nil, nil)
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != base+"/" {
+		t.Errorf("spec servers = %+v, want %s/", spec.Servers, base)
+	}
+
+	rec = do(s, http.MethodGet, base+"/api/docs", nil, nil)
+	if want := `url: "` + base + `/api/docs/json"`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("docs UI does not load the spec from %s", base+"/api/docs/json")
+	}
+
+	var manifest struct {
+		Routes []struct {
+			Path string `json:"path"`
+		} `json:"routes"`
+	}
+	rec = do(s, http.MethodGet, base+"/api/plugins/widgets/manifest", nil, nil)
+	if err := json.Unmarshal(rec.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if len(manifest.Routes) != 1 || manifest.Routes[0].Path != base+"/plugins/widgets/items" {
+		t.Errorf("manifest routes = %+v, want the route under %s", manifest.Routes, base)
+	}
+}
+
+func TestBasePathAuthExemptions(t *testing.T) {
+	const base = "/noplacelike"
+	s := newTestService(t, HTTPConfig{BasePath: base, EnableAuth: true}, newTestPlatform(t, nil))
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{base + "/healthz", http.StatusOK},
+		{base + "/readyz", http.StatusServiceUnavailable},
+		{base + "/api/plugins", http.StatusUnauthorized},
+		// Paths are only exempt under the base path
+		{"/healthz", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if rec := do(s, http.MethodGet, tt.path, nil, nil); rec.Code != tt.want {
+			t.Errorf("GET %s: status %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		origin      string
+		allowOrigin string
+		allowCreds  string
+		vary        bool
+	}{
+		{"allowed origin", []string{"https://app.example"}, false, "https://app.example", "https://app.example", "", true},
+		{"allowed origin, other case", []string{"https://App.example/"}, false, "https://app.example", "https://app.example", "", true},
+		{"disallowed origin", []string{"https://app.example"}, false, "https://evil.example", "", "", true},
+		{"nothing configured", nil, false, "https://app.example", "", "", true},
+		{"credentials", []string{"https://app.example"}, true, "https://app.example", "https://app.example", "true", true},
+		{"credentials, disallowed origin", []string{"https://app.example"}, true, "https://evil.example", "", "", true},
+		{"wildcard", []string{"*"}, false, "https://any.example", "*", "", false},
+		{"wildcard ignored with credentials", []string{"*"}, true, "https://any.example", "", "", true},
+		{"wildcard with credentials keeps listed origins", []string{"*", "https://app.example"}, true, "https://app.example", "https://app.example", "true", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, HTTPConfig{
+				EnableCORS:           true,
+				CORSAllowedOrigins:   tt.origins,
+				CORSAllowCredentials: tt.credentials,
+			}, nil)
+			rec := do(s, http.MethodGet, "/healthz", nil, http.Header{"Origin": {tt.origin}})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.allowCreds {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.allowCreds)
+			}
+			if got := strings.Contains(rec.Header().Get("Vary"), "Origin"); got != tt.vary {
+				t.Errorf("Vary: Origin = %v, want %v", got, tt.vary)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	s := newTestService(t, HTTPConfig{
+		EnableCORS:         true,
+		CORSAllowedOrigins: []string{"https://app.example"},
+		CORSMaxAge:         10 * time.Minute,
+	}, nil)
+
+	rec := do(s, http.MethodOptions, "/api/resources", nil, http.Header{
+		"Origin":                        {"https://app.example"},
+		"Access-Control-Request-Method": {"POST"},
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Access-Control-Max-Age = %q, want 600", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, "POST") {
+		t.Errorf("Access-Control-Allow-Methods = %q", got)
+	}
+
+	rec = do(s, http.MethodOptions, "/api/resources", nil, http.Header{
+		"Origin":                        {"https://evil.example"},
+		"Access-Control-Request-Method": {"POST"},
+	})
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("preflight from a disallowed origin got Access-Control-Allow-Origin %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "" {
+		t.Errorf("preflight from a disallowed origin got Access-Control-Allow-Methods %q", got)
+	}
+}
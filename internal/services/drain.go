@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultShutdownTimeout bounds Stop when HTTPConfig.ShutdownTimeout is zero
+const DefaultShutdownTimeout = 10 * time.Second
+
+// connDrainer tracks long-lived requests such as event streams and
+// WebSockets, which http.Server.Shutdown would otherwise wait on forever,
+// so they can be told to end when the service stops
+type connDrainer struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	active int
+	idle   *sync.Cond
+}
+
+func newConnDrainer() *connDrainer {
+	d := &connDrainer{}
+	d.idle = sync.NewCond(&d.mu)
+	d.reset()
+	return d
+}
+
+// reset readies the drainer for a new run of the service
+func (d *connDrainer) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+}
+
+// track registers a long-lived request. The returned context is parent,
+// cancelled as well when the drainer drains; done must be called when the
+// request ends.
+func (d *connDrainer) track(parent context.Context) (context.Context, func()) {
+	d.mu.Lock()
+	d.active++
+	drainCtx := d.ctx
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(drainCtx, cancel)
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			stop()
+			cancel()
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.active--
+			d.idle.Broadcast()
+		})
+	}
+}
+
+// drain cancels every tracked request and waits for them to end or for ctx
+// to be done. It returns how many requests were open and how many are still
+// running.
+func (d *connDrainer) drain(ctx context.Context) (open, remaining int) {
+	d.mu.Lock()
+	open = d.active
+	d.cancel()
+	d.mu.Unlock()
+
+	// Wake the wait below when ctx ends
+	stop := context.AfterFunc(ctx, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.idle.Broadcast()
+	})
+	defer stop()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for d.active > 0 && ctx.Err() == nil {
+		d.idle.Wait()
+	}
+	return open, d.active
+}
+
+// streamMiddleware caps the client's concurrent streams and tracks the
+// stream so Stop can end it
+func (s *HTTPService) streamMiddleware() gin.HandlerFunc {
+	limit := s.streams.Middleware()
+	return func(c *gin.Context) {
+		ctx, done := s.drainer.track(c.Request.Context())
+		defer done()
+		c.Request = c.Request.WithContext(ctx)
+		limit(c)
+	}
+}
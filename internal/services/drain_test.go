@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestConnDrainer(t *testing.T) {
+	tests := []struct {
+		name string
+		// cooperative requests end when their context does; stuck ones don't
+		cooperative, stuck int
+		wantRemaining      int
+	}{
+		{"nothing open", 0, 0, 0},
+		{"all end", 3, 0, 0},
+		{"some stuck", 2, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newConnDrainer()
+			release := make(chan struct{})
+			defer close(release)
+			for i := 0; i < tt.cooperative+tt.stuck; i++ {
+				ctx, done := d.track(context.Background())
+				cooperative := i < tt.cooperative
+				go func() {
+					defer done()
+					if cooperative {
+						<-ctx.Done()
+					} else {
+						<-release
+					}
+				}()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			open, remaining := d.drain(ctx)
+			if open != tt.cooperative+tt.stuck || remaining != tt.wantRemaining {
+				t.Errorf("drain = %d open, %d remaining, want %d and %d", open, remaining, tt.cooperative+tt.stuck, tt.wantRemaining)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("drain took %v with a 100ms deadline", elapsed)
+			}
+		})
+	}
+}
+
+func TestConnDrainerReset(t *testing.T) {
+	d := newConnDrainer()
+	d.drain(context.Background())
+
+	// Requests of the next run aren't cancelled by the last drain
+	d.reset()
+	ctx, done := d.track(context.Background())
+	defer done()
+	if ctx.Err() != nil {
+		t.Fatal("request tracked after reset is already cancelled")
+	}
+
+	// Ending the client's request still ends the tracked context, and
+	// calling done twice counts it once
+	parent, cancel := context.WithCancel(context.Background())
+	child, childDone := d.track(parent)
+	cancel()
+	<-child.Done()
+	childDone()
+	childDone()
+	d.mu.Lock()
+	active := d.active
+	d.mu.Unlock()
+	if active != 1 {
+		t.Errorf("%d requests tracked, want 1", active)
+	}
+}
+
+// freeTCPPort returns a loopback TCP port nothing is listening on
+func freeTCPPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startListening starts s and waits until it accepts connections,
+// returning its base URL
+func startListening(t *testing.T, s *HTTPService) string {
+	t.Helper()
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return waitListening(t, s)
+}
+
+// waitListening waits until the started service s accepts connections,
+// returning its base URL
+func waitListening(t *testing.T, s *HTTPService) string {
+	t.Helper()
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return "http://" + addr
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("service not listening: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStopDrainsEventStreams(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := NewHTTPService(HTTPConfig{
+		Host:            "127.0.0.1",
+		Port:            freeTCPPort(t),
+		MaxRequestSize:  1 << 20,
+		ShutdownTimeout: 5 * time.Second,
+	}, p)
+	url := startListening(t, s)
+
+	var streams []*bufio.Reader
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(url + "/api/events/stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("stream status %d", resp.StatusCode)
+		}
+		streams = append(streams, bufio.NewReader(resp.Body))
+	}
+
+	start := time.Now()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	// Streams end straight away rather than running out the timeout
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stop took %v with open event streams", elapsed)
+	}
+	for i, stream := range streams {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, err := stream.ReadString('\n'); err != nil {
+					return
+				}
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("stream %d still open after Stop", i)
+		}
+	}
+}
+
+func TestStopClosesStuckRequests(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	entered := make(chan struct{}, 1)
+	// A request that ignores its context, so only closing its connection
+	// ends it for the client
+	stuck := func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}
+	plugin := &routePlugin{id: "slow", routes: []core.Route{{Method: http.MethodGet, Path: "/wait", Handler: stuck}}}
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	const timeout = 100 * time.Millisecond
+	s := NewHTTPService(HTTPConfig{
+		Host:            "127.0.0.1",
+		Port:            freeTCPPort(t),
+		MaxRequestSize:  1 << 20,
+		ShutdownTimeout: timeout,
+	}, p)
+	url := startListening(t, s)
+
+	failed := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url + "/plugins/slow/wait")
+		if err == nil {
+			resp.Body.Close()
+		}
+		failed <- err
+	}()
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the handler")
+	}
+
+	start := time.Now()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < timeout || elapsed > timeout+time.Second {
+		t.Errorf("Stop took %v, want about the %v shutdown timeout", elapsed, timeout)
+	}
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Error("stuck request completed instead of having its connection closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("client still waiting after its connection was closed")
+	}
+}
+
+func TestStopClosesEventWebSockets(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := NewHTTPService(HTTPConfig{
+		Host:            "127.0.0.1",
+		Port:            freeTCPPort(t),
+		MaxRequestSize:  1 << 20,
+		ShutdownTimeout: 5 * time.Second,
+	}, p)
+	url := startListening(t, s)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/api/events/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	subscribeEvents(t, conn, "test.*")
+
+	start := time.Now()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stop took %v with an open WebSocket", elapsed)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("read after Stop = %v, want close %d", err, websocket.CloseGoingAway)
+	}
+}
+
+// stopHookPlugin is a routePlugin that calls onStop when it is stopped
+type stopHookPlugin struct {
+	*routePlugin
+	onStop func()
+}
+
+func (sp *stopHookPlugin) Stop(context.Context) error {
+	sp.onStop()
+	return nil
+}
+
+func TestPlatformStopDrainsRequestsCallingPlatform(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	// A request that needs the platform to answer once shutdown is under
+	// way. Plugins stop before the HTTP service drains its requests.
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		if _, err := p.GetPlugin("slow"); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("drained"))
+	}
+	plugin := &stopHookPlugin{
+		routePlugin: &routePlugin{id: "slow", routes: []core.Route{{Method: http.MethodGet, Path: "/wait", Handler: handler}}},
+		onStop:      func() { close(release) },
+	}
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	const timeout = 5 * time.Second
+	s := NewHTTPService(HTTPConfig{
+		Host:            "127.0.0.1",
+		Port:            freeTCPPort(t),
+		MaxRequestSize:  1 << 20,
+		ShutdownTimeout: timeout,
+	}, p)
+	if err := p.ServiceManager().RegisterService(s); err != nil {
+		t.Fatalf("RegisterService: %v", err)
+	}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	url := waitListening(t, s)
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url + "/plugins/slow/wait")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{string(body), err}
+	}()
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the handler")
+	}
+
+	start := time.Now()
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	// The handler isn't left waiting on the platform lock until the drain
+	// times out
+	if elapsed := time.Since(start); elapsed > timeout/2 {
+		t.Errorf("Stop took %v draining a request that calls the platform", elapsed)
+	}
+	select {
+	case res := <-results:
+		if res.err != nil || res.body != "drained" {
+			t.Errorf("response = %q, %v, want the handler's answer", res.body, res.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("client still waiting after Stop")
+	}
+}
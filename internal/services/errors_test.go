@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{core.ErrPluginNotFound, http.StatusNotFound},
+		{fmt.Errorf("%w: widgets", core.ErrResourceNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: cache", core.ErrServiceNotFound), http.StatusNotFound},
+		{core.ErrSubscriptionNotFound, http.StatusNotFound},
+		{fmt.Errorf("%w: widgets", core.ErrPluginExists), http.StatusConflict},
+		{fmt.Errorf("starting: %w", core.ErrInvalidState), http.StatusConflict},
+		{core.ErrPluginInUse, http.StatusConflict},
+		{core.ErrAlreadyRunning, http.StatusConflict},
+		{core.ErrInvalidConfig, http.StatusBadRequest},
+		{fmt.Errorf("%w: resource has no ID", core.ErrInvalidRequest), http.StatusBadRequest},
+		{core.ErrMissingDependency, http.StatusBadRequest},
+		{core.ErrUnauthorized, http.StatusUnauthorized},
+		{fmt.Errorf("stopping: %w", core.ErrPluginTimeout), http.StatusGatewayTimeout},
+		{errors.New("disk on fire"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestHandlersMapSentinelErrors(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	admin := bearer(issueToken(t, p, "root", "admin"))
+	if err := p.LoadPlugin(context.Background(), &routePlugin{id: "widgets"}); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/plugins/nope", http.StatusNotFound},
+		{http.MethodGet, "/api/plugins/nope/manifest", http.StatusNotFound},
+		{http.MethodGet, "/api/plugins/nope/health", http.StatusNotFound},
+		{http.MethodPost, "/api/plugins/nope/start", http.StatusNotFound},
+		{http.MethodPost, "/api/plugins/nope/stop", http.StatusNotFound},
+		{http.MethodPost, "/api/plugins/nope/reinitialize", http.StatusNotFound},
+		{http.MethodPost, "/api/plugins/nope/unload", http.StatusNotFound},
+		// Loaded but never started
+		{http.MethodPost, "/api/plugins/widgets/stop", http.StatusConflict},
+		{http.MethodGet, "/api/services/nope", http.StatusNotFound},
+		{http.MethodGet, "/api/services/nope/health", http.StatusNotFound},
+		{http.MethodGet, "/api/resources/nope", http.StatusNotFound},
+		{http.MethodGet, "/api/resources/nope/stream", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if rec := do(s, tt.method, tt.path, nil, admin); rec.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// readSSESeqs reads the ids of the first n events of an event stream
+func readSSESeqs(t *testing.T, resp *http.Response, n int) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	scanner := bufio.NewScanner(resp.Body)
+	for len(seqs) < n && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			seq, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				t.Fatalf("event id %q: %v", id, err)
+			}
+			seqs = append(seqs, seq)
+		}
+	}
+	if len(seqs) < n {
+		t.Fatalf("stream ended after %v: %v", seqs, scanner.Err())
+	}
+	return seqs
+}
+
+func TestEventReplayOrdering(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		want   []uint64
+	}{
+		{"missed events", "1", []uint64{2, 3, 4}},
+		{"from the start", "0", []uint64{1, 2, 3, 4}},
+		{"up to date", "3", []uint64{4}},
+		// The client saw events of a previous run of the process
+		{"cursor ahead after restart", "1000", []uint64{1, 2, 3, 4}},
+	}
+	transports := []struct {
+		name string
+		read func(t *testing.T, s *HTTPService, cursor string, n int, live func()) []uint64
+	}{
+		{"sse last-event-id", func(t *testing.T, s *HTTPService, cursor string, n int, live func()) []uint64 {
+			server := httptest.NewServer(s.router)
+			t.Cleanup(server.Close)
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/events/stream", nil)
+			req.Header.Set("Last-Event-ID", cursor)
+			// Published before or after the subscription, the live event
+			// arrives once, either replayed or live
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				live()
+			}()
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			return readSSESeqs(t, resp, n)
+		}},
+		{"sse since", func(t *testing.T, s *HTTPService, cursor string, n int, live func()) []uint64 {
+			server := httptest.NewServer(s.router)
+			t.Cleanup(server.Close)
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				live()
+			}()
+			resp, err := http.Get(server.URL + "/api/events/stream?since=" + cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			return readSSESeqs(t, resp, n)
+		}},
+		{"websocket", func(t *testing.T, s *HTTPService, cursor string, n int, live func()) []uint64 {
+			conn, _, err := dialEvents(t, s, "?since="+cursor, nil)
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			live()
+			var seqs []uint64
+			for len(seqs) < n {
+				var event core.Event
+				if err := conn.ReadJSON(&event); err != nil {
+					t.Fatalf("after %v: %v", seqs, err)
+				}
+				seqs = append(seqs, event.Seq)
+			}
+			return seqs
+		}},
+	}
+	for _, transport := range transports {
+		for _, tt := range tests {
+			t.Run(transport.name+" "+tt.name, func(t *testing.T) {
+				p := newTestPlatform(t, nil)
+				s := newTestService(t, HTTPConfig{}, p)
+				for i := 0; i < 3; i++ {
+					p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "test.replayed"})
+				}
+				live := func() {
+					p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "test.live"})
+				}
+
+				got := transport.read(t, s, tt.cursor, len(tt.want), live)
+				if len(got) != len(tt.want) {
+					t.Fatalf("seqs = %v, want %v", got, tt.want)
+				}
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Fatalf("seqs = %v, want %v", got, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestEventReplayRespectsTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		// live is the type of an event published after connecting
+		live string
+		want []uint64
+	}{
+		{"one type", "types=file.uploaded&since=0", "file.uploaded", []uint64{1, 4, 5}},
+		{"pattern", "types=clipboard.*&since=0", "clipboard.changed", []uint64{2, 3, 5}},
+		{"pattern after the cursor", "types=clipboard.*&since=2", "clipboard.changed", []uint64{3, 5}},
+		{"several types", "types=file.uploaded,clipboard.redacted&since=0", "file.uploaded", []uint64{1, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			s := newTestService(t, HTTPConfig{}, p)
+			for _, eventType := range []string{"file.uploaded", "clipboard.changed", "clipboard.redacted", "file.uploaded"} {
+				p.EventBus().Publish(core.Event{ID: core.NewID(), Type: eventType})
+			}
+
+			server := httptest.NewServer(s.router)
+			t.Cleanup(server.Close)
+			// The live event ends the replay, so an event replayed that
+			// the filter should have kept out shows up before it
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				p.EventBus().Publish(core.Event{ID: core.NewID(), Type: tt.live})
+			}()
+			resp, err := http.Get(server.URL + "/api/events/stream?" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if got := readSSESeqs(t, resp, len(tt.want)); !slices.Equal(got, tt.want) {
+				t.Fatalf("seqs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventReplayInvalidCursor(t *testing.T) {
+	s := newTestService(t, HTTPConfig{}, newTestPlatform(t, nil))
+	for _, target := range []string{"/api/events/stream?since=abc", "/api/events/stream?since=-1"} {
+		if rec := do(s, http.MethodGet, target, nil, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+	header := http.Header{}
+	header.Set("Last-Event-ID", "abc")
+	if rec := do(s, http.MethodGet, "/api/events/stream", nil, header); rec.Code != http.StatusBadRequest {
+		t.Errorf("Last-Event-ID abc: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+// openEventStream connects to the event stream of s with query and header,
+// returning a scanner over its lines and a function that disconnects
+func openEventStream(t *testing.T, s *HTTPService, query string, header http.Header) (*bufio.Scanner, context.CancelFunc) {
+	t.Helper()
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events/stream"+query, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	return bufio.NewScanner(resp.Body), cancel
+}
+
+// readSSETypes reads the types of the next n events from scanner
+func readSSETypes(t *testing.T, scanner *bufio.Scanner, n int) []string {
+	t.Helper()
+	var types []string
+	for len(types) < n && scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var event core.Event
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("event %q: %v", data, err)
+			}
+			types = append(types, event.Type)
+		}
+	}
+	if len(types) < n {
+		t.Fatalf("stream ended after %v: %v", types, scanner.Err())
+	}
+	return types
+}
+
+// waitForSubscribers waits until the event bus has want subscribers
+func waitForSubscribers(t *testing.T, p *platform.Platform, want int) {
+	t.Helper()
+	gauge := p.Metrics().Gauge("event_subscribers")
+	deadline := time.Now().Add(5 * time.Second)
+	for int(gauge.Get()) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%v event subscribers, want %d", gauge.Get(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEventStreamFilter(t *testing.T) {
+	published := []string{"plugin.loaded", "peer.joined", "file.uploaded", "plugin.started", "clipboard.changed", "peer.left"}
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"no filter", "", published},
+		{"one type", "?types=peer.joined", []string{"peer.joined"}},
+		{"pattern", "?types=plugin.*", []string{"plugin.loaded", "plugin.started"}},
+		{"several types", "?types=plugin.loaded,peer.joined", []string{"plugin.loaded", "peer.joined"}},
+		{"type and pattern", "?types=file.uploaded,peer.*", []string{"peer.joined", "file.uploaded", "peer.left"}},
+		{"blank entries ignored", "?types=,clipboard.changed,", []string{"clipboard.changed"}},
+		{"legacy type parameter", "?type=file.uploaded", []string{"file.uploaded"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Metrics.Enabled = true
+			})
+			s := newTestService(t, HTTPConfig{}, p)
+			scanner, _ := openEventStream(t, s, tt.query, nil)
+			waitForSubscribers(t, p, 1)
+
+			for _, eventType := range published {
+				p.EventBus().Publish(core.Event{ID: core.NewID(), Type: eventType})
+			}
+			// Every filter lets the last event through, so anything else
+			// delivered shows up before it
+			p.EventBus().Publish(core.Event{ID: core.NewID(), Type: tt.want[len(tt.want)-1]})
+
+			want := append(tt.want, tt.want[len(tt.want)-1])
+			if got := readSSETypes(t, scanner, len(want)); !slices.Equal(got, want) {
+				t.Errorf("delivered %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEventStreamUnsubscribesOnDisconnect(t *testing.T) {
+	p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+		cfg.Metrics.Enabled = true
+	})
+	s := newTestService(t, HTTPConfig{}, p)
+
+	for _, query := range []string{"", "?types=plugin.*", "?types=plugin.loaded,peer.joined"} {
+		_, disconnect := openEventStream(t, s, query, nil)
+		waitForSubscribers(t, p, 1)
+		disconnect()
+		waitForSubscribers(t, p, 0)
+	}
+}
+
+func TestEventStreamKeepAlive(t *testing.T) {
+	interval := sseKeepAliveInterval
+	sseKeepAliveInterval = 20 * time.Millisecond
+	t.Cleanup(func() { sseKeepAliveInterval = interval })
+
+	s := newTestService(t, HTTPConfig{}, newTestPlatform(t, nil))
+	scanner, _ := openEventStream(t, s, "?types=plugin.*", nil)
+
+	comments := 0
+	for comments < 2 && scanner.Scan() {
+		switch line := scanner.Text(); {
+		case line == ": keepalive":
+			comments++
+		case line != "":
+			t.Fatalf("idle stream sent %q", line)
+		}
+	}
+	if comments < 2 {
+		t.Fatalf("stream ended after %d keepalive comments: %v", comments, scanner.Err())
+	}
+}
+
+func TestEventStreamHidesAuthEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []string
+		wantAuth bool
+	}{
+		{"no token", nil, false},
+		{"operator", []string{"operator"}, false},
+		{"admin", []string{"admin"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Metrics.Enabled = true
+			})
+			s := newTestService(t, HTTPConfig{}, p)
+			var header http.Header
+			if tt.roles != nil {
+				header = bearer(issueToken(t, p, "user", tt.roles...))
+			}
+			scanner, _ := openEventStream(t, s, "", header)
+			waitForSubscribers(t, p, 1)
+
+			p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "auth.token_rejected"})
+			p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "peer.joined"})
+
+			want := []string{"peer.joined"}
+			if tt.wantAuth {
+				want = []string{"auth.token_rejected", "peer.joined"}
+			}
+			if got := readSSETypes(t, scanner, len(want)); !slices.Equal(got, want) {
+				t.Errorf("delivered %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+// Timing and size limits for event WebSockets
+const (
+	// eventWSWriteWait bounds each write to the client
+	eventWSWriteWait = 10 * time.Second
+	// eventWSPongWait is how long a client may go without answering a ping
+	// before the connection is treated as dead
+	eventWSPongWait = 60 * time.Second
+	// eventWSPingPeriod must be shorter than eventWSPongWait
+	eventWSPingPeriod = eventWSPongWait * 9 / 10
+	// eventWSMaxMessageSize limits control messages from the client
+	eventWSMaxMessageSize = 4 * 1024
+)
+
+// eventWSControl is a control message sent by an event WebSocket client.
+// {"action":"subscribe","types":["plugin.*"]} replaces the stream's filter;
+// no types streams every event.
+type eventWSControl struct {
+	Action string   `json:"action"`
+	Types  []string `json:"types"`
+}
+
+// eventWSReply acknowledges or rejects a control message. Its "control" key
+// tells it apart from the events on the same connection.
+type eventWSReply struct {
+	Control string   `json:"control"`
+	Types   []string `json:"types,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// handleEventWebSocket streams events over a WebSocket, one JSON frame per
+// event, for clients that can't use EventSource. ?types= and ?since= work
+// as for the event stream, and the filter can be changed mid-stream with a
+// control message.
+func (s *HTTPService) handleEventWebSocket(c *gin.Context) {
+	var since uint64
+	cursor := c.Query("since")
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event cursor"})
+			return
+		}
+	}
+	types := eventTypesQuery(c)
+	visible := s.eventVisibility(c)
+
+	// Cross-origin access is configured for WebSockets separately from
+	// CORS, so a permissive CORS policy doesn't open the event stream to
+	// every site
+	upgrader := websocket.Upgrader{
+		CheckOrigin: core.WebSocketOriginChecker(s.platform.Config().Security.WebSocketOrigins, true),
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered the request
+		return
+	}
+	defer conn.Close()
+
+	// The filter can change at any time, so every event is subscribed to
+	// and filtered by the writer below
+	done := make(chan struct{})
+	defer close(done)
+	events := make(chan core.Event)
+	sub, err := s.platform.EventBus().Subscribe("*", core.EventHandler(func(event core.Event) error {
+		select {
+		case events <- event:
+		case <-done:
+		}
+		return nil
+	}))
+	if err != nil {
+		conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		return
+	}
+	defer s.platform.EventBus().Unsubscribe(sub)
+
+	// Read control messages until the client goes away. Reading also
+	// processes the pongs that keep the connection alive.
+	closed := make(chan struct{})
+	controls := make(chan eventWSControl)
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(eventWSMaxMessageSize)
+		conn.SetReadDeadline(time.Now().Add(eventWSPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(eventWSPongWait))
+		})
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var control eventWSControl
+			if err := json.Unmarshal(message, &control); err != nil {
+				control = eventWSControl{}
+			}
+			select {
+			case controls <- control:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	write := func(v interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(eventWSWriteWait))
+		return conn.WriteJSON(v) == nil
+	}
+
+	// Replay what the client missed; live events already replayed are
+	// skipped below
+	matches := platform.TopicMatcher(types...)
+	since = s.replayCursor(since)
+	last := since
+	if cursor != "" {
+		for _, event := range s.platform.EventsSince(since, types...) {
+			if visible(event.Type) && !write(event) {
+				return
+			}
+			last = event.Seq
+		}
+	}
+
+	ping := time.NewTicker(eventWSPingPeriod)
+	defer ping.Stop()
+
+	// The request context ends when the service stops
+	stopping := c.Request.Context().Done()
+
+	for {
+		select {
+		case event := <-events:
+			if event.Seq <= last || !matches(event.Type) || !visible(event.Type) {
+				continue
+			}
+			if !write(event) {
+				return
+			}
+			last = event.Seq
+		case control := <-controls:
+			if control.Action != "subscribe" {
+				if !write(eventWSReply{Control: "error", Error: "unknown action"}) {
+					return
+				}
+				continue
+			}
+			matches = platform.TopicMatcher(control.Types...)
+			if !write(eventWSReply{Control: "subscribed", Types: control.Types}) {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWSWriteWait)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-stopping:
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(eventWSWriteWait))
+			return
+		}
+	}
+}
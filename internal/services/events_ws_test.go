@@ -0,0 +1,165 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+// dialEvents opens the event WebSocket of a test server for s
+func dialEvents(t *testing.T, s *HTTPService, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/events/ws" + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	}
+	return conn, resp, err
+}
+
+// subscribeEvents replaces the socket's filter and waits for the
+// acknowledgement, after which the server is subscribed to the bus
+func subscribeEvents(t *testing.T, conn *websocket.Conn, types ...string) {
+	t.Helper()
+	if err := conn.WriteJSON(eventWSControl{Action: "subscribe", Types: types}); err != nil {
+		t.Fatal(err)
+	}
+	var reply eventWSReply
+	if err := conn.ReadJSON(&reply); err != nil || reply.Control != "subscribed" {
+		t.Fatalf("reply = %+v, %v", reply, err)
+	}
+}
+
+func TestEventWebSocketDelivery(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	conn, _, err := dialEvents(t, s, "", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	subscribeEvents(t, conn, "plugin.*")
+
+	for _, eventType := range []string{"plugin.started", "platform.started", "plugin.stopped"} {
+		p.EventBus().Publish(core.Event{ID: core.NewID(), Type: eventType})
+	}
+	for _, want := range []string{"plugin.started", "plugin.stopped"} {
+		var event core.Event
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != want {
+			t.Fatalf("event = %s, want %s", event.Type, want)
+		}
+	}
+
+	// Widening the filter mid-stream lets the other events through
+	subscribeEvents(t, conn)
+	p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "platform.started"})
+	var event core.Event
+	if err := conn.ReadJSON(&event); err != nil || event.Type != "platform.started" {
+		t.Fatalf("event = %+v, %v", event, err)
+	}
+}
+
+func TestEventWebSocketUnknownAction(t *testing.T) {
+	s := newTestService(t, HTTPConfig{}, nil)
+	conn, _, err := dialEvents(t, s, "", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	conn.WriteJSON(eventWSControl{Action: "unsubscribe"})
+	var reply eventWSReply
+	if err := conn.ReadJSON(&reply); err != nil || reply.Control != "error" {
+		t.Fatalf("reply = %+v, %v", reply, err)
+	}
+}
+
+func TestEventWebSocketOrigins(t *testing.T) {
+	tests := []struct {
+		name      string
+		wsOrigins []string
+		origin    string
+		want      bool
+	}{
+		{"no origin header", nil, "", true},
+		{"cross origin", nil, "http://evil.example", false},
+		{"listed origin", []string{"https://app.example"}, "https://app.example", true},
+		{"unlisted origin", []string{"https://app.example"}, "http://evil.example", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Security.WebSocketOrigins = tt.wsOrigins
+			})
+			// CORS allowing any origin must not open the socket to them
+			s := newTestService(t, HTTPConfig{EnableCORS: true, CORSAllowedOrigins: []string{"*"}}, p)
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			conn, resp, err := dialEvents(t, s, "", header)
+			if tt.want && err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			if !tt.want {
+				if err == nil {
+					conn.Close()
+					t.Fatal("upgrade from a disallowed origin succeeded")
+				}
+				if resp == nil || resp.StatusCode != http.StatusForbidden {
+					t.Fatalf("response = %v, want 403", resp)
+				}
+			}
+		})
+	}
+}
+
+func TestEventWebSocketHidesAuthEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []string
+		wantAuth bool
+	}{
+		{"no token", nil, false},
+		{"operator", []string{"operator"}, false},
+		{"admin", []string{"admin"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			s := newTestService(t, HTTPConfig{}, p)
+			var header http.Header
+			if tt.roles != nil {
+				header = bearer(issueToken(t, p, "user", tt.roles...))
+			}
+			conn, _, err := dialEvents(t, s, "", header)
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			subscribeEvents(t, conn, "auth.*", "peer.*")
+
+			p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "auth.forbidden"})
+			p.EventBus().Publish(core.Event{ID: core.NewID(), Type: "peer.joined"})
+
+			want := []string{"peer.joined"}
+			if tt.wantAuth {
+				want = []string{"auth.forbidden", "peer.joined"}
+			}
+			for _, wantType := range want {
+				var event core.Event
+				if err := conn.ReadJSON(&event); err != nil || event.Type != wantType {
+					t.Fatalf("event = %+v, %v, want %s", event, err, wantType)
+				}
+			}
+		})
+	}
+}
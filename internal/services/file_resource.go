@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// fileResourceChunkSize is how much of a file each stream read returns
+const fileResourceChunkSize = 64 * 1024
+
+// fileResource is a core.Resource backed by a file on disk. The file is
+// opened on every read, so the resource follows changes to it.
+type fileResource struct {
+	id      string
+	path    string
+	meta    map[string]interface{}
+	started bool
+}
+
+func (f *fileResource) Start(ctx context.Context) error { f.started = true; return nil }
+func (f *fileResource) Stop(ctx context.Context) error  { f.started = false; return nil }
+func (f *fileResource) Name() string                    { return "resource:" + f.id }
+func (f *fileResource) ID() string                      { return f.id }
+func (f *fileResource) Type() string                    { return "file" }
+func (f *fileResource) Configuration() core.ConfigSchema {
+	return core.ConfigSchema{}
+}
+func (f *fileResource) GetMetadata() map[string]interface{} { return f.meta }
+
+// IsHealthy reports whether the file is still there
+func (f *fileResource) IsHealthy() bool {
+	info, err := os.Stat(f.path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (f *fileResource) Health() core.HealthStatus {
+	status := core.HealthStatusHealthy
+	if !f.IsHealthy() {
+		status = core.HealthStatusUnhealthy
+	}
+	return core.HealthStatus{Status: status, Timestamp: time.Now()}
+}
+
+// GetSize returns the file's current size, or -1 if it can't be read
+func (f *fileResource) GetSize() int64 {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// Stream reads the file in chunks of fileResourceChunkSize
+func (f *fileResource) Stream(ctx context.Context) (core.ResourceStream, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileStream{file: file}, nil
+}
+
+// Open returns the file itself, which supports seeking
+func (f *fileResource) Open(ctx context.Context) (io.ReadSeekCloser, error) {
+	return os.Open(f.path)
+}
+
+// fileStream streams an open file chunk by chunk
+type fileStream struct {
+	file *os.File
+}
+
+func (s *fileStream) Read() ([]byte, error) {
+	buf := make([]byte, fileResourceChunkSize)
+	n, err := s.file.Read(buf)
+	if n > 0 {
+		return buf[:n], nil
+	}
+	return nil, err
+}
+
+func (s *fileStream) Close() error { return s.file.Close() }
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileResourceStream(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"small", 10},
+		{"exactly one chunk", fileResourceChunkSize},
+		{"several chunks", 2*fileResourceChunkSize + 123},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := bytes.Repeat([]byte("0123456789"), tt.size/10+1)[:tt.size]
+			path := filepath.Join(t.TempDir(), "data.bin")
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			res := &fileResource{id: "data", path: path}
+			if got := res.GetSize(); got != int64(tt.size) {
+				t.Errorf("GetSize = %d, want %d", got, tt.size)
+			}
+
+			stream, err := res.Stream(context.Background())
+			if err != nil {
+				t.Fatalf("Stream: %v", err)
+			}
+			defer stream.Close()
+			var got []byte
+			for {
+				chunk, err := stream.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+				if len(chunk) == 0 || len(chunk) > fileResourceChunkSize {
+					t.Fatalf("read a chunk of %d bytes", len(chunk))
+				}
+				got = append(got, chunk...)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("streamed %d bytes, want the %d in the file", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestFileResourceMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gone.txt")
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res := &fileResource{id: "gone", path: path}
+	if !res.IsHealthy() {
+		t.Fatal("resource unhealthy while its file exists")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if res.IsHealthy() {
+		t.Error("resource healthy after its file was removed")
+	}
+	if got := res.GetSize(); got != -1 {
+		t.Errorf("GetSize = %d, want -1", got)
+	}
+	if _, err := res.Stream(context.Background()); err == nil {
+		t.Error("Stream succeeded without a file")
+	}
+}
+
+func TestCreateFileResource(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	admin := bearer(issueToken(t, p, "root", "admin"))
+	uploads := p.Config().Storage.UploadDir
+	content := bytes.Repeat([]byte("noplacelike "), fileResourceChunkSize/4)
+	path := filepath.Join(uploads, "notes.txt")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(uploads, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		header http.Header
+		status int
+	}{
+		{"allowed file", map[string]interface{}{"id": "notes", "type": "file", "path": path}, admin, http.StatusCreated},
+		{"no token", map[string]interface{}{"id": "anon", "type": "file", "path": path}, nil, http.StatusUnauthorized},
+		{"no path", map[string]interface{}{"id": "nopath", "type": "file"}, admin, http.StatusBadRequest},
+		{"directory", map[string]interface{}{"id": "dir", "type": "file", "path": uploads}, admin, http.StatusBadRequest},
+		{"outside allowed paths", map[string]interface{}{"id": "out", "type": "file", "path": outside}, admin, http.StatusForbidden},
+		{"symlink out of allowed paths", map[string]interface{}{"id": "link", "type": "file", "path": filepath.Join(uploads, "link.txt")}, admin, http.StatusForbidden},
+		{"missing file", map[string]interface{}{"id": "missing", "type": "file", "path": filepath.Join(uploads, "nope.txt")}, admin, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/resources", tt.body, tt.header)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusCreated {
+				return
+			}
+			if _, err := p.ResourceManager().GetResource(context.Background(), tt.body["id"].(string)); err == nil {
+				t.Errorf("rejected resource %s was registered", tt.body["id"])
+			}
+		})
+	}
+
+	// The registered resource streams the whole file, with its size
+	res, err := p.ResourceManager().GetResource(context.Background(), "notes")
+	if err != nil {
+		t.Fatalf("GetResource: %v", err)
+	}
+	if res.Type() != "file" || res.GetSize() != int64(len(content)) || res.GetMetadata()["name"] != "notes.txt" {
+		t.Errorf("registered %s resource of %d bytes named %v", res.Type(), res.GetSize(), res.GetMetadata()["name"])
+	}
+	rec := do(s, http.MethodGet, "/api/resources/notes/stream", nil, nil)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), content) {
+		t.Errorf("stream: status %d, %d bytes, want 200 and the %d in the file", rec.Code, rec.Body.Len(), len(content))
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestGzipDocsJSON(t *testing.T) {
+	s := newTestService(t, HTTPConfig{EnableGzip: true}, newTestPlatform(t, nil))
+
+	header := http.Header{}
+	header.Set("Accept-Encoding", "gzip, deflate")
+	rec := do(s, http.MethodGet, "/api/docs/json", nil, header)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Vary"); !strings.Contains(got, "Accept-Encoding") {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	var spec map[string]interface{}
+	if err := json.NewDecoder(gz).Decode(&spec); err != nil {
+		t.Fatalf("decompressed body is not JSON: %v", err)
+	}
+	if spec["openapi"] == nil {
+		t.Errorf("decompressed spec = %v", spec)
+	}
+}
+
+func TestGzipSkipped(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 512)...)
+	routes := []core.Route{
+		{Method: http.MethodGet, Path: "/image", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(png)
+		}},
+		{Method: http.MethodGet, Path: "/blob", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(bytes.Repeat([]byte("a"), 512))
+		}},
+		{Method: http.MethodGet, Path: "/partial", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Range", "bytes 0-511/1024")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(bytes.Repeat([]byte("a"), 512))
+		}},
+		{Method: http.MethodGet, Path: "/text", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(bytes.Repeat([]byte("a"), 512))
+		}},
+	}
+	p := newTestPlatform(t, nil)
+	if err := p.LoadPlugin(context.Background(), &routePlugin{id: "media", routes: routes}); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		config         HTTPConfig
+		method         string
+		path           string
+		acceptEncoding string
+		wantGzip       bool
+	}{
+		{"compressible", HTTPConfig{EnableGzip: true}, http.MethodGet, "/plugins/media/text", "gzip", true},
+		{"disabled", HTTPConfig{}, http.MethodGet, "/plugins/media/text", "gzip", false},
+		{"not accepted", HTTPConfig{EnableGzip: true}, http.MethodGet, "/plugins/media/text", "", false},
+		{"refused", HTTPConfig{EnableGzip: true}, http.MethodGet, "/plugins/media/text", "gzip;q=0, identity", false},
+		{"image", HTTPConfig{EnableGzip: true}, http.MethodGet, "/plugins/media/image", "gzip", false},
+		{"octet-stream", HTTPConfig{EnableGzip: true}, http.MethodGet, "/plugins/media/blob", "gzip", false},
+		{"partial content", HTTPConfig{EnableGzip: true}, http.MethodGet, "/plugins/media/partial", "gzip", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, tt.config, p)
+			header := http.Header{}
+			if tt.acceptEncoding != "" {
+				header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := do(s, tt.method, tt.path, nil, header)
+			if rec.Code != http.StatusOK && rec.Code != http.StatusPartialContent {
+				t.Fatalf("status %d", rec.Code)
+			}
+			if gzipped := rec.Header().Get("Content-Encoding") == "gzip"; gzipped != tt.wantGzip {
+				t.Fatalf("gzipped = %v, want %v", gzipped, tt.wantGzip)
+			}
+			if !tt.wantGzip && rec.Body.Len() < 512 {
+				t.Errorf("uncompressed body has %d bytes, want the handler's", rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestGzipBypassesEventStream(t *testing.T) {
+	s := newTestService(t, HTTPConfig{EnableGzip: true}, newTestPlatform(t, nil))
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/events/stream", nil)
+	// Set explicitly, so the transport doesn't decompress the body itself
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("event stream sent with Content-Encoding %q", got)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   bool
+	}{
+		{"gzip", http.MethodGet, map[string]string{"Accept-Encoding": "gzip"}, true},
+		{"among others", http.MethodGet, map[string]string{"Accept-Encoding": "br, gzip;q=0.8"}, true},
+		{"case-insensitive", http.MethodGet, map[string]string{"Accept-Encoding": "GZIP"}, true},
+		{"q=0", http.MethodGet, map[string]string{"Accept-Encoding": "gzip; q=0"}, false},
+		{"other encodings", http.MethodGet, map[string]string{"Accept-Encoding": "br, deflate"}, false},
+		{"none", http.MethodGet, nil, false},
+		{"HEAD", http.MethodHead, map[string]string{"Accept-Encoding": "gzip"}, false},
+		{"websocket upgrade", http.MethodGet, map[string]string{"Accept-Encoding": "gzip", "Upgrade": "websocket"}, false},
+		{"event stream", http.MethodGet, map[string]string{"Accept-Encoding": "gzip", "Accept": "text/event-stream"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for key, value := range tt.header {
+				r.Header.Set(key, value)
+			}
+			if got := acceptsGzip(r); got != tt.want {
+				t.Errorf("acceptsGzip = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressibleType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json; charset=utf-8", true},
+		{"text/html", true},
+		{"TEXT/PLAIN", true},
+		{"image/svg+xml", true},
+		{"application/javascript", true},
+		{"text/event-stream", false},
+		{"image/png", false},
+		{"video/mp4", false},
+		{"audio/mpeg", false},
+		{"application/octet-stream", false},
+		{"application/zip", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := compressibleType(tt.contentType); got != tt.want {
+			t.Errorf("compressibleType(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestGzipStreamingFlush(t *testing.T) {
+	// Each flushed chunk reaches the client before the handler finishes
+	release := make(chan struct{})
+	route := core.Route{Method: http.MethodGet, Path: "/stream", Handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second\n"))
+	}}
+	p := newTestPlatform(t, nil)
+	if err := p.LoadPlugin(context.Background(), &routePlugin{id: "streamer", routes: []core.Route{route}}); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	s := newTestService(t, HTTPConfig{EnableGzip: true}, p)
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/plugins/streamer/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		close(release)
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		close(release)
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	first := make([]byte, len("first\n"))
+	_, err = io.ReadFull(gz, first)
+	close(release)
+	if err != nil || string(first) != "first\n" {
+		t.Fatalf("first chunk = %q, %v", first, err)
+	}
+	rest, err := io.ReadAll(gz)
+	if err != nil || string(rest) != "second\n" {
+		t.Errorf("rest = %q, %v", rest, err)
+	}
+}
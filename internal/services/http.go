@@ -4,15 +4,24 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 	"github.com/nathfavour/noplacelike.go/internal/platform"
 )
 
@@ -22,10 +31,17 @@ type HTTPService struct {
 	name     string
 	config   HTTPConfig
 	server   *http.Server
+	redirect *http.Server
 	router   *gin.Engine
+	root     *gin.RouterGroup
 	platform *platform.Platform
 	logger   core.Logger
 	started  bool
+	lockout  *authLockout
+	limiter  *rateLimiter
+	streams  *core.StreamLimiter
+	drainer  *connDrainer
+	routes   []routeMethods
 }
 
 // HTTPConfig contains HTTP service configuration
@@ -35,6 +51,8 @@ type HTTPConfig struct {
 	EnableTLS      bool          `json:"enableTLS"`
 	TLSCertFile    string        `json:"tlsCertFile"`
 	TLSKeyFile     string        `json:"tlsKeyFile"`
+	RedirectHTTP   bool          `json:"redirectHTTP"`
+	RedirectPort   int           `json:"redirectPort"`
 	ReadTimeout    time.Duration `json:"readTimeout"`
 	WriteTimeout   time.Duration `json:"writeTimeout"`
 	IdleTimeout    time.Duration `json:"idleTimeout"`
@@ -44,6 +62,54 @@ type HTTPConfig struct {
 	EnableDocs     bool          `json:"enableDocs"`
 	RateLimitRPS   int           `json:"rateLimitRPS"`
 	EnableGzip     bool          `json:"enableGzip"`
+	// CORSAllowedOrigins lists the origins cross-origin requests are
+	// accepted from; "*" accepts any. With none, no CORS headers are sent.
+	CORSAllowedOrigins []string `json:"corsAllowedOrigins"`
+	// CORSAllowCredentials lets browsers send cookies and auth headers with
+	// cross-origin requests from the listed origins. "*" is then ignored, so
+	// credentials are never shared with every site.
+	CORSAllowCredentials bool `json:"corsAllowCredentials"`
+	// CORSAllowedHeaders are the request headers allowed cross-origin
+	// (empty uses DefaultCORSAllowedHeaders)
+	CORSAllowedHeaders []string `json:"corsAllowedHeaders"`
+	// CORSMaxAge is how long browsers may cache preflight responses (zero
+	// leaves it to the browser)
+	CORSMaxAge time.Duration `json:"corsMaxAge"`
+	// EnableAuth requires a valid token on every route except AuthExemptPaths.
+	// Entries ending in "/*" exempt the whole subtree.
+	EnableAuth      bool     `json:"enableAuth"`
+	AuthExemptPaths []string `json:"authExemptPaths"`
+	// BasePath mounts every route under a prefix (e.g. "/noplacelike") for
+	// deployments behind a reverse proxy subpath
+	BasePath string `json:"basePath"`
+	// MaxStreamsPerClient caps the event and resource streams each client
+	// IP may hold open at once (0 uses core.DefaultMaxStreamsPerClient,
+	// negative removes the cap)
+	MaxStreamsPerClient int `json:"maxStreamsPerClient"`
+	// ShutdownTimeout is how long Stop waits for streams and in-flight
+	// requests to end before closing their connections (0 uses
+	// DefaultShutdownTimeout)
+	ShutdownTimeout time.Duration `json:"shutdownTimeout"`
+}
+
+// DefaultCORSAllowedHeaders are allowed cross-origin when
+// HTTPConfig.CORSAllowedHeaders is empty
+var DefaultCORSAllowedHeaders = []string{"Content-Type", "Authorization", core.RequestIDHeader}
+
+// DefaultAuthExemptPaths are reachable without a token so probes, scrapers
+// and clients refreshing a token keep working when auth is enabled. Issuing
+// a token is not among them: it needs platform:admin.
+var DefaultAuthExemptPaths = []string{
+	"/health",
+	"/healthz",
+	"/readyz",
+	"/metrics",
+	"/api/docs",
+	"/api/docs/json",
+	"/api/platform/health",
+	"/api/platform/metrics",
+	"/api/platform/token/refresh",
+	"/api/platform/jwks",
 }
 
 // NewHTTPService creates a new HTTP service
@@ -51,12 +117,39 @@ func NewHTTPService(config HTTPConfig, platform *platform.Platform) *HTTPService
 	// Set gin mode based on environment
 	gin.SetMode(gin.ReleaseMode)
 
+	var maxAttempts int
+	var lockoutDuration time.Duration
+	var trustedProxies []string
+	if cfg := platform.Config(); cfg != nil {
+		maxAttempts = cfg.Security.MaxLoginAttempts
+		lockoutDuration = cfg.Security.LockoutDuration
+		trustedProxies = cfg.Security.TrustedProxies
+	}
+
+	// Client IPs key the rate limit, lockout and stream caps, so forwarding
+	// headers are only believed from the configured proxies
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		platform.Logger().Warn("Ignoring invalid trusted proxies", core.Field{Key: "error", Value: err})
+		router.SetTrustedProxies(nil)
+	}
+
+	maxStreams := config.MaxStreamsPerClient
+	if maxStreams == 0 {
+		maxStreams = core.DefaultMaxStreamsPerClient
+	}
+	streams := core.NewStreamLimiter(maxStreams)
+	streams.Key = (*gin.Context).ClientIP
+
 	return &HTTPService{
 		name:     "http",
 		config:   config,
-		router:   gin.New(),
+		router:   router,
 		platform: platform,
 		logger:   platform.Logger(),
+		lockout:  newAuthLockout(maxAttempts, lockoutDuration),
+		streams:  streams,
+		drainer:  newConnDrainer(),
 	}
 }
 
@@ -74,11 +167,14 @@ func (s *HTTPService) Start(ctx context.Context) error {
 		return fmt.Errorf("HTTP service already started")
 	}
 
+	s.drainer.reset()
+
 	// Setup middleware
 	s.setupMiddleware()
 
 	// Setup routes
 	s.setupRoutes()
+	s.buildRouteTable()
 
 	// Create HTTP server
 	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
@@ -109,12 +205,47 @@ func (s *HTTPService) Start(ctx context.Context) error {
 		}
 	}()
 
+	// Redirect plain HTTP to HTTPS on a second port
+	if s.config.EnableTLS && s.config.RedirectHTTP && s.config.RedirectPort > 0 {
+		redirectAddr := fmt.Sprintf("%s:%d", s.config.Host, s.config.RedirectPort)
+		s.redirect = &http.Server{
+			Addr:         redirectAddr,
+			Handler:      http.HandlerFunc(s.redirectToHTTPS),
+			ReadTimeout:  s.config.ReadTimeout,
+			WriteTimeout: s.config.WriteTimeout,
+			IdleTimeout:  s.config.IdleTimeout,
+		}
+
+		go func() {
+			s.logger.Info("Starting HTTP to HTTPS redirect listener",
+				core.Field{Key: "address", Value: redirectAddr},
+			)
+			if err := s.redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("HTTP redirect server error", core.Field{Key: "error", Value: err})
+			}
+		}()
+	}
+
 	s.started = true
 	s.logger.Info("HTTP service started successfully")
 	return nil
 }
 
-// Stop gracefully shuts down the HTTP service
+// redirectToHTTPS permanently redirects a plain HTTP request to the TLS listener
+func (s *HTTPService) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.config.Port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(s.config.Port))
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
+
+// Stop gracefully shuts down the HTTP service. Streams are told to end and
+// in-flight requests may finish until ShutdownTimeout passes (or ctx ends),
+// after which the remaining connections are closed.
 func (s *HTTPService) Stop(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -125,9 +256,40 @@ func (s *HTTPService) Stop(ctx context.Context) error {
 
 	s.logger.Info("Stopping HTTP service")
 
-	if err := s.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			s.logger.Warn("Failed to shutdown HTTP redirect server", core.Field{Key: "error", Value: err})
+		}
+		s.redirect = nil
+	}
+
+	if s.limiter != nil {
+		s.limiter.close()
+	}
+
+	timeout := s.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
 	}
+	shutdownCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Streams never finish on their own, so end them before waiting on the
+	// server; WebSockets are hijacked and not waited on by Shutdown at all
+	open, remaining := s.drainer.drain(shutdownCtx)
+	if err := s.server.Shutdown(shutdownCtx); err != nil {
+		// Cut off whatever is still running after the timeout
+		s.logger.Warn("HTTP requests still running after shutdown timeout, closing connections",
+			core.Field{Key: "timeout", Value: timeout},
+		)
+		if err := s.server.Close(); err != nil {
+			return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+		}
+	}
+	s.logger.Info("HTTP streams drained",
+		core.Field{Key: "drained", Value: open - remaining},
+		core.Field{Key: "forced", Value: remaining},
+	)
 
 	s.started = false
 	s.logger.Info("HTTP service stopped")
@@ -190,14 +352,30 @@ func (s *HTTPService) setupMiddleware() {
 	// Recovery middleware
 	s.router.Use(gin.Recovery())
 
+	// Request ID middleware, ahead of logging so log lines carry the ID
+	s.router.Use(s.requestIDMiddleware())
+
 	// Logging middleware
 	s.router.Use(s.loggingMiddleware())
 
+	// Request metrics, counting requests the middleware below rejects too
+	if s.metricsEnabled() {
+		s.router.Use(s.metricsMiddleware())
+	}
+
 	// CORS middleware
 	if s.config.EnableCORS {
 		s.router.Use(s.corsMiddleware())
 	}
 
+	// Per-route OPTIONS responses
+	s.router.Use(s.optionsMiddleware())
+
+	// Global authentication middleware
+	if s.config.EnableAuth {
+		s.router.Use(s.globalAuthMiddleware())
+	}
+
 	// Rate limiting middleware
 	if s.config.RateLimitRPS > 0 {
 		s.router.Use(s.rateLimitMiddleware())
@@ -205,7 +383,7 @@ func (s *HTTPService) setupMiddleware() {
 
 	// Gzip compression middleware
 	if s.config.EnableGzip {
-		// Would implement gzip middleware
+		s.router.Use(s.gzipMiddleware())
 	}
 
 	// Security headers middleware
@@ -218,12 +396,15 @@ func (s *HTTPService) setupMiddleware() {
 // setupRoutes configures HTTP routes
 func (s *HTTPService) setupRoutes() {
 	// API version info
-	s.router.GET("/", s.handleRoot)
-	s.router.GET("/health", s.handleHealth)
-	s.router.GET("/info", s.handleInfo)
+	s.root = s.router.Group(s.basePath())
+	s.root.GET("/", s.handleRoot)
+	s.root.GET("/health", s.handleHealth)
+	s.root.GET("/healthz", s.handleLiveness)
+	s.root.GET("/readyz", s.handleReadiness)
+	s.root.GET("/info", s.handleInfo)
 
 	// API routes
-	api := s.router.Group("/api")
+	api := s.root.Group("/api")
 	{
 		// API documentation
 		api.GET("/docs", s.handleAPIDocsUI)
@@ -233,8 +414,13 @@ func (s *HTTPService) setupRoutes() {
 		{
 			platform.GET("/health", s.handlePlatformHealth)
 			platform.GET("/info", s.handlePlatformInfo)
-			platform.GET("/metrics", s.handleMetrics)
-			platform.POST("/token", s.handleIssueToken)
+			platform.POST("/token", s.authMiddleware([]string{"platform:admin"}), s.handleIssueToken)
+			platform.POST("/token/refresh", s.handleRefreshToken)
+			platform.POST("/token/revoke", s.authMiddleware(nil), s.handleRevokeToken)
+			platform.GET("/jwks", s.handleJWKS)
+			platform.POST("/reload", s.authMiddleware([]string{"platform:admin"}), s.handleReload)
+			platform.GET("/config/export", s.authMiddleware(nil), s.handleExportConfig)
+			platform.POST("/config/import", s.authMiddleware([]string{"platform:admin"}), s.handleImportConfig)
 		}
 
 		// Plugin management
@@ -242,8 +428,11 @@ func (s *HTTPService) setupRoutes() {
 		{
 			plugins.GET("", s.handleListPlugins)
 			plugins.GET("/:name", s.handleGetPlugin)
+			plugins.GET("/:name/manifest", s.handleGetPluginManifest)
 			plugins.POST("/:name/start", s.authMiddleware([]string{"plugins:start"}), s.handleStartPlugin)
 			plugins.POST("/:name/stop", s.authMiddleware([]string{"plugins:stop"}), s.handleStopPlugin)
+			plugins.POST("/:name/reinitialize", s.authMiddleware([]string{"plugins:start"}), s.handleReinitializePlugin)
+			plugins.POST("/:name/unload", s.authMiddleware([]string{"plugins:stop"}), s.handleUnloadPlugin)
 			plugins.GET("/:name/health", s.handlePluginHealth)
 		}
 
@@ -270,21 +459,51 @@ func (s *HTTPService) setupRoutes() {
 			resources.GET("/:id", s.handleGetResource)
 			resources.POST("", s.authMiddleware([]string{"resources:create"}), s.handleCreateResource)
 			resources.DELETE("/:id", s.authMiddleware([]string{"resources:delete"}), s.handleDeleteResource)
-			resources.GET("/:id/stream", s.handleStreamResource)
+			resources.GET("/:id/stream", s.streamMiddleware(), s.handleStreamResource)
 		}
 
 		// Events and subscriptions
 		events := api.Group("/events")
 		{
-			events.GET("/stream", s.handleEventStream)
+			events.GET("/stream", s.streamMiddleware(), s.handleEventStream)
+			events.GET("/ws", s.streamMiddleware(), s.handleEventWebSocket)
 			events.POST("/publish", s.handlePublishEvent)
 		}
 	}
 
+	// Metrics
+	if s.metricsEnabled() {
+		s.registerMetricsRoute()
+	}
+
 	// Register plugin routes
 	s.registerPluginRoutes()
 }
 
+// registerMetricsRoute mounts the metrics handler at the configured endpoint,
+// behind authentication when the metrics config requires it
+func (s *HTTPService) registerMetricsRoute() {
+	config := s.platform.Config().Metrics
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = platform.DefaultMetricsEndpoint
+	}
+
+	handlers := make([]gin.HandlerFunc, 0, 2)
+	if config.RequireAuth {
+		var permissions []string
+		if config.Permission != "" {
+			permissions = []string{config.Permission}
+		}
+		handlers = append(handlers, s.authMiddleware(permissions))
+	}
+	handlers = append(handlers, s.handleMetrics)
+
+	s.root.GET(endpoint, handlers...)
+	s.root.DELETE(endpoint, s.authMiddleware([]string{"platform:admin"}), s.handleResetMetrics)
+}
+
 // registerPluginRoutes registers routes provided by plugins
 func (s *HTTPService) registerPluginRoutes() {
 	plugins := s.platform.ListPlugins()
@@ -294,7 +513,7 @@ func (s *HTTPService) registerPluginRoutes() {
 
 		for _, route := range routes {
 			// Create a group for the plugin
-			group := s.router.Group(fmt.Sprintf("/plugins/%s", name))
+			group := s.root.Group(fmt.Sprintf("/plugins/%s", name))
 
 			// Add authentication middleware if required
 			var handlers []gin.HandlerFunc
@@ -316,29 +535,6 @@ func (s *HTTPService) registerPluginRoutes() {
 	}
 }
 
-// HTTP Handlers
-// memoryResource is an in-memory implementation of core.Resource and core.Service
-type memoryResource struct {
-	id      string
-	typ     string
-	meta    map[string]interface{}
-	data    []byte
-	started bool
-}
-
-func (m *memoryResource) Start(ctx context.Context) error { m.started = true; return nil }
-func (m *memoryResource) Stop(ctx context.Context) error  { m.started = false; return nil }
-func (m *memoryResource) IsHealthy() bool                 { return true }
-func (m *memoryResource) Name() string                    { return "resource:" + m.id }
-func (m *memoryResource) Health() core.HealthStatus {
-	return core.HealthStatus{Status: core.HealthStatusHealthy, Timestamp: time.Now()}
-}
-func (m *memoryResource) Configuration() core.ConfigSchema { return core.ConfigSchema{} }
-func (m *memoryResource) ID() string                       { return m.id }
-func (m *memoryResource) Type() string                     { return m.typ }
-func (m *memoryResource) GetMetadata() map[string]interface{} { return m.meta }
-func (m *memoryResource) GetSize() int64                   { return int64(len(m.data)) }
-
 // HTTP Handlers
 func (s *HTTPService) handleRoot(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -346,6 +542,10 @@ func (s *HTTPService) handleRoot(c *gin.Context) {
 		"version": s.platform.Health().Details["version"],
 		"status":  "running",
 		"uptime":  s.platform.Health().Details["uptime"],
+		"links": gin.H{
+			"docs":   s.basePath() + "/api/docs",
+			"health": s.basePath() + "/health",
+		},
 	})
 }
 
@@ -362,6 +562,25 @@ func (s *HTTPService) handleHealth(c *gin.Context) {
 	c.JSON(statusCode, health)
 }
 
+func (s *HTTPService) handleLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (s *HTTPService) handleReadiness(c *gin.Context) {
+	health := s.platform.Readiness()
+
+	statusCode := http.StatusOK
+	if health.Status == core.HealthStatusUnhealthy {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	response := gin.H{"status": health.Status, "checks": health.Checks}
+	if health.Error != "" {
+		response["error"] = health.Error
+	}
+	c.JSON(statusCode, response)
+}
+
 func (s *HTTPService) handleInfo(c *gin.Context) {
 	info := map[string]interface{}{
 		"platform": s.platform.Health().Details,
@@ -381,21 +600,189 @@ func (s *HTTPService) handlePlatformInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, s.platform.Health().Details)
 }
 
+// handleReload re-reads the configuration, applies it and restarts the
+// plugins. A config that doesn't validate is rejected and the current one
+// kept.
+func (s *HTTPService) handleReload(c *gin.Context) {
+	if err := s.platform.Reload(c.Request.Context()); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrInvalidConfig) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.platform.Health())
+}
+
+// handleExportConfig returns the platform configuration. Secrets are only
+// included for callers with the platform:admin permission.
+func (s *HTTPService) handleExportConfig(c *gin.Context) {
+	redact := !hasPermission(c, "platform:admin")
+
+	config, err := s.platform.ExportConfig(redact)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"config": config, "redacted": redact})
+}
+
+// handleImportConfig validates and applies a configuration bundle as produced
+// by handleExportConfig
+func (s *HTTPService) handleImportConfig(c *gin.Context) {
+	var req struct {
+		Config *platform.PlatformConfig `json:"config"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Config == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "config is required"})
+		return
+	}
+
+	if err := s.platform.ImportConfig(c.Request.Context(), req.Config); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrInvalidConfig) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Configuration imported"})
+}
+
+// handleRevokeToken revokes a token or token ID. Callers may revoke their
+// own tokens; revoking someone else's, or a bare jti, needs platform:admin.
+func (s *HTTPService) handleRevokeToken(c *gin.Context) {
+	var req struct {
+		Token string `json:"token"`
+		JTI   string `json:"jti"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Token == "" && req.JTI == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token or jti is required"})
+		return
+	}
+
+	security := s.platform.SecurityManager()
+	isAdmin := hasPermission(c, "platform:admin")
+	userID := c.GetString("userID")
+
+	if req.Token != "" {
+		revoker, ok := security.(interface {
+			RevokeTokenString(token string) (string, error)
+		})
+		if !ok {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "token revocation by value is not supported"})
+			return
+		}
+		// Check ownership before revoking so a caller cannot revoke tokens
+		// they don't own
+		info, err := security.ValidateToken(c.Request.Context(), req.Token)
+		if err == nil && info.Valid && info.UserID != userID && !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "cannot revoke another user's token"})
+			return
+		}
+		subject, err := revoker.RevokeTokenString(req.Token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.publishAuthEvent(c, "auth.token_revoked", subject, "")
+		c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+		return
+	}
+
+	if !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "platform:admin permission required to revoke by jti"})
+		return
+	}
+	if err := security.RevokeToken(req.JTI); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.publishAuthEvent(c, "auth.token_revoked", userID, "")
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+// hasPermission reports whether the authenticated caller holds permission
+func hasPermission(c *gin.Context, permission string) bool {
+	permissions, ok := c.Get("permissions")
+	if !ok {
+		return false
+	}
+	perms, _ := permissions.([]string)
+	for _, perm := range perms {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// handleJWKS publishes the token verification keys as a JSON Web Key Set
+func (s *HTTPService) handleJWKS(c *gin.Context) {
+	provider, ok := s.platform.SecurityManager().(interface {
+		JWKS() map[string]interface{}
+	})
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"keys": []interface{}{}})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, provider.JWKS())
+}
+
+// handleIssueToken issues a token pair for any subject. Only admins reach
+// it; the roles granted must be defined in the security config.
 func (s *HTTPService) handleIssueToken(c *gin.Context) {
 	var req struct {
-		UserID string `json:"userId"`
+		UserID      string   `json:"userId"`
+		Roles       []string `json:"roles"`
+		Permissions []string `json:"permissions"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
 		return
 	}
-	user := &core.User{ID: req.UserID, Username: req.UserID}
-	token, err := s.platform.SecurityManager().GenerateToken(user)
+	roles := s.platform.Config().Security.Roles
+	for _, role := range req.Roles {
+		if _, ok := roles[role]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role " + role})
+			return
+		}
+	}
+	user := &core.User{ID: req.UserID, Username: req.UserID, Roles: req.Roles, Permissions: req.Permissions}
+	pair, err := s.platform.SecurityManager().GenerateTokenPair(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	s.publishAuthEvent(c, "auth.token_issued", req.UserID, "")
+	c.JSON(http.StatusOK, pair)
+}
+
+func (s *HTTPService) handleRefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refreshToken is required"})
+		return
+	}
+	if s.rejectLockedOut(c) {
+		return
+	}
+	pair, err := s.platform.SecurityManager().RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		s.recordAuthFailure(c, "invalid refresh token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	s.lockout.reset(c.ClientIP())
+	c.JSON(http.StatusOK, pair)
 }
 
 func (s *HTTPService) handleAPIDocsJSON(c *gin.Context) {
@@ -405,6 +792,9 @@ func (s *HTTPService) handleAPIDocsJSON(c *gin.Context) {
 			"title":   "NoPlaceLike Platform API",
 			"version": "v1",
 		},
+		"servers": []map[string]interface{}{
+			{"url": s.basePath() + "/"},
+		},
 		"paths": map[string]interface{}{
 			"/health": map[string]interface{}{
 				"get": map[string]interface{}{
@@ -434,6 +824,7 @@ func (s *HTTPService) handleAPIDocsJSON(c *gin.Context) {
 }
 
 func (s *HTTPService) handleAPIDocsUI(c *gin.Context) {
+	specURL, _ := json.Marshal(s.basePath() + "/api/docs/json")
 	html := `<!DOCTYPE html>
 <html>
   <head>
@@ -447,7 +838,7 @@ func (s *HTTPService) handleAPIDocsUI(c *gin.Context) {
     <script>
       window.onload = () => {
         window.ui = SwaggerUIBundle({
-          url: '/api/docs/json',
+          url: ` + string(specURL) + `,
           dom_id: '#swagger-ui',
         });
       };
@@ -468,9 +859,10 @@ func (s *HTTPService) handleMetrics(c *gin.Context) {
 			return
 		}
 		var parsed struct {
-			Counters   map[string]float64            `json:"counters"`
-			Gauges     map[string]float64            `json:"gauges"`
-			Histograms map[string]map[string]float64 `json:"histograms"`
+			Counters    map[string]float64                    `json:"counters"`
+			CounterVecs map[string][]platform.CounterSeries   `json:"counterVecs"`
+			Gauges      map[string]float64                    `json:"gauges"`
+			Histograms  map[string]platform.HistogramSnapshot `json:"histograms"`
 		}
 		if err := json.Unmarshal(data, &parsed); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse metrics"})
@@ -484,19 +876,28 @@ func (s *HTTPService) handleMetrics(c *gin.Context) {
 		for k, v := range parsed.Counters {
 			fmt.Fprintf(&b, "npl_counter{metric=%q} %v\n", k, v)
 		}
+		// Labeled counters are exposed under their own names
+		for k, series := range parsed.CounterVecs {
+			fmt.Fprintf(&b, "# TYPE npl_%s counter\n", k)
+			for _, s := range series {
+				fmt.Fprintf(&b, "npl_%s%s %v\n", k, platform.FormatLabels(s.Labels), s.Value)
+			}
+		}
 		// Gauges
 		b.WriteString("# HELP npl_gauge Arbitrary gauges\n")
 		b.WriteString("# TYPE npl_gauge gauge\n")
 		for k, v := range parsed.Gauges {
 			fmt.Fprintf(&b, "npl_gauge{metric=%q} %v\n", k, v)
 		}
-		// Histograms (export count of observations)
-		b.WriteString("# HELP npl_histogram_count Number of observations\n")
-		b.WriteString("# TYPE npl_histogram_count counter\n")
-		for k, obj := range parsed.Histograms {
-			if cnt, ok := obj["count"]; ok {
-				fmt.Fprintf(&b, "npl_histogram_count{metric=%q} %v\n", k, cnt)
+		// Histograms
+		b.WriteString("# HELP npl_histogram Observed value distributions\n")
+		b.WriteString("# TYPE npl_histogram histogram\n")
+		for k, h := range parsed.Histograms {
+			for _, bucket := range h.Buckets {
+				fmt.Fprintf(&b, "npl_histogram_bucket{metric=%q,le=%q} %d\n", k, bucket.LE, bucket.Count)
 			}
+			fmt.Fprintf(&b, "npl_histogram_sum{metric=%q} %v\n", k, h.Sum)
+			fmt.Fprintf(&b, "npl_histogram_count{metric=%q} %d\n", k, h.Count)
 		}
 
 		c.Data(http.StatusOK, "text/plain; version=0.0.4", []byte(b.String()))
@@ -521,10 +922,26 @@ func (s *HTTPService) handleListPlugins(c *gin.Context) {
 
 	result := make([]map[string]interface{}, 0, len(plugins))
 	for name, plugin := range plugins {
+		status, _ := s.platform.PluginStatus(name)
 		result = append(result, map[string]interface{}{
-			"name":    name,
+			"id":      name,
+			"name":    plugin.Name(),
 			"version": plugin.Version(),
 			"health":  plugin.Health(),
+			"state":   status.State,
+			"status":  status,
+		})
+	}
+	// Optional plugins that never loaded are reported as failed
+	for name, reason := range s.platform.FailedPlugins() {
+		if _, loaded := plugins[name]; loaded {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"id":     name,
+			"name":   name,
+			"state":  core.PluginStateFailed,
+			"status": platform.PluginStatus{State: core.PluginStateFailed, Error: reason},
 		})
 	}
 
@@ -536,30 +953,91 @@ func (s *HTTPService) handleGetPlugin(c *gin.Context) {
 
 	plugin, err := s.platform.GetPlugin(name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	status, _ := s.platform.PluginStatus(name)
 	c.JSON(http.StatusOK, map[string]interface{}{
+		"id":           plugin.ID(),
 		"name":         plugin.Name(),
 		"version":      plugin.Version(),
 		"health":       plugin.Health(),
+		"state":        status.State,
+		"status":       status,
 		"dependencies": plugin.Dependencies(),
 		"routes":       plugin.Routes(),
+		"manifest":     s.pluginManifest(name, plugin),
 	})
 }
 
-func (s *HTTPService) handleStartPlugin(c *gin.Context) {
+// pluginRouteInfo describes a plugin route as it is mounted
+type pluginRouteInfo struct {
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+	Description  string   `json:"description,omitempty"`
+	AuthRequired bool     `json:"authRequired"`
+	Permissions  []string `json:"permissions,omitempty"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+// pluginRouteInfo lists the routes of the plugin mounted as name
+func (s *HTTPService) pluginRouteInfo(name string, plugin core.Plugin) []pluginRouteInfo {
+	routes := plugin.Routes()
+	info := make([]pluginRouteInfo, 0, len(routes))
+	for _, route := range routes {
+		info = append(info, pluginRouteInfo{
+			Method:       route.Method,
+			Path:         fmt.Sprintf("%s/plugins/%s%s", s.basePath(), name, route.Path),
+			Description:  route.Description,
+			AuthRequired: route.Auth.Required,
+			Permissions:  route.Auth.Permissions,
+			Roles:        route.Auth.Roles,
+		})
+	}
+	return info
+}
+
+// handleGetPluginManifest describes everything a plugin exposes: its routes,
+// the events it subscribes to, its config schema and its current state
+func (s *HTTPService) handleGetPluginManifest(c *gin.Context) {
 	name := c.Param("name")
 
 	plugin, err := s.platform.GetPlugin(name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := plugin.Start(c.Request.Context()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, s.pluginManifest(name, plugin))
+}
+
+// pluginManifest builds the manifest of the plugin mounted as name
+func (s *HTTPService) pluginManifest(name string, plugin core.Plugin) gin.H {
+	status, _ := s.platform.PluginStatus(name)
+	return gin.H{
+		"name":         name,
+		"id":           plugin.ID(),
+		"version":      plugin.Version(),
+		"dependencies": plugin.Dependencies(),
+		"routes":       s.pluginRouteInfo(name, plugin),
+		"events":       s.platform.PluginSubscriptions(name),
+		"config":       plugin.Configuration(),
+		"state":        status.State,
+		"status":       status,
+	}
+}
+
+func (s *HTTPService) handleStartPlugin(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, err := s.platform.GetPlugin(name); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.platform.StartPlugin(c.Request.Context(), name); err != nil {
+		s.pluginLifecycleError(c, err)
 		return
 	}
 
@@ -569,26 +1047,101 @@ func (s *HTTPService) handleStartPlugin(c *gin.Context) {
 func (s *HTTPService) handleStopPlugin(c *gin.Context) {
 	name := c.Param("name")
 
-	plugin, err := s.platform.GetPlugin(name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	if _, err := s.platform.GetPlugin(name); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := plugin.Stop(c.Request.Context()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := s.platform.StopPlugin(c.Request.Context(), name); err != nil {
+		s.pluginLifecycleError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "stopped"})
 }
 
+func (s *HTTPService) handleReinitializePlugin(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, err := s.platform.GetPlugin(name); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.platform.ReinitializePlugin(name); err != nil {
+		s.pluginLifecycleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "initialized"})
+}
+
+// handleUnloadPlugin unloads a plugin. With ?cascade=true the plugins that
+// depend on it are unloaded first; otherwise it is refused while any exist.
+func (s *HTTPService) handleUnloadPlugin(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, err := s.platform.GetPlugin(name); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("cascade") != "true" {
+		if err := s.platform.UnloadPlugin(c.Request.Context(), name); err != nil {
+			s.pluginLifecycleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "unloaded", "unloaded": []string{name}})
+		return
+	}
+
+	unloaded, err := s.platform.UnloadPluginCascade(c.Request.Context(), name)
+	if err != nil {
+		s.pluginLifecycleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "unloaded", "unloaded": unloaded})
+}
+
+// pluginLifecycleError reports a failed plugin transition, as 409 when the
+// plugin is not in a state that allows it or other plugins depend on it, and
+// as 504 when the plugin did not respond in time
+func (s *HTTPService) pluginLifecycleError(c *gin.Context, err error) {
+	c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+}
+
+// errorStatus maps the platform's sentinel errors to HTTP status codes.
+// Anything else is a 500.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ErrPluginNotFound),
+		errors.Is(err, core.ErrResourceNotFound),
+		errors.Is(err, core.ErrServiceNotFound),
+		errors.Is(err, core.ErrSubscriptionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrPluginExists),
+		errors.Is(err, core.ErrInvalidState),
+		errors.Is(err, core.ErrPluginInUse),
+		errors.Is(err, core.ErrAlreadyRunning):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrInvalidConfig),
+		errors.Is(err, core.ErrInvalidRequest),
+		errors.Is(err, core.ErrMissingDependency):
+		return http.StatusBadRequest
+	case errors.Is(err, core.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, core.ErrPluginTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
 func (s *HTTPService) handlePluginHealth(c *gin.Context) {
 	name := c.Param("name")
 
 	plugin, err := s.platform.GetPlugin(name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -605,7 +1158,7 @@ func (s *HTTPService) handleGetService(c *gin.Context) {
 
 	service, err := s.platform.ServiceManager().GetService(name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -621,7 +1174,7 @@ func (s *HTTPService) handleServiceHealth(c *gin.Context) {
 
 	service, err := s.platform.ServiceManager().GetService(name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -658,20 +1211,80 @@ func (s *HTTPService) handleDiscoverPeers(c *gin.Context) {
 }
 
 func (s *HTTPService) handleListResources(c *gin.Context) {
+	// With no filters every resource is listed. ?meta.<key>=<value> matches
+	// any metadata value, and ?limit= and ?offset= page through the results.
+	limit, ok := queryCount(c, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := queryCount(c, "offset")
+	if !ok {
+		return
+	}
+
 	filter := core.ResourceFilter{
-		Name: "example",
-		Type: "file",
-		// Type:  c.Query("type"),
-		// Owner: c.Query("owner"),
+		Name:  c.Query("name"),
+		Type:  c.Query("type"),
+		Owner: c.Query("owner"),
+	}
+	for key, values := range c.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "meta."); ok && name != "" && len(values) > 0 {
+			if filter.Metadata == nil {
+				filter.Metadata = make(map[string]string)
+			}
+			filter.Metadata[name] = values[0]
+		}
 	}
 
 	resources, err := s.platform.ResourceManager().ListResources(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"resources": resources})
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID() < resources[j].ID() })
+	total := len(resources)
+	resources = resources[min(offset, total):]
+	if limit > 0 && limit < len(resources) {
+		resources = resources[:limit]
+	}
+
+	result := make([]gin.H, 0, len(resources))
+	for _, resource := range resources {
+		result = append(result, resourceInfo(resource))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"resources": result,
+		"total":     total,
+		"offset":    offset,
+	})
+}
+
+// queryCount reads a non-negative integer query parameter, 0 if it's
+// absent. It answers 400 and returns false if the value is invalid.
+func queryCount(c *gin.Context, name string) (int, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a non-negative integer"})
+		return 0, false
+	}
+	return n, true
+}
+
+// resourceInfo describes a resource for API responses. Resources are
+// interfaces whose fields are usually unexported, so they can't be encoded
+// directly.
+func resourceInfo(resource core.Resource) gin.H {
+	return gin.H{
+		"id":       resource.ID(),
+		"type":     resource.Type(),
+		"size":     resource.GetSize(),
+		"metadata": resource.GetMetadata(),
+	}
 }
 
 func (s *HTTPService) handleGetResource(c *gin.Context) {
@@ -679,11 +1292,11 @@ func (s *HTTPService) handleGetResource(c *gin.Context) {
 
 	resource, err := s.platform.ResourceManager().GetResource(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, resource)
+	c.JSON(http.StatusOK, resourceInfo(resource))
 }
 
 func (s *HTTPService) handleCreateResource(c *gin.Context) {
@@ -692,6 +1305,8 @@ func (s *HTTPService) handleCreateResource(c *gin.Context) {
 		Type     string                 `json:"type"`
 		Metadata map[string]interface{} `json:"metadata"`
 		Data     string                 `json:"data"`
+		// Path is the file served by a "file" resource
+		Path string `json:"path"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -701,27 +1316,45 @@ func (s *HTTPService) handleCreateResource(c *gin.Context) {
 		req.Type = "memory"
 	}
 	if req.ID == "" {
-		req.ID = fmt.Sprintf("res-%d", time.Now().UnixNano())
+		req.ID = "res-" + core.NewID()
 	}
 
-	res := &memoryResource{
-		id:   req.ID,
-		typ:  req.Type,
-		meta: req.Metadata,
-		data: []byte(req.Data),
+	var res core.Resource
+	if req.Type == "file" {
+		if req.Path == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "path is required for file resources"})
+			return
+		}
+		if !s.platform.PathAllowed(req.Path) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "path is not allowed"})
+			return
+		}
+		if info, err := os.Stat(req.Path); err != nil || !info.Mode().IsRegular() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "path is not a regular file"})
+			return
+		}
+		if req.Metadata == nil {
+			req.Metadata = make(map[string]interface{})
+		}
+		if _, ok := req.Metadata["name"]; !ok {
+			req.Metadata["name"] = filepath.Base(req.Path)
+		}
+		if _, ok := req.Metadata[core.ResourceContentTypeKey]; !ok {
+			if info, known := filetype.Lookup(req.Path); known {
+				req.Metadata[core.ResourceContentTypeKey] = info.MIMEType
+			}
+		}
+		res = &fileResource{id: req.ID, path: req.Path, meta: req.Metadata}
+	} else {
+		res = core.NewMemoryResource(req.ID, req.Type, []byte(req.Data), req.Metadata)
 	}
 
 	if err := s.platform.ResourceManager().RegisterResource(res); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":       res.ID(),
-		"type":     res.Type(),
-		"size":     res.GetSize(),
-		"metadata": res.GetMetadata(),
-	})
+	c.JSON(http.StatusCreated, resourceInfo(res))
 }
 
 func (s *HTTPService) handleDeleteResource(c *gin.Context) {
@@ -735,39 +1368,120 @@ func (s *HTTPService) handleDeleteResource(c *gin.Context) {
 func (s *HTTPService) handleStreamResource(c *gin.Context) {
 	id := c.Param("id")
 
+	resource, err := s.platform.ResourceManager().GetResource(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The content type comes from metadata set by whoever created the
+	// resource, or else the ID's extension, so only types that can't run
+	// script on this origin are served as such
+	contentType, _ := resource.GetMetadata()[core.ResourceContentTypeKey].(string)
+	if contentType == "" {
+		contentType = filetype.MIMEType(resource.ID())
+	}
+
+	// Seekable resources are served with Range support: http.ServeContent
+	// answers byte ranges with 206 and sets Accept-Ranges and
+	// Content-Length
+	if seekable, ok := resource.(core.SeekableResource); ok {
+		content, err := seekable.Open(c.Request.Context())
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		defer content.Close()
+
+		filetype.SetUntrustedMediaHeaders(c.Writer.Header(), contentType)
+		http.ServeContent(c.Writer, c.Request, resource.ID(), time.Time{}, content)
+		return
+	}
+
 	stream, err := s.platform.ResourceManager().StreamResource(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	defer stream.Close()
 
-	// Stream the resource content
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Transfer-Encoding", "chunked")
+	// Stream the resource content. Report the length up front when the
+	// resource streams its own content of a known size.
+	filetype.SetUntrustedMediaHeaders(c.Writer.Header(), contentType)
+	if _, ok := resource.(core.StreamableResource); ok && resource.GetSize() >= 0 {
+		c.Header("Content-Length", strconv.FormatInt(resource.GetSize(), 10))
+	} else {
+		c.Header("Transfer-Encoding", "chunked")
+	}
 
-	// Copy stream to response
+	// Copy stream to response, stopping as soon as the client goes away
+	ctx := c.Request.Context()
 	c.Stream(func(w io.Writer) bool {
+		if ctx.Err() != nil {
+			return false
+		}
 		data, err := stream.Read()
 		if err != nil {
 			return false
 		}
-		w.Write(data)
-		return true
+		if _, err := w.Write(data); err != nil {
+			return false
+		}
+		return ctx.Err() == nil
 	})
 }
 
+// sseKeepAliveInterval is how often an idle event stream gets a comment so
+// proxies don't close it. It is a variable so tests can shorten it.
+var sseKeepAliveInterval = 15 * time.Second
+
 func (s *HTTPService) handleEventStream(c *gin.Context) {
-	// Implementation for Server-Sent Events
+	// Implementation for Server-Sent Events. ?types= limits the stream to a
+	// comma-separated list of event types or patterns such as "plugin.*".
+	types := eventTypesQuery(c)
+	visible := s.eventVisibility(c)
+
+	// One type is left to the event bus; several share a catch-all
+	// subscription filtered here
+	subscribeTo := "*"
+	matches := platform.TopicMatcher(types...)
+	if len(types) == 1 {
+		subscribeTo = types[0]
+		matches = platform.TopicMatcher()
+	}
+
+	// A reconnecting browser sends the last id it saw; ?since= does the same
+	// for clients that set the cursor themselves
+	cursor := c.GetHeader("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.Query("since")
+	}
+	var since uint64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event cursor"})
+			return
+		}
+	}
+
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 
-	// Subscribe to events
-	err := s.platform.EventBus().Subscribe("*", core.EventHandler(func(event core.Event) error {
-		data, _ := json.Marshal(event)
-		c.Writer.Write([]byte(fmt.Sprintf("data: %s\n\n", data)))
-		c.Writer.Flush()
+	// Subscribe to events until the client goes away. The handler only
+	// hands events over; they are written from this goroutine, which owns
+	// the response writer.
+	done := c.Request.Context().Done()
+	events := make(chan core.Event)
+	sub, err := s.platform.EventBus().Subscribe(subscribeTo, core.EventHandler(func(event core.Event) error {
+		if !matches(event.Type) || !visible(event.Type) {
+			return nil
+		}
+		select {
+		case events <- event:
+		case <-done:
+		}
 		return nil
 	}))
 
@@ -775,9 +1489,96 @@ func (s *HTTPService) handleEventStream(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	defer s.platform.EventBus().Unsubscribe(sub)
+
+	// Send the headers now rather than with the first event, so a quiet
+	// stream still connects
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Flush()
+
+	// Replay what the client missed. Subscribing first means nothing is lost
+	// in between; live events already replayed are skipped below.
+	since = s.replayCursor(since)
+	last := since
+	if cursor != "" {
+		for _, event := range s.platform.EventsSince(since, types...) {
+			if visible(event.Type) {
+				writeServerSentEvent(c, event)
+			}
+			last = event.Seq
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if event.Seq <= last {
+				continue
+			}
+			writeServerSentEvent(c, event)
+			last = event.Seq
+		case <-keepAlive.C:
+			c.Writer.Write([]byte(": keepalive\n\n"))
+			c.Writer.Flush()
+		case <-done:
+			return
+		}
+	}
+}
+
+// replayCursor returns the sequence number to replay events after for a
+// client that last saw since. Numbering starts over when the process
+// restarts, so a cursor ahead of the latest event is from an earlier run
+// and everything retained is replayed, rather than live events being
+// skipped until the count catches up.
+func (s *HTTPService) replayCursor(since uint64) uint64 {
+	if since > s.platform.LastEventSeq() {
+		return 0
+	}
+	return since
+}
+
+// isAuthEvent reports whether eventType is an authentication audit event.
+// These carry client IPs and user IDs, and only the platform publishes them.
+func isAuthEvent(eventType string) bool {
+	return strings.HasPrefix(eventType, "auth.")
+}
+
+// eventVisibility returns a filter for the events the caller may be sent:
+// authentication events only go to platform admins. Event streams aren't
+// behind authMiddleware, so a bearer token is checked here if one is sent.
+func (s *HTTPService) eventVisibility(c *gin.Context) func(eventType string) bool {
+	admin := hasPermission(c, "platform:admin")
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && !admin {
+		info, err := s.platform.SecurityManager().ValidateToken(c.Request.Context(), token)
+		admin = err == nil && info.Valid && slices.Contains(info.Permissions, "platform:admin")
+	}
+	return func(eventType string) bool {
+		return admin || !isAuthEvent(eventType)
+	}
+}
+
+// eventTypesQuery returns the event types or patterns listed in ?types=,
+// or the single ?type=
+func eventTypesQuery(c *gin.Context) []string {
+	var types []string
+	for _, eventType := range strings.Split(c.DefaultQuery("types", c.Query("type")), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+	return types
+}
 
-	// Keep connection alive
-	<-c.Request.Context().Done()
+// writeServerSentEvent writes event to an event stream, using its sequence
+// number as the id a reconnecting client sends back
+func writeServerSentEvent(c *gin.Context, event core.Event) {
+	data, _ := json.Marshal(event)
+	c.Writer.Write([]byte(fmt.Sprintf("id: %d\ndata: %s\n\n", event.Seq, data)))
+	c.Writer.Flush()
 }
 
 func (s *HTTPService) handlePublishEvent(c *gin.Context) {
@@ -787,7 +1588,15 @@ func (s *HTTPService) handlePublishEvent(c *gin.Context) {
 		return
 	}
 
+	// Audit events are only raised by the platform, so clients can't
+	// forge entries in the audit log
+	if isAuthEvent(event.Type) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "auth.* events are reserved for the platform"})
+		return
+	}
+
 	// topic := c.DefaultQuery("topic", "custom")
+	event.Data = core.WithRequestIDData(c.Request.Context(), event.Data)
 
 	if err := s.platform.EventBus().Publish(event); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -797,19 +1606,30 @@ func (s *HTTPService) handlePublishEvent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "published"})
 }
 
+// handleResetMetrics zeroes every metric, or only those named with ?metric=
+func (s *HTTPService) handleResetMetrics(c *gin.Context) {
+	names := c.QueryArray("metric")
+	if err := s.platform.Metrics().Reset(names...); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, platform.ErrMetricNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "reset", "metrics": names})
+}
+
+// metricsEnabled reports whether HTTP metrics are recorded and exposed
+func (s *HTTPService) metricsEnabled() bool {
+	return s.config.EnableMetrics && s.platform.MetricsEnabled()
+}
+
 // Middleware functions
 func (s *HTTPService) loggingMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// Basic metrics: request counters and latency histogram
-		// Global counter
-		s.platform.Metrics().Counter("http_requests_total").Inc()
-		// Method/Path/Status counters (flattened; label-less)
-		key := fmt.Sprintf("http_requests_total_%s_%s_%d", param.Method, param.Path, param.StatusCode)
-		s.platform.Metrics().Counter(key).Inc()
-		// Latency (milliseconds)
-		s.platform.Metrics().Histogram("http_request_latency_ms").Observe(float64(param.Latency.Milliseconds()))
-
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
+		requestID, _ := param.Keys[requestIDKey].(string)
+		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\" request_id=%s\n",
 			param.ClientIP,
 			param.TimeStamp.Format(time.RFC3339),
 			param.Method,
@@ -819,28 +1639,126 @@ func (s *HTTPService) loggingMiddleware() gin.HandlerFunc {
 			param.Latency,
 			param.Request.UserAgent(),
 			param.ErrorMessage,
+			requestID,
 		)
 	})
 }
 
+// unmatchedRoute is the path label of requests that match no route
+const unmatchedRoute = "unmatched"
+
+// metricsMiddleware counts requests and records their latency. Requests are
+// labeled with the route they matched, such as /api/resources/:id, rather
+// than the path requested, so clients can't grow the label set.
+func (s *HTTPService) metricsMiddleware() gin.HandlerFunc {
+	metrics := s.platform.Metrics()
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.Counter("http_requests_total").Inc()
+		metrics.CounterVec("http_requests_total", "method", "path", "status").With(map[string]string{
+			"method": c.Request.Method,
+			"path":   route,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}).Inc()
+		metrics.Histogram("http_request_latency_ms").Observe(float64(time.Since(start).Milliseconds()))
+	}
+}
+
+// requestIDKey is the gin context key holding the request ID
+const requestIDKey = "requestID"
+
+// requestIDMiddleware gives every request an ID, taken from the
+// X-Request-ID header when the client or a proxy sent a usable one. The ID
+// is stored in the gin context and the request context, so events published
+// while handling the request can carry it, and echoed on the response.
+func (s *HTTPService) requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(core.RequestIDHeader)
+		if !core.ValidRequestID(id) {
+			id = core.NewRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(core.WithRequestID(c.Request.Context(), id))
+		c.Header(core.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// corsMiddleware adds CORS headers to requests from the allowed origins
 func (s *HTTPService) corsMiddleware() gin.HandlerFunc {
+	origins := make(map[string]bool, len(s.config.CORSAllowedOrigins))
+	for _, origin := range s.config.CORSAllowedOrigins {
+		origins[core.NormalizeOrigin(origin)] = true
+	}
+	credentials := s.config.CORSAllowCredentials
+	if credentials && origins["*"] {
+		s.logger.Warn("Ignoring CORS wildcard origin because credentials are allowed")
+		delete(origins, "*")
+	}
+	allowAny := origins["*"]
+
+	headers := s.config.CORSAllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultCORSAllowedHeaders
+	}
+	allowHeaders := strings.Join(headers, ", ")
+	maxAge := strconv.Itoa(int(s.config.CORSMaxAge.Seconds()))
+
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
+		// The response depends on the origin unless every origin gets "*"
+		if !allowAny {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if !allowAny && !origins[core.NormalizeOrigin(origin)] {
+			c.Next()
 			return
 		}
 
+		if allowAny {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if credentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", allowHeaders)
+		c.Header("Access-Control-Expose-Headers", core.RequestIDHeader)
+		if c.Request.Method == http.MethodOptions && s.config.CORSMaxAge > 0 {
+			c.Header("Access-Control-Max-Age", maxAge)
+		}
+
+		// OPTIONS requests are answered by optionsMiddleware
 		c.Next()
 	}
 }
 
+// rateLimitMiddleware limits each client IP to RateLimitRPS requests per
+// second with bursts of twice that, answering 429 with Retry-After beyond it
 func (s *HTTPService) rateLimitMiddleware() gin.HandlerFunc {
-	// Implementation would use a rate limiter
+	s.limiter = newRateLimiter(s.config.RateLimitRPS, 2*s.config.RateLimitRPS)
+	limiter := s.limiter
 	return func(c *gin.Context) {
+		if ok, wait := limiter.allow(c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -868,8 +1786,13 @@ func (s *HTTPService) requestSizeLimitMiddleware() gin.HandlerFunc {
 
 func (s *HTTPService) authMiddleware(permissions []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if s.rejectLockedOut(c) {
+			return
+		}
+
 		token := c.GetHeader("Authorization")
 		if token == "" {
+			s.publishAuthEvent(c, "auth.token_rejected", "", "missing authorization header")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization required"})
 			c.Abort()
 			return
@@ -877,6 +1800,7 @@ func (s *HTTPService) authMiddleware(permissions []string) gin.HandlerFunc {
 
 		// Require "Bearer " prefix
 		if len(token) <= 7 || token[:7] != "Bearer " {
+			s.recordAuthFailure(c, "invalid authorization scheme")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization scheme"})
 			c.Abort()
 			return
@@ -886,10 +1810,12 @@ func (s *HTTPService) authMiddleware(permissions []string) gin.HandlerFunc {
 		// Validate token
 		tokenInfo, err := s.platform.SecurityManager().ValidateToken(c.Request.Context(), token)
 		if err != nil || !tokenInfo.Valid {
+			s.recordAuthFailure(c, "invalid token")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
+		s.lockout.reset(c.ClientIP())
 
 		// Check permissions
 		for _, permission := range permissions {
@@ -902,6 +1828,7 @@ func (s *HTTPService) authMiddleware(permissions []string) gin.HandlerFunc {
 			}
 
 			if !hasPermission {
+				s.publishAuthEvent(c, "auth.forbidden", tokenInfo.UserID, "missing permission "+permission)
 				c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 				c.Abort()
 				return
@@ -915,3 +1842,104 @@ func (s *HTTPService) authMiddleware(permissions []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// globalAuthMiddleware requires a valid token on every request whose path is
+// not auth-exempt
+func (s *HTTPService) globalAuthMiddleware() gin.HandlerFunc {
+	auth := s.authMiddleware(nil)
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions || s.isAuthExempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// isAuthExempt reports whether path matches the configured (or default)
+// auth-exempt list
+func (s *HTTPService) isAuthExempt(path string) bool {
+	if base := s.basePath(); base != "" {
+		if path != base && !strings.HasPrefix(path, base+"/") {
+			return false
+		}
+		path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, base), "/")
+	}
+
+	exempt := s.config.AuthExemptPaths
+	if exempt == nil {
+		exempt = DefaultAuthExemptPaths
+	}
+	for _, p := range exempt {
+		if strings.HasSuffix(p, "/*") {
+			prefix := strings.TrimSuffix(p, "*")
+			if path == strings.TrimSuffix(prefix, "/") || strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// basePath returns the configured base path normalized to "/prefix" form, or
+// "" when routes are mounted at the root
+func (s *HTTPService) basePath() string {
+	base := strings.Trim(s.config.BasePath, "/")
+	if base == "" {
+		return ""
+	}
+	return "/" + base
+}
+
+// rejectLockedOut aborts the request with 429 if the client is locked out
+// after too many failed authentication attempts
+func (s *HTTPService) rejectLockedOut(c *gin.Context) bool {
+	left := s.lockout.remaining(c.ClientIP())
+	if left <= 0 {
+		return false
+	}
+	s.publishAuthEvent(c, "auth.token_rejected", "", "locked out")
+	c.Header("Retry-After", fmt.Sprintf("%d", int(left.Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed authentication attempts"})
+	c.Abort()
+	return true
+}
+
+// recordAuthFailure audits a rejected credential and counts it towards lockout
+func (s *HTTPService) recordAuthFailure(c *gin.Context, reason string) {
+	s.publishAuthEvent(c, "auth.token_rejected", "", reason)
+	if s.lockout.fail(c.ClientIP()) {
+		s.logger.Warn("Client locked out after repeated auth failures",
+			core.Field{Key: "ip", Value: c.ClientIP()},
+		)
+	}
+}
+
+// publishAuthEvent emits an authentication audit event. The token itself is
+// never included.
+func (s *HTTPService) publishAuthEvent(c *gin.Context, eventType, userID, reason string) {
+	event := core.Event{
+		ID:     core.NewID(),
+		Type:   eventType,
+		Source: s.name,
+		Data: core.WithRequestIDData(c.Request.Context(), map[string]interface{}{
+			"userId": userID,
+			"ip":     c.ClientIP(),
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"reason": reason,
+		}),
+		Timestamp: time.Now().Unix(),
+	}
+
+	if err := s.platform.EventBus().Publish(event); err != nil {
+		s.logger.Warn("Failed to publish auth event",
+			core.Field{Key: "type", Value: eventType},
+			core.Field{Key: "error", Value: err},
+		)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// maxLockoutEntries caps the clients tracked at once, so failures from many
+// addresses can't grow the table without bound
+const maxLockoutEntries = 10000
+
+// authLockout tracks failed authentication attempts per client and locks the
+// client out for a fixed duration once the limit is reached. Failures only
+// count towards the limit for that same duration after the first of them.
+type authLockout struct {
+	mu          sync.Mutex
+	maxAttempts int
+	duration    time.Duration
+	maxEntries  int
+	entries     map[string]*lockoutEntry
+}
+
+type lockoutEntry struct {
+	failures int
+	// windowStart is the first failure counted towards the limit
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// expired reports whether the entry no longer affects its client at now: it
+// is not locked out and its failure window has passed
+func (e *lockoutEntry) expired(now time.Time, window time.Duration) bool {
+	return !now.Before(e.lockedUntil) && now.Sub(e.windowStart) >= window
+}
+
+// newAuthLockout creates a lockout tracker. A maxAttempts of zero or less
+// disables lockout.
+func newAuthLockout(maxAttempts int, duration time.Duration) *authLockout {
+	return &authLockout{
+		maxAttempts: maxAttempts,
+		duration:    duration,
+		maxEntries:  maxLockoutEntries,
+		entries:     make(map[string]*lockoutEntry),
+	}
+}
+
+// remaining returns how long key stays locked out, or zero if it is not
+func (l *authLockout) remaining(key string) time.Duration {
+	if l.maxAttempts <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok || entry.lockedUntil.IsZero() {
+		return 0
+	}
+	left := time.Until(entry.lockedUntil)
+	if left <= 0 {
+		// Lockout expired; start counting afresh
+		delete(l.entries, key)
+		return 0
+	}
+	return left
+}
+
+// fail records a failed attempt and reports whether key is now locked out
+func (l *authLockout) fail(key string) bool {
+	if l.maxAttempts <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := l.entries[key]
+	if ok && entry.expired(now, l.duration) {
+		// Earlier failures are too old to count
+		ok = false
+	}
+	if !ok {
+		if _, tracked := l.entries[key]; !tracked && len(l.entries) >= l.maxEntries {
+			l.evictLocked(now)
+		}
+		entry = &lockoutEntry{windowStart: now}
+		l.entries[key] = entry
+	}
+	entry.failures++
+	if entry.failures >= l.maxAttempts {
+		entry.lockedUntil = now.Add(l.duration)
+		return true
+	}
+	return false
+}
+
+// evictLocked makes room for a new entry by dropping the expired ones, or
+// failing that the one whose failure window started first. The caller holds
+// l.mu.
+func (l *authLockout) evictLocked(now time.Time) {
+	var oldestKey string
+	var oldest *lockoutEntry
+	for key, entry := range l.entries {
+		if entry.expired(now, l.duration) {
+			delete(l.entries, key)
+			continue
+		}
+		if oldest == nil || entry.windowStart.Before(oldest.windowStart) {
+			oldestKey, oldest = key, entry
+		}
+	}
+	if len(l.entries) >= l.maxEntries && oldest != nil {
+		delete(l.entries, oldestKey)
+	}
+}
+
+// reset clears the failure count for key after a successful attempt
+func (l *authLockout) reset(key string) {
+	if l.maxAttempts <= 0 {
+		return
+	}
+	l.mu.Lock()
+	delete(l.entries, key)
+	l.mu.Unlock()
+}
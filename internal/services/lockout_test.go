@@ -0,0 +1,138 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+func TestAuthLockout(t *testing.T) {
+	const duration = 100 * time.Millisecond
+	tests := []struct {
+		name string
+		// attempts are the credentials presented in turn: true for a valid
+		// token, false for a bad one
+		attempts   []bool
+		wantLocked bool
+	}{
+		{"below the limit", []bool{false, false}, false},
+		{"at the limit", []bool{false, false, false}, true},
+		{"success resets the count", []bool{false, false, true, false, false}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Security.MaxLoginAttempts = 3
+				cfg.Security.LockoutDuration = duration
+			})
+			s := newTestService(t, HTTPConfig{}, p)
+			valid := bearer(issueToken(t, p, "op", "operator"))
+			request := func(header http.Header) int {
+				return do(s, http.MethodPost, "/api/plugins/missing/start", nil, header).Code
+			}
+
+			for i, ok := range tt.attempts {
+				header, want := bearer("bad-token"), http.StatusUnauthorized
+				if ok {
+					header, want = valid, http.StatusNotFound
+				}
+				if code := request(header); code != want {
+					t.Fatalf("attempt %d: status = %d, want %d", i, code, want)
+				}
+			}
+
+			// Once locked out, even a valid token is refused
+			code := request(valid)
+			if !tt.wantLocked {
+				if code != http.StatusNotFound {
+					t.Fatalf("status = %d, want %d", code, http.StatusNotFound)
+				}
+				return
+			}
+			if code != http.StatusTooManyRequests {
+				t.Fatalf("status while locked out = %d, want %d", code, http.StatusTooManyRequests)
+			}
+
+			time.Sleep(duration + 20*time.Millisecond)
+			if code := request(valid); code != http.StatusNotFound {
+				t.Errorf("status after lockout expired = %d, want %d", code, http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestAuthLockoutDisabled(t *testing.T) {
+	l := newAuthLockout(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if l.fail("client") {
+			t.Fatal("lockout triggered with no attempt limit")
+		}
+	}
+	if left := l.remaining("client"); left != 0 {
+		t.Errorf("remaining = %v, want 0", left)
+	}
+}
+
+func TestAuthLockoutFailureWindow(t *testing.T) {
+	const window = 50 * time.Millisecond
+	tests := []struct {
+		name string
+		// pause is the wait between the first two failures and the third
+		pause      time.Duration
+		wantLocked bool
+	}{
+		{"within the window", 0, true},
+		{"after the window", window + 20*time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newAuthLockout(3, window)
+			l.fail("client")
+			l.fail("client")
+			time.Sleep(tt.pause)
+			if locked := l.fail("client"); locked != tt.wantLocked {
+				t.Errorf("locked = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestAuthLockoutEntryCap(t *testing.T) {
+	tests := []struct {
+		name string
+		// pause is the wait before the client over the cap fails
+		pause    time.Duration
+		duration time.Duration
+		wantKeys []string
+	}{
+		// Expired entries are swept to make room
+		{"expired entries", 70 * time.Millisecond, 50 * time.Millisecond, []string{"d"}},
+		// Otherwise the oldest entry goes
+		{"active entries", 0, time.Minute, []string{"b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newAuthLockout(5, tt.duration)
+			l.maxEntries = 3
+			for _, key := range []string{"a", "b", "c"} {
+				l.fail(key)
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(tt.pause)
+			l.fail("d")
+
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if len(l.entries) != len(tt.wantKeys) {
+				t.Fatalf("%d entries tracked, want %v", len(l.entries), tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := l.entries[key]; !ok {
+					t.Errorf("entry %s evicted", key)
+				}
+			}
+		})
+	}
+}
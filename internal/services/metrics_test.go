@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+// requestPathLabels returns the path labels of the request counter
+func requestPathLabels(t *testing.T, p *platform.Platform) map[string]bool {
+	t.Helper()
+	data, err := p.Metrics().Export("json")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var exported struct {
+		CounterVecs map[string][]platform.CounterSeries `json:"counterVecs"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("decode metrics: %v", err)
+	}
+	labels := make(map[string]bool)
+	for _, series := range exported.CounterVecs["http_requests_total"] {
+		labels[series.Labels["path"]] = true
+	}
+	return labels
+}
+
+func TestRequestMetricsPathLabels(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name:  "route parameters",
+			paths: []string{"/api/network/peers/a1", "/api/network/peers/b2", "/api/network/peers/c3"},
+			want:  []string{"/api/network/peers/:id"},
+		},
+		{
+			name:  "unknown paths",
+			paths: []string{"/no/such/1", "/no/such/2", "/api/missing"},
+			want:  []string{unmatchedRoute},
+		},
+		{
+			name:  "mixed",
+			paths: []string{"/health", "/api/plugins/one", "/api/plugins/two", "/random-1", "/random-2"},
+			want:  []string{"/health", "/api/plugins/:name", unmatchedRoute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Metrics.Enabled = true
+			})
+			s := newTestService(t, HTTPConfig{EnableMetrics: true}, p)
+			for _, path := range tt.paths {
+				do(s, http.MethodGet, path, nil, nil)
+			}
+
+			got := requestPathLabels(t, p)
+			if len(got) != len(tt.want) {
+				t.Errorf("got path labels %v, want %v", got, tt.want)
+			}
+			for _, label := range tt.want {
+				if !got[label] {
+					t.Errorf("missing path label %q in %v", label, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	tests := []struct {
+		name            string
+		platformMetrics bool
+		httpMetrics     bool
+		wantEndpoint    bool
+	}{
+		{"enabled", true, true, true},
+		{"disabled in platform", false, true, false},
+		{"disabled in service", true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Metrics.Enabled = tt.platformMetrics
+			})
+			s := newTestService(t, HTTPConfig{EnableMetrics: tt.httpMetrics}, p)
+			do(s, http.MethodGet, "/health", nil, nil)
+
+			rec := do(s, http.MethodGet, platform.DefaultMetricsEndpoint, nil, nil)
+			if got := rec.Code != http.StatusNotFound; got != tt.wantEndpoint {
+				t.Errorf("metrics endpoint status %d, want it served = %v", rec.Code, tt.wantEndpoint)
+			}
+			if tt.wantEndpoint {
+				return
+			}
+			// Nothing is recorded for requests
+			if !tt.platformMetrics {
+				if _, err := p.Metrics().Export("json"); !errors.Is(err, platform.ErrMetricsDisabled) {
+					t.Errorf("Export error = %v, want %v", err, platform.ErrMetricsDisabled)
+				}
+			} else if labels := requestPathLabels(t, p); len(labels) != 0 {
+				t.Errorf("recorded requests to %v with metrics disabled", labels)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpointConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    string
+		requireAuth bool
+		permission  string
+		path        string
+		user        string
+		role        string
+		want        int
+	}{
+		{"default path", "", false, "", platform.DefaultMetricsEndpoint, "", "", http.StatusOK},
+		{"custom path", "/internal/metrics", false, "", "/internal/metrics", "", "", http.StatusOK},
+		{"default path moved", "/internal/metrics", false, "", platform.DefaultMetricsEndpoint, "", "", http.StatusNotFound},
+		{"auth without token", "", true, "", platform.DefaultMetricsEndpoint, "", "", http.StatusUnauthorized},
+		{"auth with token", "", true, "", platform.DefaultMetricsEndpoint, "op", "operator", http.StatusOK},
+		{"permission missing", "", true, "platform:admin", platform.DefaultMetricsEndpoint, "op", "operator", http.StatusForbidden},
+		{"permission granted", "", true, "platform:admin", platform.DefaultMetricsEndpoint, "root", "admin", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Metrics = platform.MetricsConfig{
+					Enabled:     true,
+					Endpoint:    tt.endpoint,
+					RequireAuth: tt.requireAuth,
+					Permission:  tt.permission,
+				}
+			})
+			s := newTestService(t, HTTPConfig{EnableMetrics: true}, p)
+
+			var header http.Header
+			if tt.user != "" {
+				header = bearer(issueToken(t, p, tt.user, tt.role))
+			}
+			if rec := do(s, http.MethodGet, tt.path, nil, header); rec.Code != tt.want {
+				t.Errorf("GET %s: status %d, want %d", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusHistogramExposition(t *testing.T) {
+	p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+		cfg.Metrics.Enabled = true
+		cfg.Metrics.HistogramBuckets = []float64{10, 100}
+	})
+	latency := p.Metrics().Histogram("latency_ms")
+	for _, v := range []float64{1, 5, 50, 500} {
+		latency.Observe(v)
+	}
+	s := newTestService(t, HTTPConfig{EnableMetrics: true}, p)
+
+	rec := do(s, http.MethodGet, platform.DefaultMetricsEndpoint, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE npl_histogram histogram",
+		`npl_histogram_bucket{metric="latency_ms",le="10"} 2`,
+		`npl_histogram_bucket{metric="latency_ms",le="100"} 3`,
+		`npl_histogram_bucket{metric="latency_ms",le="+Inf"} 4`,
+		`npl_histogram_sum{metric="latency_ms"} 556`,
+		`npl_histogram_count{metric="latency_ms"} 4`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("exposition is missing %q", line)
+		}
+	}
+}
+
+func TestResetMetricsEndpoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		roles  []string
+		query  string
+		status int
+		// zeroed lists the counters expected to read zero afterwards
+		zeroed map[string]bool
+	}{
+		{"anonymous", nil, "", http.StatusUnauthorized, nil},
+		{"not an admin", []string{"operator"}, "", http.StatusForbidden, nil},
+		{"everything", []string{"admin"}, "", http.StatusOK, map[string]bool{"uploads": true, "downloads": true}},
+		{"one metric", []string{"admin"}, "?metric=uploads", http.StatusOK, map[string]bool{"uploads": true}},
+		{"several metrics", []string{"admin"}, "?metric=uploads&metric=downloads", http.StatusOK, map[string]bool{"uploads": true, "downloads": true}},
+		{"unknown metric", []string{"admin"}, "?metric=nope", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Metrics.Enabled = true
+			})
+			s := newTestService(t, HTTPConfig{EnableMetrics: true}, p)
+			uploads, downloads := p.Metrics().Counter("uploads"), p.Metrics().Counter("downloads")
+			uploads.Add(4)
+			downloads.Add(2)
+
+			var header http.Header
+			if tt.roles != nil {
+				header = bearer(issueToken(t, p, "alice", tt.roles...))
+			}
+			rec := do(s, http.MethodDelete, platform.DefaultMetricsEndpoint+tt.query, nil, header)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			for name, counter := range map[string]interface{ Get() float64 }{"uploads": uploads, "downloads": downloads} {
+				if zeroed := counter.Get() == 0; zeroed != tt.zeroed[name] {
+					t.Errorf("%s = %v, want zeroed = %v", name, counter.Get(), tt.zeroed[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeMethods lists the methods registered for one route pattern
+type routeMethods struct {
+	segments []string
+	methods  []string
+}
+
+// buildRouteTable indexes the registered routes by pattern so OPTIONS
+// requests can report the methods a path actually supports
+func (s *HTTPService) buildRouteTable() {
+	byPath := make(map[string][]string)
+	for _, route := range s.router.Routes() {
+		byPath[route.Path] = append(byPath[route.Path], route.Method)
+	}
+
+	table := make([]routeMethods, 0, len(byPath))
+	for path, methods := range byPath {
+		table = append(table, routeMethods{segments: splitPath(path), methods: methods})
+	}
+	s.routes = table
+}
+
+// allowedMethods returns the methods registered for paths matching path,
+// plus OPTIONS, sorted. It returns nil if no route matches.
+func (s *HTTPService) allowedMethods(path string) []string {
+	segments := splitPath(path)
+
+	set := make(map[string]bool)
+	for _, route := range s.routes {
+		if matchSegments(route.segments, segments) {
+			for _, method := range route.methods {
+				set[method] = true
+			}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	set[http.MethodOptions] = true
+
+	methods := make([]string, 0, len(set))
+	for method := range set {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// optionsMiddleware answers OPTIONS requests with the methods allowed for the
+// requested path in both the Allow and CORS headers
+func (s *HTTPService) optionsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		methods := s.allowedMethods(c.Request.URL.Path)
+		if methods == nil {
+			if s.config.EnableCORS {
+				// Keep answering preflights for unknown paths
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		allow := strings.Join(methods, ", ")
+		c.Header("Allow", allow)
+		// Only for origins corsMiddleware accepted
+		if c.Writer.Header().Get("Access-Control-Allow-Origin") != "" {
+			c.Header("Access-Control-Allow-Methods", allow)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matchSegments reports whether a request path matches a gin route pattern,
+// honoring :param and *catchAll segments
+func matchSegments(pattern, path []string) bool {
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(path) {
+			return false
+		}
+		if !strings.HasPrefix(segment, ":") && segment != path[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(path)
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestOptionsAllowedMethods(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		allow string
+	}{
+		{"get and post", "/api/resources", "GET, OPTIONS, POST"},
+		{"route parameter", "/api/resources/abc", "DELETE, GET, OPTIONS"},
+		{"nested route parameter", "/api/resources/abc/stream", "GET, OPTIONS"},
+		{"trailing slash", "/api/resources/", "GET, OPTIONS, POST"},
+		{"unknown path", "/api/nothing/here", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, HTTPConfig{}, nil)
+			rec := do(s, http.MethodOptions, tt.path, nil, nil)
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+			if tt.allow != "" && rec.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+			}
+		})
+	}
+}
+
+func TestOptionsPreflight(t *testing.T) {
+	s := newTestService(t, HTTPConfig{
+		EnableCORS:         true,
+		CORSAllowedOrigins: []string{"https://app.example"},
+	}, nil)
+
+	tests := []struct {
+		name         string
+		origin       string
+		path         string
+		allowMethods string
+	}{
+		{"allowed origin", "https://app.example", "/api/resources", "GET, OPTIONS, POST"},
+		{"disallowed origin", "https://evil.example", "/api/resources", ""},
+		// Preflights for unknown paths are still answered
+		{"unknown path", "https://app.example", "/api/nothing/here", "GET, POST, PUT, DELETE, OPTIONS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodOptions, tt.path, nil, http.Header{"Origin": {tt.origin}})
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.allowMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.allowMethods)
+			}
+		})
+	}
+}
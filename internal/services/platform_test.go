@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+func TestReloadEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		// role is the caller's role, or empty for no token
+		role         string
+		edit         func(*platform.PlatformConfig)
+		loadErr      error
+		wantStatus   int
+		wantMaxPeers int
+	}{
+		{"no token", "", nil, nil, http.StatusUnauthorized, 0},
+		{"operator", "operator", nil, nil, http.StatusForbidden, 0},
+		{"admin", "admin", func(c *platform.PlatformConfig) { c.Network.MaxPeers = 5 }, nil, http.StatusOK, 5},
+		{"invalid config", "admin", func(c *platform.PlatformConfig) { c.Network.Port = -1 }, nil, http.StatusBadRequest, 0},
+		{"loader failure", "admin", nil, errors.New("disk unavailable"), http.StatusInternalServerError, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p := newTestPlatform(t, nil)
+			loads := 0
+			p.SetConfigLoader(func() (*platform.PlatformConfig, error) {
+				loads++
+				if tt.loadErr != nil {
+					return nil, tt.loadErr
+				}
+				config := *p.Config()
+				if tt.edit != nil {
+					tt.edit(&config)
+				}
+				return &config, nil
+			})
+			if err := p.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			defer p.Stop(ctx)
+			s := newTestService(t, HTTPConfig{}, p)
+
+			var header http.Header
+			if tt.role != "" {
+				header = bearer(issueToken(t, p, "user", tt.role))
+			}
+			rec := do(s, http.MethodPost, "/api/platform/reload", nil, header)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusUnauthorized || tt.wantStatus == http.StatusForbidden {
+				if loads != 0 {
+					t.Errorf("config loaded %d times by a rejected caller", loads)
+				}
+				return
+			}
+			if loads != 1 {
+				t.Errorf("config loaded %d times, want once", loads)
+			}
+			if got := p.Config().Network.MaxPeers; got != tt.wantMaxPeers {
+				t.Errorf("MaxPeers after reload = %d, want %d", got, tt.wantMaxPeers)
+			}
+		})
+	}
+}
+
+// exportConfig fetches the config bundle with token
+func exportConfig(t *testing.T, s *HTTPService, token string) (*platform.PlatformConfig, bool) {
+	t.Helper()
+	rec := do(s, http.MethodGet, "/api/platform/config/export", nil, bearer(token))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status %d: %s", rec.Code, rec.Body)
+	}
+	var bundle struct {
+		Config   *platform.PlatformConfig `json:"config"`
+		Redacted bool                     `json:"redacted"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	return bundle.Config, bundle.Redacted
+}
+
+func TestConfigExportImportRoundTrip(t *testing.T) {
+	source := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+		cfg.Network.MaxPeers = 9
+		cfg.Security.TrustedProxies = []string{"10.0.0.0/8"}
+	})
+	exported, redacted := exportConfig(t, newTestService(t, HTTPConfig{}, source), issueToken(t, source, "root", "admin"))
+	if redacted || exported.Security.JWTSecret != testSecret {
+		t.Fatalf("admin export redacted = %v, secret %q", redacted, exported.Security.JWTSecret)
+	}
+
+	target := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, target)
+	admin := issueToken(t, target, "root", "admin")
+	if rec := do(s, http.MethodPost, "/api/platform/config/import", map[string]interface{}{"config": exported}, bearer(admin)); rec.Code != http.StatusOK {
+		t.Fatalf("import status %d: %s", rec.Code, rec.Body)
+	}
+
+	roundTripped, _ := exportConfig(t, s, admin)
+	if !reflect.DeepEqual(roundTripped, exported) {
+		t.Errorf("config after round trip = %+v, want %+v", roundTripped, exported)
+	}
+}
+
+func TestConfigExportImportPermissions(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	operator := issueToken(t, p, "op", "operator")
+	admin := issueToken(t, p, "root", "admin")
+
+	// Callers without platform:admin get the secret redacted
+	exported, redacted := exportConfig(t, s, operator)
+	if !redacted || exported.Security.JWTSecret != platform.RedactedValue {
+		t.Fatalf("operator export redacted = %v, secret %q", redacted, exported.Security.JWTSecret)
+	}
+
+	invalid := *exported
+	invalid.Network.Port = -1
+	tests := []struct {
+		name   string
+		token  string
+		config *platform.PlatformConfig
+		want   int
+	}{
+		{"operator", operator, exported, http.StatusForbidden},
+		{"invalid config", admin, &invalid, http.StatusBadRequest},
+		{"no config", admin, nil, http.StatusBadRequest},
+		// A redacted bundle keeps the current secret
+		{"redacted bundle", admin, exported, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/platform/config/import", map[string]interface{}{"config": tt.config}, bearer(tt.token))
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if got := p.Config().Security.JWTSecret; got != testSecret {
+				t.Errorf("JWTSecret after import = %q, want it unchanged", got)
+			}
+		})
+	}
+}
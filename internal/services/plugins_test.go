@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/plugins"
+)
+
+// routePlugin is a plugin depending on deps, serving routes and reporting
+// status as its health
+type routePlugin struct {
+	id     string
+	deps   []string
+	routes []core.Route
+	status string
+}
+
+func (rp *routePlugin) Start(context.Context) error            { return nil }
+func (rp *routePlugin) Stop(context.Context) error             { return nil }
+func (rp *routePlugin) IsHealthy() bool                        { return true }
+func (rp *routePlugin) Name() string                           { return rp.id }
+func (rp *routePlugin) Health() core.HealthStatus              { return core.HealthStatus{Status: rp.status} }
+func (rp *routePlugin) Configuration() core.ConfigSchema       { return core.ConfigSchema{} }
+func (rp *routePlugin) ID() string                             { return rp.id }
+func (rp *routePlugin) Version() string                        { return "1.0.0" }
+func (rp *routePlugin) Dependencies() []string                 { return rp.deps }
+func (rp *routePlugin) Initialize(core.PlatformAPI) error      { return nil }
+func (rp *routePlugin) Configure(map[string]interface{}) error { return nil }
+func (rp *routePlugin) Routes() []core.Route                   { return rp.routes }
+func (rp *routePlugin) HandleEvent(core.Event) error           { return nil }
+
+func TestGetPluginRoutes(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	plugin := &routePlugin{id: "four", routes: []core.Route{
+		{Method: http.MethodGet, Path: "/items", Handler: ok, Description: "List items"},
+		{Method: http.MethodPost, Path: "/items", Handler: ok, Auth: core.AuthRequirement{Required: true, Permissions: []string{"items:create"}}},
+		{Method: http.MethodDelete, Path: "/items/:id", Handler: ok, Auth: core.AuthRequirement{Required: true, Roles: []string{"admin"}}},
+		{Method: http.MethodGet, Path: "/status", Handler: ok, Middleware: []func(http.Handler) http.Handler{func(h http.Handler) http.Handler { return h }}},
+	}}
+	p := newTestPlatform(t, nil)
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	s := newTestService(t, HTTPConfig{}, p)
+
+	type route struct {
+		Method      string
+		Path        string
+		Auth        core.AuthRequirement
+		Description string
+	}
+	type mountedRoute struct {
+		Method       string   `json:"method"`
+		Path         string   `json:"path"`
+		AuthRequired bool     `json:"authRequired"`
+		Permissions  []string `json:"permissions"`
+		Roles        []string `json:"roles"`
+	}
+	type manifest struct {
+		ID     string         `json:"id"`
+		Routes []mountedRoute `json:"routes"`
+	}
+
+	rec := do(s, http.MethodGet, "/api/plugins/four", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got struct {
+		Routes   []map[string]json.RawMessage `json:"routes"`
+		Manifest manifest                     `json:"manifest"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// routes keeps the shape of core.Route
+	if len(got.Routes) != len(plugin.routes) {
+		t.Fatalf("got %d routes, want %d", len(got.Routes), len(plugin.routes))
+	}
+	for i, raw := range got.Routes {
+		for _, key := range []string{"Method", "Path", "Auth", "Description"} {
+			if _, ok := raw[key]; !ok {
+				t.Errorf("route %d has no %q field: %v", i, key, raw)
+			}
+		}
+		for _, key := range []string{"Handler", "Middleware"} {
+			if _, ok := raw[key]; ok {
+				t.Errorf("route %d has a %q field", i, key)
+			}
+		}
+		data, _ := json.Marshal(raw)
+		var r route
+		json.Unmarshal(data, &r)
+		want := plugin.routes[i]
+		if r.Method != want.Method || r.Path != want.Path || r.Description != want.Description || !reflect.DeepEqual(r.Auth, want.Auth) {
+			t.Errorf("route %d = %+v, want %+v", i, r, want)
+		}
+	}
+
+	// The manifest lists the routes as mounted
+	tests := []mountedRoute{
+		{http.MethodGet, "/plugins/four/items", false, nil, nil},
+		{http.MethodPost, "/plugins/four/items", true, []string{"items:create"}, nil},
+		{http.MethodDelete, "/plugins/four/items/:id", true, nil, []string{"admin"}},
+		{http.MethodGet, "/plugins/four/status", false, nil, nil},
+	}
+	if len(got.Manifest.Routes) != len(tests) {
+		t.Fatalf("manifest has %d routes, want %d", len(got.Manifest.Routes), len(tests))
+	}
+	for i, want := range tests {
+		if !reflect.DeepEqual(got.Manifest.Routes[i], want) {
+			t.Errorf("manifest route %d = %+v, want %+v", i, got.Manifest.Routes[i], want)
+		}
+	}
+
+	// and matches the manifest endpoint
+	rec = do(s, http.MethodGet, "/api/plugins/four/manifest", nil, nil)
+	var fromEndpoint manifest
+	if err := json.Unmarshal(rec.Body.Bytes(), &fromEndpoint); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if !reflect.DeepEqual(fromEndpoint, got.Manifest) {
+		t.Errorf("manifest endpoint = %+v, want %+v", fromEndpoint, got.Manifest)
+	}
+}
+
+func TestFileManagerManifest(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	plugin := plugins.NewFileManagerPlugin(t.TempDir(), t.TempDir(), 0)
+	if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	s := newTestService(t, HTTPConfig{}, p)
+
+	rec := do(s, http.MethodGet, "/api/plugins/file-manager/manifest", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got struct {
+		ID     string `json:"id"`
+		Routes []struct {
+			Method string `json:"method"`
+			Path   string `json:"path"`
+		} `json:"routes"`
+		Events []string         `json:"events"`
+		Config json.RawMessage  `json:"config"`
+		State  core.PluginState `json:"state"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.ID != "file-manager" {
+		t.Errorf("id = %q, want file-manager", got.ID)
+	}
+	if len(got.Routes) != len(plugin.Routes()) {
+		t.Errorf("manifest has %d routes, want %d", len(got.Routes), len(plugin.Routes()))
+	}
+	mounted := make(map[string]bool)
+	for _, route := range got.Routes {
+		mounted[route.Method+" "+route.Path] = true
+	}
+	for _, want := range []string{
+		"GET /plugins/file-manager/files",
+		"POST /plugins/file-manager/files",
+		"GET /plugins/file-manager/files/:filename",
+		"DELETE /plugins/file-manager/files/:filename",
+	} {
+		if !mounted[want] {
+			t.Errorf("manifest does not list %s: %+v", want, got.Routes)
+		}
+	}
+	if got.Events == nil || len(got.Events) != 0 {
+		t.Errorf("events = %v, want an empty list", got.Events)
+	}
+	if len(got.Config) == 0 || string(got.Config) == "null" {
+		t.Error("manifest has no config schema")
+	}
+	// The platform hasn't started, so neither has the plugin
+	if got.State != core.PluginStateInitialized {
+		t.Errorf("state = %q, want %q", got.State, core.PluginStateInitialized)
+	}
+
+	if rec := do(s, http.MethodGet, "/api/plugins/none/manifest", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown plugin: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestUnloadPluginEndpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		role         string
+		wantStatus   int
+		wantUnloaded []string
+	}{
+		{"no token", "/api/plugins/base/unload?cascade=true", "", http.StatusUnauthorized, nil},
+		{"missing permission", "/api/plugins/base/unload?cascade=true", "viewer", http.StatusForbidden, nil},
+		{"unknown plugin", "/api/plugins/none/unload", "operator", http.StatusNotFound, nil},
+		{"dependents without cascade", "/api/plugins/base/unload", "operator", http.StatusConflict, nil},
+		{"leaf without cascade", "/api/plugins/leaf/unload", "operator", http.StatusOK, []string{"leaf"}},
+		{"cascade", "/api/plugins/base/unload?cascade=true", "operator", http.StatusOK, []string{"leaf", "middle", "base"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			// base <- middle <- leaf
+			for _, plugin := range []*routePlugin{
+				{id: "base"},
+				{id: "middle", deps: []string{"base"}},
+				{id: "leaf", deps: []string{"middle"}},
+			} {
+				if err := p.LoadPlugin(context.Background(), plugin); err != nil {
+					t.Fatalf("LoadPlugin: %v", err)
+				}
+			}
+			s := newTestService(t, HTTPConfig{}, p)
+
+			var header http.Header
+			if tt.role != "" {
+				header = bearer(issueToken(t, p, "user", tt.role))
+			}
+			rec := do(s, http.MethodPost, tt.path, nil, header)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				var got struct {
+					Unloaded []string `json:"unloaded"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if !slices.Equal(got.Unloaded, tt.wantUnloaded) {
+					t.Errorf("unloaded %v, want %v", got.Unloaded, tt.wantUnloaded)
+				}
+			}
+			for _, id := range []string{"base", "middle", "leaf"} {
+				_, err := p.GetPlugin(id)
+				if loaded := err == nil; loaded == slices.Contains(tt.wantUnloaded, id) {
+					t.Errorf("plugin %s loaded = %v", id, loaded)
+				}
+			}
+		})
+	}
+}
+
+// displayNamePlugin is a routePlugin whose display name differs from its ID
+type displayNamePlugin struct {
+	*routePlugin
+	name string
+}
+
+func (dp *displayNamePlugin) Name() string { return dp.name }
+
+func TestPluginIDsAndNames(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	if err := p.LoadPlugin(context.Background(), &displayNamePlugin{&routePlugin{id: "file-manager"}, "File Manager Plugin"}); err != nil {
+		t.Fatalf("LoadPlugin: %v", err)
+	}
+	s := newTestService(t, HTTPConfig{}, p)
+
+	rec := do(s, http.MethodGet, "/api/plugins", nil, nil)
+	var list struct {
+		Plugins []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"plugins"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if len(list.Plugins) != 1 || list.Plugins[0].ID != "file-manager" || list.Plugins[0].Name != "File Manager Plugin" {
+		t.Errorf("listed %+v", list.Plugins)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/plugins/file-manager", http.StatusOK},
+		{"/api/plugins/" + url.PathEscape("File Manager Plugin"), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := do(s, http.MethodGet, tt.path, nil, nil)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: status %d, want %d", tt.path, rec.Code, tt.want)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var got struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body, err)
+		}
+		if got.ID != "file-manager" || got.Name != "File Manager Plugin" {
+			t.Errorf("GET %s = %+v", tt.path, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+func TestRateLimitTrustsOnlyConfiguredProxies(t *testing.T) {
+	// httptest requests come from 192.0.2.1
+	tests := []struct {
+		name    string
+		proxies []string
+		want    []int
+	}{
+		{"no trusted proxies", nil, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{"other proxy trusted", []string{"10.0.0.0/8"}, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{"peer trusted", []string{"192.0.2.1"}, []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"peer's range trusted", []string{"192.0.2.0/24"}, []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Security.TrustedProxies = tt.proxies
+			})
+			// One request per second with bursts of two
+			s := newTestService(t, HTTPConfig{RateLimitRPS: 1}, p)
+
+			// Each request claims to be forwarded for another client
+			for i, forwarded := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+				rec := do(s, http.MethodGet, "/healthz", nil, http.Header{"X-Forwarded-For": {forwarded}})
+				if rec.Code != tt.want[i] {
+					t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, tt.want[i])
+				}
+			}
+		})
+	}
+}
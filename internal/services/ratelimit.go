@@ -0,0 +1,93 @@
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimitIdleTTL is how long a client's bucket is kept after its last request
+const rateLimitIdleTTL = 5 * time.Minute
+
+// rateLimiter is a per-client token bucket. Each client may make rps requests
+// per second on average, with bursts of up to burst requests.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rps     float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	stop    chan struct{}
+	once    sync.Once
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// newRateLimiter creates a limiter allowing rps requests per second with the
+// given burst, and starts a sweeper that evicts idle clients
+func newRateLimiter(rps, burst int) *rateLimiter {
+	if burst < rps {
+		burst = rps
+	}
+	l := &rateLimiter{
+		rps:     float64(rps),
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		stop:    make(chan struct{}),
+	}
+	go l.sweep(rateLimitIdleTTL)
+	return l
+}
+
+// allow takes a token from key's bucket. When the bucket is empty it returns
+// false and how long until a token is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = bucket
+	} else {
+		elapsed := now.Sub(bucket.lastSeen).Seconds()
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.rps)
+		bucket.lastSeen = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / l.rps * float64(time.Second))
+	return false, wait
+}
+
+// sweep periodically removes buckets that have been idle for longer than ttl
+func (l *rateLimiter) sweep(ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			for key, bucket := range l.buckets {
+				if now.Sub(bucket.lastSeen) > ttl {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// close stops the sweeper
+func (l *rateLimiter) close() {
+	l.once.Do(func() { close(l.stop) })
+}
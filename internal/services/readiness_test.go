@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name string
+		// statuses are the health of the loaded plugins by ID
+		statuses map[string]string
+		// failed plugins were skipped at autoload
+		failed     []string
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name:       "all healthy",
+			statuses:   map[string]string{"core": core.HealthStatusHealthy, "needed": core.HealthStatusHealthy, "extra": core.HealthStatusHealthy},
+			wantCode:   http.StatusOK,
+			wantStatus: core.HealthStatusHealthy,
+		},
+		{
+			name:       "core plugin unhealthy",
+			statuses:   map[string]string{"core": core.HealthStatusUnhealthy, "needed": core.HealthStatusHealthy, "extra": core.HealthStatusHealthy},
+			wantCode:   http.StatusOK,
+			wantStatus: core.HealthStatusDegraded,
+		},
+		{
+			name:       "core plugin failed to load",
+			statuses:   map[string]string{"needed": core.HealthStatusHealthy, "extra": core.HealthStatusHealthy},
+			failed:     []string{"core"},
+			wantCode:   http.StatusOK,
+			wantStatus: core.HealthStatusDegraded,
+		},
+		{
+			name:       "required plugin unhealthy",
+			statuses:   map[string]string{"core": core.HealthStatusHealthy, "needed": core.HealthStatusUnhealthy, "extra": core.HealthStatusHealthy},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: core.HealthStatusUnhealthy,
+		},
+		{
+			name:       "required plugin degraded",
+			statuses:   map[string]string{"core": core.HealthStatusHealthy, "needed": core.HealthStatusDegraded, "extra": core.HealthStatusHealthy},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: core.HealthStatusUnhealthy,
+		},
+		{
+			name:       "required plugin not loaded",
+			statuses:   map[string]string{"core": core.HealthStatusHealthy, "extra": core.HealthStatusHealthy},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: core.HealthStatusUnhealthy,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, func(cfg *platform.PlatformConfig) {
+				cfg.Plugins.AutoLoad = []string{"core"}
+				cfg.Plugins.Required = []string{"needed"}
+			})
+			for id, status := range tt.statuses {
+				if err := p.LoadPlugin(context.Background(), &routePlugin{id: id, status: status}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, id := range tt.failed {
+				p.RecordPluginFailure(id, errors.New("broken"))
+			}
+			if err := p.Start(context.Background()); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			t.Cleanup(func() { p.Stop(context.Background()) })
+
+			rec := do(newTestService(t, HTTPConfig{}, p), http.MethodGet, "/readyz", nil, nil)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			var body struct {
+				Status string                          `json:"status"`
+				Checks map[string]core.ComponentHealth `json:"checks"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.wantStatus {
+				t.Errorf("readiness = %s, want %s", body.Status, tt.wantStatus)
+			}
+			// Only core and required plugins are checked
+			if _, ok := body.Checks["extra"]; ok || len(body.Checks) != 2 {
+				t.Errorf("checks = %v, want core and needed", body.Checks)
+			}
+			for _, id := range tt.failed {
+				if reason := body.Checks[id].Error; reason != "broken" {
+					t.Errorf("%s check error = %q, want the load failure", id, reason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name   string
+		port   int
+		target string
+		want   string
+	}{
+		{"default HTTPS port", 443, "http://example.com:8080/api/resources?limit=5", "https://example.com/api/resources?limit=5"},
+		{"custom HTTPS port", 8443, "http://example.com:8080/healthz", "https://example.com:8443/healthz"},
+		{"host without port", 8443, "http://example.com/", "https://example.com:8443/"},
+		{"IPv6 host", 8443, "http://[::1]:8080/x", "https://[::1]:8443/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &HTTPService{config: HTTPConfig{Port: tt.port, EnableTLS: true, RedirectHTTP: true}}
+			rec := httptest.NewRecorder()
+			s.redirectToHTTPS(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusMovedPermanently {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+			}
+			if got := rec.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+// withRequestID returns a header carrying the request ID id
+func withRequestID(id string) http.Header {
+	header := http.Header{}
+	header.Set(core.RequestIDHeader, id)
+	return header
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	s := newTestService(t, HTTPConfig{}, nil)
+	tests := []struct {
+		name    string
+		inbound string
+		// wantEcho is whether the inbound ID comes back as is
+		wantEcho bool
+	}{
+		{"supplied", "client-abc-123", true},
+		{"none", "", false},
+		{"too long", strings.Repeat("x", 200), false},
+		{"not printable", "bad id", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			if tt.inbound != "" {
+				header = withRequestID(tt.inbound)
+			}
+			rec := do(s, http.MethodGet, "/healthz", nil, header)
+			got := rec.Header().Get(core.RequestIDHeader)
+			if got == "" {
+				t.Fatal("no request ID on the response")
+			}
+			if echoed := got == tt.inbound; echoed != tt.wantEcho {
+				t.Errorf("response ID = %q for inbound %q, want echoed = %v", got, tt.inbound, tt.wantEcho)
+			}
+			if !core.ValidRequestID(got) {
+				t.Errorf("response ID %q is not valid", got)
+			}
+		})
+	}
+
+	// Generated IDs differ between requests, including unmatched routes
+	first := do(s, http.MethodGet, "/healthz", nil, nil).Header().Get(core.RequestIDHeader)
+	second := do(s, http.MethodGet, "/no/such/route", nil, nil).Header().Get(core.RequestIDHeader)
+	if first == second || second == "" {
+		t.Errorf("request IDs %q and %q, want two distinct IDs", first, second)
+	}
+}
+
+func TestRequestIDLogged(t *testing.T) {
+	var logs bytes.Buffer
+	gin.DefaultWriter = &logs
+	t.Cleanup(func() { gin.DefaultWriter = io.Discard })
+	s := newTestService(t, HTTPConfig{}, nil)
+
+	do(s, http.MethodGet, "/healthz", nil, withRequestID("log-me-42"))
+	if !strings.Contains(logs.String(), "request_id=log-me-42\n") {
+		t.Errorf("access log %q does not carry the request ID", logs.String())
+	}
+}
+
+func TestRequestIDInPublishedEvents(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { p.Stop(context.Background()) })
+	s := newTestService(t, HTTPConfig{}, p)
+	events := make(chan core.Event, 1)
+	if _, err := p.EventBus().Subscribe("test.tagged", func(event core.Event) error {
+		events <- event
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := do(s, http.MethodPost, "/api/events/publish",
+		map[string]interface{}{"type": "test.tagged", "data": map[string]interface{}{"n": 1}},
+		withRequestID("req-publish"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("publish status %d: %s", rec.Code, rec.Body)
+	}
+	select {
+	case event := <-events:
+		if got := event.Data[core.RequestIDDataKey]; got != "req-publish" {
+			t.Errorf("event request ID = %v, want req-publish", got)
+		}
+		if event.Data["n"] != float64(1) {
+			t.Errorf("event data = %v", event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("published event not delivered")
+	}
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+)
+
+func TestStreamResourceRanges(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	resource := core.NewMemoryResource("digits", "memory", []byte("0123456789"),
+		map[string]interface{}{core.ResourceContentTypeKey: "text/plain"})
+	if err := p.ResourceManager().RegisterResource(resource); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		rangeHeader  string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"whole", "", http.StatusOK, "0123456789", ""},
+		{"slice", "bytes=2-5", http.StatusPartialContent, "2345", "bytes 2-5/10"},
+		{"open ended", "bytes=7-", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"suffix", "bytes=-3", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"past end", "bytes=20-30", http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.rangeHeader != "" {
+				header.Set("Range", tt.rangeHeader)
+			}
+			rec := do(s, http.MethodGet, "/api/resources/digits/stream", nil, header)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusRequestedRangeNotSatisfiable && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body, tt.body)
+			}
+			if got := rec.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if got := rec.Header().Get("Accept-Ranges"); tt.status != http.StatusRequestedRangeNotSatisfiable && got != "bytes" {
+				t.Errorf("Accept-Ranges = %q", got)
+			}
+		})
+	}
+}
+
+func TestStreamFileResourceRange(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	path := filepath.Join(p.Config().Storage.UploadDir, "clip.mp4")
+	if err := os.WriteFile(path, []byte("abcdefghij"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := do(s, http.MethodPost, "/api/resources",
+		map[string]interface{}{"id": "clip", "type": "file", "path": path},
+		bearer(issueToken(t, p, "root", "admin")))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = do(s, http.MethodGet, "/api/resources/clip/stream", nil, http.Header{"Range": {"bytes=3-4"}})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "de" {
+		t.Fatalf("got %d %q, want 206 \"de\"", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "video/mp4" {
+		t.Errorf("Content-Type = %q, want video/mp4", got)
+	}
+}
+
+func TestStreamResourceContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		declared    string
+		contentType string
+		attachment  bool
+	}{
+		{"plain text", "a", "text/plain; charset=utf-8", "text/plain; charset=utf-8", true},
+		{"image", "b", "image/png", "image/png", false},
+		{"video", "c", "video/mp4", "video/mp4", true},
+		{"html", "d", "text/html", "application/octet-stream", true},
+		{"svg", "e", "image/svg+xml", "application/octet-stream", true},
+		{"from id", "f.webp", "", "image/webp", false},
+		{"html id", "g.html", "", "application/octet-stream", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			s := newTestService(t, HTTPConfig{}, p)
+			meta := map[string]interface{}{}
+			if tt.declared != "" {
+				meta[core.ResourceContentTypeKey] = tt.declared
+			}
+			resource := core.NewMemoryResource(tt.id, "memory", []byte("<script>alert(1)</script>"), meta)
+			if err := p.ResourceManager().RegisterResource(resource); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := do(s, http.MethodGet, "/api/resources/"+tt.id+"/stream", nil, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q", got)
+			}
+			if got := rec.Header().Get("Content-Disposition") == "attachment"; got != tt.attachment {
+				t.Errorf("attachment = %v, want %v", got, tt.attachment)
+			}
+		})
+	}
+}
+
+// streamOnlyResource streams its content but can't be opened for range
+// requests, and reports size as its size
+type streamOnlyResource struct {
+	core.StreamableResource
+	size int64
+}
+
+func (r streamOnlyResource) GetSize() int64 { return r.size }
+
+// plainResource can't stream its own content
+type plainResource struct {
+	core.Resource
+}
+
+func TestStreamResourceContentLength(t *testing.T) {
+	digits := core.NewMemoryResource("digits", "memory", []byte("0123456789"), nil)
+	tests := []struct {
+		name          string
+		resource      core.Resource
+		contentLength int64
+		chunked       bool
+	}{
+		{"seekable", digits, 10, false},
+		{"streamable with size", streamOnlyResource{digits, 10}, 10, false},
+		{"streamable without size", streamOnlyResource{digits, -1}, -1, true},
+		{"not streamable", plainResource{digits}, -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			s := newTestService(t, HTTPConfig{}, p)
+			if err := p.ResourceManager().RegisterResource(tt.resource); err != nil {
+				t.Fatal(err)
+			}
+			// Streaming needs a real connection rather than a recorder
+			server := httptest.NewServer(s.router)
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/api/resources/digits/stream")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d", resp.StatusCode)
+			}
+			if resp.ContentLength != tt.contentLength {
+				t.Errorf("Content-Length = %d, want %d", resp.ContentLength, tt.contentLength)
+			}
+			if got := slices.Contains(resp.TransferEncoding, "chunked"); got != tt.chunked {
+				t.Errorf("chunked = %v, want %v", got, tt.chunked)
+			}
+		})
+	}
+}
+
+// endlessResource streams chunks until its stream is closed, counting the
+// reads
+type endlessResource struct {
+	core.Resource
+	reads  atomic.Int64
+	closed chan struct{}
+}
+
+func (r *endlessResource) GetSize() int64 { return -1 }
+
+func (r *endlessResource) Stream(ctx context.Context) (core.ResourceStream, error) {
+	return endlessStream{r}, nil
+}
+
+type endlessStream struct{ r *endlessResource }
+
+func (s endlessStream) Read() ([]byte, error) {
+	s.r.reads.Add(1)
+	return bytes.Repeat([]byte("x"), 32<<10), nil
+}
+
+func (s endlessStream) Close() error {
+	close(s.r.closed)
+	return nil
+}
+
+func TestStreamResourceStopsOnDisconnect(t *testing.T) {
+	resource := &endlessResource{
+		Resource: core.NewMemoryResource("endless", "memory", nil, nil),
+		closed:   make(chan struct{}),
+	}
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	if err := p.ResourceManager().RegisterResource(resource); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/resources/endless/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(resp.Body, make([]byte, 64<<10)); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case <-resource.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream still open after the client left, %d reads", resource.reads.Load())
+	}
+	// No reads happen once the handler has given up on the stream
+	reads := resource.reads.Load()
+	time.Sleep(50 * time.Millisecond)
+	if got := resource.reads.Load(); got != reads {
+		t.Errorf("stream read %d more times after it was closed", got-reads)
+	}
+}
+
+func TestListResources(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	for _, resource := range []core.Resource{
+		// The sample resource main registers
+		core.NewMemoryResource("mem-hello", "memory", []byte("hello"), map[string]interface{}{"name": "hello"}),
+		core.NewMemoryResource("mem-other", "memory", []byte("other"), map[string]interface{}{"name": "other"}),
+		core.NewMemoryResource("blob", "blob", nil, nil),
+	} {
+		if err := p.ResourceManager().RegisterResource(resource); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"no filter", "", []string{"blob", "mem-hello", "mem-other"}},
+		{"by type", "?type=memory", []string{"mem-hello", "mem-other"}},
+		{"by name", "?name=hello", []string{"mem-hello"}},
+		{"by name and type", "?name=hello&type=blob", []string{}},
+		{"no match", "?type=file", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodGet, "/api/resources"+tt.query, nil, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			var body struct {
+				Resources []struct {
+					ID string `json:"id"`
+				} `json:"resources"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			if body.Resources == nil {
+				t.Fatalf("resources is not a list: %s", rec.Body)
+			}
+			ids := []string{}
+			for _, resource := range body.Resources {
+				ids = append(ids, resource.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("listed %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestListResourcesByMetadata(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	for _, resource := range []core.Resource{
+		core.NewMemoryResource("hello-alice", "memory", nil, map[string]interface{}{"name": "hello", "owner": "alice", "lang": "en"}),
+		core.NewMemoryResource("hello-bob", "memory", nil, map[string]interface{}{"name": "hello", "owner": "bob", "lang": "fr"}),
+		core.NewMemoryResource("bye-alice", "memory", nil, map[string]interface{}{"name": "bye", "owner": "alice", "lang": "en"}),
+	} {
+		if err := p.ResourceManager().RegisterResource(resource); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?meta.name=hello", []string{"hello-alice", "hello-bob"}},
+		{"?meta.lang=en", []string{"bye-alice", "hello-alice"}},
+		{"?meta.name=hello&meta.lang=fr", []string{"hello-bob"}},
+		{"?owner=alice", []string{"bye-alice", "hello-alice"}},
+		{"?owner=alice&meta.name=hello", []string{"hello-alice"}},
+		{"?meta.lang=de", []string{}},
+		{"?meta.missing=x", []string{}},
+		// A bare prefix isn't a metadata filter
+		{"?meta.=x", []string{"bye-alice", "hello-alice", "hello-bob"}},
+	}
+	for _, tt := range tests {
+		rec := do(s, http.MethodGet, "/api/resources"+tt.query, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.query, rec.Code, rec.Body)
+		}
+		var body struct {
+			Resources []struct {
+				ID string `json:"id"`
+			} `json:"resources"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode %s: %v", tt.query, rec.Body, err)
+		}
+		ids := []string{}
+		for _, resource := range body.Resources {
+			ids = append(ids, resource.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: listed %v, want %v", tt.query, ids, tt.want)
+		}
+	}
+}
+
+func TestListResourcesPaging(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+	// r0 to r5, owned alternately by alice and bob, the last two blobs
+	for i, owner := range []string{"alice", "bob", "alice", "bob", "alice", "bob"} {
+		kind := "memory"
+		if i >= 4 {
+			kind = "blob"
+		}
+		resource := core.NewMemoryResource(fmt.Sprintf("r%d", i), kind, nil, map[string]interface{}{"owner": owner})
+		if err := p.ResourceManager().RegisterResource(resource); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		want   []string
+		total  int
+	}{
+		{"", http.StatusOK, []string{"r0", "r1", "r2", "r3", "r4", "r5"}, 6},
+		{"?limit=2", http.StatusOK, []string{"r0", "r1"}, 6},
+		{"?limit=2&offset=2", http.StatusOK, []string{"r2", "r3"}, 6},
+		{"?limit=10&offset=4", http.StatusOK, []string{"r4", "r5"}, 6},
+		{"?offset=6", http.StatusOK, []string{}, 6},
+		{"?offset=100", http.StatusOK, []string{}, 6},
+		{"?limit=0", http.StatusOK, []string{"r0", "r1", "r2", "r3", "r4", "r5"}, 6},
+		{"?type=memory", http.StatusOK, []string{"r0", "r1", "r2", "r3"}, 4},
+		{"?owner=bob", http.StatusOK, []string{"r1", "r3", "r5"}, 3},
+		{"?owner=alice&type=memory&limit=1&offset=1", http.StatusOK, []string{"r2"}, 2},
+		{"?limit=-1", http.StatusBadRequest, nil, 0},
+		{"?offset=x", http.StatusBadRequest, nil, 0},
+	}
+	for _, tt := range tests {
+		rec := do(s, http.MethodGet, "/api/resources"+tt.query, nil, nil)
+		if rec.Code != tt.status {
+			t.Fatalf("%s: status %d, want %d: %s", tt.query, rec.Code, tt.status, rec.Body)
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Resources []struct {
+				ID string `json:"id"`
+			} `json:"resources"`
+			Total int `json:"total"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode %s: %v", tt.query, rec.Body, err)
+		}
+		ids := []string{}
+		for _, resource := range body.Resources {
+			ids = append(ids, resource.ID)
+		}
+		if !slices.Equal(ids, tt.want) || body.Total != tt.total {
+			t.Errorf("%s: listed %v of %d, want %v of %d", tt.query, ids, body.Total, tt.want, tt.total)
+		}
+	}
+}
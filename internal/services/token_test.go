@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+func TestIssueTokenRequiresAdmin(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   map[string]interface{}
+		want   int
+	}{
+		{"no token", nil, map[string]interface{}{"userId": "mallory", "roles": []string{"admin"}}, http.StatusUnauthorized},
+		{"operator", bearer(issueToken(t, p, "op", "operator")), map[string]interface{}{"userId": "op", "roles": []string{"admin"}}, http.StatusForbidden},
+		{"admin", bearer(issueToken(t, p, "root", "admin")), map[string]interface{}{"userId": "alice", "roles": []string{"operator"}}, http.StatusOK},
+		{"unknown role", bearer(issueToken(t, p, "root", "admin")), map[string]interface{}{"userId": "alice", "roles": []string{"superuser"}}, http.StatusBadRequest},
+		{"missing subject", bearer(issueToken(t, p, "root", "admin")), map[string]interface{}{"roles": []string{"operator"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/platform/token", tt.body, tt.header)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestIssueTokenNotAuthExempt(t *testing.T) {
+	for _, path := range DefaultAuthExemptPaths {
+		if path == "/api/platform/token" {
+			t.Fatal("token issuing endpoint is auth-exempt by default")
+		}
+	}
+
+	s := newTestService(t, HTTPConfig{EnableAuth: true}, nil)
+	rec := do(s, http.MethodPost, "/api/platform/token", map[string]interface{}{"userId": "mallory"}, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestIssuedTokenCarriesRolePermissions(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+
+	rec := do(s, http.MethodPost, "/api/platform/token",
+		map[string]interface{}{"userId": "alice", "roles": []string{"operator"}},
+		bearer(issueToken(t, p, "root", "admin")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var pair struct {
+		AccessToken string `json:"token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &pair); err != nil || pair.AccessToken == "" {
+		t.Fatalf("no access token in %s", rec.Body)
+	}
+
+	// An operator may start plugins but not issue tokens
+	rec = do(s, http.MethodPost, "/api/plugins/missing/start", nil, bearer(pair.AccessToken))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("start status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	rec = do(s, http.MethodPost, "/api/platform/token",
+		map[string]interface{}{"userId": "alice", "roles": []string{"admin"}},
+		bearer(pair.AccessToken))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("issue status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestIssuedTokenCarriesPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       []string
+		permissions []string
+		want        int
+	}{
+		{"granted permission", nil, []string{"plugins:start"}, http.StatusOK},
+		{"other permission", nil, []string{"plugins:stop"}, http.StatusForbidden},
+		{"granted by role", []string{"operator"}, nil, http.StatusOK},
+		{"nothing granted", nil, nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			if err := p.LoadPlugin(context.Background(), &routePlugin{id: "widgets", status: core.HealthStatusHealthy}); err != nil {
+				t.Fatalf("LoadPlugin: %v", err)
+			}
+			s := newTestService(t, HTTPConfig{}, p)
+
+			rec := do(s, http.MethodPost, "/api/platform/token",
+				map[string]interface{}{"userId": "alice", "roles": tt.roles, "permissions": tt.permissions},
+				bearer(issueToken(t, p, "root", "admin")))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("issue status = %d: %s", rec.Code, rec.Body)
+			}
+			var pair core.TokenPair
+			if err := json.Unmarshal(rec.Body.Bytes(), &pair); err != nil || pair.AccessToken == "" {
+				t.Fatalf("no access token in %s", rec.Body)
+			}
+
+			rec = do(s, http.MethodPost, "/api/plugins/widgets/start", nil, bearer(pair.AccessToken))
+			if rec.Code != tt.want {
+				t.Fatalf("start status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare may use or revoke the pair before the refresh and returns
+		// the refresh request body
+		prepare func(t *testing.T, s *HTTPService, pair *core.TokenPair) interface{}
+		want    int
+	}{
+		{"valid", func(t *testing.T, s *HTTPService, pair *core.TokenPair) interface{} {
+			return map[string]string{"refreshToken": pair.RefreshToken}
+		}, http.StatusOK},
+		{"revoked", func(t *testing.T, s *HTTPService, pair *core.TokenPair) interface{} {
+			rec := do(s, http.MethodPost, "/api/platform/token/revoke",
+				map[string]string{"token": pair.RefreshToken}, bearer(pair.AccessToken))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("revoke status = %d: %s", rec.Code, rec.Body)
+			}
+			return map[string]string{"refreshToken": pair.RefreshToken}
+		}, http.StatusUnauthorized},
+		{"already used", func(t *testing.T, s *HTTPService, pair *core.TokenPair) interface{} {
+			body := map[string]string{"refreshToken": pair.RefreshToken}
+			if rec := do(s, http.MethodPost, "/api/platform/token/refresh", body, nil); rec.Code != http.StatusOK {
+				t.Fatalf("first refresh status = %d: %s", rec.Code, rec.Body)
+			}
+			return body
+		}, http.StatusUnauthorized},
+		{"access token", func(t *testing.T, s *HTTPService, pair *core.TokenPair) interface{} {
+			return map[string]string{"refreshToken": pair.AccessToken}
+		}, http.StatusUnauthorized},
+		{"missing", func(t *testing.T, s *HTTPService, pair *core.TokenPair) interface{} {
+			return map[string]string{}
+		}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			s := newTestService(t, HTTPConfig{}, p)
+			pair, err := p.SecurityManager().GenerateTokenPair(&core.User{ID: "alice", Roles: []string{"operator"}})
+			if err != nil {
+				t.Fatalf("GenerateTokenPair: %v", err)
+			}
+
+			rec := do(s, http.MethodPost, "/api/platform/token/refresh", tt.prepare(t, s, pair), nil)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var refreshed core.TokenPair
+			if err := json.Unmarshal(rec.Body.Bytes(), &refreshed); err != nil || refreshed.AccessToken == "" || refreshed.RefreshToken == "" {
+				t.Fatalf("no token pair in %s", rec.Body)
+			}
+			// The new access token keeps the subject's permissions
+			info, err := p.SecurityManager().ValidateToken(context.Background(), refreshed.AccessToken)
+			if err != nil || info.UserID != "alice" || !slices.Contains(info.Permissions, "plugins:start") {
+				t.Errorf("refreshed token info = %+v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareExpandsRoles(t *testing.T) {
+	p := newTestPlatform(t, nil)
+	s := newTestService(t, HTTPConfig{}, p)
+
+	tests := []struct {
+		name  string
+		roles []string
+		want  int
+	}{
+		// Past the permission check, the missing plugin is reported
+		{"admin", []string{"admin"}, http.StatusNotFound},
+		{"operator", []string{"operator"}, http.StatusNotFound},
+		{"unknown role", []string{"nobody"}, http.StatusForbidden},
+		{"no roles", nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/plugins/missing/start", nil, bearer(issueToken(t, p, "user", tt.roles...)))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestJWKSEndpoint(t *testing.T) {
+	// Served without a token even when auth is enabled
+	p := newTestPlatform(t, func(cfg *platform.PlatformConfig) { cfg.Security.EnableAuth = true })
+	rec := do(newTestService(t, HTTPConfig{}, p), http.MethodGet, "/api/platform/jwks", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Cache-Control"); got == "" {
+		t.Error("JWKS response is not cacheable")
+	}
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &jwks); err != nil {
+		t.Fatal(err)
+	}
+	// HS256 has no public key to publish
+	if jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("keys = %v, want an empty set", jwks.Keys)
+	}
+}
+
+// tokenJTI returns the jti claim of a signed token
+func tokenJTI(t *testing.T, token string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %q", token)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("decode claims: %v", err)
+	}
+	var claims struct {
+		JTI string `json:"jti"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.JTI == "" {
+		t.Fatalf("no jti in %s: %v", payload, err)
+	}
+	return claims.JTI
+}
+
+func TestRevokeAccessTokenByJTI(t *testing.T) {
+	tests := []struct {
+		name string
+		// revoker is the role of the caller revoking the token
+		revoker     string
+		wantStatus  int
+		wantRevoked bool
+	}{
+		{"admin", "admin", http.StatusOK, true},
+		{"not admin", "operator", http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlatform(t, nil)
+			s := newTestService(t, HTTPConfig{}, p)
+			token := issueToken(t, p, "alice", "operator")
+
+			rec := do(s, http.MethodPost, "/api/platform/token/revoke",
+				map[string]string{"jti": tokenJTI(t, token)}, bearer(issueToken(t, p, "root", tt.revoker)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("revoke status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			info, err := p.SecurityManager().ValidateToken(context.Background(), token)
+			if err != nil || info.Valid == tt.wantRevoked {
+				t.Errorf("ValidateToken = %+v, %v, want valid %v", info, err, !tt.wantRevoked)
+			}
+			// Past the auth middleware, the missing plugin is reported
+			want := http.StatusNotFound
+			if tt.wantRevoked {
+				want = http.StatusUnauthorized
+			}
+			if rec := do(s, http.MethodPost, "/api/plugins/missing/start", nil, bearer(token)); rec.Code != want {
+				t.Errorf("request with the token: status %d, want %d: %s", rec.Code, want, rec.Body)
+			}
+		})
+	}
+}
@@ -51,6 +51,24 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Failed to initialize platform: %v\n", err)
 		os.Exit(1)
 	}
+	// Re-read the legacy config file on reload
+	p.SetConfigLoader(func() (*platform.PlatformConfig, error) {
+		legacy, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		return convertLegacyConfig(legacy), nil
+	})
+	// Write the settings that came from the legacy config file back to it
+	p.SetConfigSaver(func(cfg *platform.PlatformConfig) error {
+		legacy, err := config.Load()
+		if err != nil {
+			return err
+		}
+		applyPlatformConfig(legacy, cfg)
+		return config.Save(legacy)
+	})
+
 	// Set logger if method exists
 	if setter, ok := interface{}(p).(interface{ SetLogger(core.Logger) }); ok {
 		setter.SetLogger(log)
@@ -75,10 +93,16 @@ func main() {
 		IdleTimeout:    120 * time.Second,
 		MaxRequestSize: int64(legacy.MaxFileContentSize),
 		EnableCORS:     true,
-		EnableMetrics:  true,
+		EnableMetrics:  platformConfig.Metrics.Enabled,
 		EnableDocs:     true,
 		RateLimitRPS:   100,
 		EnableGzip:     true,
+		EnableAuth:     platformConfig.Security.EnableAuth,
+		// Accept the WebSocket origins cross-origin too; with none, browsers
+		// only get same-origin access
+		CORSAllowedOrigins:  legacy.WebSocketOrigins,
+		CORSMaxAge:          10 * time.Minute,
+		MaxStreamsPerClient: legacy.MaxStreamsPerClient,
 	}
 	httpService := services.NewHTTPService(httpConfig, p)
 	if err := p.ServiceManager().RegisterService(httpService); err != nil {
@@ -94,6 +118,24 @@ func main() {
 
 	// Plugins are preloaded before platform start; nothing to do here
 
+	// Issuing tokens over the API needs an admin token, so write one for
+	// the operator
+	if path, err := p.WriteAdminToken(); err != nil {
+		log.Warn("Failed to write admin token", core.Field{Key: "error", Value: err})
+	} else if path != "" {
+		fmt.Printf("🔑 Admin token written to %s\n", path)
+	}
+
+	// Serve the legacy API and UI alongside the platform when configured
+	startLegacyServer(p, legacy)
+
+	// Hot-reload the config file when it is edited
+	if path, err := config.Path(); err == nil {
+		if err := p.WatchConfigFile(path); err != nil {
+			log.Warn("Failed to watch config file", core.Field{Key: "error", Value: err})
+		}
+	}
+
 	// Register a sample in-memory resource to make the resources API functional out of the box
 	registerSampleResource(p)
 
@@ -104,8 +146,11 @@ func main() {
 	go func() {
 		<-sigChan
 		log.Info("Received shutdown signal, gracefully shutting down...")
-		// Stop the platform (stops all services/plugins)
-		_ = p.Stop(context.Background())
+		// Stop the platform (stops all services/plugins), giving background
+		// workers a bounded time to exit
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		_ = p.Stop(stopCtx)
+		stopCancel()
 		os.Exit(0)
 	}()
 
@@ -122,7 +167,7 @@ func convertLegacyConfig(legacy *config.Config) *platform.PlatformConfig {
 
 		Network: platform.NetworkConfig{
 			Host:              legacy.Host,
-			Port:              legacy.Port,
+			Port:              peerPort(legacy),
 			EnableDiscovery:   true,
 			DiscoveryPort:     legacy.Port + 1,
 			DiscoveryInterval: 30 * time.Second,
@@ -140,9 +185,27 @@ func convertLegacyConfig(legacy *config.Config) *platform.PlatformConfig {
 			EncryptionAlgo:   "AES-256-GCM",
 			MaxLoginAttempts: 3,
 			LockoutDuration:  15 * time.Minute,
+			EnableAuditLog:   true,
+			AuditLogFile:     "~/.noplacelike/audit.log",
 			JWTSecret:        legacy.JWTSecret,
 			JWTIssuer:        legacy.JWTIssuer,
 			JWTAudience:      legacy.JWTAudience,
+			JWTSecretFile:    "~/.noplacelike/jwt.secret",
+			// Admin routes check tokens even with auth disabled, so an
+			// unset or default secret is replaced by a generated one
+			AutoGenerateSecret: true,
+			AdminTokenFile:     "~/.noplacelike/admin.token",
+			WebSocketOrigins:   legacy.WebSocketOrigins,
+			Roles: map[string][]string{
+				"admin": {
+					"platform:admin",
+					"plugins:start",
+					"plugins:stop",
+					"resources:create",
+					"resources:delete",
+				},
+				"operator": {"plugins:start", "plugins:stop"},
+			},
 		},
 
 		Performance: platform.PerformanceConfig{
@@ -163,6 +226,7 @@ func convertLegacyConfig(legacy *config.Config) *platform.PlatformConfig {
 			AutoLoad:      []string{"file-manager", "clipboard", "system-info"},
 			Disabled:      []string{},
 			Sandbox:       false, // Start with sandbox disabled
+			Required:      []string{"file-manager"},
 		},
 
 		Logging: platform.LoggingConfig{
@@ -177,40 +241,94 @@ func convertLegacyConfig(legacy *config.Config) *platform.PlatformConfig {
 
 		Metrics: platform.MetricsConfig{
 			Enabled:         true,
-			Endpoint:        "/metrics",
+			Endpoint:        platform.DefaultMetricsEndpoint,
 			Interval:        30 * time.Second,
 			RetentionTime:   24 * time.Hour,
 			ExportFormat:    "prometheus",
 			EnableProfiling: false,
 		},
+
+		Storage: platform.StorageConfig{
+			UploadDir:    legacy.UploadFolder,
+			DownloadDir:  legacy.DownloadFolder,
+			AllowedPaths: legacy.AllowedPaths,
+		},
+	}
+}
+
+// peerPort returns the port peer connections are served on, which must
+// differ from the HTTP service's
+func peerPort(legacy *config.Config) int {
+	if legacy.PeerPort > 0 {
+		return legacy.PeerPort
 	}
+	return legacy.Port + 2
 }
 
-// loadCorePlugins loads essential plugins
+// applyPlatformConfig copies the platform settings that convertLegacyConfig
+// reads from the legacy config back into it
+func applyPlatformConfig(legacy *config.Config, cfg *platform.PlatformConfig) {
+	legacy.Host = cfg.Network.Host
+	legacy.PeerPort = cfg.Network.Port
+	legacy.JWTSecret = cfg.Security.JWTSecret
+	legacy.JWTIssuer = cfg.Security.JWTIssuer
+	legacy.JWTAudience = cfg.Security.JWTAudience
+	legacy.WebSocketOrigins = cfg.Security.WebSocketOrigins
+	legacy.MaxFileContentSize = int(cfg.Performance.MaxRequestSize)
+	legacy.UploadFolder = cfg.Storage.UploadDir
+	legacy.DownloadFolder = cfg.Storage.DownloadDir
+	legacy.AllowedPaths = cfg.Storage.AllowedPaths
+}
+
+// loadCorePlugins loads essential plugins. A plugin that fails to load is
+// skipped (leaving the platform degraded) unless it is marked required.
 func loadCorePlugins(ctx context.Context, p *platform.Platform, legacy *config.Config) error {
 	// File Manager Plugin
-	filePlugin := plugins.NewFileManagerPlugin(
+	fileManager := plugins.NewFileManagerPlugin(
 		legacy.UploadFolder,
 		legacy.DownloadFolder,
 		int64(legacy.MaxFileContentSize),
 	)
-
-	if err := p.LoadPlugin(ctx, filePlugin); err != nil {
-		return fmt.Errorf("failed to load file manager plugin: %w", err)
+	if err := fileManager.Configure(map[string]interface{}{
+		"filenameStrategy":   legacy.UploadFilenameStrategy,
+		"rejectEmptyUploads": legacy.RejectEmptyUploads,
+		"webSocketOrigins":   legacy.WebSocketOrigins,
+		"copyBufferSize":     legacy.CopyBufferSize,
+		"staleTempAge":       (time.Duration(legacy.StaleTempFileSeconds) * time.Second).String(),
+	}); err != nil {
+		return fmt.Errorf("failed to configure file manager: %w", err)
 	}
 
-	// Clipboard Plugin
-	clipboardPlugin := plugins.NewClipboardPlugin(legacy.ClipboardHistorySize)
+	clipboard := plugins.NewClipboardPlugin(legacy.ClipboardHistorySize)
+	if err := clipboard.Configure(map[string]interface{}{
+		"defaultTTL":        (time.Duration(legacy.ClipboardTTLSeconds) * time.Second).String(),
+		"redactionPatterns": legacy.ClipboardRedactionPatterns,
+		"maxHistoryBytes":   legacy.ClipboardHistoryBytes,
+	}); err != nil {
+		return fmt.Errorf("failed to configure clipboard: %w", err)
+	}
 
-	if err := p.LoadPlugin(ctx, clipboardPlugin); err != nil {
-		return fmt.Errorf("failed to load clipboard plugin: %w", err)
+	corePlugins := []core.Plugin{
+		fileManager,
+		// Clipboard Plugin
+		clipboard,
+		// System Info Plugin
+		plugins.NewSystemInfoPlugin(),
 	}
 
-	// System Info Plugin
-	systemPlugin := plugins.NewSystemInfoPlugin()
+	return autoloadPlugins(ctx, p, corePlugins)
+}
 
-	if err := p.LoadPlugin(ctx, systemPlugin); err != nil {
-		return fmt.Errorf("failed to load system info plugin: %w", err)
+// autoloadPlugins loads each plugin in turn. A plugin that fails to load is
+// skipped (leaving the platform degraded) unless it is marked required.
+func autoloadPlugins(ctx context.Context, p *platform.Platform, corePlugins []core.Plugin) error {
+	for _, plugin := range corePlugins {
+		if err := p.LoadPlugin(ctx, plugin); err != nil {
+			if p.IsPluginRequired(plugin.ID()) {
+				return fmt.Errorf("failed to load required plugin %s: %w", plugin.ID(), err)
+			}
+			p.RecordPluginFailure(plugin.ID(), err)
+		}
 	}
 
 	return nil
@@ -231,6 +349,8 @@ func startHTTPService(ctx context.Context, p *platform.Platform, legacy *config.
 		EnableDocs:     true,
 		RateLimitRPS:   100,
 		EnableGzip:     true,
+
+		MaxStreamsPerClient: legacy.MaxStreamsPerClient,
 	}
 
 	httpService := services.NewHTTPService(httpConfig, p)
@@ -240,6 +360,21 @@ func startHTTPService(ctx context.Context, p *platform.Platform, legacy *config.
 	return err
 }
 
+// startLegacyServer serves the legacy API and web UI on LegacyPort, if set.
+// Its guarded endpoints accept the tokens the platform issues, and changes
+// in the directories it monitors are published on the platform event bus.
+func startLegacyServer(p *platform.Platform, legacy *config.Config) {
+	if legacy.LegacyPort <= 0 {
+		return
+	}
+	cfg := *legacy
+	cfg.Port = legacy.LegacyPort
+	srv := server.NewServer(&cfg)
+	srv.SetTokenVerifier(p.SecurityManager())
+	srv.SetEventPublisher(p.PublishEvent)
+	go srv.Start()
+}
+
 // displayAccessInfo shows connection information
 func displayAccessInfo(host string, port int) {
 	// Print QR codes and network URLs first
@@ -259,6 +394,7 @@ func displayAccessInfo(host string, port int) {
 	fmt.Printf("   • Network Peers: /api/network/peers\n")
 	fmt.Printf("   • Resource Management: /api/resources\n")
 	fmt.Printf("   • Event Stream: /api/events/stream\n")
+	fmt.Printf("   • Event WebSocket: /api/events/ws\n")
 	fmt.Printf("\n")
 	fmt.Printf("🔌 Plugin APIs:\n")
 	fmt.Printf("   • File Manager: /plugins/file-manager/files\n")
@@ -280,47 +416,8 @@ func displayAccessInfo(host string, port int) {
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 }
 
-// memoryResource is a simple in-memory core.Resource implementation
-type memoryResource struct {
-	id       string
-	typ      string
-	data     []byte
-	meta     map[string]interface{}
-	started  bool
-}
-
-// Service interface methods
-func (m *memoryResource) Start(ctx context.Context) error {
-	m.started = true
-	return nil
-}
-func (m *memoryResource) Stop(ctx context.Context) error {
-	m.started = false
-	return nil
-}
-func (m *memoryResource) IsHealthy() bool { return true }
-func (m *memoryResource) Name() string    { return "resource:" + m.id }
-func (m *memoryResource) Health() core.HealthStatus {
-	return core.HealthStatus{
-		Status:    core.HealthStatusHealthy,
-		Timestamp: time.Now(),
-	}
-}
-func (m *memoryResource) Configuration() core.ConfigSchema { return core.ConfigSchema{} }
-
-// Resource interface methods
-func (m *memoryResource) ID() string                         { return m.id }
-func (m *memoryResource) Type() string                       { return m.typ }
-func (m *memoryResource) GetMetadata() map[string]interface{} { return m.meta }
-func (m *memoryResource) GetSize() int64                     { return int64(len(m.data)) }
-
 // registerSampleResource registers a trivial in-memory resource
 func registerSampleResource(p *platform.Platform) {
-	res := &memoryResource{
-		id:   "mem-hello",
-		typ:  "memory",
-		data: []byte("hello"),
-		meta: map[string]interface{}{"name": "hello"},
-	}
+	res := core.NewMemoryResource("mem-hello", "memory", []byte("hello"), map[string]interface{}{"name": "hello"})
 	_ = p.ResourceManager().RegisterResource(res)
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/logger"
+	"github.com/nathfavour/noplacelike.go/internal/platform"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{})                    {}
+func (nopLogger) Info(string, ...interface{})                     {}
+func (nopLogger) Warn(string, ...interface{})                     {}
+func (nopLogger) Error(string, ...interface{})                    {}
+func (nopLogger) Fatal(string, ...interface{})                    {}
+func (nopLogger) WithFields(map[string]interface{}) logger.Logger { return nopLogger{} }
+
+// autoloadPlugin is a plugin whose initialization returns err
+type autoloadPlugin struct {
+	id  string
+	err error
+}
+
+func (ap *autoloadPlugin) Start(context.Context) error { return nil }
+func (ap *autoloadPlugin) Stop(context.Context) error  { return nil }
+func (ap *autoloadPlugin) IsHealthy() bool             { return true }
+func (ap *autoloadPlugin) Name() string                { return ap.id }
+func (ap *autoloadPlugin) Health() core.HealthStatus {
+	return core.HealthStatus{Status: core.HealthStatusHealthy}
+}
+func (ap *autoloadPlugin) Configuration() core.ConfigSchema       { return core.ConfigSchema{} }
+func (ap *autoloadPlugin) ID() string                             { return ap.id }
+func (ap *autoloadPlugin) Version() string                        { return "test" }
+func (ap *autoloadPlugin) Dependencies() []string                 { return nil }
+func (ap *autoloadPlugin) Initialize(core.PlatformAPI) error      { return ap.err }
+func (ap *autoloadPlugin) Configure(map[string]interface{}) error { return nil }
+func (ap *autoloadPlugin) Routes() []core.Route                   { return nil }
+func (ap *autoloadPlugin) HandleEvent(core.Event) error           { return nil }
+
+func TestAutoloadPlugins(t *testing.T) {
+	errBroken := errors.New("broken")
+	tests := []struct {
+		name     string
+		required []string
+		wantErr  bool
+		// wantLoaded lists the plugins loaded once autoload returns
+		wantLoaded []string
+	}{
+		{"failing optional plugin skipped", nil, false, []string{"first", "last"}},
+		{"failing required plugin aborts", []string{"broken"}, true, []string{"first"}},
+		{"other plugin required", []string{"first"}, false, []string{"first", "last"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := platform.NewPlatform(&platform.PlatformConfig{
+				Name:    "test",
+				Version: "test",
+				Storage:  platform.StorageConfig{UploadDir: t.TempDir(), DownloadDir: t.TempDir()},
+				Plugins:  platform.PluginsConfig{Required: tt.required},
+				Security: platform.SecurityConfig{AutoGenerateSecret: true},
+			}, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewPlatform: %v", err)
+			}
+			if err := p.Start(context.Background()); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			t.Cleanup(func() { p.Stop(context.Background()) })
+
+			err = autoloadPlugins(context.Background(), p, []core.Plugin{
+				&autoloadPlugin{id: "first"},
+				&autoloadPlugin{id: "broken", err: errBroken},
+				&autoloadPlugin{id: "last"},
+			})
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("autoloadPlugins error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errBroken) {
+				t.Errorf("error %v does not wrap the plugin's failure", err)
+			}
+
+			for _, id := range tt.wantLoaded {
+				if _, err := p.GetPlugin(id); err != nil {
+					t.Errorf("plugin %s not loaded: %v", id, err)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+			// The platform carries on degraded and reports why
+			if failed := p.FailedPlugins(); failed["broken"] == "" || len(failed) != 1 {
+				t.Errorf("FailedPlugins = %v, want only broken", failed)
+			}
+			if status := p.Health().Status; status != core.HealthStatusDegraded {
+				t.Errorf("health = %s, want %s", status, core.HealthStatusDegraded)
+			}
+		})
+	}
+}
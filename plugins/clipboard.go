@@ -3,14 +3,18 @@ package plugins
 import (
 	"context"
 	"crypto/md5"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/nathfavour/noplacelike.go/internal/core"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 	"github.com/nathfavour/noplacelike.go/internal/logger"
 )
 
@@ -21,11 +25,14 @@ type ClipboardPlugin struct {
 	logger     logger.Logger
 	platform   core.PlatformAPI
 	config     ClipboardConfig
-	clipboard  ClipboardData
-	history    []ClipboardEntry
+	channels   map[string]*clipboardChannel
 	mu         sync.RWMutex
 	running    bool
 	maxHistory int
+	redactors  []*regexp.Regexp
+	sweepStop  chan struct{}
+	// subscriptions holds the event handlers registered in Start
+	subscriptions []core.Subscription
 }
 
 type ClipboardConfig struct {
@@ -33,14 +40,52 @@ type ClipboardConfig struct {
 	EnableHistory  bool `json:"enableHistory"`
 	MaxHistory     int  `json:"maxHistory"`
 	EnableCORS     bool `json:"enableCors"`
+	// RedactionPatterns are regular expressions for content that must not be
+	// synced (e.g. card numbers or API keys). Matching content is stored as
+	// RedactedPlaceholder and never broadcast.
+	RedactionPatterns []string `json:"redactionPatterns"`
+	// DefaultTTLSeconds is how long content is kept when a set request
+	// doesn't give a TTL. Zero keeps content until it is replaced or evicted.
+	DefaultTTLSeconds int `json:"defaultTtlSeconds"`
 }
 
+// RedactedPlaceholder replaces clipboard content matching a redaction pattern
+const RedactedPlaceholder = "[redacted]"
+
+// clipboardSweepInterval is how often expired content is removed
+const clipboardSweepInterval = 5 * time.Second
+
+// DefaultClipboardChannel is the channel used when a request doesn't name one
+const DefaultClipboardChannel = "default"
+
+var channelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// clipboardChannel holds the clipboard content and history of a named
+// channel. Devices only see the content of the channels they use.
+type clipboardChannel struct {
+	clipboard ClipboardData
+	history   []ClipboardEntry
+}
+
+// ClipboardData is a channel's clipboard content. Text is kept in Content;
+// binary content such as images is kept base64-encoded in Data, with Type
+// holding its MIME type.
 type ClipboardData struct {
+	Channel   string `json:"channel,omitempty"`
 	Content   string `json:"content"`
+	Data      string `json:"data,omitempty"`
 	Type      string `json:"type"`
 	Source    string `json:"source"`
 	UpdatedAt int64  `json:"updatedAt"`
 	Hash      string `json:"hash"`
+	// ExpiresAt is the Unix time from which the content is no longer
+	// served; zero means it does not expire
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// expired reports whether the content has passed its expiry at now
+func (d ClipboardData) expired(now int64) bool {
+	return d.ExpiresAt != 0 && now >= d.ExpiresAt
 }
 
 type ClipboardEntry struct {
@@ -60,7 +105,7 @@ func NewClipboardPlugin() core.Plugin {
 			MaxHistory:     50,
 			EnableCORS:     true,
 		},
-		history:    make([]ClipboardEntry, 0),
+		channels:   make(map[string]*clipboardChannel),
 		maxHistory: 50,
 	}
 }
@@ -100,12 +145,46 @@ func (p *ClipboardPlugin) Configure(config map[string]interface{}) error {
 	}
 
 	p.maxHistory = p.config.MaxHistory
+	p.compileRedactionPatterns()
 	p.logger.Info("Clipboard plugin configured", "config", p.config)
 	return nil
 }
 
+// compileRedactionPatterns compiles the configured redaction patterns,
+// skipping (and logging) any that are invalid
+func (p *ClipboardPlugin) compileRedactionPatterns() {
+	redactors := make([]*regexp.Regexp, 0, len(p.config.RedactionPatterns))
+	for _, pattern := range p.config.RedactionPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			p.logger.Warn("Invalid clipboard redaction pattern", "pattern", pattern, "error", err)
+			continue
+		}
+		redactors = append(redactors, re)
+	}
+
+	p.mu.Lock()
+	p.redactors = redactors
+	p.mu.Unlock()
+}
+
+// shouldRedact reports whether content matches any redaction pattern
+func (p *ClipboardPlugin) shouldRedact(content string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, re := range p.redactors {
+		if re.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *ClipboardPlugin) Start(ctx context.Context) error {
 	p.running = true
+	p.sweepStop = make(chan struct{})
+	go p.sweepExpired(p.sweepStop)
 	p.logger.Info("Clipboard plugin started")
 
 	// Register as a resource provider
@@ -124,8 +203,17 @@ func (p *ClipboardPlugin) Start(ctx context.Context) error {
 
 	// Subscribe to network events for clipboard sync
 	if eventBus := p.platform.GetEventBus(); eventBus != nil {
-		eventBus.Subscribe("clipboard.sync", p.handleSyncEvent)
-		eventBus.Subscribe("peer.connected", p.handlePeerConnected)
+		handlers := map[string]core.EventHandler{
+			"clipboard.sync": p.handleSyncEvent,
+			"peer.connected": p.handlePeerConnected,
+		}
+		for eventType, handler := range handlers {
+			sub, err := eventBus.Subscribe(eventType, handler)
+			if err != nil {
+				return fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+			}
+			p.subscriptions = append(p.subscriptions, sub)
+		}
 	}
 
 	return nil
@@ -133,17 +221,25 @@ func (p *ClipboardPlugin) Start(ctx context.Context) error {
 
 func (p *ClipboardPlugin) Stop(ctx context.Context) error {
 	p.running = false
+	if p.sweepStop != nil {
+		close(p.sweepStop)
+		p.sweepStop = nil
+	}
 
 	// Unregister resource
 	if resourceMgr := p.platform.GetResourceManager(); resourceMgr != nil {
 		resourceMgr.UnregisterResource(p.id)
 	}
 
-	// Unsubscribe from events
+	// Unsubscribe our own handlers, leaving other subscribers in place
 	if eventBus := p.platform.GetEventBus(); eventBus != nil {
-		eventBus.Unsubscribe("clipboard.sync", p.handleSyncEvent)
-		eventBus.Unsubscribe("peer.connected", p.handlePeerConnected)
+		for _, sub := range p.subscriptions {
+			if err := eventBus.Unsubscribe(sub); err != nil {
+				p.logger.Warn("Failed to unsubscribe", "event", sub.EventType, "error", err)
+			}
+		}
 	}
+	p.subscriptions = nil
 
 	p.logger.Info("Clipboard plugin stopped")
 	return nil
@@ -211,9 +307,17 @@ func (p *ClipboardPlugin) handleGetClipboard(w http.ResponseWriter, r *http.Requ
 		p.setCORSHeaders(w)
 	}
 
-	p.mu.RLock()
-	clipboard := p.clipboard
-	p.mu.RUnlock()
+	channel, ok := p.channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	clipboard, _ := p.snapshot(channel)
+
+	if r.URL.Query().Get("raw") == "true" {
+		p.writeRaw(w, clipboard)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(clipboard)
@@ -228,10 +332,17 @@ func (p *ClipboardPlugin) handleSetClipboard(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	channel, ok := p.channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	var request struct {
-		Content string `json:"content"`
-		Type    string `json:"type"`
-		Source  string `json:"source"`
+		Content    string `json:"content"`
+		Data       string `json:"data"`
+		Type       string `json:"type"`
+		Source     string `json:"source"`
+		TTLSeconds int    `json:"ttlSeconds"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -240,6 +351,17 @@ func (p *ClipboardPlugin) handleSetClipboard(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if request.TTLSeconds < 0 {
+		http.Error(w, "ttlSeconds must not be negative", http.StatusBadRequest)
+		return
+	}
+	ttl := time.Duration(request.TTLSeconds) * time.Second
+
+	if request.Data != "" {
+		p.setBinaryFromRequest(w, channel, request.Data, request.Type, request.Source, ttl)
+		return
+	}
+
 	// Validate content size
 	if len(request.Content) > p.config.MaxContentSize {
 		http.Error(w, "Content too large", http.StatusRequestEntityTooLarge)
@@ -254,30 +376,109 @@ func (p *ClipboardPlugin) handleSetClipboard(w http.ResponseWriter, r *http.Requ
 		request.Source = "unknown"
 	}
 
+	// Redact sensitive content: keep a placeholder locally and don't sync it
+	if p.shouldRedact(request.Content) {
+		p.setClipboardContent(channel, RedactedPlaceholder, "text/plain", request.Source, ttl)
+
+		p.platform.PublishEvent("clipboard.redacted", map[string]interface{}{
+			"channel": channel,
+			"type":    request.Type,
+			"source":  request.Source,
+			"size":    len(request.Content),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Clipboard content redacted",
+			"redacted": true,
+		})
+		return
+	}
+
 	// Update clipboard
-	p.setClipboardContent(request.Content, request.Type, request.Source)
+	clipboard := p.setClipboardContent(channel, request.Content, request.Type, request.Source, ttl)
 
 	// Broadcast to peers
-	if eventBus := p.platform.GetEventBus(); eventBus != nil {
-		event := core.Event{
-			Type:   "clipboard.changed",
-			Source: p.id,
-			Data: map[string]interface{}{
-				"content": request.Content,
-				"type":    request.Type,
-				"source":  request.Source,
-			},
-		}
-		eventBus.Publish(event)
+	p.platform.PublishEvent("clipboard.changed", map[string]interface{}{
+		"channel": channel,
+		"content": request.Content,
+		"type":    request.Type,
+		"source":  request.Source,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   "Clipboard updated successfully",
+		"channel":   channel,
+		"hash":      clipboard.Hash,
+		"expiresAt": clipboard.ExpiresAt,
+	})
+}
+
+// setBinaryFromRequest stores a base64 payload sent to handleSetClipboard.
+// Binary content is not checked against the redaction patterns, which only
+// apply to text.
+func (p *ClipboardPlugin) setBinaryFromRequest(w http.ResponseWriter, channel, encoded, contentType, source string, ttl time.Duration) {
+	if contentType == "" {
+		http.Error(w, "A content type is required for binary data", http.StatusBadRequest)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		http.Error(w, "Data is not valid base64", http.StatusBadRequest)
+		return
+	}
+	if len(data) > p.config.MaxContentSize {
+		http.Error(w, "Content too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if source == "" {
+		source = "unknown"
 	}
 
+	clipboard := p.setClipboardData(channel, data, contentType, source, ttl)
+
+	p.platform.PublishEvent("clipboard.changed", map[string]interface{}{
+		"channel": channel,
+		"data":    encoded,
+		"type":    contentType,
+		"source":  source,
+	})
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Clipboard updated successfully",
-		"hash":    p.clipboard.Hash,
+		"message":   "Clipboard updated successfully",
+		"channel":   channel,
+		"hash":      clipboard.Hash,
+		"size":      len(data),
+		"expiresAt": clipboard.ExpiresAt,
 	})
 }
 
+// writeRaw writes the clipboard's bytes with its own MIME type. The type was
+// chosen by whoever set the clipboard, so only images and plain text are
+// served as such; anything else is sent as a download.
+func (p *ClipboardPlugin) writeRaw(w http.ResponseWriter, clipboard ClipboardData) {
+	if clipboard.Content == "" && clipboard.Data == "" {
+		http.Error(w, "Clipboard is empty", http.StatusNotFound)
+		return
+	}
+
+	body := []byte(clipboard.Content)
+	contentType := clipboard.Type
+	if clipboard.Data != "" {
+		data, err := base64.StdEncoding.DecodeString(clipboard.Data)
+		if err != nil {
+			http.Error(w, "Stored clipboard data is corrupt", http.StatusInternalServerError)
+			return
+		}
+		body = data
+	}
+	filetype.SetUntrustedHeaders(w.Header(), contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
 func (p *ClipboardPlugin) handleGetHistory(w http.ResponseWriter, r *http.Request) {
 	if p.config.EnableCORS {
 		p.setCORSHeaders(w)
@@ -288,13 +489,16 @@ func (p *ClipboardPlugin) handleGetHistory(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	p.mu.RLock()
-	history := make([]ClipboardEntry, len(p.history))
-	copy(history, p.history)
-	p.mu.RUnlock()
+	channel, ok := p.channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	_, history := p.snapshot(channel)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
+		"channel": channel,
 		"history": history,
 		"count":   len(history),
 	})
@@ -310,11 +514,18 @@ func (p *ClipboardPlugin) handleClearHistory(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	channel, ok := p.channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	p.mu.Lock()
-	p.history = make([]ClipboardEntry, 0)
+	if ch, exists := p.channels[channel]; exists {
+		ch.history = make([]ClipboardEntry, 0)
+	}
 	p.mu.Unlock()
 
-	p.logger.Info("Clipboard history cleared")
+	p.logger.Info("Clipboard history cleared", "channel", channel)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -338,15 +549,19 @@ func (p *ClipboardPlugin) handleGetHistoryEntry(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	p.mu.RLock()
+	channel, ok := p.channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	_, history := p.snapshot(channel)
 	var entry *ClipboardEntry
-	for _, h := range p.history {
-		if h.ID == id {
-			entry = &h
+	for i := range history {
+		if history[i].ID == id {
+			entry = &history[i]
 			break
 		}
 	}
-	p.mu.RUnlock()
 
 	if entry == nil {
 		http.Error(w, "Entry not found", http.StatusNotFound)
@@ -362,12 +577,18 @@ func (p *ClipboardPlugin) handleSyncClipboard(w http.ResponseWriter, r *http.Req
 		p.setCORSHeaders(w)
 	}
 
+	channel, ok := p.channelFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	// Trigger clipboard sync across all peers
 	if networkMgr := p.platform.GetNetworkManager(); networkMgr != nil {
 		peers := networkMgr.ListPeers()
+		clipboard, _ := p.snapshot(channel)
 
 		syncData := map[string]interface{}{
-			"clipboard": p.clipboard,
+			"clipboard": clipboard,
 			"action":    "sync_request",
 		}
 
@@ -383,56 +604,196 @@ func (p *ClipboardPlugin) handleSyncClipboard(w http.ResponseWriter, r *http.Req
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Sync initiated",
+		"channel": channel,
 		"peers":   "all",
 	})
 }
 
 // Helper methods
-func (p *ClipboardPlugin) setClipboardContent(content, contentType, source string) {
+func (p *ClipboardPlugin) setClipboardContent(channel, content, contentType, source string, ttl time.Duration) ClipboardData {
+	return p.storeClipboard(channel, ttl, ClipboardData{
+		Content: content,
+		Type:    contentType,
+		Source:  source,
+		Hash:    fmt.Sprintf("%x", md5.Sum([]byte(content))),
+	})
+}
+
+// setClipboardData sets a channel's clipboard to binary content
+func (p *ClipboardPlugin) setClipboardData(channel string, data []byte, contentType, source string, ttl time.Duration) ClipboardData {
+	return p.storeClipboard(channel, ttl, ClipboardData{
+		Data:   base64.StdEncoding.EncodeToString(data),
+		Type:   contentType,
+		Source: source,
+		Hash:   fmt.Sprintf("%x", md5.Sum(data)),
+	})
+}
+
+// storeClipboard makes clipboard the channel's current content and records
+// it in the history. The content expires after ttl, or the configured
+// default TTL when ttl is zero.
+func (p *ClipboardPlugin) storeClipboard(channel string, ttl time.Duration, clipboard ClipboardData) ClipboardData {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// Generate content hash
-	hash := fmt.Sprintf("%x", md5.Sum([]byte(content)))
+	ch := p.getChannel(channel)
 
-	// Update clipboard
-	p.clipboard = ClipboardData{
-		Content:   content,
-		Type:      contentType,
-		Source:    source,
-		UpdatedAt: time.Now().Unix(),
-		Hash:      hash,
+	now := time.Now()
+	if ttl <= 0 {
+		ttl = time.Duration(p.config.DefaultTTLSeconds) * time.Second
+	}
+	clipboard.Channel = channel
+	clipboard.UpdatedAt = now.Unix()
+	if ttl > 0 {
+		// Round up so content lives for at least its TTL
+		clipboard.ExpiresAt = now.Add(ttl + time.Second - 1).Unix()
+	}
+	ch.clipboard = clipboard
+	hash := clipboard.Hash
+
+	// Setting the same content again renews its expiry
+	if len(ch.history) > 0 && ch.history[0].Hash == hash {
+		ch.history[0].ExpiresAt = clipboard.ExpiresAt
 	}
 
 	// Add to history if enabled and content is different
-	if p.config.EnableHistory && (len(p.history) == 0 || p.history[0].Hash != hash) {
+	if p.config.EnableHistory && (len(ch.history) == 0 || ch.history[0].Hash != hash) {
 		entry := ClipboardEntry{
-			ClipboardData: p.clipboard,
-			ID:            fmt.Sprintf("clip_%d", time.Now().UnixNano()),
+			ClipboardData: ch.clipboard,
+			ID:            "clip_" + core.NewID(),
 			CreatedAt:     time.Now().Unix(),
 		}
 
 		// Prepend to history
-		p.history = append([]ClipboardEntry{entry}, p.history...)
+		ch.history = append([]ClipboardEntry{entry}, ch.history...)
 
 		// Limit history size
-		if len(p.history) > p.maxHistory {
-			p.history = p.history[:p.maxHistory]
+		if len(ch.history) > p.maxHistory {
+			ch.history = ch.history[:p.maxHistory]
+		}
+	}
+
+	p.logger.Info("Clipboard updated", "channel", channel, "source", clipboard.Source, "type", clipboard.Type, "size", len(clipboard.Content)+len(clipboard.Data))
+	return ch.clipboard
+}
+
+// getChannel returns the named channel, creating it if needed. The caller
+// must hold p.mu for writing.
+func (p *ClipboardPlugin) getChannel(name string) *clipboardChannel {
+	ch, ok := p.channels[name]
+	if !ok {
+		ch = &clipboardChannel{history: make([]ClipboardEntry, 0)}
+		p.channels[name] = ch
+	}
+	return ch
+}
+
+// snapshot returns a copy of a channel's current content and history,
+// leaving out anything that has expired but not yet been swept
+func (p *ClipboardPlugin) snapshot(name string) (ClipboardData, []ClipboardEntry) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ch, ok := p.channels[name]
+	if !ok {
+		return ClipboardData{Channel: name}, make([]ClipboardEntry, 0)
+	}
+	now := time.Now().Unix()
+	clipboard := ch.clipboard
+	if clipboard.expired(now) {
+		clipboard = ClipboardData{Channel: name}
+	}
+	history := make([]ClipboardEntry, 0, len(ch.history))
+	for _, entry := range ch.history {
+		if !entry.expired(now) {
+			history = append(history, entry)
+		}
+	}
+	return clipboard, history
+}
+
+// sweepExpired removes expired content from every channel until stop is
+// closed
+func (p *ClipboardPlugin) sweepExpired(stop <-chan struct{}) {
+	ticker := time.NewTicker(clipboardSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.removeExpired(time.Now().Unix())
 		}
 	}
+}
 
-	p.logger.Info("Clipboard updated", "source", source, "type", contentType, "size", len(content))
+// removeExpired drops content that has expired at now
+func (p *ClipboardPlugin) removeExpired(now int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, ch := range p.channels {
+		if ch.clipboard.expired(now) {
+			ch.clipboard = ClipboardData{Channel: name}
+		}
+		kept := ch.history[:0]
+		for _, entry := range ch.history {
+			if !entry.expired(now) {
+				kept = append(kept, entry)
+			}
+		}
+		// Clear the tail so expired content can be collected
+		clear(ch.history[len(kept):])
+		ch.history = kept
+	}
+}
+
+// channelFromRequest returns the channel named by the "channel" query
+// parameter, defaulting to DefaultClipboardChannel. It writes a 400 response
+// and returns false if the name is invalid.
+func (p *ClipboardPlugin) channelFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	channel := r.URL.Query().Get("channel")
+	if channel == "" {
+		return DefaultClipboardChannel, true
+	}
+	if !channelNamePattern.MatchString(channel) {
+		http.Error(w, "Invalid channel name", http.StatusBadRequest)
+		return "", false
+	}
+	return channel, true
 }
 
 func (p *ClipboardPlugin) handleSyncEvent(event core.Event) error {
 	// Handle clipboard sync events from other instances
 	if data, ok := event.Data["clipboard"].(map[string]interface{}); ok {
 		content, _ := data["content"].(string)
+		encoded, _ := data["data"].(string)
 		contentType, _ := data["type"].(string)
 		source, _ := data["source"].(string)
+		channel, _ := data["channel"].(string)
+		if channel == "" || !channelNamePattern.MatchString(channel) {
+			channel = DefaultClipboardChannel
+		}
 
-		if content != "" {
-			p.setClipboardContent(content, contentType, source)
+		// Keep the sender's expiry, and drop content that already expired
+		var ttl time.Duration
+		if expiresAt, ok := data["expiresAt"].(float64); ok && expiresAt > 0 {
+			ttl = time.Until(time.Unix(int64(expiresAt), 0))
+			if ttl <= 0 {
+				return nil
+			}
+		}
+
+		if encoded != "" {
+			binary, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(binary) > p.config.MaxContentSize {
+				p.logger.Warn("Ignoring invalid clipboard sync data", "channel", channel, "error", err)
+				return nil
+			}
+			p.setClipboardData(channel, binary, contentType, source, ttl)
+		} else if content != "" {
+			p.setClipboardContent(channel, content, contentType, source, ttl)
 		}
 	}
 
@@ -452,9 +813,7 @@ func (p *ClipboardPlugin) syncToNewPeer(peerData map[string]interface{}) {
 			return
 		}
 
-		p.mu.RLock()
-		clipboard := p.clipboard
-		p.mu.RUnlock()
+		clipboard, _ := p.snapshot(DefaultClipboardChannel)
 
 		syncData := map[string]interface{}{
 			"clipboard": clipboard,
@@ -236,17 +236,10 @@ func (p *FileManagerPlugin) handleUploadFile(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Publish event
-	if eventBus := p.platform.GetEventBus(); eventBus != nil {
-		event := core.Event{
-			Type:   "file.uploaded",
-			Source: p.id,
-			Data: map[string]interface{}{
-				"filename": filename,
-				"size":     header.Size,
-			},
-		}
-		eventBus.Publish(event)
-	}
+	p.platform.PublishEvent("file.uploaded", map[string]interface{}{
+		"filename": filename,
+		"size":     header.Size,
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -336,16 +329,9 @@ func (p *FileManagerPlugin) handleDeleteFile(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Publish event
-	if eventBus := p.platform.GetEventBus(); eventBus != nil {
-		event := core.Event{
-			Type:   "file.deleted",
-			Source: p.id,
-			Data: map[string]interface{}{
-				"filename": filename,
-			},
-		}
-		eventBus.Publish(event)
-	}
+	p.platform.PublishEvent("file.deleted", map[string]interface{}{
+		"filename": filename,
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
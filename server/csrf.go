@@ -0,0 +1,110 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSRF protection uses a double-submit token: the token is kept in a cookie
+// and embedded in every UI page, and state-changing requests must echo it in
+// the X-CSRF-Token header (or csrf_token form field). Another site can make
+// the browser send the cookie but cannot read the token to echo it.
+const (
+	csrfCookieName = "npl_csrf"
+	csrfHeaderName = "X-CSRF-Token"
+	csrfFormField  = "csrf_token"
+	csrfTokenBytes = 32
+)
+
+// csrfScript adds the page's CSRF token to every unsafe fetch request
+const csrfScript = `<script>
+(function() {
+  var meta = document.querySelector('meta[name="csrf-token"]');
+  if (!meta || !window.fetch) return;
+  var token = meta.getAttribute('content');
+  var originalFetch = window.fetch;
+  window.fetch = function(input, init) {
+    init = init || {};
+    var method = (init.method || (input && input.method) || 'GET').toUpperCase();
+    if (['GET', 'HEAD', 'OPTIONS'].indexOf(method) === -1) {
+      var headers = new Headers(init.headers || (input && input.headers) || {});
+      headers.set('` + csrfHeaderName + `', token);
+      init.headers = headers;
+    }
+    return originalFetch(input, init);
+  };
+})();
+</script>`
+
+// csrfMiddleware issues the CSRF token and rejects unsafe requests made with
+// the session cookie that do not carry it. Requests authenticated with a
+// token or API key are exempt, as browsers never attach those on their own.
+func (s *Server) csrfMiddleware(c *gin.Context) {
+	token, err := c.Cookie(csrfCookieName)
+	if err != nil || len(token) != 2*csrfTokenBytes {
+		token = generateCSRFToken()
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.SetCookie(csrfCookieName, token, 365*24*3600, "/", "", isSecureRequest(c.Request), true)
+	}
+	c.Set("csrfToken", token)
+
+	if csrfSafeMethod(c.Request.Method) || !cookieAuthenticated(c.Request) {
+		c.Next()
+		return
+	}
+
+	sent := c.GetHeader(csrfHeaderName)
+	if sent == "" {
+		sent = c.PostForm(csrfFormField)
+	}
+	if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "missing or invalid CSRF token",
+		})
+		return
+	}
+	c.Next()
+}
+
+// csrfSafeMethod reports whether method cannot change state
+func csrfSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// cookieAuthenticated reports whether r relies on the device cookie rather
+// than explicit credentials
+func cookieAuthenticated(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != "" {
+		return false
+	}
+	_, err := r.Cookie("npl_device_id")
+	return err == nil
+}
+
+func generateCSRFToken() string {
+	b := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate CSRF token: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// withCSRF embeds the request's CSRF token and the fetch wrapper in page
+func withCSRF(c *gin.Context, page string) string {
+	token := c.GetString("csrfToken")
+	meta := `<meta name="csrf-token" content="` + html.EscapeString(token) + `">`
+	if idx := strings.Index(page, "</head>"); idx != -1 {
+		return page[:idx] + meta + csrfScript + page[idx:]
+	}
+	return meta + csrfScript + page
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newCSRFRouter serves the home page and a state-changing endpoint behind
+// the CSRF middleware
+func newCSRFRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+	router := gin.New()
+	router.Use(s.csrfMiddleware)
+	router.GET("/", s.uiHome)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/clipboard", ok)
+	router.DELETE("/clipboard", ok)
+	return router
+}
+
+// csrfToken loads the home page and returns the token set in its cookie,
+// failing unless the page embeds the same token
+func csrfToken(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var token string
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == csrfCookieName {
+			token = cookie.Value
+			if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+				t.Errorf("CSRF cookie = %+v, want HttpOnly and SameSite=Strict", cookie)
+			}
+		}
+	}
+	if token == "" {
+		t.Fatal("no CSRF cookie issued with the page")
+	}
+	if !strings.Contains(rec.Body.String(), `<meta name="csrf-token" content="`+token+`">`) {
+		t.Fatal("page doesn't embed the CSRF token")
+	}
+	return token
+}
+
+func TestCSRF(t *testing.T) {
+	router := newCSRFRouter()
+	token := csrfToken(t, router)
+
+	tests := []struct {
+		name   string
+		method string
+		// device sends the session cookie
+		device bool
+		header http.Header
+		form   string
+		want   int
+	}{
+		{"no token", http.MethodPost, true, nil, "", http.StatusForbidden},
+		{"wrong token", http.MethodPost, true, http.Header{csrfHeaderName: {strings.Repeat("0", len(token))}}, "", http.StatusForbidden},
+		{"header token", http.MethodPost, true, http.Header{csrfHeaderName: {token}}, "", http.StatusOK},
+		{"form token", http.MethodPost, true, nil, csrfFormField + "=" + url.QueryEscape(token), http.StatusOK},
+		{"delete without token", http.MethodDelete, true, nil, "", http.StatusForbidden},
+		{"delete with token", http.MethodDelete, true, http.Header{csrfHeaderName: {token}}, "", http.StatusOK},
+		{"bearer token auth", http.MethodPost, true, http.Header{"Authorization": {"Bearer abc"}}, "", http.StatusOK},
+		{"api key auth", http.MethodPost, true, http.Header{"X-Api-Key": {"abc"}}, "", http.StatusOK},
+		{"no session cookie", http.MethodPost, false, nil, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/clipboard", strings.NewReader(tt.form))
+			if tt.form != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for key, values := range tt.header {
+				req.Header.Set(key, values[0])
+			}
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+			if tt.device {
+				req.AddCookie(&http.Cookie{Name: "npl_device_id", Value: "device"})
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCSRFTokenReused(t *testing.T) {
+	router := newCSRFRouter()
+	token := csrfToken(t, router)
+
+	// A page load with the cookie keeps its token rather than issuing a new one
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("new cookies %v issued, want the existing token kept", cookies)
+	}
+	if !strings.Contains(rec.Body.String(), token) {
+		t.Error("page doesn't embed the existing token")
+	}
+}
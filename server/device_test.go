@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// responseCookie returns the cookie named name set by rec, or nil
+func responseCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestDeviceCookie(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		header     http.Header
+		maxAge     int
+		wantSecure bool
+		wantMaxAge int
+	}{
+		{"plain http", "http://device.test/", nil, 0, false, config.DefaultDeviceCookieMaxAge},
+		{"https", "https://device.test/", nil, 0, true, config.DefaultDeviceCookieMaxAge},
+		{"https terminated by proxy", "http://device.test/", http.Header{"X-Forwarded-Proto": {"https"}}, 0, true, config.DefaultDeviceCookieMaxAge},
+		{"configured lifetime", "https://device.test/", nil, 3600, true, 3600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			s := &Server{
+				config:  &config.Config{DeviceCookieMaxAgeSeconds: tt.maxAge},
+				devices: make(map[string]*DeviceInfo),
+			}
+			router := gin.New()
+			router.Use(s.csrfMiddleware, s.deviceTrackingMiddleware)
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				req.Header.Set(key, values[0])
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			device := responseCookie(rec, "npl_device_id")
+			if device == nil {
+				t.Fatal("no device cookie set")
+			}
+			if device.Secure != tt.wantSecure || device.SameSite != http.SameSiteLaxMode || !device.HttpOnly {
+				t.Errorf("device cookie Secure = %v, SameSite = %v, HttpOnly = %v, want Secure %v, Lax, HttpOnly",
+					device.Secure, device.SameSite, device.HttpOnly, tt.wantSecure)
+			}
+			if device.MaxAge != tt.wantMaxAge {
+				t.Errorf("device cookie MaxAge = %d, want %d", device.MaxAge, tt.wantMaxAge)
+			}
+			if csrf := responseCookie(rec, csrfCookieName); csrf == nil || csrf.Secure != tt.wantSecure {
+				t.Errorf("CSRF cookie = %+v, want Secure %v", csrf, tt.wantSecure)
+			}
+		})
+	}
+}
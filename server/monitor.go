@@ -0,0 +1,234 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// FileChangedEvent is the event type published for monitored changes
+	FileChangedEvent = "filesystem.changed"
+
+	// monitorHistorySize is the number of recent changes kept for status
+	monitorHistorySize = 256
+	// monitorCoalesceWindow is how long repeats of the same change to the
+	// same file are dropped; editors often write a file several times a save
+	monitorCoalesceWindow = 100 * time.Millisecond
+)
+
+var (
+	errAlreadyMonitored = errors.New("path is already monitored")
+	errNotMonitored     = errors.New("path is not monitored")
+)
+
+// FileChange is a single change seen in a monitored directory
+type FileChange struct {
+	Dir  string    `json:"dir"`
+	Path string    `json:"path"`
+	Op   string    `json:"op"` // create, modify, delete or rename
+	Time time.Time `json:"time"`
+}
+
+// EventPublisher publishes an event to the platform event bus
+type EventPublisher func(eventType string, data map[string]interface{}) error
+
+// dirMonitor watches directories with fsnotify and records what changes in
+// them. Watches are not recursive: changes in subdirectories are only seen
+// as a modification of the subdirectory itself.
+type dirMonitor struct {
+	mu       sync.Mutex
+	watchers map[string]*fsnotify.Watcher
+	since    map[string]time.Time
+	history  []FileChange // ring buffer, next is the oldest entry once full
+	next     int
+	lastSeen map[string]time.Time // path+op -> last recorded, for coalescing
+	publish  EventPublisher
+}
+
+func newDirMonitor() *dirMonitor {
+	return &dirMonitor{
+		watchers: make(map[string]*fsnotify.Watcher),
+		since:    make(map[string]time.Time),
+		history:  make([]FileChange, 0, monitorHistorySize),
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+func (m *dirMonitor) setPublisher(publish EventPublisher) {
+	m.mu.Lock()
+	m.publish = publish
+	m.mu.Unlock()
+}
+
+// Watch starts watching dir
+func (m *dirMonitor) Watch(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.watchers[dir]; ok {
+		return errAlreadyMonitored
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+	m.watchers[dir] = watcher
+	m.since[dir] = time.Now()
+
+	go m.run(dir, watcher)
+	return nil
+}
+
+// Unwatch stops watching dir
+func (m *dirMonitor) Unwatch(dir string) error {
+	m.mu.Lock()
+	watcher, ok := m.watchers[dir]
+	delete(m.watchers, dir)
+	delete(m.since, dir)
+	m.mu.Unlock()
+
+	if !ok {
+		return errNotMonitored
+	}
+	return watcher.Close()
+}
+
+// Close stops every watch
+func (m *dirMonitor) Close() {
+	m.mu.Lock()
+	watchers := m.watchers
+	m.watchers = make(map[string]*fsnotify.Watcher)
+	m.since = make(map[string]time.Time)
+	m.mu.Unlock()
+
+	for _, watcher := range watchers {
+		watcher.Close()
+	}
+}
+
+// Monitored returns the watched directories and when each watch started
+func (m *dirMonitor) Monitored() map[string]time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dirs := make(map[string]time.Time, len(m.since))
+	for dir, since := range m.since {
+		dirs[dir] = since
+	}
+	return dirs
+}
+
+// Recent returns the recorded changes, oldest first, optionally limited to
+// those in dir
+func (m *dirMonitor) Recent(dir string) []FileChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ordered := append(append([]FileChange{}, m.history[m.next:]...), m.history[:m.next]...)
+	if dir == "" {
+		return ordered
+	}
+	changes := make([]FileChange, 0, len(ordered))
+	for _, change := range ordered {
+		if change.Dir == dir {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (m *dirMonitor) run(dir string, watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if op := changeOp(event.Op); op != "" {
+				m.record(FileChange{Dir: dir, Path: event.Name, Op: op, Time: time.Now()})
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Printf("Monitor error on %s: %v\n", dir, err)
+		}
+	}
+}
+
+// record stores change and publishes it, unless the same change was just
+// recorded
+func (m *dirMonitor) record(change FileChange) {
+	key := change.Op + "\x00" + change.Path
+
+	m.mu.Lock()
+	if last, ok := m.lastSeen[key]; ok && change.Time.Sub(last) < monitorCoalesceWindow {
+		m.mu.Unlock()
+		return
+	}
+	m.lastSeen[key] = change.Time
+	if len(m.lastSeen) > monitorHistorySize {
+		for k, seen := range m.lastSeen {
+			if change.Time.Sub(seen) >= monitorCoalesceWindow {
+				delete(m.lastSeen, k)
+			}
+		}
+	}
+
+	if len(m.history) < monitorHistorySize {
+		m.history = append(m.history, change)
+	} else {
+		m.history[m.next] = change
+		m.next = (m.next + 1) % monitorHistorySize
+	}
+	publish := m.publish
+	m.mu.Unlock()
+
+	if publish == nil {
+		return
+	}
+	err := publish(FileChangedEvent, map[string]interface{}{
+		"dir":  change.Dir,
+		"path": change.Path,
+		"name": filepath.Base(change.Path),
+		"op":   change.Op,
+		"time": change.Time,
+	})
+	if err != nil {
+		fmt.Printf("Failed to publish change to %s: %v\n", change.Path, err)
+	}
+}
+
+// changeOp names the change an fsnotify event describes. Permission changes
+// are not reported.
+func changeOp(op fsnotify.Op) string {
+	switch {
+	case op.Has(fsnotify.Create):
+		return "create"
+	case op.Has(fsnotify.Remove):
+		return "delete"
+	case op.Has(fsnotify.Rename):
+		return "rename"
+	case op.Has(fsnotify.Write):
+		return "modify"
+	}
+	return ""
+}
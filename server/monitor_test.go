@@ -0,0 +1,100 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// publishedChange is a change as handed to the event publisher
+type publishedChange struct {
+	eventType string
+	data      map[string]interface{}
+}
+
+func TestDirMonitorPublishesChanges(t *testing.T) {
+	dir := t.TempDir()
+	events := make(chan publishedChange, 64)
+	m := newDirMonitor()
+	defer m.Close()
+	m.setPublisher(func(eventType string, data map[string]interface{}) error {
+		events <- publishedChange{eventType, data}
+		return nil
+	})
+	if err := m.Watch(dir); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	path := filepath.Join(dir, "notes.txt")
+	steps := []struct {
+		name   string
+		change func() error
+		op     string
+	}{
+		{"create", func() error { return os.WriteFile(path, []byte("hi"), 0644) }, "create"},
+		{"delete", func() error { return os.Remove(path) }, "delete"},
+	}
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		// Other ops, such as the write after create, may come first
+		deadline := time.After(5 * time.Second)
+		for found := false; !found; {
+			select {
+			case ev := <-events:
+				if ev.eventType != FileChangedEvent {
+					t.Fatalf("event type = %q, want %q", ev.eventType, FileChangedEvent)
+				}
+				if ev.data["op"] != step.op {
+					continue
+				}
+				found = true
+				if ev.data["path"] != path || ev.data["name"] != "notes.txt" || ev.data["dir"] != dir {
+					t.Errorf("%s event data = %v", step.name, ev.data)
+				}
+			case <-deadline:
+				t.Fatalf("no %s event published", step.op)
+			}
+		}
+	}
+
+	ops := map[string]bool{}
+	for _, change := range m.Recent(dir) {
+		ops[change.Op] = true
+	}
+	if !ops["create"] || !ops["delete"] {
+		t.Errorf("recent changes = %v, want create and delete", m.Recent(dir))
+	}
+}
+
+func TestDirMonitorWatchErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newDirMonitor()
+	defer m.Close()
+
+	if err := m.Watch(dir); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	tests := []struct {
+		name  string
+		call  func() error
+		check func(error) bool
+	}{
+		{"watch twice", func() error { return m.Watch(dir) }, func(err error) bool { return err == errAlreadyMonitored }},
+		{"watch a file", func() error { return m.Watch(file) }, func(err error) bool { return err != nil }},
+		{"watch a missing dir", func() error { return m.Watch(filepath.Join(dir, "nope")) }, os.IsNotExist},
+		{"unwatch", func() error { return m.Unwatch(dir) }, func(err error) bool { return err == nil }},
+		{"unwatch twice", func() error { return m.Unwatch(dir) }, func(err error) bool { return err == errNotMonitored }},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !tt.check(err) {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+}
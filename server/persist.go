@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+)
+
+// loadJSONFile reads the JSON file at path into v. A missing file leaves v
+// untouched. A file that can't be parsed is moved aside to
+// <path>.corrupt.<timestamp> so its contents aren't lost, and v is reset to
+// its zero value so the caller starts from empty state.
+func loadJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		// Unmarshal may have partially filled v before failing
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && !rv.IsNil() {
+			rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+		}
+
+		backup := fmt.Sprintf("%s.corrupt.%d", path, time.Now().Unix())
+		if renameErr := os.Rename(path, backup); renameErr != nil {
+			return fmt.Errorf("failed to back up corrupt %s: %w", path, renameErr)
+		}
+		fmt.Printf("⚠️  %s is corrupt (%v); backed up to %s and starting fresh\n", path, err, backup)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadJSONFile(t *testing.T) {
+	tests := []struct {
+		name string
+		// content is written to the file first, unless nil
+		content    []byte
+		want       []TransferHistoryEntry
+		wantBackup bool
+	}{
+		{"missing", nil, []TransferHistoryEntry{{ID: "initial"}}, false},
+		{"valid", []byte(`[{"id":"a"},{"id":"b"}]`), []TransferHistoryEntry{{ID: "a"}, {ID: "b"}}, false},
+		{"corrupt", []byte(`{not json`), nil, true},
+		{"truncated", []byte(`[{"id":"a"},{"id":`), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transfer_history.json")
+			if tt.content != nil {
+				if err := os.WriteFile(path, tt.content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			history := []TransferHistoryEntry{{ID: "initial"}}
+			if err := loadJSONFile(path, &history); err != nil {
+				t.Fatalf("loadJSONFile: %v", err)
+			}
+			if !slices.EqualFunc(history, tt.want, func(a, b TransferHistoryEntry) bool { return a.ID == b.ID }) {
+				t.Errorf("loaded %+v, want %+v", history, tt.want)
+			}
+
+			backups, _ := filepath.Glob(path + ".corrupt.*")
+			if !tt.wantBackup {
+				if len(backups) != 0 {
+					t.Errorf("unexpected backups %v", backups)
+				}
+				return
+			}
+			if len(backups) != 1 {
+				t.Fatalf("backups = %v, want one", backups)
+			}
+			if data, _ := os.ReadFile(backups[0]); string(data) != string(tt.content) {
+				t.Errorf("backup holds %q, want the corrupt content", data)
+			}
+			// The corrupt file is moved aside, so the next load starts fresh
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("corrupt file still in place: %v", err)
+			}
+		})
+	}
+}
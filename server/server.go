@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/mdp/qrterminal/v3"
 	"github.com/nathfavour/noplacelike.go/api"
 	"github.com/nathfavour/noplacelike.go/config"
+	"github.com/nathfavour/noplacelike.go/internal/core"
 )
 
 type DeviceInfo struct {
@@ -42,6 +44,8 @@ type Server struct {
 	server    *http.Server
 	clipboard string                 // In-memory clipboard storage
 	devices   map[string]*DeviceInfo // deviceID -> info
+	monitor   *dirMonitor
+	api       *api.API
 }
 
 // NewServer creates a new HTTP server
@@ -51,9 +55,15 @@ func NewServer(config *config.Config) *Server {
 		config:  config,
 		router:  gin.Default(),
 		devices: make(map[string]*DeviceInfo),
+		monitor: newDirMonitor(),
 	}
 
-	// Add device tracking middleware
+	// Device tracking keys on the client IP, which is only taken from
+	// forwarding headers of trusted proxies, and there are none here
+	server.router.SetTrustedProxies(nil)
+
+	// Reject cross-site state changes, then track devices
+	server.router.Use(server.csrfMiddleware)
 	server.router.Use(server.deviceTrackingMiddleware)
 
 	// Start live audio broadcaster and mock capture
@@ -79,8 +89,22 @@ func (s *Server) Start() {
 	}
 }
 
+// SetEventPublisher sends directory monitor changes to the platform event
+// bus as filesystem.changed events
+func (s *Server) SetEventPublisher(publish EventPublisher) {
+	s.monitor.setPublisher(publish)
+}
+
+// SetTokenVerifier sets what the API endpoints that need a bearer token,
+// such as the Ollama model pull and delete, check it with
+func (s *Server) SetTokenVerifier(verifier api.TokenVerifier) {
+	s.api.SetTokenVerifier(verifier)
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown() {
+	s.monitor.Close()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -92,8 +116,8 @@ func (s *Server) Shutdown() {
 // setupRoutes sets up the API routes
 func (s *Server) setupRoutes() {
 	// Initialize API and create its routes on the router
-	apiHandler := api.NewAPI(s.config)
-	apiHandler.CreateRoutes(s.router) // Changed from SetupRoutes to CreateRoutes
+	s.api = api.NewAPI(s.config)
+	s.api.CreateRoutes(s.router) // Changed from SetupRoutes to CreateRoutes
 
 	// Redirect root to UI
 	s.router.GET("/", func(c *gin.Context) {
@@ -108,6 +132,16 @@ func (s *Server) setupRoutes() {
 	s.router.GET("/admin", s.adminPanel)
 	s.router.GET("/ollama", s.ollamaUI)
 
+	// Audio streaming used by the UI's Audio tab
+	s.router.GET("/stream/list", s.listAudio)
+	s.router.GET("/stream/play", s.streamAudio)
+	s.router.HEAD("/stream/play", s.streamAudio)
+
+	// Streaming directory administration
+	s.router.GET("/admin/dirs", s.getAudioDirs)
+	s.router.POST("/admin/dirs", s.addAudioDir)
+	s.router.DELETE("/admin/dirs", s.removeAudioDir)
+
 	// Serve static files
 	s.router.Static("/static", "./static")
 
@@ -293,7 +327,12 @@ func (s *Server) deviceTrackingMiddleware(c *gin.Context) {
 		// Generate a new device ID
 		deviceID = generateDeviceID()
 		// Set cookie for future requests
-		c.SetCookie("npl_device_id", deviceID, 365*24*3600, "/", "", false, true)
+		maxAge := s.config.DeviceCookieMaxAgeSeconds
+		if maxAge <= 0 {
+			maxAge = config.DefaultDeviceCookieMaxAge
+		}
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie("npl_device_id", deviceID, maxAge, "/", "", isSecureRequest(c.Request), true)
 	}
 	userAgent := c.Request.UserAgent()
 	ip := c.ClientIP()
@@ -309,9 +348,15 @@ func (s *Server) deviceTrackingMiddleware(c *gin.Context) {
 	c.Next()
 }
 
+// isSecureRequest reports whether r arrived over TLS, directly or through a
+// proxy that terminated it
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
 // generateDeviceID creates a random device ID
 func generateDeviceID() string {
-	return fmt.Sprintf("dev-%d-%d", time.Now().UnixNano(), os.Getpid())
+	return "dev-" + core.NewID()
 }
 
 // getDevices returns all connected devices except the requester
@@ -359,8 +404,9 @@ func logTransfer(entry TransferHistoryEntry) {
 	fpath := filepath.Join(dir, "transfer_history.json")
 
 	var history []TransferHistoryEntry
-	if data, err := os.ReadFile(fpath); err == nil {
-		_ = json.Unmarshal(data, &history)
+	if err := loadJSONFile(fpath, &history); err != nil {
+		fmt.Printf("❌ Failed to load transfer history: %v\n", err)
+		return
 	}
 	history = append([]TransferHistoryEntry{entry}, history...)
 	if len(history) > 1000 {
@@ -383,8 +429,9 @@ func (s *Server) GetTransferHistory(c *gin.Context) {
 	}
 	fpath := filepath.Join(home, ".noplacelike", "transfer_history.json")
 	var history []TransferHistoryEntry
-	if data, err := os.ReadFile(fpath); err == nil {
-		_ = json.Unmarshal(data, &history)
+	if err := loadJSONFile(fpath, &history); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load transfer history"})
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{"history": history})
 }
@@ -400,9 +447,7 @@ func (s *Server) RemoveDevice(c *gin.Context) {
 	c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
 }
 
-// Directory monitoring (simple polling-based)
-var monitoredDirs = make(map[string]time.Time)
-
+// Directory monitoring
 func (s *Server) StartMonitor(c *gin.Context) {
 	var req struct {
 		Path string `json:"path"`
@@ -411,8 +456,16 @@ func (s *Server) StartMonitor(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing path"})
 		return
 	}
-	monitoredDirs[req.Path] = time.Now()
-	c.JSON(http.StatusOK, gin.H{"status": "monitoring", "path": req.Path})
+	dir := filepath.Clean(expandPath(req.Path))
+	if err := s.monitor.Watch(dir); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errAlreadyMonitored) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to monitor %s: %v", req.Path, err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "monitoring", "path": dir})
 }
 
 func (s *Server) StopMonitor(c *gin.Context) {
@@ -423,19 +476,27 @@ func (s *Server) StopMonitor(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing path"})
 		return
 	}
-	delete(monitoredDirs, req.Path)
-	c.JSON(http.StatusOK, gin.H{"status": "stopped", "path": req.Path})
+	dir := filepath.Clean(expandPath(req.Path))
+	if err := s.monitor.Unwatch(dir); err != nil {
+		if errors.Is(err, errNotMonitored) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Path is not monitored"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to stop monitoring: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "stopped", "path": dir})
 }
 
+// MonitorStatus lists the monitored directories and their recent changes,
+// limited to one directory with ?path=
 func (s *Server) MonitorStatus(c *gin.Context) {
-	changes := make(map[string][]string)
-	for dir := range monitoredDirs {
-		files, _ := os.ReadDir(dir)
-		var names []string
-		for _, f := range files {
-			names = append(names, f.Name())
-		}
-		changes[dir] = names
+	dir := c.Query("path")
+	if dir != "" {
+		dir = filepath.Clean(expandPath(dir))
 	}
-	c.JSON(http.StatusOK, gin.H{"monitored": changes})
+	c.JSON(http.StatusOK, gin.H{
+		"monitored": s.monitor.Monitored(),
+		"changes":   s.monitor.Recent(dir),
+	})
 }
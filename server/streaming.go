@@ -1,13 +1,12 @@
 package server
 
 import (
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/internal/filetype"
 )
 
 // streamAudio streams an audio file
@@ -60,7 +59,7 @@ func (s *Server) streamAudio(c *gin.Context) {
 	}
 	defer file.Close()
 	
-	// Get file info for size
+	// Get file info for size and modification time
 	info, err := file.Stat()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -70,28 +69,17 @@ func (s *Server) streamAudio(c *gin.Context) {
 	}
 	
 	// Set content type based on file extension
-	contentType := "audio/mpeg"
-	if strings.HasSuffix(strings.ToLower(safeFilename), ".ogg") {
-		contentType = "audio/ogg"
-	} else if strings.HasSuffix(strings.ToLower(safeFilename), ".wav") {
-		contentType = "audio/wav"
-	} else if strings.HasSuffix(strings.ToLower(safeFilename), ".flac") {
-		contentType = "audio/flac"
-	}
+	contentType := filetype.MIMEType(safeFilename)
 	
-	// Set response headers
+	// Stream the file; ServeContent handles Range requests so the player can seek
 	c.Header("Content-Type", contentType)
-	c.Header("Content-Length", string(info.Size()))
-	c.Header("Accept-Ranges", "bytes")
-	
-	// Stream the file
-	c.Status(http.StatusOK)
-	io.Copy(c.Writer, file)
+	http.ServeContent(c.Writer, c.Request, safeFilename, info.ModTime(), file)
 }
 
 // listAudio lists audio files from all configured folders
 func (s *Server) listAudio(c *gin.Context) {
 	result := make(map[string][]string)
+	audioExts := s.config.AudioExtensions()
 	
 	for _, folder := range s.config.AudioFolders {
 		expandedFolder := expandPath(folder)
@@ -107,10 +95,8 @@ func (s *Server) listAudio(c *gin.Context) {
 		fileList := []string{}
 		for _, file := range files {
 			if !file.IsDir() {
-				// Simple extension check for audio files
 				name := file.Name()
-				ext := strings.ToLower(filepath.Ext(name))
-				if ext == ".mp3" || ext == ".ogg" || ext == ".wav" || ext == ".flac" {
+				if audioExts.Has(name) {
 					fileList = append(fileList, name)
 				}
 			}
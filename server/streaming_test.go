@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nathfavour/noplacelike.go/config"
+)
+
+// newStreamingRouter serves the audio streaming endpoints from folders
+func newStreamingRouter(folders ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	s := &Server{config: &config.Config{AudioFolders: folders}}
+	router := gin.New()
+	router.GET("/stream/list", s.listAudio)
+	router.GET("/stream/play", s.streamAudio)
+	router.HEAD("/stream/play", s.streamAudio)
+	return router
+}
+
+func TestListAudioGroupsByFolder(t *testing.T) {
+	music, podcasts := t.TempDir(), t.TempDir()
+	for _, path := range []string{
+		filepath.Join(music, "song.mp3"),
+		filepath.Join(music, "notes.txt"),
+		filepath.Join(podcasts, "episode.ogg"),
+	} {
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(music, "album.mp3"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	rec := httptest.NewRecorder()
+	newStreamingRouter(music, podcasts, missing).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream/list", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Files map[string][]string `json:"files"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+
+	tests := []struct {
+		folder string
+		want   []string
+	}{
+		{music, []string{"song.mp3"}},
+		{podcasts, []string{"episode.ogg"}},
+		// Unreadable folders are listed empty rather than failing the request
+		{missing, []string{}},
+	}
+	for _, tt := range tests {
+		got, ok := body.Files[tt.folder]
+		if !ok || !slices.Equal(got, tt.want) {
+			t.Errorf("files[%s] = %v (present %v), want %v", tt.folder, got, ok, tt.want)
+		}
+	}
+}
+
+func TestStreamAudio(t *testing.T) {
+	music := t.TempDir()
+	if err := os.WriteFile(filepath.Join(music, "song.mp3"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	router := newStreamingRouter(t.TempDir(), music)
+
+	tests := []struct {
+		name        string
+		target      string
+		rangeHeader string
+		status      int
+		body        string
+	}{
+		{"whole file", "/stream/play?file=song.mp3", "", http.StatusOK, "0123456789"},
+		{"range", "/stream/play?file=song.mp3", "bytes=2-4", http.StatusPartialContent, "234"},
+		{"missing parameter", "/stream/play", "", http.StatusBadRequest, ""},
+		{"path traversal", "/stream/play?file=../song.mp3", "", http.StatusBadRequest, ""},
+		{"unknown file", "/stream/play?file=other.mp3", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.body == "" {
+				return
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body, tt.body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "audio/mpeg" {
+				t.Errorf("Content-Type = %q, want audio/mpeg", got)
+			}
+		})
+	}
+}
+
+func TestStreamAudioHead(t *testing.T) {
+	music := t.TempDir()
+	if err := os.WriteFile(filepath.Join(music, "song.mp3"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	router := newStreamingRouter(music)
+
+	tests := []struct {
+		target        string
+		status        int
+		contentLength string
+	}{
+		{"/stream/play?file=song.mp3", http.StatusOK, "10"},
+		{"/stream/play?file=other.mp3", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("HEAD %s: status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+		if tt.status == http.StatusOK && rec.Body.Len() != 0 {
+			t.Errorf("HEAD %s: body = %q, want none", tt.target, rec.Body)
+		}
+		if tt.contentLength != "" && rec.Header().Get("Content-Length") != tt.contentLength {
+			t.Errorf("HEAD %s: Content-Length = %q, want %q", tt.target, rec.Header().Get("Content-Length"), tt.contentLength)
+		}
+	}
+}
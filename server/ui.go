@@ -11,13 +11,13 @@ import (
 // uiHome renders the main UI page
 func (s *Server) uiHome(c *gin.Context) {
 	c.Header("Content-Type", "text/html")
-	c.String(http.StatusOK, homeTemplate)
+	c.String(http.StatusOK, withCSRF(c, homeTemplate))
 }
 
 // adminPanel renders the admin UI
 func (s *Server) adminPanel(c *gin.Context) {
 	c.Header("Content-Type", "text/html")
-	c.String(http.StatusOK, adminTemplate)
+	c.String(http.StatusOK, withCSRF(c, adminTemplate))
 }
 
 // uiHomeWithTab renders the main UI page and sets the initial tab
@@ -34,7 +34,7 @@ func (s *Server) uiHomeWithTab(c *gin.Context, tab string) {
 	if idx := strings.Index(html, headEnd); idx != -1 {
 		html = html[:idx] + configScript + tabScript + html[idx:]
 	}
-	c.String(http.StatusOK, html)
+	c.String(http.StatusOK, withCSRF(c, html))
 }
 
 // ollamaUI serves the Ollama chat UI
@@ -61,6 +61,13 @@ func (s *Server) ollamaUI(c *gin.Context) {
     .input-row button:disabled { opacity: 0.5; }
     .model-select { margin-bottom: 1em; }
     .model-select select { padding: 0.5em; border-radius: 6px; border: 1px solid #ccc; }
+    .model-manage { display: flex; gap: 0.5em; flex-wrap: wrap; margin-bottom: 0.5em; }
+    .model-manage input { flex: 1; padding: 0.5em; border-radius: 6px; border: 1px solid #ccc; }
+    .model-manage button { background: #4444ff; color: #fff; border: none; border-radius: 6px; padding: 0.5em 1em; cursor: pointer; }
+    .model-manage button.danger { background: #d33; }
+    .model-manage button:disabled { opacity: 0.5; }
+    .pull-status { font-size: 0.9em; color: #555; margin-bottom: 1em; min-height: 1.2em; }
+    .pull-status progress { width: 100%; }
   </style>
 </head>
 <body>
@@ -69,7 +76,14 @@ func (s *Server) ollamaUI(c *gin.Context) {
     <div class="model-select">
       <label for="model">Model:</label>
       <select id="model"></select>
+      <button type="button" id="deleteModel" class="danger">Delete</button>
     </div>
+    <div class="model-manage">
+      <input id="pullName" placeholder="Model to pull, e.g. llama3">
+      <button type="button" id="pullModel">Pull</button>
+      <input id="authToken" type="password" placeholder="Access token">
+    </div>
+    <div class="pull-status" id="pullStatus"></div>
     <div class="chat-history" id="chatHistory"></div>
     <form id="chatForm" class="input-row">
       <textarea id="userInput" rows="2" placeholder="Type your message..." required></textarea>
@@ -99,6 +113,77 @@ func (s *Server) ollamaUI(c *gin.Context) {
       }
     }
 
+    const pullStatus = document.getElementById('pullStatus');
+    const pullButton = document.getElementById('pullModel');
+    const deleteButton = document.getElementById('deleteModel');
+    const tokenInput = document.getElementById('authToken');
+    tokenInput.value = localStorage.getItem('ollamaToken') || '';
+    tokenInput.addEventListener('change', () => localStorage.setItem('ollamaToken', tokenInput.value));
+
+    function authHeaders(extra) {
+      const headers = Object.assign({}, extra);
+      if (tokenInput.value) headers['Authorization'] = 'Bearer ' + tokenInput.value;
+      return headers;
+    }
+
+    function showProgress(p) {
+      pullStatus.textContent = p.error ? 'Error: ' + p.error : (p.status || '');
+      if (p.total) {
+        const bar = document.createElement('progress');
+        bar.max = p.total;
+        bar.value = p.completed || 0;
+        pullStatus.appendChild(bar);
+      }
+    }
+
+    pullButton.onclick = async () => {
+      const name = document.getElementById('pullName').value.trim();
+      if (!name) return;
+      pullButton.disabled = true;
+      pullStatus.textContent = 'Pulling ' + name + '...';
+      try {
+        const res = await fetch('/api/v1/ollama/pull', {
+          method: 'POST',
+          headers: authHeaders({ 'Content-Type': 'application/json' }),
+          body: JSON.stringify({ model: name })
+        });
+        if (!res.ok) {
+          const data = await res.json().catch(() => ({}));
+          pullStatus.textContent = 'Error: ' + (data.error || res.status);
+          return;
+        }
+        const reader = res.body.getReader();
+        const decoder = new TextDecoder();
+        let buffered = '';
+        for (;;) {
+          const { done, value } = await reader.read();
+          if (done) break;
+          buffered += decoder.decode(value, { stream: true });
+          const lines = buffered.split('\n');
+          buffered = lines.pop();
+          lines.filter(l => l.trim()).forEach(l => showProgress(JSON.parse(l)));
+        }
+        if (buffered.trim()) showProgress(JSON.parse(buffered));
+        await fetchModels();
+      } catch (err) {
+        pullStatus.textContent = 'Error: ' + err.message;
+      } finally {
+        pullButton.disabled = false;
+      }
+    };
+
+    deleteButton.onclick = async () => {
+      const name = modelSelect.value;
+      if (!name || !confirm('Delete model ' + name + '?')) return;
+      const res = await fetch('/api/v1/ollama/models/' + encodeURIComponent(name), {
+        method: 'DELETE',
+        headers: authHeaders()
+      });
+      const data = await res.json().catch(() => ({}));
+      pullStatus.textContent = res.ok ? 'Deleted ' + name : 'Error: ' + (data.error || res.status);
+      await fetchModels();
+    };
+
     modelSelect.addEventListener('change', () => {
       currentModel = modelSelect.value;
     });
@@ -140,7 +225,7 @@ func (s *Server) ollamaUI(c *gin.Context) {
   </script>
 </body>
 </html>`
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(withCSRF(c, html)))
 }
 
 // HTML templates for UI components
@@ -533,7 +618,8 @@ const homeTemplate = `<!DOCTYPE html>
                     }
                     (data.directories || []).forEach(function(dir) {
                         var li = document.createElement('li');
-                        li.innerHTML = '<span class="icon">📁</span> <button class="folder-link" onclick="loadFileBrowser(\'' + joinPath(path, dir) + '\')">' + dir + '</button>';
+                        li.innerHTML = '<span class="icon">📁</span> <button class="folder-link" onclick="loadFileBrowser(\'' + joinPath(path, dir) + '\')">' + dir + '</button>' +
+                                       '<span class="file-button-group"><button onclick="downloadArchive(\'' + joinPath(path, dir) + '\')" class="button small">Download ZIP</button></span>';
                         ul.appendChild(li);
                     });
                     (data.files || []).forEach(function(file) {
@@ -549,6 +635,9 @@ const homeTemplate = `<!DOCTYPE html>
                     });
                 });
         }
+        function downloadArchive(path) {
+            window.open('/api/v1/filesystem/archive?path=' + encodeURIComponent(path), '_blank');
+        }
         function parentDir(path) {
             if (path === '/' || !path) return '/';
             var parts = path.split('/').filter(Boolean);